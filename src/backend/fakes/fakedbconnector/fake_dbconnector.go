@@ -19,10 +19,14 @@ package fakedbconnector
 
 import (
 	"context"
+	"time"
 
+	"github.com/rmbarron/SnackInventory/src/backend/server/connector"
 	sipb "github.com/rmbarron/SnackInventory/src/proto/snackinventory"
 )
 
+var _ connector.Store = (*FakeDBConnector)(nil)
+
 type FakeDBConnector struct {
 	CreateSnackErr error
 	ListSnacksRes  []*sipb.Snack
@@ -30,13 +34,49 @@ type FakeDBConnector struct {
 	UpdateSnackErr error
 	DeleteSnackErr error
 
+	// Batch errors are returned as is; use *connector.BatchError to fail an item.
+	BatchCreateSnacksErr error
+	BatchUpdateSnacksErr error
+	BatchDeleteSnacksErr error
+
+	CreateLocationRes int64
 	CreateLocationErr error
 	ListLocationsRes  []*sipb.Location
 	ListLocationsErr  error
+	UpdateLocationRes *sipb.Location
+	UpdateLocationErr error
+	DeleteLocationRes []*sipb.StockChange
 	DeleteLocationErr error
+
+	AdjustStockRes int32
+	AdjustStockErr error
+	ListStockRes   []*sipb.Stock
+	ListStockErr   error
+
+	ListDeletedRes      []*sipb.DeletedEntity
+	ListDeletedErr      error
+	UndeleteSnackRes    *sipb.Snack
+	UndeleteSnackErr    error
+	UndeleteLocationRes *sipb.Location
+	UndeleteLocationErr error
+	// PurgeDeletedBefore records the time passed to PurgeDeleted.
+	PurgeDeletedBefore    time.Time
+	PurgeDeletedSnacks    int64
+	PurgeDeletedLocations int64
+	PurgeDeletedErr       error
+
+	GetSnackHistoryRes []*sipb.SnackRevision
+	GetSnackHistoryErr error
+	// ListSnacksAsOfTime & ListLocationsAsOfTime record the times passed.
+	ListSnacksAsOfTime    time.Time
+	ListSnacksAsOfRes     []*sipb.Snack
+	ListSnacksAsOfErr     error
+	ListLocationsAsOfTime time.Time
+	ListLocationsAsOfRes  []*sipb.Location
+	ListLocationsAsOfErr  error
 }
 
-func (f *FakeDBConnector) CreateSnack(_ context.Context, _, _ string) error {
+func (f *FakeDBConnector) CreateSnack(_ context.Context, _ *sipb.Snack) error {
 	return f.CreateSnackErr
 }
 
@@ -47,7 +87,7 @@ func (f *FakeDBConnector) ListSnacks(_ context.Context) ([]*sipb.Snack, error) {
 	return f.ListSnacksRes, nil
 }
 
-func (f *FakeDBConnector) UpdateSnack(_ context.Context, _, _ string) error {
+func (f *FakeDBConnector) UpdateSnack(_ context.Context, _ *sipb.Snack) error {
 	return f.UpdateSnackErr
 }
 
@@ -55,8 +95,23 @@ func (f *FakeDBConnector) DeleteSnack(_ context.Context, _ string) error {
 	return f.DeleteSnackErr
 }
 
-func (f *FakeDBConnector) CreateLocation(_ context.Context, _ string) error {
-	return f.CreateLocationErr
+func (f *FakeDBConnector) BatchCreateSnacks(_ context.Context, _ []*sipb.Snack) error {
+	return f.BatchCreateSnacksErr
+}
+
+func (f *FakeDBConnector) BatchUpdateSnacks(_ context.Context, _ []*sipb.Snack) error {
+	return f.BatchUpdateSnacksErr
+}
+
+func (f *FakeDBConnector) BatchDeleteSnacks(_ context.Context, _ []string) error {
+	return f.BatchDeleteSnacksErr
+}
+
+func (f *FakeDBConnector) CreateLocation(_ context.Context, _ *sipb.Location) (int64, error) {
+	if f.CreateLocationErr != nil {
+		return 0, f.CreateLocationErr
+	}
+	return f.CreateLocationRes, nil
 }
 
 func (f *FakeDBConnector) ListLocations(_ context.Context) ([]*sipb.Location, error) {
@@ -66,6 +121,79 @@ func (f *FakeDBConnector) ListLocations(_ context.Context) ([]*sipb.Location, er
 	return f.ListLocationsRes, nil
 }
 
-func (f *FakeDBConnector) DeleteLocation(_ context.Context, _ string) error {
-	return f.DeleteLocationErr
+func (f *FakeDBConnector) UpdateLocation(_ context.Context, _ *sipb.Location, _ []string) (*sipb.Location, error) {
+	if f.UpdateLocationErr != nil {
+		return nil, f.UpdateLocationErr
+	}
+	return f.UpdateLocationRes, nil
+}
+
+func (f *FakeDBConnector) DeleteLocation(_ context.Context, _ string, _ sipb.ContentsPolicy, _ string) ([]*sipb.StockChange, error) {
+	return f.DeleteLocationRes, f.DeleteLocationErr
+}
+
+func (f *FakeDBConnector) AdjustStock(_ context.Context, _, _ string, _ int32) (int32, error) {
+	if f.AdjustStockErr != nil {
+		return 0, f.AdjustStockErr
+	}
+	return f.AdjustStockRes, nil
+}
+
+func (f *FakeDBConnector) ListStock(_ context.Context, _ string) ([]*sipb.Stock, error) {
+	if f.ListStockErr != nil {
+		return nil, f.ListStockErr
+	}
+	return f.ListStockRes, nil
+}
+
+func (f *FakeDBConnector) ListDeleted(_ context.Context) ([]*sipb.DeletedEntity, error) {
+	if f.ListDeletedErr != nil {
+		return nil, f.ListDeletedErr
+	}
+	return f.ListDeletedRes, nil
+}
+
+func (f *FakeDBConnector) UndeleteSnack(_ context.Context, _ string) (*sipb.Snack, error) {
+	if f.UndeleteSnackErr != nil {
+		return nil, f.UndeleteSnackErr
+	}
+	return f.UndeleteSnackRes, nil
+}
+
+func (f *FakeDBConnector) UndeleteLocation(_ context.Context, _ string) (*sipb.Location, error) {
+	if f.UndeleteLocationErr != nil {
+		return nil, f.UndeleteLocationErr
+	}
+	return f.UndeleteLocationRes, nil
+}
+
+func (f *FakeDBConnector) PurgeDeleted(_ context.Context, before time.Time) (int64, int64, error) {
+	f.PurgeDeletedBefore = before
+	if f.PurgeDeletedErr != nil {
+		return 0, 0, f.PurgeDeletedErr
+	}
+	return f.PurgeDeletedSnacks, f.PurgeDeletedLocations, nil
+}
+
+func (f *FakeDBConnector) GetSnackHistory(_ context.Context, _ string) ([]*sipb.SnackRevision, error) {
+	if f.GetSnackHistoryErr != nil {
+		return nil, f.GetSnackHistoryErr
+	}
+	return f.GetSnackHistoryRes, nil
+}
+
+func (f *FakeDBConnector) ListSnacksAsOf(_ context.Context, asOf time.Time) ([]*sipb.Snack, error) {
+	f.ListSnacksAsOfTime = asOf
+	if f.ListSnacksAsOfErr != nil {
+		return nil, f.ListSnacksAsOfErr
+	}
+	return f.ListSnacksAsOfRes, nil
+}
+
+func (f *FakeDBConnector) ListLocationsAsOf(_ context.Context, asOf time.Time) ([]*sipb.Location, error) {
+	f.ListLocationsAsOfTime = asOf
+	if f.ListLocationsAsOfErr != nil {
+		return nil, f.ListLocationsAsOfErr
+	}
+	return f.ListLocationsAsOfRes, nil
 }
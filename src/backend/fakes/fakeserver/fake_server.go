@@ -19,6 +19,8 @@ package fakeserver
 
 import (
 	"context"
+	"errors"
+	"io"
 
 	sipb "github.com/rmbarron/SnackInventory/src/proto/snackinventory"
 )
@@ -38,13 +40,57 @@ type FakeSnackInventoryServer struct {
 	DeleteSnackRes *sipb.DeleteSnackResponse
 	DeleteSnackErr error
 
+	// Batch Snack Operations.
+	BatchCreateSnacksRes *sipb.BatchCreateSnacksResponse
+	BatchCreateSnacksErr error
+	BatchUpdateSnacksRes *sipb.BatchUpdateSnacksResponse
+	BatchUpdateSnacksErr error
+	BatchDeleteSnacksRes *sipb.BatchDeleteSnacksResponse
+	BatchDeleteSnacksErr error
+
 	// LocationRegistry Operations.
 	CreateLocationRes *sipb.CreateLocationResponse
 	CreateLocationErr error
 	ListLocationsRes  *sipb.ListLocationsResponse
 	ListLocationsErr  error
+	UpdateLocationRes *sipb.UpdateLocationResponse
+	UpdateLocationErr error
 	DeleteLocationRes *sipb.DeleteLocationResponse
 	DeleteLocationErr error
+
+	// Change Notifications.
+	// WatchChangesRes are sent in order, after which WatchChangesErr is returned.
+	WatchChangesRes []*sipb.Change
+	WatchChangesErr error
+
+	// Stock Operations.
+	AdjustStockRes *sipb.AdjustStockResponse
+	AdjustStockErr error
+	ListStockRes   *sipb.ListStockResponse
+	ListStockErr   error
+
+	// Trash.
+	ListDeletedRes      *sipb.ListDeletedResponse
+	ListDeletedErr      error
+	UndeleteSnackRes    *sipb.UndeleteSnackResponse
+	UndeleteSnackErr    error
+	UndeleteLocationRes *sipb.UndeleteLocationResponse
+	UndeleteLocationErr error
+	PurgeDeletedRes     *sipb.PurgeDeletedResponse
+	PurgeDeletedErr     error
+
+	// History.
+	GetSnackHistoryRes *sipb.GetSnackHistoryResponse
+	GetSnackHistoryErr error
+
+	// Backup & Restore.
+	// ExportAllRes are sent in order, after which ExportAllErr is returned.
+	ExportAllRes []*sipb.BackupRecord
+	ExportAllErr error
+	// ImportAllRes is returned once the client closes its stream, unless
+	// ImportAllErr is set.
+	ImportAllRes *sipb.ImportAllResponse
+	ImportAllErr error
 }
 
 // CreateSnack creates a snack in SnackInventory.
@@ -79,6 +125,30 @@ func (f *FakeSnackInventoryServer) DeleteSnack(_ context.Context, _ *sipb.Delete
 	return f.DeleteSnackRes, nil
 }
 
+// BatchCreateSnacks creates many snacks in SnackInventory.
+func (f *FakeSnackInventoryServer) BatchCreateSnacks(_ context.Context, _ *sipb.BatchCreateSnacksRequest) (*sipb.BatchCreateSnacksResponse, error) {
+	if f.BatchCreateSnacksErr != nil {
+		return &sipb.BatchCreateSnacksResponse{}, f.BatchCreateSnacksErr
+	}
+	return f.BatchCreateSnacksRes, nil
+}
+
+// BatchUpdateSnacks updates many snacks in SnackInventory.
+func (f *FakeSnackInventoryServer) BatchUpdateSnacks(_ context.Context, _ *sipb.BatchUpdateSnacksRequest) (*sipb.BatchUpdateSnacksResponse, error) {
+	if f.BatchUpdateSnacksErr != nil {
+		return &sipb.BatchUpdateSnacksResponse{}, f.BatchUpdateSnacksErr
+	}
+	return f.BatchUpdateSnacksRes, nil
+}
+
+// BatchDeleteSnacks deletes many snacks from SnackInventory.
+func (f *FakeSnackInventoryServer) BatchDeleteSnacks(_ context.Context, _ *sipb.BatchDeleteSnacksRequest) (*sipb.BatchDeleteSnacksResponse, error) {
+	if f.BatchDeleteSnacksErr != nil {
+		return &sipb.BatchDeleteSnacksResponse{}, f.BatchDeleteSnacksErr
+	}
+	return f.BatchDeleteSnacksRes, nil
+}
+
 // CreateLocation adds a new location to SnackInventory.
 func (f *FakeSnackInventoryServer) CreateLocation(_ context.Context, _ *sipb.CreateLocationRequest) (*sipb.CreateLocationResponse, error) {
 	if f.CreateLocationErr != nil {
@@ -95,6 +165,14 @@ func (f *FakeSnackInventoryServer) ListLocations(_ context.Context, _ *sipb.List
 	return f.ListLocationsRes, nil
 }
 
+// UpdateLocation updates a location in SnackInventory.
+func (f *FakeSnackInventoryServer) UpdateLocation(_ context.Context, _ *sipb.UpdateLocationRequest) (*sipb.UpdateLocationResponse, error) {
+	if f.UpdateLocationErr != nil {
+		return &sipb.UpdateLocationResponse{}, f.UpdateLocationErr
+	}
+	return f.UpdateLocationRes, nil
+}
+
 // DeleteLocation removes a location from SnackInventory.
 func (f *FakeSnackInventoryServer) DeleteLocation(_ context.Context, _ *sipb.DeleteLocationRequest) (*sipb.DeleteLocationResponse, error) {
 	if f.DeleteLocationErr != nil {
@@ -102,3 +180,95 @@ func (f *FakeSnackInventoryServer) DeleteLocation(_ context.Context, _ *sipb.Del
 	}
 	return f.DeleteLocationRes, nil
 }
+
+// WatchChanges streams changes to SnackInventory.
+func (f *FakeSnackInventoryServer) WatchChanges(_ *sipb.WatchChangesRequest, stream sipb.SnackInventory_WatchChangesServer) error {
+	for _, c := range f.WatchChangesRes {
+		if err := stream.Send(c); err != nil {
+			return err
+		}
+	}
+	return f.WatchChangesErr
+}
+
+// AdjustStock changes the count of a snack at a location.
+func (f *FakeSnackInventoryServer) AdjustStock(_ context.Context, _ *sipb.AdjustStockRequest) (*sipb.AdjustStockResponse, error) {
+	if f.AdjustStockErr != nil {
+		return &sipb.AdjustStockResponse{}, f.AdjustStockErr
+	}
+	return f.AdjustStockRes, nil
+}
+
+// ListStock lists the snacks in stock.
+func (f *FakeSnackInventoryServer) ListStock(_ context.Context, _ *sipb.ListStockRequest) (*sipb.ListStockResponse, error) {
+	if f.ListStockErr != nil {
+		return &sipb.ListStockResponse{}, f.ListStockErr
+	}
+	return f.ListStockRes, nil
+}
+
+// ListDeleted lists the snacks & locations in the trash.
+func (f *FakeSnackInventoryServer) ListDeleted(_ context.Context, _ *sipb.ListDeletedRequest) (*sipb.ListDeletedResponse, error) {
+	if f.ListDeletedErr != nil {
+		return &sipb.ListDeletedResponse{}, f.ListDeletedErr
+	}
+	return f.ListDeletedRes, nil
+}
+
+// UndeleteSnack restores a snack from the trash.
+func (f *FakeSnackInventoryServer) UndeleteSnack(_ context.Context, _ *sipb.UndeleteSnackRequest) (*sipb.UndeleteSnackResponse, error) {
+	if f.UndeleteSnackErr != nil {
+		return &sipb.UndeleteSnackResponse{}, f.UndeleteSnackErr
+	}
+	return f.UndeleteSnackRes, nil
+}
+
+// UndeleteLocation restores a location from the trash.
+func (f *FakeSnackInventoryServer) UndeleteLocation(_ context.Context, _ *sipb.UndeleteLocationRequest) (*sipb.UndeleteLocationResponse, error) {
+	if f.UndeleteLocationErr != nil {
+		return &sipb.UndeleteLocationResponse{}, f.UndeleteLocationErr
+	}
+	return f.UndeleteLocationRes, nil
+}
+
+// PurgeDeleted empties the trash.
+func (f *FakeSnackInventoryServer) PurgeDeleted(_ context.Context, _ *sipb.PurgeDeletedRequest) (*sipb.PurgeDeletedResponse, error) {
+	if f.PurgeDeletedErr != nil {
+		return &sipb.PurgeDeletedResponse{}, f.PurgeDeletedErr
+	}
+	return f.PurgeDeletedRes, nil
+}
+
+// GetSnackHistory lists the revisions of a snack.
+func (f *FakeSnackInventoryServer) GetSnackHistory(_ context.Context, _ *sipb.GetSnackHistoryRequest) (*sipb.GetSnackHistoryResponse, error) {
+	if f.GetSnackHistoryErr != nil {
+		return &sipb.GetSnackHistoryResponse{}, f.GetSnackHistoryErr
+	}
+	return f.GetSnackHistoryRes, nil
+}
+
+// ExportAll streams every snack & location in SnackInventory.
+func (f *FakeSnackInventoryServer) ExportAll(_ *sipb.ExportAllRequest, stream sipb.SnackInventory_ExportAllServer) error {
+	for _, rec := range f.ExportAllRes {
+		if err := stream.Send(rec); err != nil {
+			return err
+		}
+	}
+	return f.ExportAllErr
+}
+
+// ImportAll restores records streamed by the client.
+func (f *FakeSnackInventoryServer) ImportAll(stream sipb.SnackInventory_ImportAllServer) error {
+	if f.ImportAllErr != nil {
+		return f.ImportAllErr
+	}
+	for {
+		_, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return stream.SendAndClose(f.ImportAllRes)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,648 @@
+/*
+Copyright 2020 Robert Barron
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package fakestore implements connector.Store in memory, for tests that need
+// a working backend rather than canned results. It shares the semantics of
+// connector.SQLImpl, per connectortest.RunConformance.
+package fakestore
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/rmbarron/SnackInventory/src/backend/server/connector"
+	sipb "github.com/rmbarron/SnackInventory/src/proto/snackinventory"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+var _ connector.Store = (*FakeStore)(nil)
+
+// FakeStore is safe for concurrent use. Create with New.
+type FakeStore struct {
+	mu sync.Mutex
+	// Deleted snacks & locations are kept, with deletion times set, until
+	// purged.
+	snacks    map[string]*snack
+	locations []*location // Ordered by id.
+	lastID    int64
+	stock     map[stockKey]int32
+
+	snackRevisions    []*sipb.SnackRevision
+	locationRevisions []*locationRevision
+}
+
+type snack struct {
+	snack     *sipb.Snack
+	deletedAt time.Time
+}
+
+type location struct {
+	location  *sipb.Location
+	deletedAt time.Time
+}
+
+type stockKey struct {
+	barcode, location string
+}
+
+type locationRevision struct {
+	location *sipb.Location
+	typ      sipb.Change_Type
+	at       time.Time
+}
+
+// New returns an empty FakeStore.
+func New() *FakeStore {
+	return &FakeStore{
+		snacks: map[string]*snack{},
+		stock:  map[stockKey]int32{},
+	}
+}
+
+// recordSnack records a revision of s, as it is now.
+func (f *FakeStore) recordSnack(s *snack, typ sipb.Change_Type, at time.Time) {
+	f.snackRevisions = append(f.snackRevisions, &sipb.SnackRevision{
+		Snack:        proto.Clone(s.snack).(*sipb.Snack),
+		Type:         typ,
+		RevisionTime: timestamppb.New(at),
+	})
+}
+
+// recordLocation records a revision of l, as it is now.
+func (f *FakeStore) recordLocation(l *location, typ sipb.Change_Type, at time.Time) {
+	f.locationRevisions = append(f.locationRevisions, &locationRevision{
+		location: proto.Clone(l.location).(*sipb.Location),
+		typ:      typ,
+		at:       at,
+	})
+}
+
+// liveSnack returns the snack with barcode, unless it isn't registered or is
+// in the trash.
+func (f *FakeStore) liveSnack(barcode string) *snack {
+	if s, ok := f.snacks[barcode]; ok && s.deletedAt.IsZero() {
+		return s
+	}
+	return nil
+}
+
+// locationByName returns the location named name, including in the trash.
+func (f *FakeStore) locationByName(name string) *location {
+	for _, l := range f.locations {
+		if l.location.GetName() == name {
+			return l
+		}
+	}
+	return nil
+}
+
+// liveLocation returns the location named name, unless it isn't registered
+// or is in the trash.
+func (f *FakeStore) liveLocation(name string) *location {
+	if l := f.locationByName(name); l != nil && l.deletedAt.IsZero() {
+		return l
+	}
+	return nil
+}
+
+func snackExistsError(s *snack) error {
+	if !s.deletedAt.IsZero() {
+		return status.Errorf(codes.AlreadyExists, "barcode %q is in the trash; restore or purge it first", s.snack.GetBarcode())
+	}
+	return status.Errorf(codes.AlreadyExists, "barcode %q already has an entry", s.snack.GetBarcode())
+}
+
+func (f *FakeStore) CreateSnack(ctx context.Context, s *sipb.Snack) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if existing, ok := f.snacks[s.GetBarcode()]; ok {
+		return snackExistsError(existing)
+	}
+	f.createSnack(s, time.Now())
+	return nil
+}
+
+func (f *FakeStore) createSnack(s *sipb.Snack, at time.Time) {
+	created := &snack{snack: proto.Clone(s).(*sipb.Snack)}
+	f.snacks[s.GetBarcode()] = created
+	f.recordSnack(created, sipb.Change_CREATED, at)
+}
+
+func (f *FakeStore) ListSnacks(ctx context.Context) ([]*sipb.Snack, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var snacks []*sipb.Snack
+	for _, s := range f.snacks {
+		if s.deletedAt.IsZero() {
+			snacks = append(snacks, proto.Clone(s.snack).(*sipb.Snack))
+		}
+	}
+	sort.Slice(snacks, func(i, j int) bool { return snacks[i].GetBarcode() < snacks[j].GetBarcode() })
+	return snacks, nil
+}
+
+// UpdateSnack ignores snacks that aren't registered.
+func (f *FakeStore) UpdateSnack(ctx context.Context, s *sipb.Snack) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if existing := f.liveSnack(s.GetBarcode()); existing != nil {
+		existing.snack = proto.Clone(s).(*sipb.Snack)
+		f.recordSnack(existing, sipb.Change_UPDATED, time.Now())
+	}
+	return nil
+}
+
+// DeleteSnack ignores snacks that aren't registered.
+func (f *FakeStore) DeleteSnack(ctx context.Context, barcode string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.deleteSnack(barcode, time.Now())
+	return nil
+}
+
+func (f *FakeStore) deleteSnack(barcode string, at time.Time) {
+	if existing := f.liveSnack(barcode); existing != nil {
+		existing.deletedAt = at
+		f.recordSnack(existing, sipb.Change_DELETED, at)
+	}
+}
+
+func (f *FakeStore) BatchCreateSnacks(ctx context.Context, snacks []*sipb.Snack) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	seen := map[string]bool{}
+	for i, s := range snacks {
+		if seen[s.GetBarcode()] {
+			return &connector.BatchError{Index: i, Err: status.Errorf(codes.AlreadyExists, "barcode %q is repeated in the batch", s.GetBarcode())}
+		}
+		seen[s.GetBarcode()] = true
+	}
+	for i, s := range snacks {
+		if existing, ok := f.snacks[s.GetBarcode()]; ok {
+			return &connector.BatchError{Index: i, Err: snackExistsError(existing)}
+		}
+	}
+	now := time.Now()
+	for _, s := range snacks {
+		f.createSnack(s, now)
+	}
+	return nil
+}
+
+func (f *FakeStore) BatchUpdateSnacks(ctx context.Context, snacks []*sipb.Snack) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i, s := range snacks {
+		if f.liveSnack(s.GetBarcode()) == nil {
+			return &connector.BatchError{Index: i, Err: status.Errorf(codes.NotFound, "barcode %q is not registered", s.GetBarcode())}
+		}
+	}
+	now := time.Now()
+	for _, s := range snacks {
+		existing := f.liveSnack(s.GetBarcode())
+		existing.snack = proto.Clone(s).(*sipb.Snack)
+		f.recordSnack(existing, sipb.Change_UPDATED, now)
+	}
+	return nil
+}
+
+// BatchDeleteSnacks ignores snacks that aren't registered.
+func (f *FakeStore) BatchDeleteSnacks(ctx context.Context, barcodes []string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	now := time.Now()
+	for _, b := range barcodes {
+		f.deleteSnack(b, now)
+	}
+	return nil
+}
+
+// checkNameFree returns an AlreadyExists error if a location is named name,
+// including in the trash.
+func (f *FakeStore) checkNameFree(name string) error {
+	l := f.locationByName(name)
+	switch {
+	case l == nil:
+		return nil
+	case !l.deletedAt.IsZero():
+		return status.Errorf(codes.AlreadyExists, "name %q is in the trash; restore or purge it first", name)
+	default:
+		return status.Errorf(codes.AlreadyExists, "name %q already has an entry", name)
+	}
+}
+
+// checkParent returns an error unless name may be nested inside parent:
+// NotFound if parent is not registered, or FailedPrecondition if parent is
+// name or inside it.
+func (f *FakeStore) checkParent(name, parent string) error {
+	if parent == "" {
+		return nil
+	}
+	if f.liveLocation(parent) == nil {
+		return status.Errorf(codes.NotFound, "parent location %q is not registered", parent)
+	}
+	seen := map[string]bool{}
+	for ancestor := parent; ancestor != "" && !seen[ancestor]; {
+		if ancestor == name {
+			return status.Errorf(codes.FailedPrecondition, "location %q can't be nested inside itself", name)
+		}
+		seen[ancestor] = true
+		l := f.liveLocation(ancestor)
+		if l == nil {
+			break
+		}
+		ancestor = l.location.GetParent()
+	}
+	return nil
+}
+
+func (f *FakeStore) CreateLocation(ctx context.Context, l *sipb.Location) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.checkNameFree(l.GetName()); err != nil {
+		return 0, err
+	}
+	if err := f.checkParent(l.GetName(), l.GetParent()); err != nil {
+		return 0, err
+	}
+	f.lastID++
+	created := &location{location: &sipb.Location{
+		Id:          f.lastID,
+		Name:        l.GetName(),
+		Parent:      l.GetParent(),
+		Description: l.GetDescription(),
+		Type:        l.GetType(),
+	}}
+	f.locations = append(f.locations, created)
+	f.recordLocation(created, sipb.Change_CREATED, time.Now())
+	return f.lastID, nil
+}
+
+func (f *FakeStore) ListLocations(ctx context.Context) ([]*sipb.Location, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var locations []*sipb.Location
+	for _, l := range f.locations {
+		if l.deletedAt.IsZero() {
+			locations = append(locations, proto.Clone(l.location).(*sipb.Location))
+		}
+	}
+	return locations, nil
+}
+
+func (f *FakeStore) UpdateLocation(ctx context.Context, l *sipb.Location, fields []string) (*sipb.Location, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var existing *location
+	for _, e := range f.locations {
+		if e.location.GetId() == l.GetId() && e.deletedAt.IsZero() {
+			existing = e
+		}
+	}
+	if existing == nil {
+		return nil, status.Errorf(codes.NotFound, "location %d is not registered", l.GetId())
+	}
+
+	updated := proto.Clone(existing.location).(*sipb.Location)
+	if len(fields) == 0 {
+		return updated, nil
+	}
+	oldName, oldParent := updated.GetName(), updated.GetParent()
+	for _, field := range fields {
+		switch field {
+		case "name":
+			updated.Name = l.GetName()
+		case "parent":
+			updated.Parent = l.GetParent()
+		case "description":
+			updated.Description = l.GetDescription()
+		case "type":
+			updated.Type = l.GetType()
+		default:
+			return nil, status.Errorf(codes.InvalidArgument, "unknown location field %q", field)
+		}
+	}
+	// Ancestors are still stored under the old name.
+	if updated.GetParent() != oldParent {
+		if err := f.checkParent(oldName, updated.GetParent()); err != nil {
+			return nil, err
+		}
+	}
+	if updated.GetName() != oldName {
+		if err := f.checkNameFree(updated.GetName()); err != nil {
+			return nil, err
+		}
+	}
+
+	now := time.Now()
+	existing.location = updated
+	f.recordLocation(existing, sipb.Change_UPDATED, now)
+	if updated.GetName() == oldName {
+		return proto.Clone(updated).(*sipb.Location), nil
+	}
+	for _, child := range f.locations {
+		if child.location.GetParent() != oldName {
+			continue
+		}
+		child.location.Parent = updated.GetName()
+		if child.deletedAt.IsZero() {
+			f.recordLocation(child, sipb.Change_UPDATED, now)
+		}
+	}
+	for k, count := range f.stock {
+		if k.location == oldName {
+			delete(f.stock, k)
+			f.stock[stockKey{k.barcode, updated.GetName()}] = count
+		}
+	}
+	return proto.Clone(updated).(*sipb.Location), nil
+}
+
+func (f *FakeStore) DeleteLocation(ctx context.Context, name string, contents sipb.ContentsPolicy, target string) ([]*sipb.StockChange, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, l := range f.locations {
+		if l.location.GetParent() == name && l.deletedAt.IsZero() {
+			return nil, status.Errorf(codes.FailedPrecondition,
+				"location %q contains %q; move or delete it first", name, l.location.GetName())
+		}
+	}
+	changes, err := f.clearStock(name, contents, target)
+	if err != nil {
+		return nil, err
+	}
+	if existing := f.liveLocation(name); existing != nil {
+		now := time.Now()
+		existing.deletedAt = now
+		f.recordLocation(existing, sipb.Change_DELETED, now)
+	}
+	return changes, nil
+}
+
+// clearStock removes all stock at name, moving it to target or discarding it
+// per contents, & returns the changes made.
+func (f *FakeStore) clearStock(name string, contents sipb.ContentsPolicy, target string) ([]*sipb.StockChange, error) {
+	if contents == sipb.ContentsPolicy_MOVE {
+		if target == name {
+			return nil, status.Errorf(codes.InvalidArgument, "can't move stock from %q to itself", name)
+		}
+		if f.liveLocation(target) == nil {
+			return nil, status.Errorf(codes.NotFound, "location %q is not registered", target)
+		}
+	}
+
+	var barcodes []string
+	for k := range f.stock {
+		if k.location == name {
+			barcodes = append(barcodes, k.barcode)
+		}
+	}
+	if len(barcodes) == 0 {
+		return nil, nil
+	}
+	sort.Strings(barcodes)
+
+	reason := sipb.StockChange_DISCARDED
+	switch contents {
+	case sipb.ContentsPolicy_MOVE:
+		reason = sipb.StockChange_MOVED
+	case sipb.ContentsPolicy_DISCARD:
+	default:
+		return nil, status.Errorf(codes.FailedPrecondition,
+			"location %q holds %d snacks; move or discard them first", name, len(barcodes))
+	}
+	var changes []*sipb.StockChange
+	for _, b := range barcodes {
+		count := f.stock[stockKey{b, name}]
+		delete(f.stock, stockKey{b, name})
+		changes = append(changes, &sipb.StockChange{
+			Barcode: b, Location: name, Delta: -count, Reason: reason,
+		})
+		if reason != sipb.StockChange_MOVED {
+			continue
+		}
+		moved := f.stock[stockKey{b, target}] + count
+		f.stock[stockKey{b, target}] = moved
+		changes = append(changes, &sipb.StockChange{
+			Barcode: b, Location: target, Delta: count, Count: moved, Reason: reason,
+		})
+	}
+	return changes, nil
+}
+
+func (f *FakeStore) AdjustStock(ctx context.Context, barcode, loc string, delta int32) (int32, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.liveSnack(barcode) == nil {
+		return 0, status.Errorf(codes.NotFound, "barcode %q is not registered", barcode)
+	}
+	if f.liveLocation(loc) == nil {
+		return 0, status.Errorf(codes.NotFound, "location %q is not registered", loc)
+	}
+	k := stockKey{barcode, loc}
+	old := f.stock[k]
+	count := old + delta
+	switch {
+	case count < 0:
+		return 0, status.Errorf(codes.FailedPrecondition,
+			"only %d of %q at %q, can't remove %d", old, barcode, loc, -delta)
+	case count == 0:
+		delete(f.stock, k)
+	default:
+		f.stock[k] = count
+	}
+	return count, nil
+}
+
+func (f *FakeStore) ListStock(ctx context.Context, loc string) ([]*sipb.Stock, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var stock []*sipb.Stock
+	for k, count := range f.stock {
+		if s, ok := f.snacks[k.barcode]; ok && !s.deletedAt.IsZero() {
+			continue
+		}
+		if loc == "" || k.location == loc {
+			stock = append(stock, &sipb.Stock{Barcode: k.barcode, Location: k.location, Count: count})
+		}
+	}
+	sort.Slice(stock, func(i, j int) bool {
+		if stock[i].GetLocation() != stock[j].GetLocation() {
+			return stock[i].GetLocation() < stock[j].GetLocation()
+		}
+		return stock[i].GetBarcode() < stock[j].GetBarcode()
+	})
+	return stock, nil
+}
+
+func (f *FakeStore) ListDeleted(ctx context.Context) ([]*sipb.DeletedEntity, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var deleted []*sipb.DeletedEntity
+	var barcodes []string
+	for b, s := range f.snacks {
+		if !s.deletedAt.IsZero() {
+			barcodes = append(barcodes, b)
+		}
+	}
+	sort.Strings(barcodes)
+	for _, b := range barcodes {
+		s := f.snacks[b]
+		deleted = append(deleted, &sipb.DeletedEntity{
+			Entity:     &sipb.DeletedEntity_Snack{Snack: proto.Clone(s.snack).(*sipb.Snack)},
+			DeleteTime: timestamppb.New(s.deletedAt),
+		})
+	}
+	var locations []*location
+	for _, l := range f.locations {
+		if !l.deletedAt.IsZero() {
+			locations = append(locations, l)
+		}
+	}
+	sort.Slice(locations, func(i, j int) bool { return locations[i].location.GetName() < locations[j].location.GetName() })
+	for _, l := range locations {
+		deleted = append(deleted, &sipb.DeletedEntity{
+			Entity:     &sipb.DeletedEntity_Location{Location: proto.Clone(l.location).(*sipb.Location)},
+			DeleteTime: timestamppb.New(l.deletedAt),
+		})
+	}
+	sort.SliceStable(deleted, func(i, j int) bool {
+		return deleted[i].GetDeleteTime().AsTime().After(deleted[j].GetDeleteTime().AsTime())
+	})
+	return deleted, nil
+}
+
+func (f *FakeStore) UndeleteSnack(ctx context.Context, barcode string) (*sipb.Snack, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	s, ok := f.snacks[barcode]
+	if !ok || s.deletedAt.IsZero() {
+		return nil, status.Errorf(codes.NotFound, "barcode %q is not in the trash", barcode)
+	}
+	s.deletedAt = time.Time{}
+	f.recordSnack(s, sipb.Change_CREATED, time.Now())
+	return proto.Clone(s.snack).(*sipb.Snack), nil
+}
+
+// UndeleteLocation restores a location whose parent has since been purged at
+// the top level.
+func (f *FakeStore) UndeleteLocation(ctx context.Context, name string) (*sipb.Location, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	l := f.locationByName(name)
+	if l == nil || l.deletedAt.IsZero() {
+		return nil, status.Errorf(codes.NotFound, "location %q is not in the trash", name)
+	}
+	if parent := l.location.GetParent(); parent != "" {
+		switch p := f.locationByName(parent); {
+		case p == nil:
+			l.location.Parent = ""
+		case !p.deletedAt.IsZero():
+			return nil, status.Errorf(codes.FailedPrecondition,
+				"parent location %q of %q is in the trash; restore it first", parent, name)
+		}
+	}
+	l.deletedAt = time.Time{}
+	f.recordLocation(l, sipb.Change_CREATED, time.Now())
+	return proto.Clone(l.location).(*sipb.Location), nil
+}
+
+// PurgeDeleted keeps the history of purged snacks & locations.
+func (f *FakeStore) PurgeDeleted(ctx context.Context, before time.Time) (snacks, locations int64, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for b, s := range f.snacks {
+		if s.deletedAt.IsZero() || !s.deletedAt.Before(before) {
+			continue
+		}
+		for k := range f.stock {
+			if k.barcode == b {
+				delete(f.stock, k)
+			}
+		}
+		delete(f.snacks, b)
+		snacks++
+	}
+	kept := f.locations[:0]
+	for _, l := range f.locations {
+		if !l.deletedAt.IsZero() && l.deletedAt.Before(before) {
+			locations++
+			continue
+		}
+		kept = append(kept, l)
+	}
+	f.locations = kept
+	return snacks, locations, nil
+}
+
+func (f *FakeStore) GetSnackHistory(ctx context.Context, barcode string) ([]*sipb.SnackRevision, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var revisions []*sipb.SnackRevision
+	for _, rev := range f.snackRevisions {
+		if rev.GetSnack().GetBarcode() == barcode {
+			revisions = append(revisions, proto.Clone(rev).(*sipb.SnackRevision))
+		}
+	}
+	if len(revisions) == 0 {
+		return nil, status.Errorf(codes.NotFound, "barcode %q has no history", barcode)
+	}
+	return revisions, nil
+}
+
+func (f *FakeStore) ListSnacksAsOf(ctx context.Context, asOf time.Time) ([]*sipb.Snack, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	// Revisions are recorded in order, so later ones replace earlier ones.
+	latest := map[string]*sipb.SnackRevision{}
+	for _, rev := range f.snackRevisions {
+		if !rev.GetRevisionTime().AsTime().After(asOf) {
+			latest[rev.GetSnack().GetBarcode()] = rev
+		}
+	}
+	var snacks []*sipb.Snack
+	for _, rev := range latest {
+		if rev.GetType() != sipb.Change_DELETED {
+			snacks = append(snacks, proto.Clone(rev.GetSnack()).(*sipb.Snack))
+		}
+	}
+	sort.Slice(snacks, func(i, j int) bool { return snacks[i].GetBarcode() < snacks[j].GetBarcode() })
+	return snacks, nil
+}
+
+func (f *FakeStore) ListLocationsAsOf(ctx context.Context, asOf time.Time) ([]*sipb.Location, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	latest := map[int64]*locationRevision{}
+	for _, rev := range f.locationRevisions {
+		if !rev.at.After(asOf) {
+			latest[rev.location.GetId()] = rev
+		}
+	}
+	var locations []*sipb.Location
+	for _, rev := range latest {
+		if rev.typ != sipb.Change_DELETED {
+			locations = append(locations, proto.Clone(rev.location).(*sipb.Location))
+		}
+	}
+	sort.Slice(locations, func(i, j int) bool { return locations[i].GetId() < locations[j].GetId() })
+	return locations, nil
+}
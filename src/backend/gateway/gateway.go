@@ -0,0 +1,465 @@
+/*
+Copyright 2020 Robert Barron
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package gateway provides an HTTP+JSON gateway to the SnackInventory gRPC
+// service.
+//
+// Routes are not hand-written: they are read at startup from the
+// `google.api.http` annotations on snackinventory.proto, so adding a binding
+// to the proto is all that is needed to expose an RPC over HTTP. Requests are
+// translated to protos with protojson, forwarded to the gRPC server, and the
+// response translated back. gRPC status codes are mapped to HTTP statuses
+// following the mapping used by grpc-gateway.
+package gateway
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+
+	sipb "github.com/rmbarron/SnackInventory/src/proto/snackinventory"
+	"github.com/rmbarron/SnackInventory/src/tracing"
+	"google.golang.org/genproto/googleapis/api/annotations"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+)
+
+// OpenAPIPath is the path the generated OpenAPI document is served at.
+const OpenAPIPath = "/openapi.json"
+
+// maxBodyBytes limits the size of request bodies to gRPC's default maximum
+// received message size, which the server keeps, so larger bodies aren't read
+// into memory only to be rejected by the server.
+const maxBodyBytes = 4 << 20
+
+// errBodyTooLarge is returned by buildRequest for bodies over maxBodyBytes.
+var errBodyTooLarge = status.Errorf(codes.InvalidArgument, "body is larger than %d bytes", maxBodyBytes)
+
+var (
+	unmarshaler = protojson.UnmarshalOptions{}
+	marshaler   = protojson.MarshalOptions{UseProtoNames: true, EmitUnpopulated: true}
+)
+
+// segment is one '/'-separated piece of a path template. Exactly one of
+// literal or field is set.
+type segment struct {
+	literal string
+	// field is the dotted path of the request field bound to this segment.
+	field string
+}
+
+// route binds an HTTP method & path template to an RPC.
+type route struct {
+	httpMethod string
+	template   string
+	segments   []segment
+	// body is the request field populated from the HTTP body: "" for no body,
+	// "*" for the whole request, or a top-level field name.
+	body string
+
+	fullMethod string
+	method     protoreflect.MethodDescriptor
+	input      protoreflect.MessageType
+	output     protoreflect.MessageType
+}
+
+// Handler serves the HTTP+JSON API by forwarding to a gRPC connection.
+type Handler struct {
+	conn    grpc.ClientConnInterface
+	routes  []*route
+	openAPI []byte
+}
+
+// NewHandler creates a Handler forwarding to the SnackInventory service on conn.
+func NewHandler(conn grpc.ClientConnInterface) (*Handler, error) {
+	svc := sipb.File_snackinventory_proto.Services().ByName("SnackInventory")
+	if svc == nil {
+		return nil, fmt.Errorf("SnackInventory service descriptor not found")
+	}
+	routes, err := routesFor(svc)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := openAPI(svc, routes)
+	if err != nil {
+		return nil, fmt.Errorf("could not generate OpenAPI document: %w", err)
+	}
+	return &Handler{conn: conn, routes: routes, openAPI: doc}, nil
+}
+
+// routesFor reads the HTTP bindings of every method of svc.
+// Methods without a binding are not exposed.
+func routesFor(svc protoreflect.ServiceDescriptor) ([]*route, error) {
+	var routes []*route
+	for i := 0; i < svc.Methods().Len(); i++ {
+		m := svc.Methods().Get(i)
+		if m.IsStreamingClient() || m.IsStreamingServer() {
+			continue
+		}
+		rule, ok := proto.GetExtension(m.Options(), annotations.E_Http).(*annotations.HttpRule)
+		if !ok || rule == nil {
+			continue
+		}
+		r, err := newRoute(svc, m, rule)
+		if err != nil {
+			return nil, fmt.Errorf("bad HTTP binding for %s: %w", m.FullName(), err)
+		}
+		routes = append(routes, r)
+	}
+	return routes, nil
+}
+
+func newRoute(svc protoreflect.ServiceDescriptor, m protoreflect.MethodDescriptor, rule *annotations.HttpRule) (*route, error) {
+	r := &route{
+		body:       rule.GetBody(),
+		fullMethod: fmt.Sprintf("/%s/%s", svc.FullName(), m.Name()),
+		method:     m,
+	}
+	switch p := rule.GetPattern().(type) {
+	case *annotations.HttpRule_Get:
+		r.httpMethod, r.template = http.MethodGet, p.Get
+	case *annotations.HttpRule_Post:
+		r.httpMethod, r.template = http.MethodPost, p.Post
+	case *annotations.HttpRule_Put:
+		r.httpMethod, r.template = http.MethodPut, p.Put
+	case *annotations.HttpRule_Patch:
+		r.httpMethod, r.template = http.MethodPatch, p.Patch
+	case *annotations.HttpRule_Delete:
+		r.httpMethod, r.template = http.MethodDelete, p.Delete
+	default:
+		return nil, fmt.Errorf("unsupported pattern %v", p)
+	}
+
+	for _, s := range strings.Split(strings.Trim(r.template, "/"), "/") {
+		if strings.HasPrefix(s, "{") && strings.HasSuffix(s, "}") {
+			field := strings.TrimSuffix(strings.TrimPrefix(s, "{"), "}")
+			if strings.Contains(field, "=") {
+				return nil, fmt.Errorf("path variable sub-patterns are unsupported: %q", s)
+			}
+			if _, err := fieldPath(m.Input(), field); err != nil {
+				return nil, err
+			}
+			r.segments = append(r.segments, segment{field: field})
+			continue
+		}
+		r.segments = append(r.segments, segment{literal: s})
+	}
+	if r.body != "" && r.body != "*" && m.Input().Fields().ByName(protoreflect.Name(r.body)) == nil {
+		return nil, fmt.Errorf("body field %q not found in %s", r.body, m.Input().FullName())
+	}
+
+	var err error
+	if r.input, err = protoregistry.GlobalTypes.FindMessageByName(m.Input().FullName()); err != nil {
+		return nil, err
+	}
+	if r.output, err = protoregistry.GlobalTypes.FindMessageByName(m.Output().FullName()); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// match reports whether path matches r's template, returning bound variables.
+func (r *route) match(path string) (map[string]string, bool) {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	if len(parts) != len(r.segments) {
+		return nil, false
+	}
+	vars := map[string]string{}
+	for i, s := range r.segments {
+		if s.field == "" {
+			if parts[i] != s.literal {
+				return nil, false
+			}
+			continue
+		}
+		if parts[i] == "" {
+			return nil, false
+		}
+		vars[s.field] = parts[i]
+	}
+	return vars, true
+}
+
+// ServeHTTP implements http.Handler.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.URL.Path == OpenAPIPath && req.Method == http.MethodGet {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(h.openAPI)
+		return
+	}
+
+	pathMatched := false
+	for _, r := range h.routes {
+		vars, ok := r.match(req.URL.Path)
+		if !ok {
+			continue
+		}
+		pathMatched = true
+		if r.httpMethod != req.Method {
+			continue
+		}
+		h.serveRoute(w, req, r, vars)
+		return
+	}
+	if pathMatched {
+		writeError(w, http.StatusMethodNotAllowed, status.New(codes.Unimplemented, "method not allowed"))
+		return
+	}
+	writeError(w, http.StatusNotFound, status.Newf(codes.NotFound, "no route for %s", req.URL.Path))
+}
+
+func (h *Handler) serveRoute(w http.ResponseWriter, req *http.Request, r *route, vars map[string]string) {
+	req.Body = http.MaxBytesReader(w, req.Body, maxBodyBytes)
+	in, err := r.buildRequest(req, vars)
+	if err == errBodyTooLarge {
+		writeError(w, http.StatusRequestEntityTooLarge, status.Convert(err))
+		return
+	}
+	if err != nil {
+		writeStatus(w, status.Convert(err))
+		return
+	}
+
+	ctx := req.Context()
+	if tp := req.Header.Get(tracing.TraceparentHeader); tp != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, tracing.TraceparentHeader, tp)
+	}
+	out := r.output.New().Interface()
+	if err := h.conn.Invoke(ctx, r.fullMethod, in, out); err != nil {
+		writeStatus(w, status.Convert(err))
+		return
+	}
+
+	b, err := marshaler.Marshal(out)
+	if err != nil {
+		writeStatus(w, status.Newf(codes.Internal, "could not marshal response: %v", err))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(b)
+}
+
+// buildRequest populates the RPC request from the HTTP body, path variables,
+// and query parameters, in that order. Returns errBodyTooLarge if the body is
+// over maxBodyBytes.
+func (r *route) buildRequest(req *http.Request, vars map[string]string) (proto.Message, error) {
+	in := r.input.New()
+
+	if r.body != "" {
+		body, err := ioutil.ReadAll(req.Body)
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return nil, errBodyTooLarge
+		}
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "could not read body: %v", err)
+		}
+		if len(body) > 0 {
+			target := in
+			if r.body != "*" {
+				target = in.Mutable(in.Descriptor().Fields().ByName(protoreflect.Name(r.body))).Message()
+			}
+			if err := unmarshaler.Unmarshal(body, target.Interface()); err != nil {
+				return nil, status.Errorf(codes.InvalidArgument, "could not parse body: %v", err)
+			}
+		}
+	}
+
+	for field, v := range vars {
+		if err := setField(in, field, v); err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "bad path variable %q: %v", field, err)
+		}
+	}
+
+	if r.body != "*" {
+		for field, vs := range req.URL.Query() {
+			for _, v := range vs {
+				if err := setField(in, field, v); err != nil {
+					return nil, status.Errorf(codes.InvalidArgument, "bad query parameter %q: %v", field, err)
+				}
+			}
+		}
+	}
+	return in.Interface(), nil
+}
+
+// fieldPath resolves a dotted field path against md.
+func fieldPath(md protoreflect.MessageDescriptor, path string) ([]protoreflect.FieldDescriptor, error) {
+	var fds []protoreflect.FieldDescriptor
+	for i, name := range strings.Split(path, ".") {
+		if md == nil {
+			return nil, fmt.Errorf("%q is not a message field", strings.Join(strings.Split(path, ".")[:i], "."))
+		}
+		fd := md.Fields().ByName(protoreflect.Name(name))
+		if fd == nil {
+			return nil, fmt.Errorf("no field %q in %s", name, md.FullName())
+		}
+		fds = append(fds, fd)
+		md = fd.Message()
+	}
+	return fds, nil
+}
+
+// setField parses v into the scalar field at path in m, creating intermediate
+// messages as needed. Repeated fields are appended to.
+func setField(m protoreflect.Message, path, v string) error {
+	fds, err := fieldPath(m.Descriptor(), path)
+	if err != nil {
+		return err
+	}
+	for _, fd := range fds[:len(fds)-1] {
+		if fd.IsList() || fd.IsMap() {
+			return fmt.Errorf("%s: cannot traverse repeated field", fd.Name())
+		}
+		m = m.Mutable(fd).Message()
+	}
+	fd := fds[len(fds)-1]
+	if fd.IsMap() {
+		return fmt.Errorf("map fields are unsupported")
+	}
+	val, err := parseScalar(fd, v)
+	if err != nil {
+		return err
+	}
+	if fd.IsList() {
+		m.Mutable(fd).List().Append(val)
+		return nil
+	}
+	m.Set(fd, val)
+	return nil
+}
+
+// parseScalar parses v as the type of fd.
+func parseScalar(fd protoreflect.FieldDescriptor, v string) (protoreflect.Value, error) {
+	switch fd.Kind() {
+	case protoreflect.StringKind:
+		return protoreflect.ValueOfString(v), nil
+	case protoreflect.BytesKind:
+		return protoreflect.ValueOfBytes([]byte(v)), nil
+	case protoreflect.BoolKind:
+		b, err := strconv.ParseBool(v)
+		return protoreflect.ValueOfBool(b), err
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		n, err := strconv.ParseInt(v, 10, 32)
+		return protoreflect.ValueOfInt32(int32(n)), err
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		n, err := strconv.ParseInt(v, 10, 64)
+		return protoreflect.ValueOfInt64(n), err
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		n, err := strconv.ParseUint(v, 10, 32)
+		return protoreflect.ValueOfUint32(uint32(n)), err
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		n, err := strconv.ParseUint(v, 10, 64)
+		return protoreflect.ValueOfUint64(n), err
+	case protoreflect.FloatKind:
+		f, err := strconv.ParseFloat(v, 32)
+		return protoreflect.ValueOfFloat32(float32(f)), err
+	case protoreflect.DoubleKind:
+		f, err := strconv.ParseFloat(v, 64)
+		return protoreflect.ValueOfFloat64(f), err
+	case protoreflect.EnumKind:
+		if ev := fd.Enum().Values().ByName(protoreflect.Name(v)); ev != nil {
+			return protoreflect.ValueOfEnum(ev.Number()), nil
+		}
+		n, err := strconv.ParseInt(v, 10, 32)
+		if err != nil {
+			return protoreflect.Value{}, fmt.Errorf("unknown %s value %q", fd.Enum().FullName(), v)
+		}
+		return protoreflect.ValueOfEnum(protoreflect.EnumNumber(n)), nil
+	case protoreflect.MessageKind:
+		// Well-known wrapper-like types (ex: Timestamp) accept their JSON form.
+		m := dynamicMessage(fd)
+		if m == nil {
+			return protoreflect.Value{}, fmt.Errorf("message fields are unsupported")
+		}
+		if err := unmarshaler.Unmarshal([]byte(strconv.Quote(v)), m.Interface()); err != nil {
+			return protoreflect.Value{}, err
+		}
+		return protoreflect.ValueOfMessage(m), nil
+	}
+	return protoreflect.Value{}, fmt.Errorf("unsupported field kind %v", fd.Kind())
+}
+
+// dynamicMessage returns a new message of fd's type if it is a well-known type
+// with a JSON string representation, or nil otherwise.
+func dynamicMessage(fd protoreflect.FieldDescriptor) protoreflect.Message {
+	switch fd.Message().FullName() {
+	case "google.protobuf.Timestamp", "google.protobuf.Duration", "google.protobuf.FieldMask":
+		mt, err := protoregistry.GlobalTypes.FindMessageByName(fd.Message().FullName())
+		if err != nil {
+			return nil
+		}
+		return mt.New()
+	}
+	return nil
+}
+
+// HTTPStatusFromCode maps a gRPC status code to the corresponding HTTP status.
+func HTTPStatusFromCode(c codes.Code) int {
+	switch c {
+	case codes.OK:
+		return http.StatusOK
+	case codes.Canceled:
+		return 499 // Client Closed Request, as used by nginx & grpc-gateway.
+	case codes.InvalidArgument, codes.OutOfRange:
+		return http.StatusBadRequest
+	case codes.DeadlineExceeded:
+		return http.StatusGatewayTimeout
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.AlreadyExists, codes.Aborted:
+		return http.StatusConflict
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+	case codes.ResourceExhausted:
+		return http.StatusTooManyRequests
+	case codes.FailedPrecondition:
+		return http.StatusBadRequest
+	case codes.Unimplemented:
+		return http.StatusNotImplemented
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// writeStatus writes s as a JSON error, with the HTTP status mapped from its code.
+func writeStatus(w http.ResponseWriter, s *status.Status) {
+	writeError(w, HTTPStatusFromCode(s.Code()), s)
+}
+
+// writeError writes s as a JSON error body, in the shape of google.rpc.Status.
+func writeError(w http.ResponseWriter, httpStatus int, s *status.Status) {
+	b, err := marshaler.Marshal(s.Proto())
+	if err != nil {
+		b = []byte(`{"code": 13, "message": "could not marshal error"}`)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(httpStatus)
+	w.Write(b)
+}
@@ -0,0 +1,342 @@
+/*
+Copyright 2020 Robert Barron
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/rmbarron/SnackInventory/src/backend/fakes/fakeserver"
+	sipb "github.com/rmbarron/SnackInventory/src/proto/snackinventory"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// recordingServer records the requests of RPCs whose bodies are checked.
+type recordingServer struct {
+	*fakeserver.FakeSnackInventoryServer
+	updateReq         *sipb.UpdateSnackRequest
+	deleteReq         *sipb.DeleteSnackRequest
+	updateLocationReq *sipb.UpdateLocationRequest
+	deleteLocationReq *sipb.DeleteLocationRequest
+	listSnacksReq     *sipb.ListSnacksRequest
+	historyReq        *sipb.GetSnackHistoryRequest
+}
+
+func (r *recordingServer) UpdateSnack(ctx context.Context, req *sipb.UpdateSnackRequest) (*sipb.UpdateSnackResponse, error) {
+	r.updateReq = req
+	return r.FakeSnackInventoryServer.UpdateSnack(ctx, req)
+}
+
+func (r *recordingServer) DeleteSnack(ctx context.Context, req *sipb.DeleteSnackRequest) (*sipb.DeleteSnackResponse, error) {
+	r.deleteReq = req
+	return r.FakeSnackInventoryServer.DeleteSnack(ctx, req)
+}
+
+func (r *recordingServer) UpdateLocation(ctx context.Context, req *sipb.UpdateLocationRequest) (*sipb.UpdateLocationResponse, error) {
+	r.updateLocationReq = req
+	return r.FakeSnackInventoryServer.UpdateLocation(ctx, req)
+}
+
+func (r *recordingServer) DeleteLocation(ctx context.Context, req *sipb.DeleteLocationRequest) (*sipb.DeleteLocationResponse, error) {
+	r.deleteLocationReq = req
+	return r.FakeSnackInventoryServer.DeleteLocation(ctx, req)
+}
+
+func (r *recordingServer) ListSnacks(ctx context.Context, req *sipb.ListSnacksRequest) (*sipb.ListSnacksResponse, error) {
+	r.listSnacksReq = req
+	return r.FakeSnackInventoryServer.ListSnacks(ctx, req)
+}
+
+func (r *recordingServer) GetSnackHistory(ctx context.Context, req *sipb.GetSnackHistoryRequest) (*sipb.GetSnackHistoryResponse, error) {
+	r.historyReq = req
+	return r.FakeSnackInventoryServer.GetSnackHistory(ctx, req)
+}
+
+// startGatewayT starts a gRPC server backed by srv and a gateway in front of it.
+// Returns the gateway's base URL and a close function.
+func startGatewayT(t *testing.T, srv interface{}) (string, func()) {
+	t.Helper()
+	lis, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatalf("net.Listen(%q, %q) = got err %v, want err nil", "tcp", "localhost:0", err)
+	}
+	grpcServer := grpc.NewServer()
+	sipb.RegisterSnackInventoryService(grpcServer, sipb.NewSnackInventoryService(srv))
+	go grpcServer.Serve(lis)
+
+	conn, err := grpc.Dial(lis.Addr().String(), grpc.WithInsecure())
+	if err != nil {
+		t.Fatalf("grpc.Dial(%q) = got err %v, want err nil", lis.Addr(), err)
+	}
+	h, err := NewHandler(conn)
+	if err != nil {
+		t.Fatalf("NewHandler(conn) = got err %v, want err nil", err)
+	}
+	hs := httptest.NewServer(h)
+	return hs.URL, func() { hs.Close(); conn.Close(); grpcServer.Stop() }
+}
+
+// doT issues an HTTP request, returning the status code and body.
+func doT(t *testing.T, method, url, body string) (int, string) {
+	t.Helper()
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("http.NewRequest(%q, %q) = got err %v, want err nil", method, url, err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("%s %s = got err %v, want err nil", method, url, err)
+	}
+	defer resp.Body.Close()
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("ioutil.ReadAll(%s %s) = got err %v, want err nil", method, url, err)
+	}
+	return resp.StatusCode, string(b)
+}
+
+func TestListSnacks(t *testing.T) {
+	fsi := &fakeserver.FakeSnackInventoryServer{
+		ListSnacksRes: &sipb.ListSnacksResponse{
+			Snacks: []*sipb.Snack{{Barcode: "123", Name: "testsnack", Brand: "testbrand"}},
+		},
+	}
+	url, close := startGatewayT(t, fsi)
+	defer close()
+
+	code, body := doT(t, http.MethodGet, url+"/v1/snacks", "")
+	if code != http.StatusOK {
+		t.Fatalf("GET /v1/snacks = got status %d (%s), want %d", code, body, http.StatusOK)
+	}
+	var got map[string]interface{}
+	if err := json.Unmarshal([]byte(body), &got); err != nil {
+		t.Fatalf("json.Unmarshal(%q) = got err %v, want err nil", body, err)
+	}
+	want := map[string]interface{}{
+		"snacks": []interface{}{map[string]interface{}{
+			"barcode":      "123",
+			"name":         "testsnack",
+			"brand":        "testbrand",
+			"category":     "",
+			"package_size": "",
+		}},
+	}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Fatalf("GET /v1/snacks = got diff (-got +want): %s", diff)
+	}
+}
+
+func TestListSnacks_AsOf(t *testing.T) {
+	rs := &recordingServer{FakeSnackInventoryServer: &fakeserver.FakeSnackInventoryServer{
+		ListSnacksRes: &sipb.ListSnacksResponse{},
+	}}
+	url, close := startGatewayT(t, rs)
+	defer close()
+
+	code, body := doT(t, http.MethodGet, url+"/v1/snacks?as_of=2020-01-05T00:00:00Z", "")
+	if code != http.StatusOK {
+		t.Fatalf("GET /v1/snacks?as_of=... = got status %d (%s), want %d", code, body, http.StatusOK)
+	}
+	want := &sipb.ListSnacksRequest{AsOf: timestamppb.New(time.Date(2020, 1, 5, 0, 0, 0, 0, time.UTC))}
+	if !proto.Equal(rs.listSnacksReq, want) {
+		t.Fatalf("GET /v1/snacks?as_of=... sent %v, want %v", rs.listSnacksReq, want)
+	}
+}
+
+func TestGetSnackHistory_Path(t *testing.T) {
+	rs := &recordingServer{FakeSnackInventoryServer: &fakeserver.FakeSnackInventoryServer{
+		GetSnackHistoryRes: &sipb.GetSnackHistoryResponse{},
+	}}
+	url, close := startGatewayT(t, rs)
+	defer close()
+
+	code, body := doT(t, http.MethodGet, url+"/v1/snacks/123/history", "")
+	if code != http.StatusOK {
+		t.Fatalf("GET /v1/snacks/123/history = got status %d (%s), want %d", code, body, http.StatusOK)
+	}
+	want := &sipb.GetSnackHistoryRequest{Barcode: "123"}
+	if !proto.Equal(rs.historyReq, want) {
+		t.Fatalf("GET /v1/snacks/123/history sent %v, want %v", rs.historyReq, want)
+	}
+}
+
+func TestUpdateSnack_PathAndBody(t *testing.T) {
+	rs := &recordingServer{FakeSnackInventoryServer: &fakeserver.FakeSnackInventoryServer{
+		UpdateSnackRes: &sipb.UpdateSnackResponse{},
+	}}
+	url, close := startGatewayT(t, rs)
+	defer close()
+
+	code, body := doT(t, http.MethodPut, url+"/v1/snacks/123", `{"name": "newname"}`)
+	if code != http.StatusOK {
+		t.Fatalf("PUT /v1/snacks/123 = got status %d (%s), want %d", code, body, http.StatusOK)
+	}
+	want := &sipb.UpdateSnackRequest{Snack: &sipb.Snack{Barcode: "123", Name: "newname"}}
+	if !proto.Equal(rs.updateReq, want) {
+		t.Fatalf("PUT /v1/snacks/123 sent %v, want %v", rs.updateReq, want)
+	}
+}
+
+func TestUpdateLocation_FieldMask(t *testing.T) {
+	rs := &recordingServer{FakeSnackInventoryServer: &fakeserver.FakeSnackInventoryServer{
+		UpdateLocationRes: &sipb.UpdateLocationResponse{},
+	}}
+	url, close := startGatewayT(t, rs)
+	defer close()
+
+	code, body := doT(t, http.MethodPatch, url+"/v1/locations/7?update_mask=name,type", `{"name": "cupboard", "type": "CUPBOARD"}`)
+	if code != http.StatusOK {
+		t.Fatalf("PATCH /v1/locations/7 = got status %d (%s), want %d", code, body, http.StatusOK)
+	}
+	want := &sipb.UpdateLocationRequest{
+		Location:   &sipb.Location{Id: 7, Name: "cupboard", Type: sipb.LocationType_CUPBOARD},
+		UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"name", "type"}},
+	}
+	if !proto.Equal(rs.updateLocationReq, want) {
+		t.Fatalf("PATCH /v1/locations/7 sent %v, want %v", rs.updateLocationReq, want)
+	}
+}
+
+func TestDeleteSnack_Path(t *testing.T) {
+	rs := &recordingServer{FakeSnackInventoryServer: &fakeserver.FakeSnackInventoryServer{
+		DeleteSnackRes: &sipb.DeleteSnackResponse{},
+	}}
+	url, close := startGatewayT(t, rs)
+	defer close()
+
+	code, body := doT(t, http.MethodDelete, url+"/v1/snacks/123", "")
+	if code != http.StatusOK {
+		t.Fatalf("DELETE /v1/snacks/123 = got status %d (%s), want %d", code, body, http.StatusOK)
+	}
+	want := &sipb.DeleteSnackRequest{Barcode: "123"}
+	if !proto.Equal(rs.deleteReq, want) {
+		t.Fatalf("DELETE /v1/snacks/123 sent %v, want %v", rs.deleteReq, want)
+	}
+}
+
+func TestDeleteLocation_Contents(t *testing.T) {
+	rs := &recordingServer{FakeSnackInventoryServer: &fakeserver.FakeSnackInventoryServer{
+		DeleteLocationRes: &sipb.DeleteLocationResponse{},
+	}}
+	url, close := startGatewayT(t, rs)
+	defer close()
+
+	code, body := doT(t, http.MethodDelete, url+"/v1/locations/cupboard?contents=MOVE&target=pantry", "")
+	if code != http.StatusOK {
+		t.Fatalf("DELETE /v1/locations/cupboard = got status %d (%s), want %d", code, body, http.StatusOK)
+	}
+	want := &sipb.DeleteLocationRequest{Name: "cupboard", Contents: sipb.ContentsPolicy_MOVE, Target: "pantry"}
+	if !proto.Equal(rs.deleteLocationReq, want) {
+		t.Fatalf("DELETE /v1/locations/cupboard sent %v, want %v", rs.deleteLocationReq, want)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	fsi := &fakeserver.FakeSnackInventoryServer{
+		CreateSnackErr: status.Error(codes.AlreadyExists, "snack exists"),
+	}
+	url, close := startGatewayT(t, fsi)
+	defer close()
+
+	tests := []struct {
+		desc     string
+		method   string
+		path     string
+		body     string
+		wantCode int
+	}{
+		{"ServerError", http.MethodPost, "/v1/snacks", `{"barcode": "123"}`, http.StatusConflict},
+		{"BadBody", http.MethodPost, "/v1/snacks", `{"barcode": `, http.StatusBadRequest},
+		{"UnknownField", http.MethodPost, "/v1/snacks", `{"color": "red"}`, http.StatusBadRequest},
+		{"TooLarge", http.MethodPost, "/v1/snacks", `{"name": "` + strings.Repeat("x", maxBodyBytes) + `"}`, http.StatusRequestEntityTooLarge},
+		{"NoRoute", http.MethodGet, "/v1/nope", "", http.StatusNotFound},
+		{"WrongMethod", http.MethodPatch, "/v1/snacks", "", http.StatusMethodNotAllowed},
+	}
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			code, body := doT(t, tc.method, url+tc.path, tc.body)
+			if code != tc.wantCode {
+				t.Fatalf("%s %s = got status %d (%s), want %d", tc.method, tc.path, code, body, tc.wantCode)
+			}
+			var got struct {
+				Code    int    `json:"code"`
+				Message string `json:"message"`
+			}
+			if err := json.Unmarshal([]byte(body), &got); err != nil || got.Message == "" {
+				t.Fatalf("%s %s = got body %q, want google.rpc.Status JSON", tc.method, tc.path, body)
+			}
+		})
+	}
+}
+
+func TestOpenAPI(t *testing.T) {
+	url, close := startGatewayT(t, &fakeserver.FakeSnackInventoryServer{})
+	defer close()
+
+	code, body := doT(t, http.MethodGet, url+OpenAPIPath, "")
+	if code != http.StatusOK {
+		t.Fatalf("GET %s = got status %d, want %d", OpenAPIPath, code, http.StatusOK)
+	}
+	var doc struct {
+		Paths map[string]map[string]struct {
+			OperationID string `json:"operationId"`
+		} `json:"paths"`
+	}
+	if err := json.Unmarshal([]byte(body), &doc); err != nil {
+		t.Fatalf("json.Unmarshal(%s) = got err %v, want err nil", OpenAPIPath, err)
+	}
+	want := map[string]map[string]string{
+		"/v1/snacks":                   {"post": "CreateSnack", "get": "ListSnacks"},
+		"/v1/snacks/{snack.barcode}":   {"put": "updateSnack"},
+		"/v1/snacks/{barcode}":         {"delete": "DeleteSnack"},
+		"/v1/snacks/{barcode}/history": {"get": "GetSnackHistory"},
+		"/v1/snacks:batchCreate":       {"post": "BatchCreateSnacks"},
+		"/v1/snacks:batchUpdate":       {"post": "BatchUpdateSnacks"},
+		"/v1/snacks:batchDelete":       {"post": "BatchDeleteSnacks"},
+		"/v1/snacks:undelete":          {"post": "UndeleteSnack"},
+		"/v1/locations":                {"post": "CreateLocation", "get": "ListLocations"},
+		"/v1/locations/{location.id}":  {"patch": "UpdateLocation"},
+		"/v1/locations/{name}":         {"delete": "DeleteLocation"},
+		"/v1/locations:undelete":       {"post": "UndeleteLocation"},
+		"/v1/stock":                    {"get": "ListStock"},
+		"/v1/stock:adjust":             {"post": "AdjustStock"},
+		"/v1/trash":                    {"get": "ListDeleted"},
+		"/v1/trash:purge":              {"post": "PurgeDeleted"},
+	}
+	got := map[string]map[string]string{}
+	for path, ops := range doc.Paths {
+		got[path] = map[string]string{}
+		for method, op := range ops {
+			got[path][method] = op.OperationID
+		}
+	}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Fatalf("GET %s paths = got diff (-got +want): %s", OpenAPIPath, diff)
+	}
+}
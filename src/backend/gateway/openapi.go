@@ -0,0 +1,221 @@
+/*
+Copyright 2020 Robert Barron
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gateway
+
+import (
+	"encoding/json"
+	"strings"
+
+	spb "google.golang.org/genproto/googleapis/rpc/status"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// statusDescriptor describes the error body written by the gateway.
+func statusDescriptor() protoreflect.MessageDescriptor {
+	return (&spb.Status{}).ProtoReflect().Descriptor()
+}
+
+// schemaRef returns a JSON reference to the schema for md.
+func schemaRef(md protoreflect.MessageDescriptor) map[string]interface{} {
+	return map[string]interface{}{"$ref": "#/components/schemas/" + string(md.FullName())}
+}
+
+// schemas accumulates OpenAPI schemas for messages, keyed by full name.
+type schemas map[string]interface{}
+
+// add adds md & every message it references to s.
+func (s schemas) add(md protoreflect.MessageDescriptor) {
+	name := string(md.FullName())
+	if _, ok := s[name]; ok {
+		return
+	}
+	props := map[string]interface{}{}
+	obj := map[string]interface{}{"type": "object", "properties": props}
+	s[name] = obj
+	fields := md.Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		props[string(fd.Name())] = s.fieldSchema(fd)
+	}
+}
+
+// fieldSchema returns the schema of fd, following protojson's mapping.
+func (s schemas) fieldSchema(fd protoreflect.FieldDescriptor) map[string]interface{} {
+	if fd.IsMap() {
+		return map[string]interface{}{
+			"type":                 "object",
+			"additionalProperties": s.singularSchema(fd.MapValue()),
+		}
+	}
+	if fd.IsList() {
+		return map[string]interface{}{"type": "array", "items": s.singularSchema(fd)}
+	}
+	return s.singularSchema(fd)
+}
+
+// singularSchema returns the schema of a single value of fd's type.
+func (s schemas) singularSchema(fd protoreflect.FieldDescriptor) map[string]interface{} {
+	switch fd.Kind() {
+	case protoreflect.StringKind:
+		return map[string]interface{}{"type": "string"}
+	case protoreflect.BytesKind:
+		return map[string]interface{}{"type": "string", "format": "byte"}
+	case protoreflect.BoolKind:
+		return map[string]interface{}{"type": "boolean"}
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind,
+		protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		return map[string]interface{}{"type": "integer", "format": "int32"}
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind,
+		protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		// protojson encodes 64-bit integers as strings.
+		return map[string]interface{}{"type": "string", "format": "int64"}
+	case protoreflect.FloatKind:
+		return map[string]interface{}{"type": "number", "format": "float"}
+	case protoreflect.DoubleKind:
+		return map[string]interface{}{"type": "number", "format": "double"}
+	case protoreflect.EnumKind:
+		var names []string
+		values := fd.Enum().Values()
+		for i := 0; i < values.Len(); i++ {
+			names = append(names, string(values.Get(i).Name()))
+		}
+		return map[string]interface{}{"type": "string", "enum": names}
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		switch fd.Message().FullName() {
+		case "google.protobuf.Timestamp":
+			return map[string]interface{}{"type": "string", "format": "date-time"}
+		case "google.protobuf.Duration", "google.protobuf.FieldMask":
+			return map[string]interface{}{"type": "string"}
+		}
+		s.add(fd.Message())
+		return schemaRef(fd.Message())
+	}
+	return map[string]interface{}{}
+}
+
+// queryParams returns the fields of md that may be set as query parameters:
+// scalar fields not bound to the path, recursing into singular messages.
+func queryParams(md protoreflect.MessageDescriptor, prefix string, bound map[string]bool, s schemas) []interface{} {
+	var params []interface{}
+	fields := md.Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		name := prefix + string(fd.Name())
+		if bound[name] || fd.IsMap() {
+			continue
+		}
+		if fd.Kind() == protoreflect.MessageKind && dynamicMessage(fd) == nil {
+			if !fd.IsList() {
+				params = append(params, queryParams(fd.Message(), name+".", bound, s)...)
+			}
+			continue
+		}
+		params = append(params, map[string]interface{}{
+			"name":   name,
+			"in":     "query",
+			"schema": s.fieldSchema(fd),
+		})
+	}
+	return params
+}
+
+// openAPI generates an OpenAPI 3 document describing routes.
+func openAPI(svc protoreflect.ServiceDescriptor, routes []*route) ([]byte, error) {
+	s := schemas{}
+	paths := map[string]map[string]interface{}{}
+
+	s.add(statusDescriptor())
+	errResp := map[string]interface{}{
+		"description": "Error, in the shape of google.rpc.Status.",
+		"content": map[string]interface{}{
+			"application/json": map[string]interface{}{"schema": schemaRef(statusDescriptor())},
+		},
+	}
+
+	for _, r := range routes {
+		in, out := r.method.Input(), r.method.Output()
+		s.add(out)
+
+		var params []interface{}
+		bound := map[string]bool{}
+		for _, seg := range r.segments {
+			if seg.field == "" {
+				continue
+			}
+			bound[seg.field] = true
+			fds, _ := fieldPath(in, seg.field)
+			params = append(params, map[string]interface{}{
+				"name":     seg.field,
+				"in":       "path",
+				"required": true,
+				"schema":   s.fieldSchema(fds[len(fds)-1]),
+			})
+		}
+
+		op := map[string]interface{}{
+			"operationId": string(r.method.Name()),
+			"responses": map[string]interface{}{
+				"200": map[string]interface{}{
+					"description": "OK",
+					"content": map[string]interface{}{
+						"application/json": map[string]interface{}{"schema": schemaRef(out)},
+					},
+				},
+				"default": errResp,
+			},
+		}
+		switch r.body {
+		case "":
+			params = append(params, queryParams(in, "", bound, s)...)
+		case "*":
+			s.add(in)
+			op["requestBody"] = jsonBody(schemaRef(in))
+		default:
+			bound[r.body] = true
+			op["requestBody"] = jsonBody(s.fieldSchema(in.Fields().ByName(protoreflect.Name(r.body))))
+			params = append(params, queryParams(in, "", bound, s)...)
+		}
+		if len(params) > 0 {
+			op["parameters"] = params
+		}
+
+		if paths[r.template] == nil {
+			paths[r.template] = map[string]interface{}{}
+		}
+		paths[r.template][strings.ToLower(r.httpMethod)] = op
+	}
+
+	return json.MarshalIndent(map[string]interface{}{
+		"openapi": "3.0.3",
+		"info": map[string]interface{}{
+			"title":   string(svc.Name()),
+			"version": "v1",
+		},
+		"paths":      paths,
+		"components": map[string]interface{}{"schemas": s},
+	}, "", "  ")
+}
+
+// jsonBody returns a required JSON request body with the given schema.
+func jsonBody(schema map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"required": true,
+		"content": map[string]interface{}{
+			"application/json": map[string]interface{}{"schema": schema},
+		},
+	}
+}
@@ -0,0 +1,305 @@
+/*
+Copyright 2020 Robert Barron
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package config provides configuration for the SnackInventory server.
+//
+// Configuration is layered, with later sources overriding earlier ones:
+//  1. Defaults.
+//  2. A YAML config file.
+//  3. Environment variables, named SNACKINVENTORY_<KEY> (ex: SNACKINVENTORY_SQL_USER).
+//  4. Command line flags that were explicitly set.
+//
+// Every source uses the same keys, matching the server's flag names. Keys of
+// storage backends are defined by the backends' flags; see AddStorageFlags.
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rmbarron/SnackInventory/src/backend/server/logging"
+	"gopkg.in/yaml.v2"
+)
+
+// EnvPrefix is prepended to upper-cased keys to form environment variable names.
+const EnvPrefix = "SNACKINVENTORY_"
+
+// Config is the effective configuration of the server.
+// Fields tagged `secret:"true"` are redacted by Redacted.
+type Config struct {
+	Port                int    `yaml:"port"`
+	StorageArchitecture string `yaml:"storage_architecture"`
+	MetricsPort         int    `yaml:"metrics_port"`
+	HTTPPort            int    `yaml:"http_port"`
+	WatchHistory        int    `yaml:"watch_history"`
+	LogLevel            string `yaml:"log_level"`
+	TraceFile           string `yaml:"trace_file"`
+	TraceCollectorURL   string `yaml:"trace_collector_url"`
+	// CacheTTL is how long storage reads are cached for. Caching is disabled
+	// if 0.
+	CacheTTL time.Duration `yaml:"cache_ttl"`
+
+	// Product metadata lookup, from at most one of a dump or an API.
+	ProductDump          string        `yaml:"product_dump"`
+	ProductLookupURL     string        `yaml:"product_lookup_url"`
+	ProductLookupTimeout time.Duration `yaml:"product_lookup_timeout"`
+
+	// Storage holds the keys of storage backends, as given to their flags.
+	Storage map[string]string `yaml:",inline"`
+	// storageFlags defines the keys allowed in Storage.
+	storageFlags *flag.FlagSet
+}
+
+// Default returns a Config populated with default values.
+func Default() *Config {
+	return &Config{
+		Port:                 10000,
+		StorageArchitecture:  "mysql",
+		WatchHistory:         1000,
+		LogLevel:             "info",
+		ProductLookupTimeout: 5 * time.Second,
+	}
+}
+
+// Keys returns every configuration key other than those of storage backends,
+// in declaration order.
+func Keys() []string {
+	t := reflect.TypeOf(Config{})
+	keys := make([]string, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		if key := t.Field(i).Tag.Get("yaml"); key != "" && !strings.HasPrefix(key, ",") {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// AddStorageFlags makes the flags in fs, defined by storage backends, keys of
+// c. Setting a key sets its flag. Storage is populated with the flags' current
+// values.
+func (c *Config) AddStorageFlags(fs *flag.FlagSet) {
+	c.storageFlags = fs
+	if c.Storage == nil {
+		c.Storage = map[string]string{}
+	}
+	fs.VisitAll(func(f *flag.Flag) { c.Storage[f.Name] = f.Value.String() })
+}
+
+// SecretKey returns whether the storage key holds a secret, which are the keys
+// ending in "password". Secrets are redacted by Redacted, & have no command
+// line flag, to keep them out of shell history.
+func SecretKey(key string) bool {
+	return strings.HasSuffix(key, "password")
+}
+
+// keys returns every configuration key of c, including those of storage
+// backends.
+func (c *Config) keys() []string {
+	keys := Keys()
+	if c.storageFlags != nil {
+		c.storageFlags.VisitAll(func(f *flag.Flag) { keys = append(keys, f.Name) })
+	}
+	return keys
+}
+
+// Set sets the field or storage flag identified by key, parsing value as
+// needed.
+func (c *Config) Set(key, value string) error {
+	v := reflect.ValueOf(c).Elem()
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		if t.Field(i).Tag.Get("yaml") != key {
+			continue
+		}
+		switch f := v.Field(i); {
+		case f.Type() == reflect.TypeOf(time.Duration(0)):
+			d, err := time.ParseDuration(value)
+			if err != nil {
+				return fmt.Errorf("%s: %q is not a duration", key, value)
+			}
+			f.SetInt(int64(d))
+		case f.Kind() == reflect.String:
+			f.SetString(value)
+		case f.Kind() == reflect.Int:
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				return fmt.Errorf("%s: %q is not an integer", key, value)
+			}
+			f.SetInt(int64(n))
+		default:
+			return fmt.Errorf("%s: unsupported type %v", key, f.Kind())
+		}
+		return nil
+	}
+	if c.storageFlags != nil {
+		if f := c.storageFlags.Lookup(key); f != nil {
+			if err := f.Value.Set(value); err != nil {
+				return fmt.Errorf("%s: %q is invalid: %v", key, value, err)
+			}
+			c.Storage[key] = value
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown config key %q", key)
+}
+
+// LoadFile merges the YAML config file at path into c. Keys absent from the
+// file are left unchanged; unknown keys are an error.
+func (c *Config) LoadFile(path string) error {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("could not read config file: %w", err)
+	}
+	// Keys of storage backends are collected, unchecked, into Storage, so are
+	// set one by one after.
+	storage := c.Storage
+	c.Storage = nil
+	err = yaml.UnmarshalStrict(b, c)
+	fileStorage := c.Storage
+	c.Storage = storage
+	if err != nil {
+		return fmt.Errorf("could not parse config file %s: %w", path, err)
+	}
+	keys := make([]string, 0, len(fileStorage))
+	for key := range fileStorage {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var errs Errors
+	for _, key := range keys {
+		if err := c.Set(key, fileStorage[key]); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	if err := errs.Err(); err != nil {
+		return fmt.Errorf("could not parse config file %s: %w", path, err)
+	}
+	return nil
+}
+
+// ApplyEnv overrides fields of c from environment variables, as returned by
+// lookup (ex: os.LookupEnv).
+func (c *Config) ApplyEnv(lookup func(string) (string, bool)) error {
+	var errs Errors
+	for _, key := range c.keys() {
+		v, ok := lookup(EnvPrefix + strings.ToUpper(key))
+		if !ok {
+			continue
+		}
+		if err := c.Set(key, v); err != nil {
+			errs = append(errs, fmt.Sprintf("%s%s: %v", EnvPrefix, strings.ToUpper(key), err))
+		}
+	}
+	return errs.Err()
+}
+
+// Validate checks c for errors, reporting all of them at once along with any
+// in storage, the result of validating the storage backend's keys.
+func (c *Config) Validate(storage error) error {
+	var errs Errors
+	if c.Port <= 0 || c.Port > 65535 {
+		errs = append(errs, fmt.Sprintf("port: %d is not a valid port", c.Port))
+	}
+	if c.MetricsPort < 0 || c.MetricsPort > 65535 {
+		errs = append(errs, fmt.Sprintf("metrics_port: %d is not a valid port", c.MetricsPort))
+	}
+	if c.HTTPPort < 0 || c.HTTPPort > 65535 {
+		errs = append(errs, fmt.Sprintf("http_port: %d is not a valid port", c.HTTPPort))
+	}
+	if c.HTTPPort != 0 && (c.HTTPPort == c.Port || c.HTTPPort == c.MetricsPort) {
+		errs = append(errs, fmt.Sprintf("http_port: %d is already used by port or metrics_port", c.HTTPPort))
+	}
+	if c.WatchHistory < 1 {
+		errs = append(errs, fmt.Sprintf("watch_history: %d must be positive", c.WatchHistory))
+	}
+	if _, err := logging.ParseLevel(c.LogLevel); err != nil {
+		errs = append(errs, fmt.Sprintf("log_level: %v", err))
+	}
+	if c.TraceFile != "" && c.TraceCollectorURL != "" {
+		errs = append(errs, "trace_file, trace_collector_url: at most one may be set")
+	}
+	if c.CacheTTL < 0 {
+		errs = append(errs, fmt.Sprintf("cache_ttl: %v must not be negative", c.CacheTTL))
+	}
+	if c.ProductDump != "" && c.ProductLookupURL != "" {
+		errs = append(errs, "product_dump, product_lookup_url: at most one may be set")
+	}
+	if c.ProductLookupTimeout <= 0 {
+		errs = append(errs, fmt.Sprintf("product_lookup_timeout: %v must be positive", c.ProductLookupTimeout))
+	}
+
+	var storageErrs Errors
+	switch {
+	case errors.As(storage, &storageErrs):
+		errs = append(errs, storageErrs...)
+	case storage != nil:
+		errs = append(errs, storage.Error())
+	}
+	return errs.Err()
+}
+
+// Redacted returns a copy of c with secret fields & storage keys replaced.
+func (c *Config) Redacted() *Config {
+	r := *c
+	v := reflect.ValueOf(&r).Elem()
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		if t.Field(i).Tag.Get("secret") == "true" && v.Field(i).String() != "" {
+			v.Field(i).SetString("REDACTED")
+		}
+	}
+	if c.Storage != nil {
+		r.Storage = make(map[string]string, len(c.Storage))
+		for key, value := range c.Storage {
+			if SecretKey(key) && value != "" {
+				value = "REDACTED"
+			}
+			r.Storage[key] = value
+		}
+	}
+	return &r
+}
+
+// String formats c as YAML, with secrets redacted.
+func (c *Config) String() string {
+	b, err := yaml.Marshal(c.Redacted())
+	if err != nil {
+		return fmt.Sprintf("could not format config: %v", err)
+	}
+	return string(b)
+}
+
+// Errors are problems with the config, each formatted "key: problem".
+type Errors []string
+
+func (e Errors) Error() string {
+	return fmt.Sprintf("%d config error(s):\n  %s", len(e), strings.Join(e, "\n  "))
+}
+
+// Err returns e as an error, or nil if empty.
+func (e Errors) Err() error {
+	if len(e) == 0 {
+		return nil
+	}
+	return e
+}
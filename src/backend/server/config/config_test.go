@@ -0,0 +1,184 @@
+/*
+Copyright 2020 Robert Barron
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package config
+
+import (
+	"errors"
+	"flag"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+// writeFileT writes contents to a new file in a temp dir, returning its path.
+func writeFileT(t *testing.T, name, contents string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := ioutil.WriteFile(path, []byte(contents), 0600); err != nil {
+		t.Fatalf("ioutil.WriteFile(%q) = got err %v, want err nil", path, err)
+	}
+	return path
+}
+
+// storageFlagsT returns flags of a storage backend, as would be added by
+// connector.Factory.RegisterFlags.
+func storageFlagsT(t *testing.T) *flag.FlagSet {
+	t.Helper()
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.String("sql_user", "", "")
+	fs.String("sql_address", "", "")
+	fs.String("sql_password", "", "")
+	fs.Int("sql_read_retries", 3, "")
+	return fs
+}
+
+func TestLayering(t *testing.T) {
+	path := writeFileT(t, "config.yaml", "port: 1234\nsql_user: file_user\nsql_address: file:3306\nsql_read_retries: 5\n")
+	env := map[string]string{
+		"SNACKINVENTORY_SQL_USER":     "env_user",
+		"SNACKINVENTORY_METRICS_PORT": "9090",
+	}
+
+	fs := storageFlagsT(t)
+	c := Default()
+	c.AddStorageFlags(fs)
+	if err := c.LoadFile(path); err != nil {
+		t.Fatalf("c.LoadFile(%q) = got err %v, want err nil", path, err)
+	}
+	if err := c.ApplyEnv(func(k string) (string, bool) { v, ok := env[k]; return v, ok }); err != nil {
+		t.Fatalf("c.ApplyEnv(...) = got err %v, want err nil", err)
+	}
+	if err := c.Set("sql_address", "flag:3306"); err != nil {
+		t.Fatalf("c.Set(%q, %q) = got err %v, want err nil", "sql_address", "flag:3306", err)
+	}
+
+	want := Default()
+	want.Port = 1234
+	want.MetricsPort = 9090
+	want.Storage = map[string]string{
+		"sql_user":         "env_user",
+		"sql_address":      "flag:3306",
+		"sql_password":     "",
+		"sql_read_retries": "5",
+	}
+	if diff := cmp.Diff(c, want, cmpopts.IgnoreUnexported(Config{})); diff != "" {
+		t.Fatalf("layered config = got diff (-got +want): %s", diff)
+	}
+	// Storage keys are applied to their flags.
+	for key, value := range want.Storage {
+		if got := fs.Lookup(key).Value.String(); got != value {
+			t.Errorf("flag %s = got %q, want %q", key, got, value)
+		}
+	}
+}
+
+func TestLoadFile_UnknownStorageKey(t *testing.T) {
+	path := writeFileT(t, "config.yaml", "sql_usr: file_user\n")
+	c := Default()
+	c.AddStorageFlags(storageFlagsT(t))
+	if err := c.LoadFile(path); err == nil {
+		t.Fatalf("c.LoadFile(%q) = got err nil, want err", path)
+	}
+}
+
+func TestSet_BadStorageValue(t *testing.T) {
+	c := Default()
+	c.AddStorageFlags(storageFlagsT(t))
+	if err := c.Set("sql_read_retries", "many"); err == nil {
+		t.Fatal("c.Set(\"sql_read_retries\", \"many\") = got err nil, want err")
+	}
+}
+
+func TestLoadFile_UnknownKey(t *testing.T) {
+	path := writeFileT(t, "config.yaml", "prot: 1234\n")
+	if err := Default().LoadFile(path); err == nil {
+		t.Fatalf("c.LoadFile(%q) = got err nil, want err", path)
+	}
+}
+
+func TestApplyEnv_BadInt(t *testing.T) {
+	lookup := func(k string) (string, bool) {
+		if k == "SNACKINVENTORY_PORT" {
+			return "ten", true
+		}
+		return "", false
+	}
+	if err := Default().ApplyEnv(lookup); err == nil {
+		t.Fatal("c.ApplyEnv(...) = got err nil, want err")
+	}
+}
+
+func TestSet_UnknownKey(t *testing.T) {
+	if err := Default().Set("nope", "1"); err == nil {
+		t.Fatal("c.Set(\"nope\", \"1\") = got err nil, want err")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	if err := Default().Validate(nil); err != nil {
+		t.Fatalf("c.Validate(nil) = got err %v, want err nil", err)
+	}
+}
+
+func TestValidate_ReportsAllErrors(t *testing.T) {
+	c := Default()
+	c.Port = 0
+	c.LogLevel = "loud"
+	c.ProductDump = "/products.jsonl"
+	c.ProductLookupURL = "http://localhost:8000"
+	c.ProductLookupTimeout = 0
+	c.CacheTTL = -1
+
+	storage := Errors{"sql_user: required for mysql storage", "sql_address: required for mysql storage"}
+
+	err := c.Validate(storage)
+	if err == nil {
+		t.Fatal("c.Validate(...) = got err nil, want err")
+	}
+	for _, key := range []string{"port", "log_level", "sql_user", "sql_address",
+		"product_lookup_url", "product_lookup_timeout", "cache_ttl"} {
+		if !strings.Contains(err.Error(), key+":") {
+			t.Errorf("c.Validate(...) = got err %q, want mention of %q", err, key)
+		}
+	}
+	var errs Errors
+	if !errors.As(err, &errs) || len(errs) != 7 {
+		t.Errorf("c.Validate(...) = got err %q, want 7 config.Errors", err)
+	}
+}
+
+func TestString_RedactsSecrets(t *testing.T) {
+	c := Default()
+	c.AddStorageFlags(storageFlagsT(t))
+	if err := c.Set("sql_password", "hunter2"); err != nil {
+		t.Fatalf("c.Set(%q, %q) = got err %v, want err nil", "sql_password", "hunter2", err)
+	}
+
+	got := c.String()
+	if strings.Contains(got, "hunter2") {
+		t.Fatalf("c.String() = got %q, want password redacted", got)
+	}
+	if !strings.Contains(got, "sql_password: REDACTED") {
+		t.Fatalf("c.String() = got %q, want %q", got, "sql_password: REDACTED")
+	}
+	if c.Storage["sql_password"] != "hunter2" {
+		t.Fatalf("c.String() modified sql_password to %q, want unchanged", c.Storage["sql_password"])
+	}
+}
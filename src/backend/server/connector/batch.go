@@ -0,0 +1,264 @@
+/*
+Copyright 2020 Robert Barron
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package connector
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	sipb "github.com/rmbarron/SnackInventory/src/proto/snackinventory"
+	"github.com/rmbarron/SnackInventory/src/tracing"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// maxRowsPerStatement bounds the rows in a single multi-row statement, keeping
+// it well under MySQL's limit of 65535 placeholders.
+const maxRowsPerStatement = 1000
+
+// BatchError reports the failure of a single item that aborted a batch.
+type BatchError struct {
+	// Index of the failing item in the batch.
+	Index int
+	Err   error
+}
+
+func (e *BatchError) Error() string {
+	return fmt.Sprintf("item %d: %v", e.Index, e.Err)
+}
+
+func (e *BatchError) Unwrap() error {
+	return e.Err
+}
+
+// GRPCStatus preserves the status code of Err, so status.Code(e) reports it.
+func (e *BatchError) GRPCStatus() *status.Status {
+	return status.New(status.Code(e.Err), e.Error())
+}
+
+// withTx runs fn in a transaction, committing if fn succeeds. The transaction
+// is run again if it conflicts with a concurrent one, so fn must only change
+// state outside tx by assignment.
+func (s *SQLImpl) withTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	ctx, span := tracing.StartSpan(ctx, "SQLImpl.Tx")
+	defer span.End()
+
+	err := s.retryTx(ctx, func() error {
+		tx, err := s.db.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		if err := fn(tx); err != nil {
+			tx.Rollback()
+			return err
+		}
+		return tx.Commit()
+	})
+	span.SetError(err)
+	return err
+}
+
+// placeholders returns n comma separated copies of group, ex: "(?, ?), (?, ?)".
+func placeholders(group string, n int) string {
+	return strings.TrimSuffix(strings.Repeat(group+", ", n), ", ")
+}
+
+// existingBarcodes returns which of barcodes are registered, mapped to whether
+// the snack is in the trash.
+func existingBarcodes(ctx context.Context, tx *sql.Tx, barcodes []string) (map[string]bool, error) {
+	existing := map[string]bool{}
+	for start := 0; start < len(barcodes); start += maxRowsPerStatement {
+		end := start + maxRowsPerStatement
+		if end > len(barcodes) {
+			end = len(barcodes)
+		}
+		args := make([]interface{}, 0, end-start)
+		for _, b := range barcodes[start:end] {
+			args = append(args, b)
+		}
+		rows, err := tracedQuery(ctx, tx,
+			fmt.Sprintf("SELECT barcode, deleted_at IS NOT NULL FROM SnackRegistry WHERE barcode IN (%s) FOR UPDATE",
+				placeholders("?", len(args))),
+			args...)
+		if err != nil {
+			return nil, err
+		}
+		for rows.Next() {
+			var b string
+			var deleted bool
+			if err := rows.Scan(&b, &deleted); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			existing[b] = deleted
+		}
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return existing, nil
+}
+
+// BatchCreateSnacks creates all snacks in a single transaction.
+// If any barcode is already registered, or repeated within snacks, nothing is
+// created and a *BatchError with an AlreadyExists code is returned.
+func (s *SQLImpl) BatchCreateSnacks(ctx context.Context, snacks []*sipb.Snack) error {
+	if len(snacks) == 0 {
+		return nil
+	}
+	barcodes := make([]string, len(snacks))
+	seen := map[string]bool{}
+	for i, snack := range snacks {
+		barcodes[i] = snack.GetBarcode()
+		if seen[barcodes[i]] {
+			return &BatchError{i, status.Errorf(codes.AlreadyExists, "barcode %q is repeated in the batch", barcodes[i])}
+		}
+		seen[barcodes[i]] = true
+	}
+
+	return s.withTx(ctx, func(tx *sql.Tx) error {
+		existing, err := existingBarcodes(ctx, tx, barcodes)
+		if err != nil {
+			return err
+		}
+		for i, b := range barcodes {
+			if deleted, ok := existing[b]; ok {
+				return &BatchError{i, snackExistsError(b, deleted)}
+			}
+		}
+
+		now := changeTime()
+		for start := 0; start < len(snacks); start += maxRowsPerStatement {
+			end := start + maxRowsPerStatement
+			if end > len(snacks) {
+				end = len(snacks)
+			}
+			args := make([]interface{}, 0, 5*(end-start))
+			for _, snack := range snacks[start:end] {
+				args = append(args, snack.GetBarcode(), snack.GetName(), snack.GetBrand(), snack.GetCategory(), snack.GetPackageSize())
+			}
+			if _, err := tracedExec(ctx, tx,
+				"INSERT INTO SnackRegistry (barcode, name, brand, category, package_size) VALUES "+
+					placeholders("(?, ?, ?, ?, ?)", end-start),
+				args...); err != nil {
+				return err
+			}
+			if err := recordSnacks(ctx, tx, sipb.Change_CREATED, now, barcodes[start:end]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// BatchUpdateSnacks updates all snacks in a single transaction.
+// If any barcode is not registered, nothing is updated and a *BatchError with
+// a NotFound code is returned.
+func (s *SQLImpl) BatchUpdateSnacks(ctx context.Context, snacks []*sipb.Snack) error {
+	if len(snacks) == 0 {
+		return nil
+	}
+	barcodes := make([]string, len(snacks))
+	for i, snack := range snacks {
+		barcodes[i] = snack.GetBarcode()
+	}
+
+	return s.withTx(ctx, func(tx *sql.Tx) error {
+		existing, err := existingBarcodes(ctx, tx, barcodes)
+		if err != nil {
+			return err
+		}
+		for i, b := range barcodes {
+			if deleted, ok := existing[b]; !ok || deleted {
+				return &BatchError{i, status.Errorf(codes.NotFound, "barcode %q is not registered", b)}
+			}
+		}
+
+		stmt, err := tx.PrepareContext(ctx, updateSnackStatement)
+		if err != nil {
+			return err
+		}
+		defer stmt.Close()
+		for i, snack := range snacks {
+			if _, err := stmt.ExecContext(ctx, updateSnackArgs(snack)...); err != nil {
+				return &BatchError{i, err}
+			}
+		}
+		now := changeTime()
+		for start := 0; start < len(barcodes); start += maxRowsPerStatement {
+			end := start + maxRowsPerStatement
+			if end > len(barcodes) {
+				end = len(barcodes)
+			}
+			if err := recordSnacks(ctx, tx, sipb.Change_UPDATED, now, barcodes[start:end]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// BatchDeleteSnacks moves all snacks with the given barcodes to the trash in a
+// single transaction. As with DeleteSnack, barcodes that are not registered
+// are ignored.
+func (s *SQLImpl) BatchDeleteSnacks(ctx context.Context, barcodes []string) error {
+	if len(barcodes) == 0 {
+		return nil
+	}
+	return s.withTx(ctx, func(tx *sql.Tx) error {
+		// Only snacks not already in the trash are deleted, & recorded as such.
+		existing, err := existingBarcodes(ctx, tx, barcodes)
+		if err != nil {
+			return err
+		}
+		var live []string
+		for _, b := range barcodes {
+			if deleted, ok := existing[b]; ok && !deleted {
+				live = append(live, b)
+				// Repeated barcodes are only recorded once.
+				existing[b] = true
+			}
+		}
+
+		now := changeTime()
+		for start := 0; start < len(live); start += maxRowsPerStatement {
+			end := start + maxRowsPerStatement
+			if end > len(live) {
+				end = len(live)
+			}
+			args := make([]interface{}, 0, end-start+1)
+			args = append(args, now)
+			for _, b := range live[start:end] {
+				args = append(args, b)
+			}
+			if _, err := tracedExec(ctx, tx,
+				fmt.Sprintf("UPDATE SnackRegistry SET deleted_at = ? WHERE barcode IN (%s)",
+					placeholders("?", end-start)),
+				args...); err != nil {
+				return err
+			}
+			if err := recordSnacks(ctx, tx, sipb.Change_DELETED, now, live[start:end]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,189 @@
+/*
+Copyright 2020 Robert Barron
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package connector
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/rmbarron/SnackInventory/src/backend/server/testutils"
+	sipb "github.com/rmbarron/SnackInventory/src/proto/snackinventory"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestPlaceholders(t *testing.T) {
+	tests := []struct {
+		group string
+		n     int
+		want  string
+	}{
+		{"?", 1, "?"},
+		{"?", 3, "?, ?, ?"},
+		{"(?, ?)", 2, "(?, ?), (?, ?)"},
+	}
+	for _, tc := range tests {
+		if got := placeholders(tc.group, tc.n); got != tc.want {
+			t.Errorf("placeholders(%q, %d) = got %q, want %q", tc.group, tc.n, got, tc.want)
+		}
+	}
+}
+
+func TestBatchError(t *testing.T) {
+	inner := status.Error(codes.AlreadyExists, "barcode exists")
+	err := error(&BatchError{Index: 2, Err: inner})
+
+	if got := status.Code(err); got != codes.AlreadyExists {
+		t.Errorf("status.Code(%v) = got %v, want %v", err, got, codes.AlreadyExists)
+	}
+	if !errors.Is(err, inner) {
+		t.Errorf("errors.Is(%v, %v) = got false, want true", err, inner)
+	}
+	var be *BatchError
+	if !errors.As(err, &be) || be.Index != 2 {
+		t.Errorf("errors.As(%v, &be) = got index %d, want 2", err, be.Index)
+	}
+}
+
+// TestBatch is a parent test to create a mariadb instance for subtests.
+func TestBatch(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	db, close := testutils.StartMysqldT(ctx, t)
+	defer close()
+
+	testutils.CreateDatabaseT(ctx, t, db)
+
+	listSnacksT := func(t *testing.T, si *SQLImpl) []*sipb.Snack {
+		t.Helper()
+		got, err := si.ListSnacks(ctx)
+		if err != nil {
+			t.Fatalf("si.ListSnacks(ctx) = got err %v, want err nil", err)
+		}
+		return got
+	}
+	// wantIndexErr checks err is a *BatchError for index with the given code.
+	wantIndexErr := func(t *testing.T, err error, index int, code codes.Code) {
+		t.Helper()
+		var be *BatchError
+		if !errors.As(err, &be) || be.Index != index || status.Code(err) != code {
+			t.Fatalf("got err %v, want *BatchError at index %d with code %v", err, index, code)
+		}
+	}
+
+	t.Run("BatchCreateSnacks", func(t *testing.T) {
+		testutils.CreateTablesT(ctx, t, db)
+		defer testutils.DropTablesT(ctx, t, db)
+
+		si := &SQLImpl{db: db}
+		snacks := []*sipb.Snack{{Barcode: "1", Name: "chips"}, {Barcode: "2", Name: "salsa"}}
+		if err := si.BatchCreateSnacks(ctx, snacks); err != nil {
+			t.Fatalf("si.BatchCreateSnacks(ctx, %v) = got err %v, want err nil", snacks, err)
+		}
+		if diff := cmp.Diff(listSnacksT(t, si), snacks, cmpopts.IgnoreUnexported(sipb.Snack{})); diff != "" {
+			t.Fatalf("si.ListSnacks(ctx) = got diff (-got +want): %s", diff)
+		}
+	})
+
+	t.Run("BatchCreateSnacks_AlreadyExists", func(t *testing.T) {
+		testutils.CreateTablesT(ctx, t, db)
+		defer testutils.DropTablesT(ctx, t, db)
+
+		testutils.AddSnackT(ctx, t, db, &sipb.Snack{Barcode: "2", Name: "salsa"})
+
+		si := &SQLImpl{db: db}
+		snacks := []*sipb.Snack{{Barcode: "1", Name: "chips"}, {Barcode: "2", Name: "salsa"}}
+		wantIndexErr(t, si.BatchCreateSnacks(ctx, snacks), 1, codes.AlreadyExists)
+		// Nothing from the failed batch may be written.
+		if got := listSnacksT(t, si); len(got) != 1 {
+			t.Fatalf("si.ListSnacks(ctx) after failed batch = got %v, want only the existing snack", got)
+		}
+	})
+
+	t.Run("BatchCreateSnacks_RepeatedBarcode", func(t *testing.T) {
+		testutils.CreateTablesT(ctx, t, db)
+		defer testutils.DropTablesT(ctx, t, db)
+
+		si := &SQLImpl{db: db}
+		snacks := []*sipb.Snack{{Barcode: "1"}, {Barcode: "2"}, {Barcode: "1"}}
+		wantIndexErr(t, si.BatchCreateSnacks(ctx, snacks), 2, codes.AlreadyExists)
+		if got := listSnacksT(t, si); len(got) != 0 {
+			t.Fatalf("si.ListSnacks(ctx) after failed batch = got %v, want none", got)
+		}
+	})
+
+	t.Run("BatchUpdateSnacks", func(t *testing.T) {
+		testutils.CreateTablesT(ctx, t, db)
+		defer testutils.DropTablesT(ctx, t, db)
+
+		testutils.AddSnackT(ctx, t, db, &sipb.Snack{Barcode: "1", Name: "chips"})
+		testutils.AddSnackT(ctx, t, db, &sipb.Snack{Barcode: "2", Name: "salsa"})
+
+		si := &SQLImpl{db: db}
+		snacks := []*sipb.Snack{{Barcode: "1", Name: "crisps"}, {Barcode: "2", Name: "dip"}}
+		if err := si.BatchUpdateSnacks(ctx, snacks); err != nil {
+			t.Fatalf("si.BatchUpdateSnacks(ctx, %v) = got err %v, want err nil", snacks, err)
+		}
+		if diff := cmp.Diff(listSnacksT(t, si), snacks, cmpopts.IgnoreUnexported(sipb.Snack{})); diff != "" {
+			t.Fatalf("si.ListSnacks(ctx) = got diff (-got +want): %s", diff)
+		}
+	})
+
+	t.Run("BatchUpdateSnacks_NotFound", func(t *testing.T) {
+		testutils.CreateTablesT(ctx, t, db)
+		defer testutils.DropTablesT(ctx, t, db)
+
+		testutils.AddSnackT(ctx, t, db, &sipb.Snack{Barcode: "1", Name: "chips"})
+
+		si := &SQLImpl{db: db}
+		snacks := []*sipb.Snack{{Barcode: "1", Name: "crisps"}, {Barcode: "2", Name: "dip"}}
+		wantIndexErr(t, si.BatchUpdateSnacks(ctx, snacks), 1, codes.NotFound)
+		want := []*sipb.Snack{{Barcode: "1", Name: "chips"}}
+		if diff := cmp.Diff(listSnacksT(t, si), want, cmpopts.IgnoreUnexported(sipb.Snack{})); diff != "" {
+			t.Fatalf("si.ListSnacks(ctx) after failed batch = got diff (-got +want): %s", diff)
+		}
+	})
+
+	t.Run("BatchDeleteSnacks", func(t *testing.T) {
+		testutils.CreateTablesT(ctx, t, db)
+		defer testutils.DropTablesT(ctx, t, db)
+
+		testutils.AddSnackT(ctx, t, db, &sipb.Snack{Barcode: "1", Name: "chips"})
+		testutils.AddSnackT(ctx, t, db, &sipb.Snack{Barcode: "2", Name: "salsa"})
+		testutils.AddSnackT(ctx, t, db, &sipb.Snack{Barcode: "3", Name: "soda"})
+
+		si := &SQLImpl{db: db}
+		barcodes := []string{"1", "3", "4"}
+		if err := si.BatchDeleteSnacks(ctx, barcodes); err != nil {
+			t.Fatalf("si.BatchDeleteSnacks(ctx, %v) = got err %v, want err nil", barcodes, err)
+		}
+		want := []*sipb.Snack{{Barcode: "2", Name: "salsa"}}
+		if diff := cmp.Diff(listSnacksT(t, si), want, cmpopts.IgnoreUnexported(sipb.Snack{})); diff != "" {
+			t.Fatalf("si.ListSnacks(ctx) = got diff (-got +want): %s", diff)
+		}
+	})
+
+	t.Run("BatchCreateSnacks_SelectError", func(t *testing.T) {
+		si := &SQLImpl{db: db}
+		if err := si.BatchCreateSnacks(ctx, []*sipb.Snack{{Barcode: "1"}}); err == nil {
+			t.Fatal("si.BatchCreateSnacks(ctx, ...) without tables = got err nil, want err")
+		}
+	})
+}
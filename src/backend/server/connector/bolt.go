@@ -0,0 +1,717 @@
+/*
+Copyright 2020 Robert Barron
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package connector
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	sipb "github.com/rmbarron/SnackInventory/src/proto/snackinventory"
+	"github.com/rmbarron/SnackInventory/src/tracing"
+	bolt "go.etcd.io/bbolt"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// BoltImpl implements a connector to an embedded bbolt file, so no DB server
+// is needed. Each method runs in a single bolt transaction; writes are
+// serialized by bolt, while reads run concurrently.
+//
+// Records are JSON, in buckets:
+//
+//	snacks:             barcode -> snack
+//	snack_names:        name \x00 barcode -> (empty), indexing snacks by name
+//	locations:          id -> location, ids from the bucket's sequence
+//	location_names:     name -> id
+//	stock:              location \x00 barcode -> count
+//	snack_revisions:    barcode \x00 sequence -> snack revision
+//	location_revisions: id, sequence -> location revision
+//
+// Integers are big endian, so keys sort by them. As with SQLImpl, deleted
+// snacks & locations are kept, with their deletion time set, until purged.
+type BoltImpl struct {
+	db *bolt.DB
+}
+
+var _ Store = (*BoltImpl)(nil)
+
+var (
+	boltSnacks            = []byte("snacks")
+	boltSnackNames        = []byte("snack_names")
+	boltLocations         = []byte("locations")
+	boltLocationNames     = []byte("location_names")
+	boltStock             = []byte("stock")
+	boltSnackRevisions    = []byte("snack_revisions")
+	boltLocationRevisions = []byte("location_revisions")
+)
+
+// NewBoltImpl opens, or creates, the bolt file at path. Only one process may
+// open the file at a time; NewBoltImpl waits up to timeout for others to close
+// it, or forever if timeout is 0.
+func NewBoltImpl(path string, timeout time.Duration) (*BoltImpl, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: timeout})
+	if err != nil {
+		return nil, err
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{boltSnacks, boltSnackNames, boltLocations, boltLocationNames, boltStock,
+			boltSnackRevisions, boltLocationRevisions} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &BoltImpl{db: db}, nil
+}
+
+// Close closes the bolt file.
+func (b *BoltImpl) Close() error {
+	return b.db.Close()
+}
+
+// WriteTo writes a consistent copy of the bolt file to w, while other
+// transactions go on, and returns the number of bytes written.
+func (b *BoltImpl) WriteTo(w io.Writer) (n int64, err error) {
+	err = b.db.View(func(tx *bolt.Tx) error {
+		n, err = tx.WriteTo(w)
+		return err
+	})
+	return n, err
+}
+
+// Backup writes a copy of the bolt file to path, as WriteTo. The copy only
+// replaces path once complete.
+func (b *BoltImpl) Backup(path string) error {
+	f, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if _, err := b.WriteTo(f); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
+// view runs fn in a read-only transaction, within a trace span.
+func (b *BoltImpl) view(ctx context.Context, fn func(tx boltTx) error) error {
+	_, span := tracing.StartSpan(ctx, "BoltImpl.View")
+	defer span.End()
+
+	err := b.db.View(func(tx *bolt.Tx) error { return fn(boltTx{tx}) })
+	span.SetError(err)
+	return err
+}
+
+// update runs fn in a read-write transaction, committing if fn succeeds.
+func (b *BoltImpl) update(ctx context.Context, fn func(tx boltTx) error) error {
+	_, span := tracing.StartSpan(ctx, "BoltImpl.Update")
+	defer span.End()
+
+	err := b.db.Update(func(tx *bolt.Tx) error { return fn(boltTx{tx}) })
+	span.SetError(err)
+	return err
+}
+
+// boltSnack is the record of a snack.
+type boltSnack struct {
+	Barcode, Name, Brand, Category, PackageSize string
+	// Deleted is when the snack was moved to the trash, if it is there.
+	Deleted time.Time
+}
+
+func newBoltSnack(snack *sipb.Snack) *boltSnack {
+	return &boltSnack{
+		Barcode:     snack.GetBarcode(),
+		Name:        snack.GetName(),
+		Brand:       snack.GetBrand(),
+		Category:    snack.GetCategory(),
+		PackageSize: snack.GetPackageSize(),
+	}
+}
+
+func (s *boltSnack) proto() *sipb.Snack {
+	return &sipb.Snack{Barcode: s.Barcode, Name: s.Name, Brand: s.Brand, Category: s.Category, PackageSize: s.PackageSize}
+}
+
+// boltLocation is the record of a location.
+type boltLocation struct {
+	ID                        int64
+	Name, Parent, Description string
+	Type                      sipb.LocationType
+	// Deleted is when the location was moved to the trash, if it is there.
+	Deleted time.Time
+}
+
+func (l *boltLocation) proto() *sipb.Location {
+	return &sipb.Location{Id: l.ID, Name: l.Name, Parent: l.Parent, Description: l.Description, Type: l.Type}
+}
+
+// boltTx wraps a bolt transaction with accessors for the records.
+type boltTx struct {
+	*bolt.Tx
+}
+
+// idKey returns the key of id, or of a sequence number.
+func idKey(id uint64) []byte {
+	k := make([]byte, 8)
+	binary.BigEndian.PutUint64(k, id)
+	return k
+}
+
+// joinKey returns the key of parts, separated by \x00.
+func joinKey(parts ...string) []byte {
+	return []byte(strings.Join(parts, "\x00"))
+}
+
+// get decodes the record at key of bucket into v, returning whether there is
+// one.
+func (tx boltTx) get(bucket, key []byte, v interface{}) (bool, error) {
+	data := tx.Bucket(bucket).Get(key)
+	if data == nil {
+		return false, nil
+	}
+	return true, json.Unmarshal(data, v)
+}
+
+// put encodes v as the record at key of bucket.
+func (tx boltTx) put(bucket, key []byte, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return tx.Bucket(bucket).Put(key, data)
+}
+
+// snack returns the snack with barcode, including in the trash, or nil if it
+// isn't registered.
+func (tx boltTx) snack(barcode string) (*boltSnack, error) {
+	s := &boltSnack{}
+	if ok, err := tx.get(boltSnacks, []byte(barcode), s); !ok || err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// liveSnack returns the snack with barcode, or nil if it isn't registered or
+// is in the trash.
+func (tx boltTx) liveSnack(barcode string) (*boltSnack, error) {
+	s, err := tx.snack(barcode)
+	if s != nil && !s.Deleted.IsZero() {
+		return nil, err
+	}
+	return s, err
+}
+
+// putSnack writes s, replacing old, the record of the same snack or nil if it
+// is new, & keeps the name index up to date.
+func (tx boltTx) putSnack(s, old *boltSnack) error {
+	names := tx.Bucket(boltSnackNames)
+	if old != nil && old.Name != s.Name {
+		if err := names.Delete(joinKey(old.Name, old.Barcode)); err != nil {
+			return err
+		}
+	}
+	if err := names.Put(joinKey(s.Name, s.Barcode), []byte{}); err != nil {
+		return err
+	}
+	return tx.put(boltSnacks, []byte(s.Barcode), s)
+}
+
+// location returns the location named name, including in the trash, or nil
+// if it isn't registered.
+func (tx boltTx) location(name string) (*boltLocation, error) {
+	id := tx.Bucket(boltLocationNames).Get([]byte(name))
+	if id == nil {
+		return nil, nil
+	}
+	l := &boltLocation{}
+	if ok, err := tx.get(boltLocations, id, l); !ok || err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// liveLocation returns the location named name, or nil if it isn't registered
+// or is in the trash.
+func (tx boltTx) liveLocation(name string) (*boltLocation, error) {
+	l, err := tx.location(name)
+	if l != nil && !l.Deleted.IsZero() {
+		return nil, err
+	}
+	return l, err
+}
+
+// putLocation writes l, replacing the record of the same location named
+// oldName, or "" if it is new, & keeps the name index up to date.
+func (tx boltTx) putLocation(l *boltLocation, oldName string) error {
+	names := tx.Bucket(boltLocationNames)
+	if oldName != "" && oldName != l.Name {
+		if err := names.Delete([]byte(oldName)); err != nil {
+			return err
+		}
+	}
+	if err := names.Put([]byte(l.Name), idKey(uint64(l.ID))); err != nil {
+		return err
+	}
+	return tx.put(boltLocations, idKey(uint64(l.ID)), l)
+}
+
+// forEachLocation calls fn with each location, including in the trash, in
+// order of id.
+func (tx boltTx) forEachLocation(fn func(l *boltLocation) error) error {
+	return tx.Bucket(boltLocations).ForEach(func(_, data []byte) error {
+		l := &boltLocation{}
+		if err := json.Unmarshal(data, l); err != nil {
+			return err
+		}
+		return fn(l)
+	})
+}
+
+// CreateSnack creates a snack in the bolt file.
+// Returns an AlreadyExists error if it does, including in the trash.
+func (b *BoltImpl) CreateSnack(ctx context.Context, snack *sipb.Snack) error {
+	return b.update(ctx, func(tx boltTx) error {
+		existing, err := tx.snack(snack.GetBarcode())
+		if err != nil {
+			return err
+		}
+		if existing != nil {
+			return snackExistsError(existing.Barcode, !existing.Deleted.IsZero())
+		}
+		s := newBoltSnack(snack)
+		if err := tx.putSnack(s, nil); err != nil {
+			return err
+		}
+		return tx.recordSnack(s, sipb.Change_CREATED, changeTime())
+	})
+}
+
+// ListSnacks reads all snacks currently registered to SnackInventory, ordered
+// by barcode.
+func (b *BoltImpl) ListSnacks(ctx context.Context) ([]*sipb.Snack, error) {
+	var snacks []*sipb.Snack
+	err := b.view(ctx, func(tx boltTx) error {
+		return tx.Bucket(boltSnacks).ForEach(func(_, data []byte) error {
+			s := &boltSnack{}
+			if err := json.Unmarshal(data, s); err != nil {
+				return err
+			}
+			if s.Deleted.IsZero() {
+				snacks = append(snacks, s.proto())
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return snacks, nil
+}
+
+// FindSnacksByName reads the snacks currently registered with name, ordered
+// by barcode, via the name index.
+func (b *BoltImpl) FindSnacksByName(ctx context.Context, name string) ([]*sipb.Snack, error) {
+	var snacks []*sipb.Snack
+	err := b.view(ctx, func(tx boltTx) error {
+		prefix := append([]byte(name), 0)
+		c := tx.Bucket(boltSnackNames).Cursor()
+		for k, _ := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, _ = c.Next() {
+			s, err := tx.liveSnack(string(k[len(prefix):]))
+			if err != nil {
+				return err
+			}
+			if s != nil {
+				snacks = append(snacks, s.proto())
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return snacks, nil
+}
+
+// UpdateSnack updates a single snack in place in SnackInventory. All fields
+// are written as given.
+func (b *BoltImpl) UpdateSnack(ctx context.Context, snack *sipb.Snack) error {
+	return b.update(ctx, func(tx boltTx) error {
+		return tx.updateSnack(snack, changeTime())
+	})
+}
+
+// updateSnack updates snack, if it is registered.
+func (tx boltTx) updateSnack(snack *sipb.Snack, at time.Time) error {
+	old, err := tx.liveSnack(snack.GetBarcode())
+	if err != nil || old == nil {
+		return err
+	}
+	s := newBoltSnack(snack)
+	if err := tx.putSnack(s, old); err != nil {
+		return err
+	}
+	return tx.recordSnack(s, sipb.Change_UPDATED, at)
+}
+
+// DeleteSnack moves a single snack in SnackInventory to the trash. Its stock
+// is kept, but not listed, until the snack is restored or purged.
+func (b *BoltImpl) DeleteSnack(ctx context.Context, barcode string) error {
+	return b.update(ctx, func(tx boltTx) error {
+		return tx.deleteSnack(barcode, changeTime())
+	})
+}
+
+// deleteSnack moves the snack with barcode to the trash, if it is registered.
+func (tx boltTx) deleteSnack(barcode string, at time.Time) error {
+	s, err := tx.liveSnack(barcode)
+	if err != nil || s == nil {
+		return err
+	}
+	s.Deleted = at
+	if err := tx.put(boltSnacks, []byte(barcode), s); err != nil {
+		return err
+	}
+	return tx.recordSnack(s, sipb.Change_DELETED, at)
+}
+
+// BatchCreateSnacks creates all snacks in a single transaction.
+// If any barcode is already registered, or repeated within snacks, nothing is
+// created and a *BatchError with an AlreadyExists code is returned.
+func (b *BoltImpl) BatchCreateSnacks(ctx context.Context, snacks []*sipb.Snack) error {
+	seen := map[string]bool{}
+	for i, snack := range snacks {
+		if seen[snack.GetBarcode()] {
+			return &BatchError{i, status.Errorf(codes.AlreadyExists, "barcode %q is repeated in the batch", snack.GetBarcode())}
+		}
+		seen[snack.GetBarcode()] = true
+	}
+	if len(snacks) == 0 {
+		return nil
+	}
+	return b.update(ctx, func(tx boltTx) error {
+		for i, snack := range snacks {
+			existing, err := tx.snack(snack.GetBarcode())
+			if err != nil {
+				return err
+			}
+			if existing != nil {
+				return &BatchError{i, snackExistsError(existing.Barcode, !existing.Deleted.IsZero())}
+			}
+		}
+		now := changeTime()
+		for _, snack := range snacks {
+			s := newBoltSnack(snack)
+			if err := tx.putSnack(s, nil); err != nil {
+				return err
+			}
+			if err := tx.recordSnack(s, sipb.Change_CREATED, now); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// BatchUpdateSnacks updates all snacks in a single transaction.
+// If any barcode is not registered, nothing is updated and a *BatchError with
+// a NotFound code is returned.
+func (b *BoltImpl) BatchUpdateSnacks(ctx context.Context, snacks []*sipb.Snack) error {
+	if len(snacks) == 0 {
+		return nil
+	}
+	return b.update(ctx, func(tx boltTx) error {
+		for i, snack := range snacks {
+			s, err := tx.liveSnack(snack.GetBarcode())
+			if err != nil {
+				return err
+			}
+			if s == nil {
+				return &BatchError{i, status.Errorf(codes.NotFound, "barcode %q is not registered", snack.GetBarcode())}
+			}
+		}
+		now := changeTime()
+		for _, snack := range snacks {
+			if err := tx.updateSnack(snack, now); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// BatchDeleteSnacks moves all snacks with the given barcodes to the trash in a
+// single transaction. As with DeleteSnack, barcodes that are not registered
+// are ignored.
+func (b *BoltImpl) BatchDeleteSnacks(ctx context.Context, barcodes []string) error {
+	if len(barcodes) == 0 {
+		return nil
+	}
+	return b.update(ctx, func(tx boltTx) error {
+		now := changeTime()
+		for _, barcode := range barcodes {
+			if err := tx.deleteSnack(barcode, now); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// CreateLocation adds a new location to SnackInventory, inside its parent if
+// set, & returns its id. Returns an AlreadyExists error if it does, and a
+// NotFound error if the parent is not registered.
+func (b *BoltImpl) CreateLocation(ctx context.Context, location *sipb.Location) (int64, error) {
+	var id int64
+	err := b.update(ctx, func(tx boltTx) error {
+		if err := tx.checkNameFree(location.GetName()); err != nil {
+			return err
+		}
+		if err := tx.checkParent(location.GetName(), location.GetParent()); err != nil {
+			return err
+		}
+		seq, err := tx.Bucket(boltLocations).NextSequence()
+		if err != nil {
+			return err
+		}
+		l := &boltLocation{
+			ID:          int64(seq),
+			Name:        location.GetName(),
+			Parent:      location.GetParent(),
+			Description: location.GetDescription(),
+			Type:        location.GetType(),
+		}
+		if err := tx.putLocation(l, ""); err != nil {
+			return err
+		}
+		id = l.ID
+		return tx.recordLocation(l, sipb.Change_CREATED, changeTime())
+	})
+	if err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+// checkNameFree returns an AlreadyExists error if a location is named name,
+// including in the trash.
+func (tx boltTx) checkNameFree(name string) error {
+	l, err := tx.location(name)
+	switch {
+	case err != nil:
+		return err
+	case l != nil && !l.Deleted.IsZero():
+		return status.Errorf(codes.AlreadyExists, "name %q is in the trash; restore or purge it first", name)
+	case l != nil:
+		return status.Errorf(codes.AlreadyExists, "name %q already has an entry", name)
+	}
+	return nil
+}
+
+// checkParent returns an error unless name may be nested inside parent:
+// NotFound if parent is not registered, or FailedPrecondition if parent is
+// name or inside it, which would make a cycle.
+func (tx boltTx) checkParent(name, parent string) error {
+	if parent == "" {
+		return nil
+	}
+	seen := map[string]bool{}
+	for ancestor := parent; ancestor != "" && !seen[ancestor]; {
+		if ancestor == name {
+			return status.Errorf(codes.FailedPrecondition, "location %q can't be nested inside itself", name)
+		}
+		seen[ancestor] = true
+		l, err := tx.liveLocation(ancestor)
+		if err != nil {
+			return err
+		}
+		if l == nil {
+			if ancestor == parent {
+				return status.Errorf(codes.NotFound, "parent location %q is not registered", parent)
+			}
+			break
+		}
+		ancestor = l.Parent
+	}
+	return nil
+}
+
+// UpdateLocation sets fields, of "name", "parent", "description" & "type", of
+// the location with the id of location to its values, & returns the updated
+// location. Stock & nested locations follow a renamed location.
+// Returns a NotFound error if there is no such location, and otherwise the
+// errors of CreateLocation for a new name or parent.
+func (b *BoltImpl) UpdateLocation(ctx context.Context, location *sipb.Location, fields []string) (*sipb.Location, error) {
+	var updated *boltLocation
+	err := b.update(ctx, func(tx boltTx) error {
+		updated = &boltLocation{}
+		found, err := tx.get(boltLocations, idKey(uint64(location.GetId())), updated)
+		if err != nil {
+			return err
+		}
+		if !found || !updated.Deleted.IsZero() {
+			return status.Errorf(codes.NotFound, "location %d is not registered", location.GetId())
+		}
+		// Nothing changes, so no revision is recorded.
+		if len(fields) == 0 {
+			return nil
+		}
+
+		oldName, oldParent := updated.Name, updated.Parent
+		for _, field := range fields {
+			switch field {
+			case "name":
+				updated.Name = location.GetName()
+			case "parent":
+				updated.Parent = location.GetParent()
+			case "description":
+				updated.Description = location.GetDescription()
+			case "type":
+				updated.Type = location.GetType()
+			default:
+				return status.Errorf(codes.InvalidArgument, "unknown location field %q", field)
+			}
+		}
+		// Ancestors are still stored under the old name.
+		if updated.Parent != oldParent {
+			if err := tx.checkParent(oldName, updated.Parent); err != nil {
+				return err
+			}
+		}
+		if updated.Name != oldName {
+			if err := tx.checkNameFree(updated.Name); err != nil {
+				return err
+			}
+		}
+		if err := tx.putLocation(updated, oldName); err != nil {
+			return err
+		}
+		now := changeTime()
+		if err := tx.recordLocation(updated, sipb.Change_UPDATED, now); err != nil {
+			return err
+		}
+		if updated.Name == oldName {
+			return nil
+		}
+		// Nested locations changed too, as they refer to their parent by name.
+		var children []*boltLocation
+		err = tx.forEachLocation(func(l *boltLocation) error {
+			if l.Parent == oldName {
+				children = append(children, l)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		for _, child := range children {
+			child.Parent = updated.Name
+			if err := tx.putLocation(child, child.Name); err != nil {
+				return err
+			}
+			if child.Deleted.IsZero() {
+				if err := tx.recordLocation(child, sipb.Change_UPDATED, now); err != nil {
+					return err
+				}
+			}
+		}
+		return tx.renameStock(oldName, updated.Name)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return updated.proto(), nil
+}
+
+// ListLocations reads all locations currently associated with SnackInventory,
+// ordered by id.
+func (b *BoltImpl) ListLocations(ctx context.Context) ([]*sipb.Location, error) {
+	var locations []*sipb.Location
+	err := b.view(ctx, func(tx boltTx) error {
+		return tx.forEachLocation(func(l *boltLocation) error {
+			if l.Deleted.IsZero() {
+				locations = append(locations, l.proto())
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return locations, nil
+}
+
+// DeleteLocation moves a location with the given name in SnackInventory to the
+// trash, handling stock held there per contents. Returns the resulting changes in
+// stock, both at the location & at target.
+// Returns a FailedPrecondition error if other locations are nested inside it,
+// or if it holds stock & contents is REFUSE, and a NotFound error if target
+// is not registered when moving stock.
+func (b *BoltImpl) DeleteLocation(ctx context.Context, name string, contents sipb.ContentsPolicy, target string) ([]*sipb.StockChange, error) {
+	var changes []*sipb.StockChange
+	err := b.update(ctx, func(tx boltTx) error {
+		err := tx.forEachLocation(func(l *boltLocation) error {
+			if l.Parent == name && l.Deleted.IsZero() {
+				return status.Errorf(codes.FailedPrecondition,
+					"location %q contains %q; move or delete it first", name, l.Name)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		if changes, err = tx.clearStock(name, contents, target); err != nil {
+			return err
+		}
+		l, err := tx.liveLocation(name)
+		if err != nil || l == nil {
+			return err
+		}
+		now := changeTime()
+		l.Deleted = now
+		if err := tx.putLocation(l, l.Name); err != nil {
+			return err
+		}
+		return tx.recordLocation(l, sipb.Change_DELETED, now)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
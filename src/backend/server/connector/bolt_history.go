@@ -0,0 +1,166 @@
+/*
+Copyright 2020 Robert Barron
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package connector
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"time"
+
+	sipb "github.com/rmbarron/SnackInventory/src/proto/snackinventory"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// boltSnackRevision is the record of a snack revision.
+type boltSnackRevision struct {
+	Snack *boltSnack
+	Type  sipb.Change_Type
+	Time  time.Time
+}
+
+// boltLocationRevision is the record of a location revision.
+type boltLocationRevision struct {
+	Location *boltLocation
+	Type     sipb.Change_Type
+	Time     time.Time
+}
+
+// recordSnack records a revision of type typ, made at time at, of s.
+// Revisions of a snack are keyed by its barcode then a sequence number, so
+// are kept in order.
+func (tx boltTx) recordSnack(s *boltSnack, typ sipb.Change_Type, at time.Time) error {
+	revisions := tx.Bucket(boltSnackRevisions)
+	seq, err := revisions.NextSequence()
+	if err != nil {
+		return err
+	}
+	key := append(joinKey(s.Barcode, ""), idKey(seq)...)
+	return tx.put(boltSnackRevisions, key, &boltSnackRevision{Snack: s, Type: typ, Time: at})
+}
+
+// recordLocation records a revision of type typ, made at time at, of l, keyed
+// by its id then a sequence number.
+func (tx boltTx) recordLocation(l *boltLocation, typ sipb.Change_Type, at time.Time) error {
+	revisions := tx.Bucket(boltLocationRevisions)
+	seq, err := revisions.NextSequence()
+	if err != nil {
+		return err
+	}
+	key := append(idKey(uint64(l.ID)), idKey(seq)...)
+	return tx.put(boltLocationRevisions, key, &boltLocationRevision{Location: l, Type: typ, Time: at})
+}
+
+// GetSnackHistory reads the revisions of the snack with barcode, oldest first.
+// Returns a NotFound error if there are none.
+func (b *BoltImpl) GetSnackHistory(ctx context.Context, barcode string) ([]*sipb.SnackRevision, error) {
+	var revisions []*sipb.SnackRevision
+	err := b.view(ctx, func(tx boltTx) error {
+		prefix := joinKey(barcode, "")
+		c := tx.Bucket(boltSnackRevisions).Cursor()
+		for k, data := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, data = c.Next() {
+			rev := &boltSnackRevision{}
+			if err := json.Unmarshal(data, rev); err != nil {
+				return err
+			}
+			revisions = append(revisions, &sipb.SnackRevision{
+				Snack:        rev.Snack.proto(),
+				Type:         rev.Type,
+				RevisionTime: timestamppb.New(rev.Time),
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(revisions) == 0 {
+		return nil, status.Errorf(codes.NotFound, "barcode %q has no history", barcode)
+	}
+	return revisions, nil
+}
+
+// ListSnacksAsOf reads the snacks registered at asOf, as they were then.
+func (b *BoltImpl) ListSnacksAsOf(ctx context.Context, asOf time.Time) ([]*sipb.Snack, error) {
+	var snacks []*sipb.Snack
+	err := b.view(ctx, func(tx boltTx) error {
+		// Revisions are grouped by barcode, in order, so the latest revision
+		// of each snack at asOf is the last one seen before the next group.
+		var latest *boltSnackRevision
+		flush := func() {
+			if latest != nil && latest.Type != sipb.Change_DELETED {
+				snacks = append(snacks, latest.Snack.proto())
+			}
+			latest = nil
+		}
+		err := tx.Bucket(boltSnackRevisions).ForEach(func(_, data []byte) error {
+			rev := &boltSnackRevision{}
+			if err := json.Unmarshal(data, rev); err != nil {
+				return err
+			}
+			if latest != nil && latest.Snack.Barcode != rev.Snack.Barcode {
+				flush()
+			}
+			if !rev.Time.After(asOf) {
+				latest = rev
+			}
+			return nil
+		})
+		flush()
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return snacks, nil
+}
+
+// ListLocationsAsOf reads the locations registered at asOf, as they were then.
+func (b *BoltImpl) ListLocationsAsOf(ctx context.Context, asOf time.Time) ([]*sipb.Location, error) {
+	var locations []*sipb.Location
+	err := b.view(ctx, func(tx boltTx) error {
+		// As in ListSnacksAsOf, grouped by location id.
+		var latest *boltLocationRevision
+		flush := func() {
+			if latest != nil && latest.Type != sipb.Change_DELETED {
+				locations = append(locations, latest.Location.proto())
+			}
+			latest = nil
+		}
+		err := tx.Bucket(boltLocationRevisions).ForEach(func(_, data []byte) error {
+			rev := &boltLocationRevision{}
+			if err := json.Unmarshal(data, rev); err != nil {
+				return err
+			}
+			if latest != nil && latest.Location.ID != rev.Location.ID {
+				flush()
+			}
+			if !rev.Time.After(asOf) {
+				latest = rev
+			}
+			return nil
+		})
+		flush()
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return locations, nil
+}
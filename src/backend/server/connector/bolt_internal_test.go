@@ -0,0 +1,58 @@
+/*
+Copyright 2020 Robert Barron
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package connector
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	sipb "github.com/rmbarron/SnackInventory/src/proto/snackinventory"
+	bolt "go.etcd.io/bbolt"
+)
+
+func TestBoltUpdateLocation_NoFieldsRecordsNothing(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "test.db")
+	b, err := NewBoltImpl(path, time.Second)
+	if err != nil {
+		t.Fatalf("NewBoltImpl(%q) = got err %v, want err nil", path, err)
+	}
+	defer b.Close()
+
+	id, err := b.CreateLocation(ctx, &sipb.Location{Name: "garage"})
+	if err != nil {
+		t.Fatalf("b.CreateLocation(%q) = got err %v, want err nil", "garage", err)
+	}
+	if _, err := b.UpdateLocation(ctx, &sipb.Location{Id: id, Name: "shed"}, nil); err != nil {
+		t.Fatalf("b.UpdateLocation(%d, no fields) = got err %v, want err nil", id, err)
+	}
+
+	var revisions int
+	err = b.db.View(func(tx *bolt.Tx) error {
+		revisions = tx.Bucket(boltLocationRevisions).Stats().KeyN
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("b.db.View() = got err %v, want err nil", err)
+	}
+	// Only the creation.
+	if revisions != 1 {
+		t.Errorf("location revisions = got %d, want 1", revisions)
+	}
+}
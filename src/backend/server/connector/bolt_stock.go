@@ -0,0 +1,196 @@
+/*
+Copyright 2020 Robert Barron
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package connector
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+
+	sipb "github.com/rmbarron/SnackInventory/src/proto/snackinventory"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// stockKey returns the key of the stock of barcode at location.
+func stockKey(barcode, location string) []byte {
+	return joinKey(location, barcode)
+}
+
+// splitStockKey returns the barcode & location of a stock key.
+func splitStockKey(k []byte) (barcode, location string) {
+	i := bytes.IndexByte(k, 0)
+	return string(k[i+1:]), string(k[:i])
+}
+
+// stockCount returns the count of barcode at location, 0 if it isn't stocked.
+func (tx boltTx) stockCount(barcode, location string) int32 {
+	data := tx.Bucket(boltStock).Get(stockKey(barcode, location))
+	if data == nil {
+		return 0
+	}
+	return int32(binary.BigEndian.Uint32(data))
+}
+
+// setStock sets the count of barcode at location. Only counts above zero are
+// kept.
+func (tx boltTx) setStock(barcode, location string, count int32) error {
+	if count == 0 {
+		return tx.Bucket(boltStock).Delete(stockKey(barcode, location))
+	}
+	data := make([]byte, 4)
+	binary.BigEndian.PutUint32(data, uint32(count))
+	return tx.Bucket(boltStock).Put(stockKey(barcode, location), data)
+}
+
+// stockAt returns the stock at location, ordered by barcode, or at all
+// locations if location is "".
+func (tx boltTx) stockAt(location string) []*sipb.Stock {
+	var prefix []byte
+	if location != "" {
+		prefix = joinKey(location, "")
+	}
+	var stock []*sipb.Stock
+	c := tx.Bucket(boltStock).Cursor()
+	for k, v := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = c.Next() {
+		barcode, location := splitStockKey(k)
+		stock = append(stock, &sipb.Stock{Barcode: barcode, Location: location, Count: int32(binary.BigEndian.Uint32(v))})
+	}
+	return stock
+}
+
+// renameStock moves all stock at oldName to newName.
+func (tx boltTx) renameStock(oldName, newName string) error {
+	for _, st := range tx.stockAt(oldName) {
+		if err := tx.setStock(st.Barcode, oldName, 0); err != nil {
+			return err
+		}
+		if err := tx.setStock(st.Barcode, newName, st.Count); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AdjustStock changes the count of a snack at a location by delta, returning
+// the new count. Counts are only kept above zero.
+// Returns a NotFound error if the snack or location is not registered, and a
+// FailedPrecondition error if the count would drop below zero.
+func (b *BoltImpl) AdjustStock(ctx context.Context, barcode, location string, delta int32) (int32, error) {
+	var count int32
+	err := b.update(ctx, func(tx boltTx) error {
+		s, err := tx.liveSnack(barcode)
+		if err != nil {
+			return err
+		}
+		if s == nil {
+			return status.Errorf(codes.NotFound, "barcode %q is not registered", barcode)
+		}
+		l, err := tx.liveLocation(location)
+		if err != nil {
+			return err
+		}
+		if l == nil {
+			return status.Errorf(codes.NotFound, "location %q is not registered", location)
+		}
+		old := tx.stockCount(barcode, location)
+		count = old + delta
+		if count < 0 {
+			return status.Errorf(codes.FailedPrecondition,
+				"only %d of %q at %q, can't remove %d", old, barcode, location, -delta)
+		}
+		return tx.setStock(barcode, location, count)
+	})
+	if err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// clearStock removes all stock at location, moving it to target or discarding
+// it per contents, & returns the changes made.
+func (tx boltTx) clearStock(location string, contents sipb.ContentsPolicy, target string) ([]*sipb.StockChange, error) {
+	if contents == sipb.ContentsPolicy_MOVE {
+		if target == location {
+			return nil, status.Errorf(codes.InvalidArgument, "can't move stock from %q to itself", location)
+		}
+		l, err := tx.liveLocation(target)
+		if err != nil {
+			return nil, err
+		}
+		if l == nil {
+			return nil, status.Errorf(codes.NotFound, "location %q is not registered", target)
+		}
+	}
+
+	stock := tx.stockAt(location)
+	if len(stock) == 0 {
+		return nil, nil
+	}
+	reason := sipb.StockChange_DISCARDED
+	switch contents {
+	case sipb.ContentsPolicy_MOVE:
+		reason = sipb.StockChange_MOVED
+	case sipb.ContentsPolicy_DISCARD:
+	default:
+		return nil, status.Errorf(codes.FailedPrecondition,
+			"location %q holds %d snacks; move or discard them first", location, len(stock))
+	}
+	var changes []*sipb.StockChange
+	for _, st := range stock {
+		if err := tx.setStock(st.Barcode, location, 0); err != nil {
+			return nil, err
+		}
+		changes = append(changes, &sipb.StockChange{
+			Barcode: st.Barcode, Location: location, Delta: -st.Count, Reason: reason,
+		})
+		if reason != sipb.StockChange_MOVED {
+			continue
+		}
+		count := tx.stockCount(st.Barcode, target) + st.Count
+		if err := tx.setStock(st.Barcode, target, count); err != nil {
+			return nil, err
+		}
+		changes = append(changes, &sipb.StockChange{
+			Barcode: st.Barcode, Location: target, Delta: st.Count, Count: count, Reason: reason,
+		})
+	}
+	return changes, nil
+}
+
+// ListStock reads the stock of all snacks, or only those at location if set,
+// ordered by location & barcode.
+// Stock of snacks in the trash is skipped.
+func (b *BoltImpl) ListStock(ctx context.Context, location string) ([]*sipb.Stock, error) {
+	var stock []*sipb.Stock
+	err := b.view(ctx, func(tx boltTx) error {
+		for _, st := range tx.stockAt(location) {
+			s, err := tx.snack(st.Barcode)
+			if err != nil {
+				return err
+			}
+			if s == nil || s.Deleted.IsZero() {
+				stock = append(stock, st)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return stock, nil
+}
@@ -0,0 +1,124 @@
+/*
+Copyright 2020 Robert Barron
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package connector_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/rmbarron/SnackInventory/src/backend/server/connector"
+	"github.com/rmbarron/SnackInventory/src/backend/server/connector/connectortest"
+	sipb "github.com/rmbarron/SnackInventory/src/proto/snackinventory"
+)
+
+// newBoltImplT opens a BoltImpl on a new file in a temp dir, closed when the
+// test ends.
+func newBoltImplT(t *testing.T, dir string) *connector.BoltImpl {
+	t.Helper()
+	path := filepath.Join(dir, fmt.Sprintf("%d.db", time.Now().UnixNano()))
+	b, err := connector.NewBoltImpl(path, time.Second)
+	if err != nil {
+		t.Fatalf("NewBoltImpl(%q) = got err %v, want err nil", path, err)
+	}
+	t.Cleanup(func() { b.Close() })
+	return b
+}
+
+// TestBoltConformance runs the conformance suite against BoltImpl.
+func TestBoltConformance(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+
+	connectortest.RunConformance(t, func(t *testing.T) connector.Store {
+		return newBoltImplT(t, dir)
+	})
+}
+
+func TestBoltFindSnacksByName(t *testing.T) {
+	ctx := context.Background()
+	b := newBoltImplT(t, t.TempDir())
+
+	for _, s := range []*sipb.Snack{
+		{Barcode: "3", Name: "chips"},
+		{Barcode: "1", Name: "chips"},
+		{Barcode: "2", Name: "chips ahoy"},
+		{Barcode: "4", Name: "chips"},
+	} {
+		if err := b.CreateSnack(ctx, s); err != nil {
+			t.Fatalf("b.CreateSnack(%v) = got err %v, want err nil", s, err)
+		}
+	}
+	if err := b.DeleteSnack(ctx, "4"); err != nil {
+		t.Fatalf("b.DeleteSnack(%q) = got err %v, want err nil", "4", err)
+	}
+	if err := b.UpdateSnack(ctx, &sipb.Snack{Barcode: "3", Name: "crisps"}); err != nil {
+		t.Fatalf("b.UpdateSnack(%q) = got err %v, want err nil", "3", err)
+	}
+
+	got, err := b.FindSnacksByName(ctx, "chips")
+	if err != nil {
+		t.Fatalf("b.FindSnacksByName(%q) = got err %v, want err nil", "chips", err)
+	}
+	want := []*sipb.Snack{{Barcode: "1", Name: "chips"}}
+	if diff := cmp.Diff(want, got, cmpopts.IgnoreUnexported(sipb.Snack{})); diff != "" {
+		t.Errorf("b.FindSnacksByName(%q) = got diff (-want +got): %s", "chips", diff)
+	}
+}
+
+func TestBoltBackup(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	b := newBoltImplT(t, dir)
+
+	want := []*sipb.Snack{{Barcode: "1", Name: "chips"}}
+	if err := b.CreateSnack(ctx, want[0]); err != nil {
+		t.Fatalf("b.CreateSnack(%v) = got err %v, want err nil", want[0], err)
+	}
+
+	var buf bytes.Buffer
+	if n, err := b.WriteTo(&buf); err != nil || n != int64(buf.Len()) {
+		t.Fatalf("b.WriteTo() = got %d, err %v, want %d, err nil", n, err, buf.Len())
+	}
+
+	path := filepath.Join(dir, "backup.db")
+	if err := b.Backup(path); err != nil {
+		t.Fatalf("b.Backup(%q) = got err %v, want err nil", path, err)
+	}
+	// Changes after the backup aren't in it.
+	if err := b.CreateSnack(ctx, &sipb.Snack{Barcode: "2", Name: "dip"}); err != nil {
+		t.Fatalf("b.CreateSnack(%q) = got err %v, want err nil", "2", err)
+	}
+
+	backup, err := connector.NewBoltImpl(path, time.Second)
+	if err != nil {
+		t.Fatalf("NewBoltImpl(%q) = got err %v, want err nil", path, err)
+	}
+	defer backup.Close()
+	got, err := backup.ListSnacks(ctx)
+	if err != nil {
+		t.Fatalf("backup.ListSnacks() = got err %v, want err nil", err)
+	}
+	if diff := cmp.Diff(want, got, cmpopts.IgnoreUnexported(sipb.Snack{})); diff != "" {
+		t.Errorf("backup.ListSnacks() = got diff (-want +got): %s", diff)
+	}
+}
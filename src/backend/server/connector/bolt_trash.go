@@ -0,0 +1,204 @@
+/*
+Copyright 2020 Robert Barron
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package connector
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"time"
+
+	sipb "github.com/rmbarron/SnackInventory/src/proto/snackinventory"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// ListDeleted reads the snacks & locations in the trash, most recently deleted
+// first.
+func (b *BoltImpl) ListDeleted(ctx context.Context) ([]*sipb.DeletedEntity, error) {
+	var deleted []*sipb.DeletedEntity
+	err := b.view(ctx, func(tx boltTx) error {
+		err := tx.Bucket(boltSnacks).ForEach(func(_, data []byte) error {
+			s := &boltSnack{}
+			if err := json.Unmarshal(data, s); err != nil {
+				return err
+			}
+			if !s.Deleted.IsZero() {
+				deleted = append(deleted, &sipb.DeletedEntity{
+					Entity:     &sipb.DeletedEntity_Snack{Snack: s.proto()},
+					DeleteTime: timestamppb.New(s.Deleted),
+				})
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		// Locations by name, as for SQLImpl.
+		return tx.Bucket(boltLocationNames).ForEach(func(name, _ []byte) error {
+			l, err := tx.location(string(name))
+			if err != nil || l == nil || l.Deleted.IsZero() {
+				return err
+			}
+			deleted = append(deleted, &sipb.DeletedEntity{
+				Entity:     &sipb.DeletedEntity_Location{Location: l.proto()},
+				DeleteTime: timestamppb.New(l.Deleted),
+			})
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(deleted, func(i, j int) bool {
+		return deleted[i].GetDeleteTime().AsTime().After(deleted[j].GetDeleteTime().AsTime())
+	})
+	return deleted, nil
+}
+
+// UndeleteSnack restores the snack with barcode from the trash, along with its
+// stock, & returns it. Returns a NotFound error if it isn't in the trash.
+func (b *BoltImpl) UndeleteSnack(ctx context.Context, barcode string) (*sipb.Snack, error) {
+	var snack *sipb.Snack
+	err := b.update(ctx, func(tx boltTx) error {
+		s, err := tx.snack(barcode)
+		if err != nil {
+			return err
+		}
+		if s == nil || s.Deleted.IsZero() {
+			return status.Errorf(codes.NotFound, "barcode %q is not in the trash", barcode)
+		}
+		s.Deleted = time.Time{}
+		if err := tx.put(boltSnacks, []byte(barcode), s); err != nil {
+			return err
+		}
+		snack = s.proto()
+		return tx.recordSnack(s, sipb.Change_CREATED, changeTime())
+	})
+	if err != nil {
+		return nil, err
+	}
+	return snack, nil
+}
+
+// UndeleteLocation restores the location with name from the trash, & returns
+// it. A location whose parent has since been purged is restored at the top
+// level.
+// Returns a NotFound error if it isn't in the trash, and a FailedPrecondition
+// error if its parent is in the trash too.
+func (b *BoltImpl) UndeleteLocation(ctx context.Context, name string) (*sipb.Location, error) {
+	var location *sipb.Location
+	err := b.update(ctx, func(tx boltTx) error {
+		l, err := tx.location(name)
+		if err != nil {
+			return err
+		}
+		if l == nil || l.Deleted.IsZero() {
+			return status.Errorf(codes.NotFound, "location %q is not in the trash", name)
+		}
+		if l.Parent != "" {
+			parent, err := tx.location(l.Parent)
+			switch {
+			case err != nil:
+				return err
+			case parent == nil:
+				l.Parent = ""
+			case !parent.Deleted.IsZero():
+				return status.Errorf(codes.FailedPrecondition,
+					"parent location %q of %q is in the trash; restore it first", l.Parent, name)
+			}
+		}
+		l.Deleted = time.Time{}
+		if err := tx.putLocation(l, l.Name); err != nil {
+			return err
+		}
+		location = l.proto()
+		return tx.recordLocation(l, sipb.Change_CREATED, changeTime())
+	})
+	if err != nil {
+		return nil, err
+	}
+	return location, nil
+}
+
+// PurgeDeleted permanently removes the snacks & locations moved to the trash
+// before the given time, along with any stock of the snacks. Their history is
+// kept. Returns the number of snacks & locations removed.
+func (b *BoltImpl) PurgeDeleted(ctx context.Context, before time.Time) (snacks, locations int64, err error) {
+	err = b.update(ctx, func(tx boltTx) error {
+		snacks, locations = 0, 0
+		purged := map[string]bool{}
+		var purgedSnacks []*boltSnack
+		err := tx.Bucket(boltSnacks).ForEach(func(_, data []byte) error {
+			s := &boltSnack{}
+			if err := json.Unmarshal(data, s); err != nil {
+				return err
+			}
+			if !s.Deleted.IsZero() && s.Deleted.Before(before) {
+				purged[s.Barcode] = true
+				purgedSnacks = append(purgedSnacks, s)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		// Buckets can't be changed while iterating over them.
+		for _, s := range purgedSnacks {
+			if err := tx.Bucket(boltSnacks).Delete([]byte(s.Barcode)); err != nil {
+				return err
+			}
+			if err := tx.Bucket(boltSnackNames).Delete(joinKey(s.Name, s.Barcode)); err != nil {
+				return err
+			}
+			snacks++
+		}
+		for _, st := range tx.stockAt("") {
+			if purged[st.Barcode] {
+				if err := tx.setStock(st.Barcode, st.Location, 0); err != nil {
+					return err
+				}
+			}
+		}
+
+		var purgedLocations []*boltLocation
+		err = tx.forEachLocation(func(l *boltLocation) error {
+			if !l.Deleted.IsZero() && l.Deleted.Before(before) {
+				purgedLocations = append(purgedLocations, l)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		for _, l := range purgedLocations {
+			if err := tx.Bucket(boltLocations).Delete(idKey(uint64(l.ID))); err != nil {
+				return err
+			}
+			if err := tx.Bucket(boltLocationNames).Delete([]byte(l.Name)); err != nil {
+				return err
+			}
+			locations++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+	return snacks, locations, nil
+}
@@ -0,0 +1,94 @@
+/*
+Copyright 2020 Robert Barron
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package connector
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/rmbarron/SnackInventory/src/backend/server/config"
+)
+
+func init() {
+	Register("bolt", &boltFactory{})
+}
+
+// boltFactory opens a BoltImpl, configured by flags prefixed "bolt_".
+type boltFactory struct {
+	path    string
+	timeout time.Duration
+
+	backupPath     string
+	backupInterval time.Duration
+}
+
+func (f *boltFactory) RegisterFlags(fs *flag.FlagSet) {
+	fs.StringVar(&f.path, "bolt_path", "snackinventory.db", "Path of the bolt file, created if missing.")
+	fs.DurationVar(
+		&f.timeout, "bolt_timeout", time.Second,
+		"How long to wait for another process to close the bolt file. Forever if 0.")
+	fs.StringVar(&f.backupPath, "bolt_backup_path", "", "Path to periodically write a copy of the bolt file to.")
+	fs.DurationVar(
+		&f.backupInterval, "bolt_backup_interval", 0,
+		"How often to back up the bolt file to bolt_backup_path. Never if 0.")
+}
+
+func (f *boltFactory) Validate() error {
+	var errs config.Errors
+	if f.path == "" {
+		errs = append(errs, "bolt_path: required for bolt storage")
+	}
+	if f.timeout < 0 {
+		errs = append(errs, fmt.Sprintf("bolt_timeout: %v must not be negative", f.timeout))
+	}
+	if f.backupInterval < 0 {
+		errs = append(errs, fmt.Sprintf("bolt_backup_interval: %v must not be negative", f.backupInterval))
+	}
+	if f.backupInterval > 0 && f.backupPath == "" {
+		errs = append(errs, "bolt_backup_path: required when bolt_backup_interval is set")
+	}
+	if f.backupPath != "" && f.backupPath == f.path {
+		errs = append(errs, "bolt_backup_path: must differ from bolt_path")
+	}
+	return errs.Err()
+}
+
+func (f *boltFactory) Open(ctx context.Context) (Store, error) {
+	b, err := NewBoltImpl(f.path, f.timeout)
+	if err != nil {
+		return nil, fmt.Errorf("could not open bolt file %q: %w", f.path, err)
+	}
+	if f.backupInterval > 0 {
+		go f.backup(b)
+	}
+	return b, nil
+}
+
+// backup backs up b to backupPath every backupInterval, for the life of the
+// server. Failed backups are logged & retried at the next interval.
+func (f *boltFactory) backup(b *BoltImpl) {
+	t := time.NewTicker(f.backupInterval)
+	defer t.Stop()
+	for range t.C {
+		if err := b.Backup(f.backupPath); err != nil {
+			log.Printf("could not back up bolt file to %q: %v", f.backupPath, err)
+		}
+	}
+}
@@ -0,0 +1,278 @@
+/*
+Copyright 2020 Robert Barron
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package connector
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	sipb "github.com/rmbarron/SnackInventory/src/proto/snackinventory"
+	"google.golang.org/protobuf/proto"
+)
+
+// CachedStore wraps a Store, caching the results of ListSnacks,
+// ListLocations, ListStock, ListDeleted & GetSnackHistory for up to a TTL.
+// Every mutating method is passed through, then empties the cache, since one
+// change can affect many results (ex: renaming a location renames its stock).
+// Errors & reads as of a time are not cached.
+//
+// It is safe for concurrent use. Callers get their own copies of results, so
+// may modify them.
+type CachedStore struct {
+	s   Store
+	ttl time.Duration
+	// now is the clock used for expiry.
+	now func() time.Time
+
+	mu sync.Mutex
+	// gen is incremented on every invalidation, so results read by the
+	// backend before a write are not cached after it.
+	gen     uint64
+	entries map[string]cacheEntry
+	stats   CacheStats
+}
+
+var _ Store = (*CachedStore)(nil)
+
+// CacheStats counts the lookups of a CachedStore.
+type CacheStats struct {
+	// Hits were served from the cache; Misses were read from the backend.
+	Hits, Misses uint64
+}
+
+type cacheEntry struct {
+	value   interface{}
+	expires time.Time
+}
+
+// NewCachedStore returns a CachedStore caching the results of s for ttl.
+func NewCachedStore(s Store, ttl time.Duration) *CachedStore {
+	return &CachedStore{s: s, ttl: ttl, now: time.Now, entries: map[string]cacheEntry{}}
+}
+
+// Stats returns the hits & misses of c so far.
+func (c *CachedStore) Stats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.stats
+}
+
+// get returns the unexpired result cached under key, or else calls load &
+// caches its result if nothing was invalidated meanwhile.
+func (c *CachedStore) get(key string, load func() (interface{}, error)) (interface{}, error) {
+	c.mu.Lock()
+	if e, ok := c.entries[key]; ok && c.now().Before(e.expires) {
+		c.stats.Hits++
+		c.mu.Unlock()
+		return e.value, nil
+	}
+	c.stats.Misses++
+	gen := c.gen
+	c.mu.Unlock()
+
+	v, err := load()
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	if c.gen == gen {
+		c.entries[key] = cacheEntry{value: v, expires: c.now().Add(c.ttl)}
+	}
+	c.mu.Unlock()
+	return v, nil
+}
+
+// invalidate empties the cache. It is called after every write, whether it
+// succeeded or not, as a failed write may still have been applied (ex: a
+// timeout after commit).
+func (c *CachedStore) invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.gen++
+	c.entries = map[string]cacheEntry{}
+}
+
+// cloneSnacks returns a deep copy of src. As for the other clone functions,
+// nil stays nil, so results match those of the backend.
+func cloneSnacks(src []*sipb.Snack) []*sipb.Snack {
+	if src == nil {
+		return nil
+	}
+	dst := make([]*sipb.Snack, len(src))
+	for i, m := range src {
+		dst[i] = proto.Clone(m).(*sipb.Snack)
+	}
+	return dst
+}
+
+func cloneLocations(src []*sipb.Location) []*sipb.Location {
+	if src == nil {
+		return nil
+	}
+	dst := make([]*sipb.Location, len(src))
+	for i, m := range src {
+		dst[i] = proto.Clone(m).(*sipb.Location)
+	}
+	return dst
+}
+
+func cloneStock(src []*sipb.Stock) []*sipb.Stock {
+	if src == nil {
+		return nil
+	}
+	dst := make([]*sipb.Stock, len(src))
+	for i, m := range src {
+		dst[i] = proto.Clone(m).(*sipb.Stock)
+	}
+	return dst
+}
+
+func cloneDeleted(src []*sipb.DeletedEntity) []*sipb.DeletedEntity {
+	if src == nil {
+		return nil
+	}
+	dst := make([]*sipb.DeletedEntity, len(src))
+	for i, m := range src {
+		dst[i] = proto.Clone(m).(*sipb.DeletedEntity)
+	}
+	return dst
+}
+
+func cloneRevisions(src []*sipb.SnackRevision) []*sipb.SnackRevision {
+	if src == nil {
+		return nil
+	}
+	dst := make([]*sipb.SnackRevision, len(src))
+	for i, m := range src {
+		dst[i] = proto.Clone(m).(*sipb.SnackRevision)
+	}
+	return dst
+}
+
+func (c *CachedStore) CreateSnack(ctx context.Context, snack *sipb.Snack) error {
+	defer c.invalidate()
+	return c.s.CreateSnack(ctx, snack)
+}
+
+func (c *CachedStore) ListSnacks(ctx context.Context) ([]*sipb.Snack, error) {
+	v, err := c.get("snacks", func() (interface{}, error) { return c.s.ListSnacks(ctx) })
+	if err != nil {
+		return nil, err
+	}
+	return cloneSnacks(v.([]*sipb.Snack)), nil
+}
+
+func (c *CachedStore) UpdateSnack(ctx context.Context, snack *sipb.Snack) error {
+	defer c.invalidate()
+	return c.s.UpdateSnack(ctx, snack)
+}
+
+func (c *CachedStore) DeleteSnack(ctx context.Context, barcode string) error {
+	defer c.invalidate()
+	return c.s.DeleteSnack(ctx, barcode)
+}
+
+func (c *CachedStore) BatchCreateSnacks(ctx context.Context, snacks []*sipb.Snack) error {
+	defer c.invalidate()
+	return c.s.BatchCreateSnacks(ctx, snacks)
+}
+
+func (c *CachedStore) BatchUpdateSnacks(ctx context.Context, snacks []*sipb.Snack) error {
+	defer c.invalidate()
+	return c.s.BatchUpdateSnacks(ctx, snacks)
+}
+
+func (c *CachedStore) BatchDeleteSnacks(ctx context.Context, barcodes []string) error {
+	defer c.invalidate()
+	return c.s.BatchDeleteSnacks(ctx, barcodes)
+}
+
+func (c *CachedStore) CreateLocation(ctx context.Context, location *sipb.Location) (int64, error) {
+	defer c.invalidate()
+	return c.s.CreateLocation(ctx, location)
+}
+
+func (c *CachedStore) ListLocations(ctx context.Context) ([]*sipb.Location, error) {
+	v, err := c.get("locations", func() (interface{}, error) { return c.s.ListLocations(ctx) })
+	if err != nil {
+		return nil, err
+	}
+	return cloneLocations(v.([]*sipb.Location)), nil
+}
+
+func (c *CachedStore) UpdateLocation(ctx context.Context, location *sipb.Location, fields []string) (*sipb.Location, error) {
+	defer c.invalidate()
+	return c.s.UpdateLocation(ctx, location, fields)
+}
+
+func (c *CachedStore) DeleteLocation(ctx context.Context, name string, contents sipb.ContentsPolicy, target string) ([]*sipb.StockChange, error) {
+	defer c.invalidate()
+	return c.s.DeleteLocation(ctx, name, contents, target)
+}
+
+func (c *CachedStore) AdjustStock(ctx context.Context, barcode, location string, delta int32) (int32, error) {
+	defer c.invalidate()
+	return c.s.AdjustStock(ctx, barcode, location, delta)
+}
+
+func (c *CachedStore) ListStock(ctx context.Context, location string) ([]*sipb.Stock, error) {
+	v, err := c.get("stock\x00"+location, func() (interface{}, error) { return c.s.ListStock(ctx, location) })
+	if err != nil {
+		return nil, err
+	}
+	return cloneStock(v.([]*sipb.Stock)), nil
+}
+
+func (c *CachedStore) ListDeleted(ctx context.Context) ([]*sipb.DeletedEntity, error) {
+	v, err := c.get("deleted", func() (interface{}, error) { return c.s.ListDeleted(ctx) })
+	if err != nil {
+		return nil, err
+	}
+	return cloneDeleted(v.([]*sipb.DeletedEntity)), nil
+}
+
+func (c *CachedStore) UndeleteSnack(ctx context.Context, barcode string) (*sipb.Snack, error) {
+	defer c.invalidate()
+	return c.s.UndeleteSnack(ctx, barcode)
+}
+
+func (c *CachedStore) UndeleteLocation(ctx context.Context, name string) (*sipb.Location, error) {
+	defer c.invalidate()
+	return c.s.UndeleteLocation(ctx, name)
+}
+
+func (c *CachedStore) PurgeDeleted(ctx context.Context, before time.Time) (snacks, locations int64, err error) {
+	defer c.invalidate()
+	return c.s.PurgeDeleted(ctx, before)
+}
+
+func (c *CachedStore) GetSnackHistory(ctx context.Context, barcode string) ([]*sipb.SnackRevision, error) {
+	v, err := c.get("history\x00"+barcode, func() (interface{}, error) { return c.s.GetSnackHistory(ctx, barcode) })
+	if err != nil {
+		return nil, err
+	}
+	return cloneRevisions(v.([]*sipb.SnackRevision)), nil
+}
+
+func (c *CachedStore) ListSnacksAsOf(ctx context.Context, asOf time.Time) ([]*sipb.Snack, error) {
+	return c.s.ListSnacksAsOf(ctx, asOf)
+}
+
+func (c *CachedStore) ListLocationsAsOf(ctx context.Context, asOf time.Time) ([]*sipb.Location, error) {
+	return c.s.ListLocationsAsOf(ctx, asOf)
+}
@@ -0,0 +1,132 @@
+/*
+Copyright 2020 Robert Barron
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package connector_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/rmbarron/SnackInventory/src/backend/fakes/fakestore"
+	"github.com/rmbarron/SnackInventory/src/backend/server/connector"
+	"github.com/rmbarron/SnackInventory/src/backend/server/connector/connectortest"
+	sipb "github.com/rmbarron/SnackInventory/src/proto/snackinventory"
+)
+
+// TestCachedStoreConformance checks that caching, with invalidation on every
+// write, doesn't change the behavior of the wrapped Store.
+func TestCachedStoreConformance(t *testing.T) {
+	t.Parallel()
+	connectortest.RunConformance(t, func(t *testing.T) connector.Store {
+		return connector.NewCachedStore(fakestore.New(), time.Hour)
+	})
+}
+
+func TestCachedStore_HitsUntilExpiry(t *testing.T) {
+	ctx := context.Background()
+	backend := fakestore.New()
+	c := connector.NewCachedStore(backend, time.Minute)
+	now := time.Unix(1600000000, 0)
+	c.SetClockForTest(func() time.Time { return now })
+
+	if err := c.CreateSnack(ctx, &sipb.Snack{Barcode: "1", Name: "chips"}); err != nil {
+		t.Fatalf("c.CreateSnack(%q) = got err %v, want err nil", "1", err)
+	}
+	listSnacks := func(want ...*sipb.Snack) {
+		t.Helper()
+		got, err := c.ListSnacks(ctx)
+		if err != nil {
+			t.Fatalf("c.ListSnacks() = got err %v, want err nil", err)
+		}
+		if diff := cmp.Diff(want, got, cmpopts.IgnoreUnexported(sipb.Snack{})); diff != "" {
+			t.Errorf("c.ListSnacks() = got diff (-want +got): %s", diff)
+		}
+	}
+	chips := &sipb.Snack{Barcode: "1", Name: "chips"}
+	dip := &sipb.Snack{Barcode: "2", Name: "dip"}
+
+	listSnacks(chips)
+	// Writes straight to the backend aren't seen until the entry expires.
+	if err := backend.CreateSnack(ctx, dip); err != nil {
+		t.Fatalf("backend.CreateSnack(%q) = got err %v, want err nil", "2", err)
+	}
+	listSnacks(chips)
+	now = now.Add(time.Minute)
+	listSnacks(chips, dip)
+
+	want := connector.CacheStats{Hits: 1, Misses: 2}
+	if got := c.Stats(); got != want {
+		t.Errorf("c.Stats() = got %+v, want %+v", got, want)
+	}
+}
+
+func TestCachedStore_WritesInvalidate(t *testing.T) {
+	ctx := context.Background()
+	c := connector.NewCachedStore(fakestore.New(), time.Hour)
+
+	if _, err := c.CreateLocation(ctx, &sipb.Location{Name: "pantry"}); err != nil {
+		t.Fatalf("c.CreateLocation(%q) = got err %v, want err nil", "pantry", err)
+	}
+	if err := c.CreateSnack(ctx, &sipb.Snack{Barcode: "1", Name: "chips"}); err != nil {
+		t.Fatalf("c.CreateSnack(%q) = got err %v, want err nil", "1", err)
+	}
+	if _, err := c.AdjustStock(ctx, "1", "pantry", 2); err != nil {
+		t.Fatalf("c.AdjustStock(%q, %q, 2) = got err %v, want err nil", "1", "pantry", err)
+	}
+	if _, err := c.ListStock(ctx, "pantry"); err != nil {
+		t.Fatalf("c.ListStock(%q) = got err %v, want err nil", "pantry", err)
+	}
+
+	// Renaming the location renames its stock, so every cached result goes.
+	if _, err := c.UpdateLocation(ctx, &sipb.Location{Id: 1, Name: "cupboard"}, []string{"name"}); err != nil {
+		t.Fatalf("c.UpdateLocation(%q) = got err %v, want err nil", "cupboard", err)
+	}
+	got, err := c.ListStock(ctx, "")
+	if err != nil {
+		t.Fatalf("c.ListStock(%q) = got err %v, want err nil", "", err)
+	}
+	want := []*sipb.Stock{{Barcode: "1", Location: "cupboard", Count: 2}}
+	if diff := cmp.Diff(want, got, cmpopts.IgnoreUnexported(sipb.Stock{})); diff != "" {
+		t.Errorf("c.ListStock(%q) = got diff (-want +got): %s", "", diff)
+	}
+	if got, want := c.Stats(), (connector.CacheStats{Misses: 2}); got != want {
+		t.Errorf("c.Stats() = got %+v, want %+v", got, want)
+	}
+}
+
+func TestCachedStore_ReturnsCopies(t *testing.T) {
+	ctx := context.Background()
+	c := connector.NewCachedStore(fakestore.New(), time.Hour)
+	if err := c.CreateSnack(ctx, &sipb.Snack{Barcode: "1", Name: "chips"}); err != nil {
+		t.Fatalf("c.CreateSnack(%q) = got err %v, want err nil", "1", err)
+	}
+
+	first, err := c.ListSnacks(ctx)
+	if err != nil {
+		t.Fatalf("c.ListSnacks() = got err %v, want err nil", err)
+	}
+	first[0].Name = "changed"
+	got, err := c.ListSnacks(ctx)
+	if err != nil {
+		t.Fatalf("c.ListSnacks() = got err %v, want err nil", err)
+	}
+	if got[0].GetName() != "chips" {
+		t.Errorf("c.ListSnacks() after changing a result = got name %q, want %q", got[0].GetName(), "chips")
+	}
+}
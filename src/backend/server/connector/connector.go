@@ -29,64 +29,145 @@ import (
 
 	_ "github.com/go-sql-driver/mysql" // MySQL driver.
 	sipb "github.com/rmbarron/SnackInventory/src/proto/snackinventory"
+	"github.com/rmbarron/SnackInventory/src/tracing"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 )
 
 // SQLImpl implements a connector a SQL DB.
 // SQLImpl connects to an arbitrary address:DBName, but assumes the presence of
-// "SnackRegistry" & "Location" tables.
+// "SnackRegistry", "LocationRegistry" & "Stock" tables.
+// Deleted snacks & locations keep their rows, with "deleted_at" set, until
+// purged; all other queries skip them. Changes to either are also recorded in
+// "SnackRevisions" & "LocationRevisions".
 type SQLImpl struct {
-	db *sql.DB
+	db   *sql.DB
+	opts SQLOptions
 }
 
 // NewSQLImpl connects to SQL and creates a SQLImpl instance.
-func NewSQLImpl(ctx context.Context, user, password, hostport, dbname string) (*SQLImpl, error) {
-	db, err := sql.Open("mysql", fmt.Sprintf("%s:%s@tcp(%s)/%s", user, password, hostport, dbname))
+// If opts.StartupTimeout is set, NewSQLImpl waits for the DB to come up.
+func NewSQLImpl(ctx context.Context, user, password, hostport, dbname string, opts SQLOptions) (*SQLImpl, error) {
+	db, err := sql.Open("mysql", fmt.Sprintf("%s:%s@tcp(%s)/%s?parseTime=true", user, password, hostport, dbname))
 	if err != nil {
 		return nil, err
 	}
+	opts.applyPool(db)
+	s := &SQLImpl{db: db, opts: opts}
 	// Verify the connection to SQL is open.
-	if err = db.PingContext(ctx); err != nil {
+	if err = s.pingWithRetry(ctx); err != nil {
+		db.Close()
 		return nil, err
 	}
-	return &SQLImpl{db: db}, nil
+	return s, nil
+}
+
+// Stats returns connection pool statistics for the underlying DB.
+func (s *SQLImpl) Stats() sql.DBStats {
+	return s.db.Stats()
+}
+
+// sqlConn is implemented by both *sql.DB and *sql.Tx.
+type sqlConn interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// queryContext runs a query within a trace span, when tracing is enabled.
+func (s *SQLImpl) queryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return tracedQuery(ctx, s.db, query, args...)
+}
+
+// execContext runs a statement within a trace span, when tracing is enabled.
+func (s *SQLImpl) execContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return tracedExec(ctx, s.db, query, args...)
+}
+
+func tracedQuery(ctx context.Context, c sqlConn, query string, args ...interface{}) (*sql.Rows, error) {
+	ctx, span := tracing.StartSpan(ctx, "SQLImpl.Query")
+	defer span.End()
+	span.SetAttribute("db.statement", query)
+
+	rows, err := c.QueryContext(ctx, query, args...)
+	span.SetError(err)
+	return rows, err
+}
+
+func tracedExec(ctx context.Context, c sqlConn, query string, args ...interface{}) (sql.Result, error) {
+	ctx, span := tracing.StartSpan(ctx, "SQLImpl.Exec")
+	defer span.End()
+	span.SetAttribute("db.statement", query)
+
+	res, err := c.ExecContext(ctx, query, args...)
+	span.SetError(err)
+	return res, err
 }
 
 // CreateSnack creates a snack in the sql database.
-// Returns an AlreadyExists error if it does.
-func (s *SQLImpl) CreateSnack(ctx context.Context, barcode, name string) error {
-	rows, err := s.db.QueryContext(ctx, "SELECT * FROM SnackRegistry WHERE barcode IN (?)", barcode)
-	if err != nil {
-		return err
-	}
-	defer rows.Close()
-	// Check if the value already exists by whether there are results in the Rows.
-	if rows.Next() {
-		return status.Errorf(codes.AlreadyExists, "barcode %q already has an entry", barcode)
-	}
-	if _, err := s.db.ExecContext(ctx, "INSERT INTO SnackRegistry (barcode, name) VALUES(?, ?)", barcode, name); err != nil {
-		return err
+// Returns an AlreadyExists error if it does, including in the trash.
+func (s *SQLImpl) CreateSnack(ctx context.Context, snack *sipb.Snack) error {
+	barcode := snack.GetBarcode()
+	return s.withTx(ctx, func(tx *sql.Tx) error {
+		rows, err := tracedQuery(ctx, tx, "SELECT deleted_at IS NOT NULL FROM SnackRegistry WHERE barcode IN (?) FOR UPDATE", barcode)
+		if err != nil {
+			return err
+		}
+		// Check if the value already exists by whether there are results in the Rows.
+		var deleted bool
+		exists := rows.Next()
+		if exists {
+			err = rows.Scan(&deleted)
+		}
+		rows.Close()
+		if err != nil {
+			return err
+		}
+		if exists {
+			return snackExistsError(barcode, deleted)
+		}
+		if _, err := tracedExec(ctx, tx,
+			"INSERT INTO SnackRegistry (barcode, name, brand, category, package_size) VALUES(?, ?, ?, ?, ?)",
+			barcode, snack.GetName(), snack.GetBrand(), snack.GetCategory(), snack.GetPackageSize()); err != nil {
+			return err
+		}
+		return recordSnacks(ctx, tx, sipb.Change_CREATED, changeTime(), []string{barcode})
+	})
+}
+
+// snackExistsError returns the AlreadyExists error for creating a snack with a
+// registered barcode, deleted if the snack is in the trash.
+func snackExistsError(barcode string, deleted bool) error {
+	if deleted {
+		return status.Errorf(codes.AlreadyExists, "barcode %q is in the trash; restore or purge it first", barcode)
 	}
-	return nil
+	return status.Errorf(codes.AlreadyExists, "barcode %q already has an entry", barcode)
 }
 
-// ListSnacks reads all snacks currently registered to SnackInventory.
+// ListSnacks reads all snacks currently registered to SnackInventory, ordered
+// by barcode.
 func (s *SQLImpl) ListSnacks(ctx context.Context) ([]*sipb.Snack, error) {
+	var snacks []*sipb.Snack
+	err := s.retryRead(ctx, func() (err error) {
+		snacks, err = s.listSnacks(ctx)
+		return err
+	})
+	return snacks, err
+}
+
+func (s *SQLImpl) listSnacks(ctx context.Context) ([]*sipb.Snack, error) {
 	var retVal []*sipb.Snack
-	rows, err := s.db.QueryContext(ctx, "SELECT barcode, name FROM SnackRegistry")
+	rows, err := s.queryContext(ctx, "SELECT barcode, name, brand, category, package_size FROM SnackRegistry WHERE deleted_at IS NULL ORDER BY barcode")
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
 	for rows.Next() {
-		var barcode string
-		var name string
-		if err = rows.Scan(&barcode, &name); err != nil {
+		snack := &sipb.Snack{}
+		if err = rows.Scan(&snack.Barcode, &snack.Name, &snack.Brand, &snack.Category, &snack.PackageSize); err != nil {
 			return nil, err
 		}
-		retVal = append(retVal, &sipb.Snack{Barcode: barcode, Name: name})
+		retVal = append(retVal, snack)
 	}
 	if err = rows.Err(); err != nil {
 		return nil, err
@@ -94,56 +175,245 @@ func (s *SQLImpl) ListSnacks(ctx context.Context) ([]*sipb.Snack, error) {
 	return retVal, nil
 }
 
-// UpdateSnack updates a single snack in place in SnackInventory.
-func (s *SQLImpl) UpdateSnack(ctx context.Context, barcode, name string) error {
-	if _, err := s.db.ExecContext(ctx, "UPDATE SnackRegistry SET name = ? WHERE barcode IN (?)",
-		name, barcode); err != nil {
-		return err
-	}
-	return nil
+// UpdateSnack updates a single snack in place in SnackInventory. All fields
+// are written as given.
+func (s *SQLImpl) UpdateSnack(ctx context.Context, snack *sipb.Snack) error {
+	return s.withTx(ctx, func(tx *sql.Tx) error {
+		if _, err := tracedExec(ctx, tx, updateSnackStatement, updateSnackArgs(snack)...); err != nil {
+			return err
+		}
+		return recordSnacks(ctx, tx, sipb.Change_UPDATED, changeTime(), []string{snack.GetBarcode()})
+	})
 }
 
-// DeleteSnack deletes a single snack from SnackInventory.
+const updateSnackStatement = "UPDATE SnackRegistry SET name = ?, brand = ?, category = ?, package_size = ?" +
+	" WHERE barcode IN (?) AND deleted_at IS NULL"
+
+// updateSnackArgs returns the arguments of updateSnackStatement for snack.
+func updateSnackArgs(snack *sipb.Snack) []interface{} {
+	return []interface{}{snack.GetName(), snack.GetBrand(), snack.GetCategory(), snack.GetPackageSize(), snack.GetBarcode()}
+}
+
+// DeleteSnack moves a single snack in SnackInventory to the trash. Its stock
+// is kept, but not listed, until the snack is restored or purged.
 func (s *SQLImpl) DeleteSnack(ctx context.Context, barcode string) error {
-	if _, err := s.db.ExecContext(ctx, "DELETE FROM SnackRegistry WHERE barcode IN (?)", barcode); err != nil {
-		return err
+	return s.withTx(ctx, func(tx *sql.Tx) error {
+		now := changeTime()
+		res, err := tracedExec(ctx, tx,
+			"UPDATE SnackRegistry SET deleted_at = ? WHERE barcode IN (?) AND deleted_at IS NULL", now, barcode)
+		if err != nil {
+			return err
+		}
+		// Snacks already in the trash are left as they are.
+		if n, err := res.RowsAffected(); err != nil || n == 0 {
+			return err
+		}
+		return recordSnacks(ctx, tx, sipb.Change_DELETED, now, []string{barcode})
+	})
+}
+
+// CreateLocation adds a new location to SnackInventory, inside its parent if
+// set, & returns its id. Returns an AlreadyExists error if it does, and a
+// NotFound error if the parent is not registered.
+func (s *SQLImpl) CreateLocation(ctx context.Context, location *sipb.Location) (int64, error) {
+	name, parent := location.GetName(), location.GetParent()
+	var id int64
+	err := s.withTx(ctx, func(tx *sql.Tx) error {
+		if err := checkNameFree(ctx, tx, name); err != nil {
+			return err
+		}
+		if err := checkParent(ctx, tx, name, parent); err != nil {
+			return err
+		}
+		res, err := tracedExec(ctx, tx,
+			"INSERT INTO LocationRegistry (name, parent, description, type) VALUES(?, ?, ?, ?)",
+			name, parent, location.GetDescription(), location.GetType())
+		if err != nil {
+			return err
+		}
+		if id, err = res.LastInsertId(); err != nil {
+			return err
+		}
+		return recordLocations(ctx, tx, sipb.Change_CREATED, changeTime(), "id = ?", id)
+	})
+	if err != nil {
+		return 0, err
 	}
-	return nil
+	return id, nil
 }
 
-// CreateLocation adds a new location to SnackInventory.
-// Returns an AlreadyExists error if it does.
-func (s *SQLImpl) CreateLocation(ctx context.Context, name string) error {
-	rows, err := s.db.QueryContext(ctx, "SELECT * FROM LocationRegistry WHERE name IN (?)", name)
+// checkNameFree returns an AlreadyExists error if a location is named name,
+// including in the trash.
+func checkNameFree(ctx context.Context, tx *sql.Tx, name string) error {
+	rows, err := tracedQuery(ctx, tx,
+		"SELECT deleted_at IS NOT NULL FROM LocationRegistry WHERE name IN (?) FOR UPDATE", name)
 	if err != nil {
 		return err
 	}
-	defer rows.Close()
 	// Check if the value already exists by whether there are results in the Rows.
-	if rows.Next() {
+	var deleted bool
+	exists := rows.Next()
+	if exists {
+		err = rows.Scan(&deleted)
+	}
+	rows.Close()
+	switch {
+	case err != nil:
+		return err
+	case exists && deleted:
+		return status.Errorf(codes.AlreadyExists, "name %q is in the trash; restore or purge it first", name)
+	case exists:
 		return status.Errorf(codes.AlreadyExists, "name %q already has an entry", name)
 	}
-	if _, err := s.db.ExecContext(ctx, "INSERT INTO LocationRegistry (name) VALUES(?)", name); err != nil {
+	return nil
+}
+
+// UpdateLocation sets fields, of "name", "parent", "description" & "type", of
+// the location with the id of location to its values, & returns the updated
+// location. Stock & nested locations follow a renamed location.
+// Returns a NotFound error if there is no such location, and otherwise the
+// errors of CreateLocation for a new name or parent.
+func (s *SQLImpl) UpdateLocation(ctx context.Context, location *sipb.Location, fields []string) (*sipb.Location, error) {
+	updated := &sipb.Location{}
+	err := s.withTx(ctx, func(tx *sql.Tx) error {
+		rows, err := tracedQuery(ctx, tx,
+			"SELECT id, name, parent, description, type FROM LocationRegistry WHERE id = ? AND deleted_at IS NULL FOR UPDATE",
+			location.GetId())
+		if err != nil {
+			return err
+		}
+		found := rows.Next()
+		if found {
+			err = rows.Scan(&updated.Id, &updated.Name, &updated.Parent, &updated.Description, &updated.Type)
+		}
+		rows.Close()
+		if err != nil {
+			return err
+		}
+		if !found {
+			return status.Errorf(codes.NotFound, "location %d is not registered", location.GetId())
+		}
+		// Nothing changes, so no revision is recorded.
+		if len(fields) == 0 {
+			return nil
+		}
+
+		oldName, oldParent := updated.GetName(), updated.GetParent()
+		for _, field := range fields {
+			switch field {
+			case "name":
+				updated.Name = location.GetName()
+			case "parent":
+				updated.Parent = location.GetParent()
+			case "description":
+				updated.Description = location.GetDescription()
+			case "type":
+				updated.Type = location.GetType()
+			default:
+				return status.Errorf(codes.InvalidArgument, "unknown location field %q", field)
+			}
+		}
+		// Ancestors are still stored under the old name.
+		if updated.GetParent() != oldParent {
+			if err := checkParent(ctx, tx, oldName, updated.GetParent()); err != nil {
+				return err
+			}
+		}
+		if updated.GetName() != oldName {
+			if err := checkNameFree(ctx, tx, updated.GetName()); err != nil {
+				return err
+			}
+		}
+		if _, err := tracedExec(ctx, tx,
+			"UPDATE LocationRegistry SET name = ?, parent = ?, description = ?, type = ? WHERE id = ?",
+			updated.GetName(), updated.GetParent(), updated.GetDescription(), updated.GetType(), updated.GetId()); err != nil {
+			return err
+		}
+		now := changeTime()
+		if err := recordLocations(ctx, tx, sipb.Change_UPDATED, now, "id = ?", updated.GetId()); err != nil {
+			return err
+		}
+		if updated.GetName() == oldName {
+			return nil
+		}
+		if _, err := tracedExec(ctx, tx,
+			"UPDATE LocationRegistry SET parent = ? WHERE parent = ?", updated.GetName(), oldName); err != nil {
+			return err
+		}
+		// Nested locations changed too, as they refer to their parent by name.
+		if err := recordLocations(ctx, tx, sipb.Change_UPDATED, now, "parent = ?", updated.GetName()); err != nil {
+			return err
+		}
+		_, err = tracedExec(ctx, tx, "UPDATE Stock SET location = ? WHERE location = ?", updated.GetName(), oldName)
 		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+// checkParent returns an error unless name may be nested inside parent:
+// NotFound if parent is not registered, or FailedPrecondition if parent is
+// name or inside it, which would make a cycle. Ancestors are locked until tx
+// ends, so the check holds.
+func checkParent(ctx context.Context, tx *sql.Tx, name, parent string) error {
+	if parent == "" {
+		return nil
+	}
+	seen := map[string]bool{}
+	for ancestor := parent; ancestor != "" && !seen[ancestor]; {
+		if ancestor == name {
+			return status.Errorf(codes.FailedPrecondition, "location %q can't be nested inside itself", name)
+		}
+		seen[ancestor] = true
+		rows, err := tracedQuery(ctx, tx, "SELECT parent FROM LocationRegistry WHERE name = ? AND deleted_at IS NULL FOR UPDATE", ancestor)
+		if err != nil {
+			return err
+		}
+		found := rows.Next()
+		if found {
+			err = rows.Scan(&ancestor)
+		}
+		rows.Close()
+		if err != nil {
+			return err
+		}
+		if !found {
+			if ancestor == parent {
+				return status.Errorf(codes.NotFound, "parent location %q is not registered", parent)
+			}
+			break
+		}
 	}
 	return nil
 }
 
-// ListLocations reads all locations currently associated with SnackInventory.
+// ListLocations reads all locations currently associated with SnackInventory,
+// ordered by id.
 func (s *SQLImpl) ListLocations(ctx context.Context) ([]*sipb.Location, error) {
+	var locations []*sipb.Location
+	err := s.retryRead(ctx, func() (err error) {
+		locations, err = s.listLocations(ctx)
+		return err
+	})
+	return locations, err
+}
+
+func (s *SQLImpl) listLocations(ctx context.Context) ([]*sipb.Location, error) {
 	var retVal []*sipb.Location
-	rows, err := s.db.QueryContext(ctx, "SELECT name FROM LocationRegistry")
+	rows, err := s.queryContext(ctx, "SELECT id, name, parent, description, type FROM LocationRegistry WHERE deleted_at IS NULL ORDER BY id")
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
 	for rows.Next() {
-		var name string
-		if err = rows.Scan(&name); err != nil {
+		location := &sipb.Location{}
+		if err = rows.Scan(&location.Id, &location.Name, &location.Parent, &location.Description, &location.Type); err != nil {
 			return nil, err
 		}
-		retVal = append(retVal, &sipb.Location{Name: name})
+		retVal = append(retVal, location)
 	}
 	if err = rows.Err(); err != nil {
 		return nil, err
@@ -151,10 +421,48 @@ func (s *SQLImpl) ListLocations(ctx context.Context) ([]*sipb.Location, error) {
 	return retVal, nil
 }
 
-// DeleteLocation removes a location with the given name from SnackInventory.
-func (s *SQLImpl) DeleteLocation(ctx context.Context, name string) error {
-	if _, err := s.db.ExecContext(ctx, "DELETE FROM LocationRegistry WHERE name IN (?)", name); err != nil {
-		return err
+// DeleteLocation moves a location with the given name in SnackInventory to the
+// trash, handling stock held there per contents. Returns the resulting changes in
+// stock, both at the location & at target.
+// Returns a FailedPrecondition error if other locations are nested inside it,
+// or if it holds stock & contents is REFUSE, and a NotFound error if target
+// is not registered when moving stock.
+func (s *SQLImpl) DeleteLocation(ctx context.Context, name string, contents sipb.ContentsPolicy, target string) ([]*sipb.StockChange, error) {
+	var changes []*sipb.StockChange
+	err := s.withTx(ctx, func(tx *sql.Tx) error {
+		rows, err := tracedQuery(ctx, tx, "SELECT name FROM LocationRegistry WHERE parent = ? AND deleted_at IS NULL LIMIT 1 FOR UPDATE", name)
+		if err != nil {
+			return err
+		}
+		var child string
+		nested := rows.Next()
+		if nested {
+			err = rows.Scan(&child)
+		}
+		rows.Close()
+		if err != nil {
+			return err
+		}
+		if nested {
+			return status.Errorf(codes.FailedPrecondition,
+				"location %q contains %q; move or delete it first", name, child)
+		}
+		if changes, err = clearStock(ctx, tx, name, contents, target); err != nil {
+			return err
+		}
+		now := changeTime()
+		res, err := tracedExec(ctx, tx,
+			"UPDATE LocationRegistry SET deleted_at = ? WHERE name IN (?) AND deleted_at IS NULL", now, name)
+		if err != nil {
+			return err
+		}
+		if n, err := res.RowsAffected(); err != nil || n == 0 {
+			return err
+		}
+		return recordLocations(ctx, tx, sipb.Change_DELETED, now, "name = ?", name)
+	})
+	if err != nil {
+		return nil, err
 	}
-	return nil
+	return changes, nil
 }
@@ -23,6 +23,8 @@ import (
 	"github.com/google/go-cmp/cmp/cmpopts"
 	"github.com/rmbarron/SnackInventory/src/backend/server/testutils"
 	sipb "github.com/rmbarron/SnackInventory/src/proto/snackinventory"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 // Implementation note: Spinning up a full mariadb / mysqld instance is slow.
@@ -47,16 +49,12 @@ func TestSuccess(t *testing.T) {
 		defer testutils.DropTablesT(ctx, t, db)
 
 		si := &SQLImpl{db: db}
-		if err := si.CreateSnack(ctx, "1", "testsnack"); err != nil {
-			t.Fatalf("si.CreateSnack(ctx, %q, %q) = got err %v, want err nil", "1", "testsnack", err)
+		snack := &sipb.Snack{Barcode: "1", Name: "testsnack", Brand: "testbrand", Category: "chips", PackageSize: "150 g"}
+		if err := si.CreateSnack(ctx, snack); err != nil {
+			t.Fatalf("si.CreateSnack(ctx, %v) = got err %v, want err nil", snack, err)
 		}
 
-		want := []*sipb.Snack{
-			{
-				Barcode: "1",
-				Name:    "testsnack",
-			},
-		}
+		want := []*sipb.Snack{snack}
 		got, err := si.ListSnacks(ctx)
 		if err != nil {
 			t.Fatalf("si.ListSnacks(ctx) = got err %v, want err nil", err)
@@ -97,16 +95,12 @@ func TestSuccess(t *testing.T) {
 		testutils.AddSnackT(ctx, t, db, &sipb.Snack{Barcode: "123", Name: "testsnack"})
 
 		si := &SQLImpl{db: db}
-		if err := si.UpdateSnack(ctx, "123", "realsnack"); err != nil {
-			t.Fatalf("si.UpdateSnack(ctx, %q, %q) = got err %v, want err nil", "123", "realsnack", err)
+		snack := &sipb.Snack{Barcode: "123", Name: "realsnack", Brand: "realbrand"}
+		if err := si.UpdateSnack(ctx, snack); err != nil {
+			t.Fatalf("si.UpdateSnack(ctx, %v) = got err %v, want err nil", snack, err)
 		}
 
-		want := []*sipb.Snack{
-			{
-				Barcode: "123",
-				Name:    "realsnack",
-			},
-		}
+		want := []*sipb.Snack{snack}
 		got, err := si.ListSnacks(ctx)
 		if err != nil {
 			t.Fatalf("si.ListSnacks(ctx) = got err %v, want err nil", err)
@@ -141,13 +135,18 @@ func TestSuccess(t *testing.T) {
 		defer testutils.DropTablesT(ctx, t, db)
 
 		si := &SQLImpl{db: db}
-		if err := si.CreateLocation(ctx, "fridge"); err != nil {
-			t.Fatalf("si.CreateLocation(ctx, %q) = got err %v, want err nil", "fridge", err)
+		location := &sipb.Location{Name: "fridge", Description: "kitchen", Type: sipb.LocationType_FRIDGE}
+		id, err := si.CreateLocation(ctx, location)
+		if err != nil {
+			t.Fatalf("si.CreateLocation(ctx, %v) = got err %v, want err nil", location, err)
 		}
 
 		want := []*sipb.Location{
 			{
-				Name: "fridge",
+				Id:          id,
+				Name:        "fridge",
+				Description: "kitchen",
+				Type:        sipb.LocationType_FRIDGE,
 			},
 		}
 		got, err := si.ListLocations(ctx)
@@ -159,6 +158,37 @@ func TestSuccess(t *testing.T) {
 		}
 	})
 
+	t.Run("CreateLocation_Nested", func(t *testing.T) {
+		testutils.CreateTablesT(ctx, t, db)
+		defer testutils.DropTablesT(ctx, t, db)
+
+		testutils.AddLocationT(ctx, t, db, &sipb.Location{Name: "garage"})
+
+		si := &SQLImpl{db: db}
+		for _, location := range []*sipb.Location{
+			{Name: "freezer", Parent: "garage"},
+			{Name: "top", Parent: "freezer"},
+		} {
+			if _, err := si.CreateLocation(ctx, location); err != nil {
+				t.Fatalf("si.CreateLocation(ctx, %v) = got err %v, want err nil", location, err)
+			}
+		}
+
+		want := []*sipb.Location{
+			{Id: 2, Name: "freezer", Parent: "garage"},
+			{Id: 1, Name: "garage"},
+			{Id: 3, Name: "top", Parent: "freezer"},
+		}
+		got, err := si.ListLocations(ctx)
+		if err != nil {
+			t.Fatalf("si.ListLocations(ctx) = got err %v, want err nil", err)
+		}
+		sortLocations := cmpopts.SortSlices(func(a, b *sipb.Location) bool { return a.GetName() < b.GetName() })
+		if diff := cmp.Diff(got, want, cmpopts.IgnoreUnexported(sipb.Location{}), sortLocations); diff != "" {
+			t.Fatalf("si.ListLocations(ctx) = got diff (-got +want): %s", diff)
+		}
+	})
+
 	t.Run("ListLocations", func(t *testing.T) {
 		testutils.CreateTablesT(ctx, t, db)
 		defer testutils.DropTablesT(ctx, t, db)
@@ -173,6 +203,7 @@ func TestSuccess(t *testing.T) {
 
 		want := []*sipb.Location{
 			{
+				Id:   1,
 				Name: "fridge",
 			},
 		}
@@ -189,8 +220,8 @@ func TestSuccess(t *testing.T) {
 		testutils.AddLocationT(ctx, t, db, &sipb.Location{Name: "fridge"})
 
 		si := &SQLImpl{db: db}
-		if err := si.DeleteLocation(ctx, "fridge"); err != nil {
-			t.Fatalf("si.DeleteLocation(ctx, %q) = got err %v, want err nil", "fridge", err)
+		if _, err := si.DeleteLocation(ctx, "fridge", sipb.ContentsPolicy_REFUSE, ""); err != nil {
+			t.Fatalf("si.DeleteLocation(ctx, %q, REFUSE, \"\") = got err %v, want err nil", "fridge", err)
 		}
 
 		got, err := si.ListLocations(ctx)
@@ -201,6 +232,104 @@ func TestSuccess(t *testing.T) {
 			t.Fatalf("si.ListLocations(ctx) = got %v, want []*sipb.Location{}", got)
 		}
 	})
+
+	t.Run("UpdateLocation", func(t *testing.T) {
+		testutils.CreateTablesT(ctx, t, db)
+		defer testutils.DropTablesT(ctx, t, db)
+
+		testutils.AddLocationT(ctx, t, db, &sipb.Location{Name: "garage"})
+		testutils.AddLocationT(ctx, t, db, &sipb.Location{Name: "cupbard"})
+
+		si := &SQLImpl{db: db}
+		tests := []struct {
+			location *sipb.Location
+			fields   []string
+			want     *sipb.Location
+		}{
+			{
+				location: &sipb.Location{Id: 2, Description: "by the sink", Type: sipb.LocationType_CUPBOARD},
+				fields:   []string{"description", "type"},
+				want:     &sipb.Location{Id: 2, Name: "cupbard", Description: "by the sink", Type: sipb.LocationType_CUPBOARD},
+			},
+			{
+				location: &sipb.Location{Id: 2, Name: "cupboard", Parent: "garage"},
+				fields:   []string{"name", "parent"},
+				want:     &sipb.Location{Id: 2, Name: "cupboard", Parent: "garage", Description: "by the sink", Type: sipb.LocationType_CUPBOARD},
+			},
+			{
+				location: &sipb.Location{Id: 2},
+				fields:   []string{"parent"},
+				want:     &sipb.Location{Id: 2, Name: "cupboard", Description: "by the sink", Type: sipb.LocationType_CUPBOARD},
+			},
+		}
+		for _, tc := range tests {
+			got, err := si.UpdateLocation(ctx, tc.location, tc.fields)
+			if err != nil {
+				t.Fatalf("si.UpdateLocation(ctx, %v, %q) = got err %v, want err nil", tc.location, tc.fields, err)
+			}
+			if diff := cmp.Diff(got, tc.want, cmpopts.IgnoreUnexported(sipb.Location{})); diff != "" {
+				t.Fatalf("si.UpdateLocation(ctx, %v, %q) = got diff (-got +want): %s", tc.location, tc.fields, diff)
+			}
+		}
+	})
+
+	t.Run("UpdateLocation_RenameCascades", func(t *testing.T) {
+		testutils.CreateTablesT(ctx, t, db)
+		defer testutils.DropTablesT(ctx, t, db)
+
+		testutils.AddSnackT(ctx, t, db, &sipb.Snack{Barcode: "123", Name: "chips"})
+		testutils.AddLocationT(ctx, t, db, &sipb.Location{Name: "cupbard"})
+		testutils.AddLocationT(ctx, t, db, &sipb.Location{Name: "shelf", Parent: "cupbard"})
+
+		si := &SQLImpl{db: db}
+		if _, err := si.AdjustStock(ctx, "123", "cupbard", 2); err != nil {
+			t.Fatalf("si.AdjustStock(ctx, %q, %q, %d) = got err %v, want err nil", "123", "cupbard", 2, err)
+		}
+		location := &sipb.Location{Id: 1, Name: "cupboard"}
+		if _, err := si.UpdateLocation(ctx, location, []string{"name"}); err != nil {
+			t.Fatalf("si.UpdateLocation(ctx, %v, %q) = got err %v, want err nil", location, []string{"name"}, err)
+		}
+
+		gotLocations, err := si.ListLocations(ctx)
+		if err != nil {
+			t.Fatalf("si.ListLocations(ctx) = got err %v, want err nil", err)
+		}
+		wantLocations := []*sipb.Location{
+			{Id: 1, Name: "cupboard"},
+			{Id: 2, Name: "shelf", Parent: "cupboard"},
+		}
+		sortLocations := cmpopts.SortSlices(func(a, b *sipb.Location) bool { return a.GetId() < b.GetId() })
+		if diff := cmp.Diff(gotLocations, wantLocations, cmpopts.IgnoreUnexported(sipb.Location{}), sortLocations); diff != "" {
+			t.Fatalf("si.ListLocations(ctx) = got diff (-got +want): %s", diff)
+		}
+		gotStock, err := si.ListStock(ctx, "")
+		if err != nil {
+			t.Fatalf("si.ListStock(ctx, %q) = got err %v, want err nil", "", err)
+		}
+		wantStock := []*sipb.Stock{{Barcode: "123", Location: "cupboard", Count: 2}}
+		if diff := cmp.Diff(gotStock, wantStock, cmpopts.IgnoreUnexported(sipb.Stock{})); diff != "" {
+			t.Fatalf("si.ListStock(ctx, %q) = got diff (-got +want): %s", "", diff)
+		}
+	})
+
+	t.Run("DeleteLocation_Nested", func(t *testing.T) {
+		testutils.CreateTablesT(ctx, t, db)
+		defer testutils.DropTablesT(ctx, t, db)
+
+		testutils.AddLocationT(ctx, t, db, &sipb.Location{Name: "garage"})
+		testutils.AddLocationT(ctx, t, db, &sipb.Location{Name: "freezer", Parent: "garage"})
+
+		si := &SQLImpl{db: db}
+		if _, err := si.DeleteLocation(ctx, "garage", sipb.ContentsPolicy_DISCARD, ""); status.Code(err) != codes.FailedPrecondition {
+			t.Fatalf("si.DeleteLocation(ctx, %q, DISCARD, \"\") = got err %v, want code %v", "garage", err, codes.FailedPrecondition)
+		}
+		// Locations can be deleted from the inside out.
+		for _, name := range []string{"freezer", "garage"} {
+			if _, err := si.DeleteLocation(ctx, name, sipb.ContentsPolicy_REFUSE, ""); err != nil {
+				t.Fatalf("si.DeleteLocation(ctx, %q, REFUSE, \"\") = got err %v, want err nil", name, err)
+			}
+		}
+	})
 }
 
 // TestError is a parent test to create a mariadb instance for subtests.
@@ -216,8 +345,9 @@ func TestError(t *testing.T) {
 	// Try to read from a database with no tables, causing SELECT to fail.
 	t.Run("CreateSnack_SelectError", func(t *testing.T) {
 		si := &SQLImpl{db: db}
-		if err := si.CreateSnack(ctx, "1", "testsnack"); err == nil {
-			t.Fatalf("si.CreateSnack(ctx, %q, %q) = got err nil, want err", "1", "testsnack")
+		snack := &sipb.Snack{Barcode: "1", Name: "testsnack"}
+		if err := si.CreateSnack(ctx, snack); err == nil {
+			t.Fatalf("si.CreateSnack(ctx, %v) = got err nil, want err", snack)
 		}
 	})
 
@@ -228,8 +358,9 @@ func TestError(t *testing.T) {
 		testutils.AddSnackT(ctx, t, db, &sipb.Snack{Barcode: "1", Name: "testsnack"})
 
 		si := &SQLImpl{db: db}
-		if err := si.CreateSnack(ctx, "1", "testsnack"); err == nil {
-			t.Fatalf("si.CreateSnack(ctx, %q, %q) = got err nil, want err", "1", "testsnack")
+		snack := &sipb.Snack{Barcode: "1", Name: "testsnack"}
+		if err := si.CreateSnack(ctx, snack); err == nil {
+			t.Fatalf("si.CreateSnack(ctx, %v) = got err nil, want err", snack)
 		}
 	})
 
@@ -242,15 +373,17 @@ func TestError(t *testing.T) {
 
 	t.Run("UpdateSnack_Error", func(t *testing.T) {
 		si := &SQLImpl{db: db}
-		if err := si.UpdateSnack(ctx, "123", "realsnack"); err == nil {
-			t.Fatalf("si.UpdateSnack(ctx, %q, %q) = got err nil, want err", "123", "realsnack")
+		snack := &sipb.Snack{Barcode: "123", Name: "realsnack"}
+		if err := si.UpdateSnack(ctx, snack); err == nil {
+			t.Fatalf("si.UpdateSnack(ctx, %v) = got err nil, want err", snack)
 		}
 	})
 
 	t.Run("CreateLocation_SelectError", func(t *testing.T) {
 		si := &SQLImpl{db: db}
-		if err := si.CreateLocation(ctx, "fridge"); err == nil {
-			t.Fatalf("si.CreateLocation(ctx, %q) = got err nil, want err", "fridge")
+		location := &sipb.Location{Name: "fridge"}
+		if _, err := si.CreateLocation(ctx, location); err == nil {
+			t.Fatalf("si.CreateLocation(ctx, %v) = got err nil, want err", location)
 		}
 	})
 
@@ -261,8 +394,58 @@ func TestError(t *testing.T) {
 		testutils.AddLocationT(ctx, t, db, &sipb.Location{Name: "fridge"})
 
 		si := &SQLImpl{db: db}
-		if err := si.CreateLocation(ctx, "fridge"); err == nil {
-			t.Fatalf("si.CreateLocation(ctx, %q) = got err nil, want err", "fridge")
+		location := &sipb.Location{Name: "fridge"}
+		if _, err := si.CreateLocation(ctx, location); err == nil {
+			t.Fatalf("si.CreateLocation(ctx, %v) = got err nil, want err", location)
+		}
+	})
+
+	t.Run("CreateLocation_BadParent", func(t *testing.T) {
+		testutils.CreateTablesT(ctx, t, db)
+		defer testutils.DropTablesT(ctx, t, db)
+
+		si := &SQLImpl{db: db}
+		tests := []struct {
+			location *sipb.Location
+			want     codes.Code
+		}{
+			{&sipb.Location{Name: "top", Parent: "freezer"}, codes.NotFound},
+			{&sipb.Location{Name: "loop", Parent: "loop"}, codes.FailedPrecondition},
+		}
+		for _, tc := range tests {
+			if _, err := si.CreateLocation(ctx, tc.location); status.Code(err) != tc.want {
+				t.Errorf("si.CreateLocation(ctx, %v) = got err %v, want code %v", tc.location, err, tc.want)
+			}
+		}
+	})
+
+	t.Run("UpdateLocation_Errors", func(t *testing.T) {
+		testutils.CreateTablesT(ctx, t, db)
+		defer testutils.DropTablesT(ctx, t, db)
+
+		testutils.AddLocationT(ctx, t, db, &sipb.Location{Name: "garage"})
+		testutils.AddLocationT(ctx, t, db, &sipb.Location{Name: "freezer", Parent: "garage"})
+		testutils.AddLocationT(ctx, t, db, &sipb.Location{Name: "pantry"})
+
+		si := &SQLImpl{db: db}
+		tests := []struct {
+			desc     string
+			location *sipb.Location
+			fields   []string
+			want     codes.Code
+		}{
+			{"NoSuchID", &sipb.Location{Id: 9, Name: "cellar"}, []string{"name"}, codes.NotFound},
+			{"NameTaken", &sipb.Location{Id: 3, Name: "garage"}, []string{"name"}, codes.AlreadyExists},
+			{"NoSuchParent", &sipb.Location{Id: 3, Parent: "cellar"}, []string{"parent"}, codes.NotFound},
+			{"Cycle", &sipb.Location{Id: 1, Parent: "freezer"}, []string{"parent"}, codes.FailedPrecondition},
+			{"UnknownField", &sipb.Location{Id: 1}, []string{"size"}, codes.InvalidArgument},
+		}
+		for _, tc := range tests {
+			t.Run(tc.desc, func(t *testing.T) {
+				if _, err := si.UpdateLocation(ctx, tc.location, tc.fields); status.Code(err) != tc.want {
+					t.Fatalf("si.UpdateLocation(ctx, %v, %q) = got err %v, want code %v", tc.location, tc.fields, err, tc.want)
+				}
+			})
 		}
 	})
 
@@ -0,0 +1,728 @@
+/*
+Copyright 2020 Robert Barron
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package connectortest provides a conformance suite for implementations of
+// connector.Store, so every backend shares the semantics of connector.SQLImpl.
+package connectortest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/rmbarron/SnackInventory/src/backend/server/connector"
+	"github.com/rmbarron/SnackInventory/src/limits"
+	sipb "github.com/rmbarron/SnackInventory/src/proto/snackinventory"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// writers is the number of concurrent writers in ConcurrentWriters.
+const writers = 10
+
+var cmpOpts = []cmp.Option{
+	cmpopts.IgnoreUnexported(sipb.Snack{}, sipb.Location{}, sipb.Stock{}, sipb.StockChange{},
+		sipb.DeletedEntity{}, sipb.SnackRevision{}, timestamppb.Timestamp{}),
+	// Stores may list nothing as nil or empty.
+	cmpopts.EquateEmpty(),
+}
+
+// RunConformance runs the conformance suite against Stores returned by
+// newStore, which is called once per subtest, with its t, & must return an
+// empty Store. Subtests run one at a time, so Stores may share a backing
+// database.
+func RunConformance(t *testing.T, newStore func(t *testing.T) connector.Store) {
+	tests := []struct {
+		desc string
+		fn   func(t *testing.T, s connector.Store)
+	}{
+		{"Snacks", testSnacks},
+		{"SnackErrors", testSnackErrors},
+		{"Batch", testBatch},
+		{"Locations", testLocations},
+		{"UpdateLocation", testUpdateLocation},
+		{"DeleteLocation", testDeleteLocation},
+		{"Stock", testStock},
+		{"Unicode", testUnicode},
+		{"MaxLength", testMaxLength},
+		{"Trash", testTrash},
+		{"History", testHistory},
+		{"ConcurrentWriters", testConcurrentWriters},
+	}
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			tc.fn(t, newStore(t))
+		})
+	}
+}
+
+func testSnacks(t *testing.T, s connector.Store) {
+	ctx := context.Background()
+	createSnacksT(t, s,
+		&sipb.Snack{Barcode: "3", Name: "cookies"},
+		&sipb.Snack{Barcode: "1", Name: "chips", Brand: "Acme", Category: "Crisps", PackageSize: "200 g"},
+		&sipb.Snack{Barcode: "2", Name: "pretzels"})
+	// Snacks are listed by barcode.
+	want := []*sipb.Snack{
+		{Barcode: "1", Name: "chips", Brand: "Acme", Category: "Crisps", PackageSize: "200 g"},
+		{Barcode: "2", Name: "pretzels"},
+		{Barcode: "3", Name: "cookies"},
+	}
+	diffT(t, "s.ListSnacks(ctx)", want, listSnacksT(t, s))
+
+	// Every field is written as given, including cleared ones.
+	updated := &sipb.Snack{Barcode: "1", Name: "salty chips"}
+	if err := s.UpdateSnack(ctx, updated); err != nil {
+		t.Fatalf("s.UpdateSnack(ctx, %v) = got err %v, want err nil", updated, err)
+	}
+	if err := s.DeleteSnack(ctx, "2"); err != nil {
+		t.Fatalf("s.DeleteSnack(ctx, %q) = got err %v, want err nil", "2", err)
+	}
+	want = []*sipb.Snack{{Barcode: "1", Name: "salty chips"}, {Barcode: "3", Name: "cookies"}}
+	diffT(t, "s.ListSnacks(ctx) after update & delete", want, listSnacksT(t, s))
+}
+
+func testSnackErrors(t *testing.T, s connector.Store) {
+	ctx := context.Background()
+	createSnacksT(t, s, &sipb.Snack{Barcode: "123", Name: "chips"})
+
+	err := s.CreateSnack(ctx, &sipb.Snack{Barcode: "123", Name: "other chips"})
+	codeT(t, fmt.Sprintf("s.CreateSnack(ctx, %q) again", "123"), err, codes.AlreadyExists)
+
+	// Snacks that aren't registered are neither updated, nor created.
+	if err := s.UpdateSnack(ctx, &sipb.Snack{Barcode: "456", Name: "cookies"}); err != nil {
+		t.Fatalf("s.UpdateSnack(ctx, %q) = got err %v, want err nil", "456", err)
+	}
+	if err := s.DeleteSnack(ctx, "456"); err != nil {
+		t.Fatalf("s.DeleteSnack(ctx, %q) = got err %v, want err nil", "456", err)
+	}
+	want := []*sipb.Snack{{Barcode: "123", Name: "chips"}}
+	diffT(t, "s.ListSnacks(ctx)", want, listSnacksT(t, s))
+
+	// Barcodes in the trash can't be reused.
+	if err := s.DeleteSnack(ctx, "123"); err != nil {
+		t.Fatalf("s.DeleteSnack(ctx, %q) = got err %v, want err nil", "123", err)
+	}
+	err = s.CreateSnack(ctx, &sipb.Snack{Barcode: "123"})
+	codeT(t, fmt.Sprintf("s.CreateSnack(ctx, %q) of trashed snack", "123"), err, codes.AlreadyExists)
+}
+
+func testBatch(t *testing.T, s connector.Store) {
+	ctx := context.Background()
+	if err := s.BatchCreateSnacks(ctx, nil); err != nil {
+		t.Fatalf("s.BatchCreateSnacks(ctx, nil) = got err %v, want err nil", err)
+	}
+	batch := []*sipb.Snack{{Barcode: "2", Name: "pretzels"}, {Barcode: "1", Name: "chips"}}
+	if err := s.BatchCreateSnacks(ctx, batch); err != nil {
+		t.Fatalf("s.BatchCreateSnacks(ctx, %v) = got err %v, want err nil", batch, err)
+	}
+
+	// Failed batches change nothing.
+	batch = []*sipb.Snack{{Barcode: "3"}, {Barcode: "1"}}
+	batchErrorT(t, fmt.Sprintf("s.BatchCreateSnacks(ctx, %v)", batch), s.BatchCreateSnacks(ctx, batch), 1, codes.AlreadyExists)
+	batch = []*sipb.Snack{{Barcode: "4"}, {Barcode: "4"}}
+	batchErrorT(t, fmt.Sprintf("s.BatchCreateSnacks(ctx, %v)", batch), s.BatchCreateSnacks(ctx, batch), 1, codes.AlreadyExists)
+	batch = []*sipb.Snack{{Barcode: "1", Name: "salty chips"}, {Barcode: "5"}}
+	batchErrorT(t, fmt.Sprintf("s.BatchUpdateSnacks(ctx, %v)", batch), s.BatchUpdateSnacks(ctx, batch), 1, codes.NotFound)
+	want := []*sipb.Snack{{Barcode: "1", Name: "chips"}, {Barcode: "2", Name: "pretzels"}}
+	diffT(t, "s.ListSnacks(ctx) after failed batches", want, listSnacksT(t, s))
+
+	batch = []*sipb.Snack{{Barcode: "1", Name: "salty chips"}, {Barcode: "2", Name: "soft pretzels"}}
+	if err := s.BatchUpdateSnacks(ctx, batch); err != nil {
+		t.Fatalf("s.BatchUpdateSnacks(ctx, %v) = got err %v, want err nil", batch, err)
+	}
+	// Barcodes that aren't registered, or are repeated, are ignored.
+	barcodes := []string{"1", "5", "1"}
+	if err := s.BatchDeleteSnacks(ctx, barcodes); err != nil {
+		t.Fatalf("s.BatchDeleteSnacks(ctx, %q) = got err %v, want err nil", barcodes, err)
+	}
+	want = []*sipb.Snack{{Barcode: "2", Name: "soft pretzels"}}
+	diffT(t, "s.ListSnacks(ctx) after batches", want, listSnacksT(t, s))
+}
+
+func testLocations(t *testing.T, s connector.Store) {
+	ctx := context.Background()
+	pantry := createLocationT(t, s, &sipb.Location{Name: "pantry", Type: sipb.LocationType_PANTRY})
+	garage := createLocationT(t, s, &sipb.Location{Name: "garage", Description: "out back"})
+	freezer := createLocationT(t, s, &sipb.Location{Name: "freezer", Parent: "garage"})
+	if pantry <= 0 || garage <= pantry || freezer <= garage {
+		t.Fatalf("s.CreateLocation(ctx, ...) = got ids %d, %d, %d, want positive & increasing", pantry, garage, freezer)
+	}
+	// Locations are listed by id.
+	want := []*sipb.Location{
+		{Id: pantry, Name: "pantry", Type: sipb.LocationType_PANTRY},
+		{Id: garage, Name: "garage", Description: "out back"},
+		{Id: freezer, Name: "freezer", Parent: "garage"},
+	}
+	diffT(t, "s.ListLocations(ctx)", want, listLocationsT(t, s))
+
+	_, err := s.CreateLocation(ctx, &sipb.Location{Name: "garage"})
+	codeT(t, fmt.Sprintf("s.CreateLocation(ctx, %q) again", "garage"), err, codes.AlreadyExists)
+	_, err = s.CreateLocation(ctx, &sipb.Location{Name: "shelf", Parent: "attic"})
+	codeT(t, fmt.Sprintf("s.CreateLocation(ctx, %q) in unregistered parent", "shelf"), err, codes.NotFound)
+	diffT(t, "s.ListLocations(ctx) after errors", want, listLocationsT(t, s))
+}
+
+func testUpdateLocation(t *testing.T, s connector.Store) {
+	ctx := context.Background()
+	createSnacksT(t, s, &sipb.Snack{Barcode: "123"})
+	garage := createLocationT(t, s, &sipb.Location{Name: "garage", Type: sipb.LocationType_ROOM})
+	freezer := createLocationT(t, s, &sipb.Location{Name: "freezer", Parent: "garage"})
+	adjustStockT(t, s, "123", "garage", 2)
+
+	update := &sipb.Location{Id: garage, Name: "shed", Description: "out back", Type: sipb.LocationType_PANTRY}
+	fields := []string{"name", "description"}
+	got, err := s.UpdateLocation(ctx, update, fields)
+	if err != nil {
+		t.Fatalf("s.UpdateLocation(ctx, %v, %q) = got err %v, want err nil", update, fields, err)
+	}
+	// Only the given fields are set, & references follow the rename.
+	want := &sipb.Location{Id: garage, Name: "shed", Description: "out back", Type: sipb.LocationType_ROOM}
+	diffT(t, fmt.Sprintf("s.UpdateLocation(ctx, %v, %q)", update, fields), want, got)
+	wantLocations := []*sipb.Location{want, {Id: freezer, Name: "freezer", Parent: "shed"}}
+	diffT(t, "s.ListLocations(ctx) after rename", wantLocations, listLocationsT(t, s))
+	wantStock := []*sipb.Stock{{Barcode: "123", Location: "shed", Count: 2}}
+	diffT(t, "s.ListStock(ctx, \"\") after rename", wantStock, listStockT(t, s, ""))
+
+	// No fields is a no-op, returning the location as is.
+	got, err = s.UpdateLocation(ctx, &sipb.Location{Id: garage, Name: "barn"}, nil)
+	if err != nil {
+		t.Fatalf("s.UpdateLocation(ctx, %d, no fields) = got err %v, want err nil", garage, err)
+	}
+	diffT(t, fmt.Sprintf("s.UpdateLocation(ctx, %d, no fields)", garage), want, got)
+
+	tests := []struct {
+		desc     string
+		location *sipb.Location
+		fields   []string
+		want     codes.Code
+	}{
+		{"NotFound", &sipb.Location{Id: freezer + 100, Name: "attic"}, []string{"name"}, codes.NotFound},
+		{"UnknownField", &sipb.Location{Id: garage}, []string{"color"}, codes.InvalidArgument},
+		{"Cycle", &sipb.Location{Id: garage, Parent: "freezer"}, []string{"parent"}, codes.FailedPrecondition},
+		{"Self", &sipb.Location{Id: garage, Parent: "shed"}, []string{"parent"}, codes.FailedPrecondition},
+		{"NameTaken", &sipb.Location{Id: freezer, Name: "shed"}, []string{"name"}, codes.AlreadyExists},
+		{"UnknownParent", &sipb.Location{Id: freezer, Parent: "attic"}, []string{"parent"}, codes.NotFound},
+	}
+	for _, tc := range tests {
+		_, err := s.UpdateLocation(ctx, tc.location, tc.fields)
+		codeT(t, fmt.Sprintf("%s: s.UpdateLocation(ctx, %v, %q)", tc.desc, tc.location, tc.fields), err, tc.want)
+	}
+	diffT(t, "s.ListLocations(ctx) after errors", wantLocations, listLocationsT(t, s))
+}
+
+func testDeleteLocation(t *testing.T, s connector.Store) {
+	ctx := context.Background()
+	createSnacksT(t, s, &sipb.Snack{Barcode: "1"}, &sipb.Snack{Barcode: "2"})
+	garage := createLocationT(t, s, &sipb.Location{Name: "garage"})
+	createLocationT(t, s, &sipb.Location{Name: "freezer", Parent: "garage"})
+	pantry := createLocationT(t, s, &sipb.Location{Name: "pantry"})
+	adjustStockT(t, s, "1", "freezer", 2)
+	adjustStockT(t, s, "2", "freezer", 1)
+	adjustStockT(t, s, "1", "pantry", 3)
+
+	tests := []struct {
+		desc     string
+		name     string
+		contents sipb.ContentsPolicy
+		target   string
+		want     codes.Code
+	}{
+		{"Nested", "garage", sipb.ContentsPolicy_DISCARD, "", codes.FailedPrecondition},
+		{"Refuse", "freezer", sipb.ContentsPolicy_REFUSE, "", codes.FailedPrecondition},
+		{"MoveToSelf", "freezer", sipb.ContentsPolicy_MOVE, "freezer", codes.InvalidArgument},
+		{"MoveToUnknown", "freezer", sipb.ContentsPolicy_MOVE, "attic", codes.NotFound},
+	}
+	for _, tc := range tests {
+		_, err := s.DeleteLocation(ctx, tc.name, tc.contents, tc.target)
+		codeT(t, fmt.Sprintf("%s: s.DeleteLocation(ctx, %q, %v, %q)", tc.desc, tc.name, tc.contents, tc.target), err, tc.want)
+	}
+
+	// Stock moved into a location is added to what's there.
+	got, err := s.DeleteLocation(ctx, "freezer", sipb.ContentsPolicy_MOVE, "pantry")
+	if err != nil {
+		t.Fatalf("s.DeleteLocation(ctx, %q, MOVE, %q) = got err %v, want err nil", "freezer", "pantry", err)
+	}
+	want := []*sipb.StockChange{
+		{Barcode: "1", Location: "freezer", Delta: -2, Reason: sipb.StockChange_MOVED},
+		{Barcode: "1", Location: "pantry", Delta: 2, Count: 5, Reason: sipb.StockChange_MOVED},
+		{Barcode: "2", Location: "freezer", Delta: -1, Reason: sipb.StockChange_MOVED},
+		{Barcode: "2", Location: "pantry", Delta: 1, Count: 1, Reason: sipb.StockChange_MOVED},
+	}
+	diffT(t, fmt.Sprintf("s.DeleteLocation(ctx, %q, MOVE, %q)", "freezer", "pantry"), want, got)
+	wantStock := []*sipb.Stock{{Barcode: "1", Location: "pantry", Count: 5}, {Barcode: "2", Location: "pantry", Count: 1}}
+	diffT(t, "s.ListStock(ctx, \"\") after move", wantStock, listStockT(t, s, ""))
+	wantLocations := []*sipb.Location{{Id: garage, Name: "garage"}, {Id: pantry, Name: "pantry"}}
+	diffT(t, "s.ListLocations(ctx) after delete", wantLocations, listLocationsT(t, s))
+
+	got, err = s.DeleteLocation(ctx, "pantry", sipb.ContentsPolicy_DISCARD, "")
+	if err != nil {
+		t.Fatalf("s.DeleteLocation(ctx, %q, DISCARD, \"\") = got err %v, want err nil", "pantry", err)
+	}
+	want = []*sipb.StockChange{
+		{Barcode: "1", Location: "pantry", Delta: -5, Reason: sipb.StockChange_DISCARDED},
+		{Barcode: "2", Location: "pantry", Delta: -1, Reason: sipb.StockChange_DISCARDED},
+	}
+	diffT(t, fmt.Sprintf("s.DeleteLocation(ctx, %q, DISCARD, \"\")", "pantry"), want, got)
+	diffT(t, "s.ListStock(ctx, \"\") after discard", []*sipb.Stock{}, listStockT(t, s, ""))
+
+	// Empty locations are deleted regardless of contents.
+	got, err = s.DeleteLocation(ctx, "garage", sipb.ContentsPolicy_REFUSE, "")
+	if err != nil {
+		t.Fatalf("s.DeleteLocation(ctx, %q, REFUSE, \"\") = got err %v, want err nil", "garage", err)
+	}
+	diffT(t, fmt.Sprintf("s.DeleteLocation(ctx, %q, REFUSE, \"\")", "garage"), []*sipb.StockChange{}, got)
+	diffT(t, "s.ListLocations(ctx) after deletes", []*sipb.Location{}, listLocationsT(t, s))
+}
+
+func testStock(t *testing.T, s connector.Store) {
+	ctx := context.Background()
+	createSnacksT(t, s, &sipb.Snack{Barcode: "1"}, &sipb.Snack{Barcode: "2"})
+	createLocationT(t, s, &sipb.Location{Name: "pantry"})
+	createLocationT(t, s, &sipb.Location{Name: "fridge"})
+
+	for _, tc := range []struct {
+		barcode, location string
+		delta, want       int32
+	}{
+		{"1", "pantry", 3, 3},
+		{"1", "pantry", -1, 2},
+		{"2", "pantry", 1, 1},
+		{"1", "fridge", 4, 4},
+	} {
+		if got := adjustStockT(t, s, tc.barcode, tc.location, tc.delta); got != tc.want {
+			t.Errorf("s.AdjustStock(ctx, %q, %q, %d) = got %d, want %d", tc.barcode, tc.location, tc.delta, got, tc.want)
+		}
+	}
+	for _, tc := range []struct {
+		barcode, location string
+		delta             int32
+		want              codes.Code
+	}{
+		{"404", "pantry", 1, codes.NotFound},
+		{"1", "attic", 1, codes.NotFound},
+		{"1", "pantry", -3, codes.FailedPrecondition},
+	} {
+		_, err := s.AdjustStock(ctx, tc.barcode, tc.location, tc.delta)
+		codeT(t, fmt.Sprintf("s.AdjustStock(ctx, %q, %q, %d)", tc.barcode, tc.location, tc.delta), err, tc.want)
+	}
+
+	// Stock is listed by location, then barcode.
+	want := []*sipb.Stock{
+		{Barcode: "1", Location: "fridge", Count: 4},
+		{Barcode: "1", Location: "pantry", Count: 2},
+		{Barcode: "2", Location: "pantry", Count: 1},
+	}
+	diffT(t, "s.ListStock(ctx, \"\")", want, listStockT(t, s, ""))
+	diffT(t, fmt.Sprintf("s.ListStock(ctx, %q)", "pantry"), want[1:], listStockT(t, s, "pantry"))
+
+	// Stock that runs out isn't listed.
+	adjustStockT(t, s, "1", "fridge", -4)
+	diffT(t, fmt.Sprintf("s.ListStock(ctx, %q) after emptying", "fridge"), []*sipb.Stock{}, listStockT(t, s, "fridge"))
+}
+
+func testUnicode(t *testing.T, s connector.Store) {
+	snack := &sipb.Snack{
+		Barcode:     "4000417025005",
+		Name:        "Nuss-Splitter 🍫",
+		Brand:       "Ritter Spört",
+		Category:    "チョコレート",
+		PackageSize: "100 g ± 5",
+	}
+	createSnacksT(t, s, snack)
+	kitchen := createLocationT(t, s, &sipb.Location{Name: "Küche"})
+	fridge := createLocationT(t, s, &sipb.Location{Name: "冷蔵庫", Parent: "Küche", Description: "Ünten ✓"})
+	adjustStockT(t, s, snack.GetBarcode(), "冷蔵庫", 1)
+
+	diffT(t, "s.ListSnacks(ctx)", []*sipb.Snack{snack}, listSnacksT(t, s))
+	wantLocations := []*sipb.Location{
+		{Id: kitchen, Name: "Küche"},
+		{Id: fridge, Name: "冷蔵庫", Parent: "Küche", Description: "Ünten ✓"},
+	}
+	diffT(t, "s.ListLocations(ctx)", wantLocations, listLocationsT(t, s))
+	wantStock := []*sipb.Stock{{Barcode: snack.GetBarcode(), Location: "冷蔵庫", Count: 1}}
+	diffT(t, fmt.Sprintf("s.ListStock(ctx, %q)", "冷蔵庫"), wantStock, listStockT(t, s, "冷蔵庫"))
+}
+
+func testMaxLength(t *testing.T, s connector.Store) {
+	ctx := context.Background()
+	// Lengths are in characters, not bytes.
+	snack := &sipb.Snack{
+		Barcode:     strings.Repeat("9", limits.MaxBarcodeLen),
+		Name:        strings.Repeat("é", limits.MaxNameLen),
+		Brand:       strings.Repeat("ß", limits.MaxBrandLen),
+		Category:    strings.Repeat("c", limits.MaxCategoryLen),
+		PackageSize: strings.Repeat("ü", limits.MaxPackageSizeLen),
+	}
+	createSnacksT(t, s, snack)
+	parent := strings.Repeat("ö", limits.MaxLocationNameLen)
+	child := &sipb.Location{
+		Name:        strings.Repeat("x", limits.MaxLocationNameLen),
+		Parent:      parent,
+		Description: strings.Repeat("ø", limits.MaxDescriptionLen),
+	}
+	parentID := createLocationT(t, s, &sipb.Location{Name: parent})
+	child.Id = createLocationT(t, s, child)
+	adjustStockT(t, s, snack.GetBarcode(), child.GetName(), 1)
+
+	diffT(t, "s.ListSnacks(ctx)", []*sipb.Snack{snack}, listSnacksT(t, s))
+	diffT(t, "s.ListLocations(ctx)", []*sipb.Location{{Id: parentID, Name: parent}, child}, listLocationsT(t, s))
+	wantStock := []*sipb.Stock{{Barcode: snack.GetBarcode(), Location: child.GetName(), Count: 1}}
+	diffT(t, "s.ListStock(ctx, \"\")", wantStock, listStockT(t, s, ""))
+	revisions, err := s.GetSnackHistory(ctx, snack.GetBarcode())
+	if err != nil {
+		t.Fatalf("s.GetSnackHistory(ctx, %q) = got err %v, want err nil", snack.GetBarcode(), err)
+	}
+	if len(revisions) != 1 {
+		t.Fatalf("s.GetSnackHistory(ctx, %q) = got %d revisions, want 1", snack.GetBarcode(), len(revisions))
+	}
+	diffT(t, fmt.Sprintf("s.GetSnackHistory(ctx, %q) snack", snack.GetBarcode()), snack, revisions[0].GetSnack())
+}
+
+func testTrash(t *testing.T, s connector.Store) {
+	ctx := context.Background()
+	start := time.Now()
+	createSnacksT(t, s, &sipb.Snack{Barcode: "123", Name: "chips"})
+	pantry := createLocationT(t, s, &sipb.Location{Name: "pantry"})
+	garage := createLocationT(t, s, &sipb.Location{Name: "garage"})
+	freezer := createLocationT(t, s, &sipb.Location{Name: "freezer", Parent: "garage"})
+	adjustStockT(t, s, "123", "pantry", 2)
+
+	if err := s.DeleteSnack(ctx, "123"); err != nil {
+		t.Fatalf("s.DeleteSnack(ctx, %q) = got err %v, want err nil", "123", err)
+	}
+	diffT(t, "s.ListSnacks(ctx) after delete", []*sipb.Snack{}, listSnacksT(t, s))
+	// Stock of trashed snacks is kept, but hidden.
+	diffT(t, "s.ListStock(ctx, \"\") after delete", []*sipb.Stock{}, listStockT(t, s, ""))
+	_, err := s.AdjustStock(ctx, "123", "pantry", 1)
+	codeT(t, fmt.Sprintf("s.AdjustStock(ctx, %q, %q, 1) of trashed snack", "123", "pantry"), err, codes.NotFound)
+
+	// Deletion times may be stored to the second.
+	time.Sleep(1100 * time.Millisecond)
+	if _, err := s.DeleteLocation(ctx, "freezer", sipb.ContentsPolicy_REFUSE, ""); err != nil {
+		t.Fatalf("s.DeleteLocation(ctx, %q, REFUSE, \"\") = got err %v, want err nil", "freezer", err)
+	}
+	got, err := s.ListDeleted(ctx)
+	if err != nil {
+		t.Fatalf("s.ListDeleted(ctx) = got err %v, want err nil", err)
+	}
+	// The most recently deleted is listed first.
+	want := []*sipb.DeletedEntity{
+		{Entity: &sipb.DeletedEntity_Location{Location: &sipb.Location{Id: freezer, Name: "freezer", Parent: "garage"}}},
+		{Entity: &sipb.DeletedEntity_Snack{Snack: &sipb.Snack{Barcode: "123", Name: "chips"}}},
+	}
+	diffT(t, "s.ListDeleted(ctx)", want, got, cmpopts.IgnoreFields(sipb.DeletedEntity{}, "DeleteTime"))
+	for _, d := range got {
+		if dt := d.GetDeleteTime().AsTime(); dt.Before(start.Add(-time.Second)) || dt.After(time.Now().Add(time.Second)) {
+			t.Errorf("s.ListDeleted(ctx) = got delete time %v for %v, want between %v & now", dt, d, start)
+		}
+	}
+
+	// Locations are restored after their parent.
+	if _, err := s.DeleteLocation(ctx, "garage", sipb.ContentsPolicy_REFUSE, ""); err != nil {
+		t.Fatalf("s.DeleteLocation(ctx, %q, REFUSE, \"\") = got err %v, want err nil", "garage", err)
+	}
+	_, err = s.UndeleteLocation(ctx, "freezer")
+	codeT(t, fmt.Sprintf("s.UndeleteLocation(ctx, %q) before its parent", "freezer"), err, codes.FailedPrecondition)
+	for _, name := range []string{"garage", "freezer"} {
+		if _, err := s.UndeleteLocation(ctx, name); err != nil {
+			t.Fatalf("s.UndeleteLocation(ctx, %q) = got err %v, want err nil", name, err)
+		}
+	}
+	_, err = s.UndeleteLocation(ctx, "pantry")
+	codeT(t, fmt.Sprintf("s.UndeleteLocation(ctx, %q) of live location", "pantry"), err, codes.NotFound)
+	wantLocations := []*sipb.Location{
+		{Id: pantry, Name: "pantry"},
+		{Id: garage, Name: "garage"},
+		{Id: freezer, Name: "freezer", Parent: "garage"},
+	}
+	diffT(t, "s.ListLocations(ctx) after undelete", wantLocations, listLocationsT(t, s))
+
+	// Snacks are restored with their stock.
+	snack, err := s.UndeleteSnack(ctx, "123")
+	if err != nil {
+		t.Fatalf("s.UndeleteSnack(ctx, %q) = got err %v, want err nil", "123", err)
+	}
+	diffT(t, fmt.Sprintf("s.UndeleteSnack(ctx, %q)", "123"), &sipb.Snack{Barcode: "123", Name: "chips"}, snack)
+	wantStock := []*sipb.Stock{{Barcode: "123", Location: "pantry", Count: 2}}
+	diffT(t, "s.ListStock(ctx, \"\") after undelete", wantStock, listStockT(t, s, ""))
+	_, err = s.UndeleteSnack(ctx, "123")
+	codeT(t, fmt.Sprintf("s.UndeleteSnack(ctx, %q) again", "123"), err, codes.NotFound)
+
+	// Purged snacks free their barcode, & lose their stock.
+	if err := s.DeleteSnack(ctx, "123"); err != nil {
+		t.Fatalf("s.DeleteSnack(ctx, %q) = got err %v, want err nil", "123", err)
+	}
+	for _, tc := range []struct {
+		before                   time.Time
+		wantSnacks, wantLocation int64
+	}{
+		{start.Add(-time.Hour), 0, 0},
+		{time.Now().Add(time.Hour), 1, 0},
+	} {
+		snacks, locations, err := s.PurgeDeleted(ctx, tc.before)
+		if err != nil {
+			t.Fatalf("s.PurgeDeleted(ctx, %v) = got err %v, want err nil", tc.before, err)
+		}
+		if snacks != tc.wantSnacks || locations != tc.wantLocation {
+			t.Errorf("s.PurgeDeleted(ctx, %v) = got (%d, %d), want (%d, %d)", tc.before, snacks, locations, tc.wantSnacks, tc.wantLocation)
+		}
+	}
+	createSnacksT(t, s, &sipb.Snack{Barcode: "123"})
+	diffT(t, "s.ListStock(ctx, \"\") after purge", []*sipb.Stock{}, listStockT(t, s, ""))
+	deleted, err := s.ListDeleted(ctx)
+	if err != nil {
+		t.Fatalf("s.ListDeleted(ctx) = got err %v, want err nil", err)
+	}
+	diffT(t, "s.ListDeleted(ctx) after purge", []*sipb.DeletedEntity{}, deleted)
+}
+
+func testHistory(t *testing.T, s connector.Store) {
+	ctx := context.Background()
+	before := time.Now()
+	// Changes are spaced out, as revision times may be stored to the
+	// microsecond.
+	pause := func() { time.Sleep(10 * time.Millisecond) }
+
+	createSnacksT(t, s, &sipb.Snack{Barcode: "123", Name: "chips"})
+	garage := createLocationT(t, s, &sipb.Location{Name: "garage"})
+	pause()
+	asOf := time.Now()
+	pause()
+	if err := s.UpdateSnack(ctx, &sipb.Snack{Barcode: "123", Name: "salty chips"}); err != nil {
+		t.Fatalf("s.UpdateSnack(ctx, %q) = got err %v, want err nil", "123", err)
+	}
+	if err := s.DeleteSnack(ctx, "123"); err != nil {
+		t.Fatalf("s.DeleteSnack(ctx, %q) = got err %v, want err nil", "123", err)
+	}
+	if _, err := s.UndeleteSnack(ctx, "123"); err != nil {
+		t.Fatalf("s.UndeleteSnack(ctx, %q) = got err %v, want err nil", "123", err)
+	}
+	createSnacksT(t, s, &sipb.Snack{Barcode: "456", Name: "cookies"})
+	if err := s.DeleteSnack(ctx, "456"); err != nil {
+		t.Fatalf("s.DeleteSnack(ctx, %q) = got err %v, want err nil", "456", err)
+	}
+	update := &sipb.Location{Id: garage, Name: "shed"}
+	if _, err := s.UpdateLocation(ctx, update, []string{"name"}); err != nil {
+		t.Fatalf("s.UpdateLocation(ctx, %v, [name]) = got err %v, want err nil", update, err)
+	}
+
+	// Revisions are listed oldest first.
+	got, err := s.GetSnackHistory(ctx, "123")
+	if err != nil {
+		t.Fatalf("s.GetSnackHistory(ctx, %q) = got err %v, want err nil", "123", err)
+	}
+	want := []*sipb.SnackRevision{
+		{Snack: &sipb.Snack{Barcode: "123", Name: "chips"}, Type: sipb.Change_CREATED},
+		{Snack: &sipb.Snack{Barcode: "123", Name: "salty chips"}, Type: sipb.Change_UPDATED},
+		{Snack: &sipb.Snack{Barcode: "123", Name: "salty chips"}, Type: sipb.Change_DELETED},
+		{Snack: &sipb.Snack{Barcode: "123", Name: "salty chips"}, Type: sipb.Change_CREATED},
+	}
+	diffT(t, fmt.Sprintf("s.GetSnackHistory(ctx, %q)", "123"), want, got, cmpopts.IgnoreFields(sipb.SnackRevision{}, "RevisionTime"))
+	// Only the first revision was made by asOf.
+	for i, rev := range got {
+		rt := rev.GetRevisionTime().AsTime()
+		if made := !rt.After(asOf); made != (i == 0) {
+			t.Errorf("s.GetSnackHistory(ctx, %q) = got revision %d at %v, want made by %v? %t", "123", i, rt, asOf, i == 0)
+		}
+	}
+	_, err = s.GetSnackHistory(ctx, "789")
+	codeT(t, fmt.Sprintf("s.GetSnackHistory(ctx, %q)", "789"), err, codes.NotFound)
+
+	for _, tc := range []struct {
+		asOf          time.Time
+		wantSnacks    []*sipb.Snack
+		wantLocations []*sipb.Location
+	}{
+		{before.Add(-time.Hour), nil, nil},
+		{asOf, []*sipb.Snack{{Barcode: "123", Name: "chips"}}, []*sipb.Location{{Id: garage, Name: "garage"}}},
+		// Deleted snacks aren't listed.
+		{time.Now(), []*sipb.Snack{{Barcode: "123", Name: "salty chips"}}, []*sipb.Location{{Id: garage, Name: "shed"}}},
+	} {
+		snacks, err := s.ListSnacksAsOf(ctx, tc.asOf)
+		if err != nil {
+			t.Fatalf("s.ListSnacksAsOf(ctx, %v) = got err %v, want err nil", tc.asOf, err)
+		}
+		diffT(t, fmt.Sprintf("s.ListSnacksAsOf(ctx, %v)", tc.asOf), tc.wantSnacks, snacks)
+		locations, err := s.ListLocationsAsOf(ctx, tc.asOf)
+		if err != nil {
+			t.Fatalf("s.ListLocationsAsOf(ctx, %v) = got err %v, want err nil", tc.asOf, err)
+		}
+		diffT(t, fmt.Sprintf("s.ListLocationsAsOf(ctx, %v)", tc.asOf), tc.wantLocations, locations)
+	}
+}
+
+func testConcurrentWriters(t *testing.T, s connector.Store) {
+	ctx := context.Background()
+	createSnacksT(t, s, &sipb.Snack{Barcode: "123"})
+	createLocationT(t, s, &sipb.Location{Name: "pantry"})
+
+	// run calls fn from writers goroutines at once, returning their errors.
+	run := func(fn func(i int) error) []error {
+		errs := make([]error, writers)
+		var wg sync.WaitGroup
+		for i := 0; i < writers; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				errs[i] = fn(i)
+			}(i)
+		}
+		wg.Wait()
+		return errs
+	}
+
+	// No adjustment is lost.
+	errs := run(func(int) error {
+		_, err := s.AdjustStock(ctx, "123", "pantry", 1)
+		return err
+	})
+	for _, err := range errs {
+		if err != nil {
+			t.Errorf("s.AdjustStock(ctx, %q, %q, 1) = got err %v, want err nil", "123", "pantry", err)
+		}
+	}
+	wantStock := []*sipb.Stock{{Barcode: "123", Location: "pantry", Count: writers}}
+	diffT(t, "s.ListStock(ctx, \"\") after concurrent adjustments", wantStock, listStockT(t, s, ""))
+
+	// Exactly one create of the same barcode or name succeeds.
+	for _, tc := range []struct {
+		desc string
+		fn   func(i int) error
+	}{
+		{"s.CreateSnack(ctx, \"456\")", func(i int) error {
+			return s.CreateSnack(ctx, &sipb.Snack{Barcode: "456", Name: fmt.Sprint("writer ", i)})
+		}},
+		{"s.CreateLocation(ctx, \"fridge\")", func(i int) error {
+			_, err := s.CreateLocation(ctx, &sipb.Location{Name: "fridge", Description: fmt.Sprint("writer ", i)})
+			return err
+		}},
+	} {
+		created := 0
+		for _, err := range run(tc.fn) {
+			switch status.Code(err) {
+			case codes.OK:
+				created++
+			case codes.AlreadyExists:
+			default:
+				t.Errorf("%s = got err %v, want err nil or code %v", tc.desc, err, codes.AlreadyExists)
+			}
+		}
+		if created != 1 {
+			t.Errorf("%s from %d writers = got %d successes, want 1", tc.desc, writers, created)
+		}
+	}
+
+	// Distinct creates all succeed.
+	errs = run(func(i int) error {
+		return s.CreateSnack(ctx, &sipb.Snack{Barcode: fmt.Sprint(1000 + i)})
+	})
+	for i, err := range errs {
+		if err != nil {
+			t.Errorf("s.CreateSnack(ctx, %q) = got err %v, want err nil", fmt.Sprint(1000+i), err)
+		}
+	}
+	if got, want := len(listSnacksT(t, s)), writers+2; got != want {
+		t.Errorf("s.ListSnacks(ctx) after concurrent creates = got %d snacks, want %d", got, want)
+	}
+}
+
+// diffT reports an error if got differs from want.
+func diffT(t *testing.T, call string, want, got interface{}, opts ...cmp.Option) {
+	t.Helper()
+	if diff := cmp.Diff(want, got, append(opts, cmpOpts...)...); diff != "" {
+		t.Errorf("%s = got diff (-want +got): %s", call, diff)
+	}
+}
+
+// codeT fails t unless err has code want.
+func codeT(t *testing.T, call string, err error, want codes.Code) {
+	t.Helper()
+	if got := status.Code(err); got != want {
+		t.Fatalf("%s = got err %v, want code %v", call, err, want)
+	}
+}
+
+// batchErrorT fails t unless err is a *connector.BatchError for the item at
+// index, with code want.
+func batchErrorT(t *testing.T, call string, err error, index int, want codes.Code) {
+	t.Helper()
+	var be *connector.BatchError
+	if !errors.As(err, &be) || be.Index != index || status.Code(be.Err) != want {
+		t.Fatalf("%s = got err %v, want *connector.BatchError for item %d with code %v", call, err, index, want)
+	}
+}
+
+func createSnacksT(t *testing.T, s connector.Store, snacks ...*sipb.Snack) {
+	t.Helper()
+	for _, snack := range snacks {
+		if err := s.CreateSnack(context.Background(), snack); err != nil {
+			t.Fatalf("s.CreateSnack(ctx, %v) = got err %v, want err nil", snack, err)
+		}
+	}
+}
+
+func createLocationT(t *testing.T, s connector.Store, location *sipb.Location) int64 {
+	t.Helper()
+	id, err := s.CreateLocation(context.Background(), location)
+	if err != nil {
+		t.Fatalf("s.CreateLocation(ctx, %v) = got err %v, want err nil", location, err)
+	}
+	return id
+}
+
+func adjustStockT(t *testing.T, s connector.Store, barcode, location string, delta int32) int32 {
+	t.Helper()
+	count, err := s.AdjustStock(context.Background(), barcode, location, delta)
+	if err != nil {
+		t.Fatalf("s.AdjustStock(ctx, %q, %q, %d) = got err %v, want err nil", barcode, location, delta, err)
+	}
+	return count
+}
+
+func listSnacksT(t *testing.T, s connector.Store) []*sipb.Snack {
+	t.Helper()
+	snacks, err := s.ListSnacks(context.Background())
+	if err != nil {
+		t.Fatalf("s.ListSnacks(ctx) = got err %v, want err nil", err)
+	}
+	return snacks
+}
+
+func listLocationsT(t *testing.T, s connector.Store) []*sipb.Location {
+	t.Helper()
+	locations, err := s.ListLocations(context.Background())
+	if err != nil {
+		t.Fatalf("s.ListLocations(ctx) = got err %v, want err nil", err)
+	}
+	return locations
+}
+
+func listStockT(t *testing.T, s connector.Store, location string) []*sipb.Stock {
+	t.Helper()
+	stock, err := s.ListStock(context.Background(), location)
+	if err != nil {
+		t.Fatalf("s.ListStock(ctx, %q) = got err %v, want err nil", location, err)
+	}
+	return stock
+}
@@ -0,0 +1,38 @@
+/*
+Copyright 2020 Robert Barron
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package connector
+
+import (
+	"database/sql"
+	"time"
+)
+
+// NewSQLImplForTest returns a SQLImpl using db, for tests outside the package.
+func NewSQLImplForTest(db *sql.DB) *SQLImpl {
+	return &SQLImpl{db: db}
+}
+
+// NewPostgresImplForTest returns a PostgresImpl using db, for tests outside
+// the package.
+func NewPostgresImplForTest(db *sql.DB) *PostgresImpl {
+	return &PostgresImpl{db: db}
+}
+
+// SetClockForTest sets the clock c uses for expiry.
+func (c *CachedStore) SetClockForTest(now func() time.Time) {
+	c.now = now
+}
@@ -0,0 +1,195 @@
+/*
+Copyright 2020 Robert Barron
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package connector
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	sipb "github.com/rmbarron/SnackInventory/src/proto/snackinventory"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Every change to "SnackRegistry" & "LocationRegistry" is recorded, within the
+// transaction making it, as a row of "SnackRevisions" or "LocationRevisions"
+// holding the entity as it was after the change. Past state is rebuilt from
+// the latest revision of each entity at that time.
+
+// changeTime returns the time to record for a change made now.
+// Stored in UTC, as are the times PurgeDeleted & the AsOf reads compare against.
+func changeTime() time.Time {
+	return time.Now().UTC()
+}
+
+// recordSnacks records a revision of type typ, made at time at, of each snack
+// with one of barcodes as it is within tx. Snacks in the trash are only
+// recorded for DELETED revisions, and only they are.
+func recordSnacks(ctx context.Context, tx *sql.Tx, typ sipb.Change_Type, at time.Time, barcodes []string) error {
+	if len(barcodes) == 0 {
+		return nil
+	}
+	args := make([]interface{}, 0, len(barcodes)+2)
+	args = append(args, typ, at)
+	for _, b := range barcodes {
+		args = append(args, b)
+	}
+	// Placeholders in a SELECT list are untyped, so the time is cast explicitly.
+	_, err := tracedExec(ctx, tx,
+		"INSERT INTO SnackRevisions (barcode, name, brand, category, package_size, change_type, revision_time)"+
+			" SELECT barcode, name, brand, category, package_size, ?, CAST(? AS DATETIME(6)) FROM SnackRegistry"+
+			fmt.Sprintf(" WHERE barcode IN (%s) AND %s", placeholders("?", len(barcodes)), deletedCondition(typ)),
+		args...)
+	return err
+}
+
+// recordLocations records a revision of type typ, made at time at, of each
+// location matching the SQL condition where as it is within tx. As with
+// recordSnacks, locations in the trash are only recorded for DELETED revisions.
+func recordLocations(ctx context.Context, tx *sql.Tx, typ sipb.Change_Type, at time.Time, where string, args ...interface{}) error {
+	_, err := tracedExec(ctx, tx,
+		"INSERT INTO LocationRevisions (location_id, name, parent, description, type, change_type, revision_time)"+
+			" SELECT id, name, parent, description, type, ?, CAST(? AS DATETIME(6)) FROM LocationRegistry"+
+			fmt.Sprintf(" WHERE (%s) AND %s", where, deletedCondition(typ)),
+		append([]interface{}{typ, at}, args...)...)
+	return err
+}
+
+// deletedCondition returns the SQL condition selecting registry rows that a
+// revision of type typ may record.
+func deletedCondition(typ sipb.Change_Type) string {
+	if typ == sipb.Change_DELETED {
+		return "deleted_at IS NOT NULL"
+	}
+	return "deleted_at IS NULL"
+}
+
+// GetSnackHistory reads the revisions of the snack with barcode, oldest first.
+// Returns a NotFound error if there are none.
+func (s *SQLImpl) GetSnackHistory(ctx context.Context, barcode string) ([]*sipb.SnackRevision, error) {
+	var revisions []*sipb.SnackRevision
+	err := s.retryRead(ctx, func() (err error) {
+		revisions, err = s.getSnackHistory(ctx, barcode)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(revisions) == 0 {
+		return nil, status.Errorf(codes.NotFound, "barcode %q has no history", barcode)
+	}
+	return revisions, nil
+}
+
+func (s *SQLImpl) getSnackHistory(ctx context.Context, barcode string) ([]*sipb.SnackRevision, error) {
+	var retVal []*sipb.SnackRevision
+	rows, err := s.queryContext(ctx,
+		"SELECT barcode, name, brand, category, package_size, change_type, revision_time FROM SnackRevisions"+
+			" WHERE barcode IN (?) ORDER BY id", barcode)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		snack := &sipb.Snack{}
+		rev := &sipb.SnackRevision{Snack: snack}
+		var revisionTime time.Time
+		if err = rows.Scan(&snack.Barcode, &snack.Name, &snack.Brand, &snack.Category, &snack.PackageSize, &rev.Type, &revisionTime); err != nil {
+			return nil, err
+		}
+		rev.RevisionTime = timestamppb.New(revisionTime)
+		retVal = append(retVal, rev)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return retVal, nil
+}
+
+// ListSnacksAsOf reads the snacks registered at asOf, as they were then.
+func (s *SQLImpl) ListSnacksAsOf(ctx context.Context, asOf time.Time) ([]*sipb.Snack, error) {
+	var snacks []*sipb.Snack
+	err := s.retryRead(ctx, func() (err error) {
+		snacks, err = s.listSnacksAsOf(ctx, asOf.UTC())
+		return err
+	})
+	return snacks, err
+}
+
+func (s *SQLImpl) listSnacksAsOf(ctx context.Context, asOf time.Time) ([]*sipb.Snack, error) {
+	var retVal []*sipb.Snack
+	rows, err := s.queryContext(ctx,
+		"SELECT r.barcode, r.name, r.brand, r.category, r.package_size FROM SnackRevisions r"+
+			" JOIN (SELECT MAX(id) AS id FROM SnackRevisions WHERE revision_time <= ? GROUP BY barcode) latest"+
+			" ON r.id = latest.id WHERE r.change_type != ? ORDER BY r.barcode",
+		asOf, sipb.Change_DELETED)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		snack := &sipb.Snack{}
+		if err = rows.Scan(&snack.Barcode, &snack.Name, &snack.Brand, &snack.Category, &snack.PackageSize); err != nil {
+			return nil, err
+		}
+		retVal = append(retVal, snack)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return retVal, nil
+}
+
+// ListLocationsAsOf reads the locations registered at asOf, as they were then.
+func (s *SQLImpl) ListLocationsAsOf(ctx context.Context, asOf time.Time) ([]*sipb.Location, error) {
+	var locations []*sipb.Location
+	err := s.retryRead(ctx, func() (err error) {
+		locations, err = s.listLocationsAsOf(ctx, asOf.UTC())
+		return err
+	})
+	return locations, err
+}
+
+func (s *SQLImpl) listLocationsAsOf(ctx context.Context, asOf time.Time) ([]*sipb.Location, error) {
+	var retVal []*sipb.Location
+	rows, err := s.queryContext(ctx,
+		"SELECT r.location_id, r.name, r.parent, r.description, r.type FROM LocationRevisions r"+
+			" JOIN (SELECT MAX(id) AS id FROM LocationRevisions WHERE revision_time <= ? GROUP BY location_id) latest"+
+			" ON r.id = latest.id WHERE r.change_type != ? ORDER BY r.location_id",
+		asOf, sipb.Change_DELETED)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		location := &sipb.Location{}
+		if err = rows.Scan(&location.Id, &location.Name, &location.Parent, &location.Description, &location.Type); err != nil {
+			return nil, err
+		}
+		retVal = append(retVal, location)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return retVal, nil
+}
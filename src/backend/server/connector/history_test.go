@@ -0,0 +1,185 @@
+/*
+Copyright 2020 Robert Barron
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package connector
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/rmbarron/SnackInventory/src/backend/server/testutils"
+	sipb "github.com/rmbarron/SnackInventory/src/proto/snackinventory"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// TestHistory is a parent test to create a mariadb instance for subtests.
+func TestHistory(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	db, close := testutils.StartMysqldT(ctx, t)
+	defer close()
+
+	testutils.CreateDatabaseT(ctx, t, db)
+
+	day := func(d int) time.Time { return time.Date(2020, 1, d, 0, 0, 0, 0, time.UTC) }
+	// setRevisionTimesT moves the revisions in table, in order, to the start of
+	// consecutive days from 2020-01-01, as changes may otherwise fall within the
+	// same instant.
+	setRevisionTimesT := func(t *testing.T, table string, n int) {
+		t.Helper()
+		for id := 1; id <= n; id++ {
+			if _, err := db.ExecContext(ctx, "UPDATE "+table+" SET revision_time = ? WHERE id = ?", day(id), id); err != nil {
+				t.Fatalf("db.ExecContext(ctx, ...) = got err %v, want err nil", err)
+			}
+		}
+	}
+
+	t.Run("GetSnackHistory", func(t *testing.T) {
+		testutils.CreateTablesT(ctx, t, db)
+		defer testutils.DropTablesT(ctx, t, db)
+		si := &SQLImpl{db: db}
+
+		if err := si.CreateSnack(ctx, &sipb.Snack{Barcode: "123", Name: "chips"}); err != nil {
+			t.Fatalf("si.CreateSnack(ctx, %q) = got err %v, want err nil", "123", err)
+		}
+		if err := si.BatchUpdateSnacks(ctx, []*sipb.Snack{{Barcode: "123", Name: "salty chips"}}); err != nil {
+			t.Fatalf("si.BatchUpdateSnacks(ctx, %q) = got err %v, want err nil", "123", err)
+		}
+		for i := 0; i < 2; i++ {
+			if err := si.DeleteSnack(ctx, "123"); err != nil {
+				t.Fatalf("si.DeleteSnack(ctx, %q) = got err %v, want err nil", "123", err)
+			}
+		}
+		if _, err := si.UndeleteSnack(ctx, "123"); err != nil {
+			t.Fatalf("si.UndeleteSnack(ctx, %q) = got err %v, want err nil", "123", err)
+		}
+		// Snacks that aren't registered aren't recorded.
+		if err := si.UpdateSnack(ctx, &sipb.Snack{Barcode: "456"}); err != nil {
+			t.Fatalf("si.UpdateSnack(ctx, %q) = got err %v, want err nil", "456", err)
+		}
+		setRevisionTimesT(t, "SnackRevisions", 4)
+
+		got, err := si.GetSnackHistory(ctx, "123")
+		if err != nil {
+			t.Fatalf("si.GetSnackHistory(ctx, %q) = got err %v, want err nil", "123", err)
+		}
+		chips := &sipb.Snack{Barcode: "123", Name: "chips"}
+		salty := &sipb.Snack{Barcode: "123", Name: "salty chips"}
+		want := []*sipb.SnackRevision{
+			{Snack: chips, Type: sipb.Change_CREATED},
+			{Snack: salty, Type: sipb.Change_UPDATED},
+			{Snack: salty, Type: sipb.Change_DELETED},
+			{Snack: salty, Type: sipb.Change_CREATED},
+		}
+		for i, rev := range got {
+			if rev.GetRevisionTime().AsTime() != day(i+1) {
+				t.Errorf("si.GetSnackHistory(ctx, %q)[%d] = got time %v, want %v", "123", i, rev.GetRevisionTime().AsTime(), day(i+1))
+			}
+		}
+		opts := []cmp.Option{
+			cmpopts.IgnoreUnexported(sipb.SnackRevision{}, sipb.Snack{}),
+			cmpopts.IgnoreFields(sipb.SnackRevision{}, "RevisionTime"),
+		}
+		if diff := cmp.Diff(want, got, opts...); diff != "" {
+			t.Errorf("si.GetSnackHistory(ctx, %q) = got diff (-want +got): %s", "123", diff)
+		}
+		if _, err := si.GetSnackHistory(ctx, "456"); status.Code(err) != codes.NotFound {
+			t.Fatalf("si.GetSnackHistory(ctx, %q) = got err %v, want code %v", "456", err, codes.NotFound)
+		}
+	})
+
+	t.Run("ListSnacksAsOf", func(t *testing.T) {
+		testutils.CreateTablesT(ctx, t, db)
+		defer testutils.DropTablesT(ctx, t, db)
+		si := &SQLImpl{db: db}
+
+		if err := si.BatchCreateSnacks(ctx, []*sipb.Snack{{Barcode: "123", Name: "chips"}, {Barcode: "456"}}); err != nil {
+			t.Fatalf("si.BatchCreateSnacks(ctx, ...) = got err %v, want err nil", err)
+		}
+		if err := si.UpdateSnack(ctx, &sipb.Snack{Barcode: "123", Name: "salty chips"}); err != nil {
+			t.Fatalf("si.UpdateSnack(ctx, %q) = got err %v, want err nil", "123", err)
+		}
+		if err := si.BatchDeleteSnacks(ctx, []string{"456", "456", "789"}); err != nil {
+			t.Fatalf("si.BatchDeleteSnacks(ctx, ...) = got err %v, want err nil", err)
+		}
+		// Both creations are on day 1 & 2, the update day 3, the deletion day 4.
+		setRevisionTimesT(t, "SnackRevisions", 4)
+
+		tests := []struct {
+			asOf time.Time
+			want []*sipb.Snack
+		}{
+			{asOf: day(1).Add(-time.Second)},
+			{asOf: day(2), want: []*sipb.Snack{{Barcode: "123", Name: "chips"}, {Barcode: "456"}}},
+			{asOf: day(3).Add(time.Hour), want: []*sipb.Snack{{Barcode: "123", Name: "salty chips"}, {Barcode: "456"}}},
+			{asOf: day(4), want: []*sipb.Snack{{Barcode: "123", Name: "salty chips"}}},
+		}
+		for _, tc := range tests {
+			got, err := si.ListSnacksAsOf(ctx, tc.asOf)
+			if err != nil {
+				t.Fatalf("si.ListSnacksAsOf(ctx, %v) = got err %v, want err nil", tc.asOf, err)
+			}
+			if diff := cmp.Diff(tc.want, got, cmpopts.IgnoreUnexported(sipb.Snack{})); diff != "" {
+				t.Errorf("si.ListSnacksAsOf(ctx, %v) = got diff (-want +got): %s", tc.asOf, diff)
+			}
+		}
+	})
+
+	t.Run("ListLocationsAsOf", func(t *testing.T) {
+		testutils.CreateTablesT(ctx, t, db)
+		defer testutils.DropTablesT(ctx, t, db)
+		si := &SQLImpl{db: db}
+
+		for _, l := range []*sipb.Location{{Name: "garage"}, {Name: "freezer", Parent: "garage"}} {
+			if _, err := si.CreateLocation(ctx, l); err != nil {
+				t.Fatalf("si.CreateLocation(ctx, %q) = got err %v, want err nil", l.GetName(), err)
+			}
+		}
+		// Renaming garage moves freezer too.
+		if _, err := si.UpdateLocation(ctx, &sipb.Location{Id: 1, Name: "shed"}, []string{"name"}); err != nil {
+			t.Fatalf("si.UpdateLocation(ctx, 1, name) = got err %v, want err nil", err)
+		}
+		if _, err := si.DeleteLocation(ctx, "freezer", sipb.ContentsPolicy_REFUSE, ""); err != nil {
+			t.Fatalf("si.DeleteLocation(ctx, %q, REFUSE, \"\") = got err %v, want err nil", "freezer", err)
+		}
+		// Revisions 3 & 4, of the rename, are moved to days 3 & 4.
+		setRevisionTimesT(t, "LocationRevisions", 5)
+
+		tests := []struct {
+			asOf time.Time
+			want []*sipb.Location
+		}{
+			{asOf: day(1), want: []*sipb.Location{{Id: 1, Name: "garage"}}},
+			{asOf: day(2), want: []*sipb.Location{{Id: 1, Name: "garage"}, {Id: 2, Name: "freezer", Parent: "garage"}}},
+			{asOf: day(4), want: []*sipb.Location{{Id: 1, Name: "shed"}, {Id: 2, Name: "freezer", Parent: "shed"}}},
+			{asOf: day(5), want: []*sipb.Location{{Id: 1, Name: "shed"}}},
+		}
+		for _, tc := range tests {
+			got, err := si.ListLocationsAsOf(ctx, tc.asOf)
+			if err != nil {
+				t.Fatalf("si.ListLocationsAsOf(ctx, %v) = got err %v, want err nil", tc.asOf, err)
+			}
+			if diff := cmp.Diff(tc.want, got, cmpopts.IgnoreUnexported(sipb.Location{})); diff != "" {
+				t.Errorf("si.ListLocationsAsOf(ctx, %v) = got diff (-want +got): %s", tc.asOf, diff)
+			}
+		}
+	})
+}
@@ -0,0 +1,105 @@
+/*
+Copyright 2020 Robert Barron
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package connector
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/rmbarron/SnackInventory/src/backend/server/config"
+)
+
+func init() {
+	Register("postgres", &pgFactory{stdin: os.Stdin})
+}
+
+// pgSSLModes are the supported values of pg_sslmode.
+var pgSSLModes = map[string]bool{"disable": true, "require": true, "verify-ca": true, "verify-full": true}
+
+// pgFactory opens a PostgresImpl, configured by flags prefixed "pg_".
+type pgFactory struct {
+	user, address, database, sslmode string
+	// As for sqlFactory.
+	password, passwordFile string
+	stdin                  io.Reader
+
+	opts SQLOptions
+}
+
+func (f *pgFactory) RegisterFlags(fs *flag.FlagSet) {
+	fs.StringVar(&f.user, "pg_user", "", "Username for connecting to Postgres.")
+	fs.StringVar(&f.address, "pg_address", "", "host:port address for connecting to Postgres.")
+	fs.StringVar(&f.database, "pg_database", "snackinventory", "Postgres database name to connect to.")
+	fs.StringVar(&f.sslmode, "pg_sslmode", "disable", "TLS for connecting to Postgres: disable, require, verify-ca or verify-full.")
+	fs.StringVar(&f.password, "pg_password", "", "Postgres password. Prefer pg_password_file.")
+	fs.StringVar(
+		&f.passwordFile, "pg_password_file", "",
+		"File containing the Postgres password. If unset, the password is read from the first line of stdin.")
+	fs.IntVar(&f.opts.MaxOpenConns, "pg_max_open_conns", 10, "Maximum open connections to Postgres. Unlimited if 0.")
+	fs.IntVar(&f.opts.MaxIdleConns, "pg_max_idle_conns", 5, "Maximum idle connections kept open to Postgres.")
+	fs.DurationVar(
+		&f.opts.ConnMaxLifetime, "pg_conn_max_lifetime", 5*time.Minute,
+		"Maximum time a Postgres connection is reused. Forever if 0.")
+	fs.DurationVar(
+		&f.opts.StartupTimeout, "pg_startup_timeout", time.Minute,
+		"How long to wait, retrying with backoff, for Postgres to accept connections at startup.")
+	fs.IntVar(
+		&f.opts.ReadRetries, "pg_read_retries", 3,
+		"Times to retry idempotent reads after transient Postgres errors (ex: dropped connections, deadlocks).")
+}
+
+func (f *pgFactory) Validate() error {
+	var errs config.Errors
+	if f.user == "" {
+		errs = append(errs, "pg_user: required for postgres storage")
+	}
+	if f.address == "" {
+		errs = append(errs, "pg_address: required for postgres storage")
+	}
+	if f.database == "" {
+		errs = append(errs, "pg_database: required for postgres storage")
+	}
+	if !pgSSLModes[f.sslmode] {
+		errs = append(errs, fmt.Sprintf("pg_sslmode: %q is not one of disable, require, verify-ca or verify-full", f.sslmode))
+	}
+	if f.password != "" && f.passwordFile != "" {
+		errs = append(errs, "pg_password, pg_password_file: at most one may be set")
+	}
+	if f.opts.MaxOpenConns > 0 && f.opts.MaxIdleConns > f.opts.MaxOpenConns {
+		errs = append(errs, fmt.Sprintf("pg_max_idle_conns: %d exceeds pg_max_open_conns %d", f.opts.MaxIdleConns, f.opts.MaxOpenConns))
+	}
+	if f.opts.ReadRetries < 0 {
+		errs = append(errs, fmt.Sprintf("pg_read_retries: %d must not be negative", f.opts.ReadRetries))
+	}
+	return errs.Err()
+}
+
+func (f *pgFactory) Open(ctx context.Context) (Store, error) {
+	pwd, err := readPassword(f.passwordFile, "pg_password_file", f.password, f.stdin)
+	if err != nil {
+		return nil, fmt.Errorf("could not read Postgres password: %w", err)
+	}
+	p, err := NewPostgresImpl(ctx, f.user, pwd, f.address, f.database, f.sslmode, f.opts)
+	if err != nil {
+		return nil, fmt.Errorf("could not connect to Postgres: %w", err)
+	}
+	return p, nil
+}
@@ -0,0 +1,506 @@
+/*
+Copyright 2020 Robert Barron
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package connector
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"net"
+	"net/url"
+
+	"github.com/lib/pq"
+	sipb "github.com/rmbarron/SnackInventory/src/proto/snackinventory"
+	"github.com/rmbarron/SnackInventory/src/tracing"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// PostgresImpl implements a connector to a PostgreSQL DB.
+// It shares the storage model of SQLImpl, with Postgres types: the same tables,
+// with text columns compared bytewise (COLLATE "C") so items are listed in the
+// same order. See the README for the schema.
+type PostgresImpl struct {
+	db   *sql.DB
+	opts SQLOptions
+}
+
+var _ Store = (*PostgresImpl)(nil)
+
+// Postgres error codes that indicate a transaction may succeed if retried.
+const (
+	pgErrUniqueViolation      = "23505"
+	pgErrSerializationFailure = "40001"
+	pgErrDeadlockDetected     = "40P01"
+	pgErrLockNotAvailable     = "55P03"
+	pgErrAdminShutdown        = "57P01"
+	pgErrCannotConnectNow     = "57P03"
+)
+
+// NewPostgresImpl connects to Postgres and creates a PostgresImpl instance.
+// sslmode is as for libpq, ex: "disable" or "verify-full".
+// If opts.StartupTimeout is set, NewPostgresImpl waits for the DB to come up.
+func NewPostgresImpl(ctx context.Context, user, password, hostport, dbname, sslmode string, opts SQLOptions) (*PostgresImpl, error) {
+	dsn := &url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(user, password),
+		Host:     hostport,
+		Path:     "/" + dbname,
+		RawQuery: url.Values{"sslmode": {sslmode}}.Encode(),
+	}
+	db, err := sql.Open("postgres", dsn.String())
+	if err != nil {
+		return nil, err
+	}
+	opts.applyPool(db)
+	p := &PostgresImpl{db: db, opts: opts}
+	if err = opts.pingWithRetry(ctx, db, pgIsTransient); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return p, nil
+}
+
+// Stats returns connection pool statistics for the underlying DB.
+func (p *PostgresImpl) Stats() sql.DBStats {
+	return p.db.Stats()
+}
+
+// pgErrCode returns the Postgres error code of err, or "" if it has none.
+func pgErrCode(err error) pq.ErrorCode {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return pqErr.Code
+	}
+	return ""
+}
+
+// pgIsTransient reports whether err may succeed if the operation is retried:
+// dropped or refused connections (ex: the DB restarted) and lock conflicts.
+func pgIsTransient(err error) bool {
+	if errors.Is(err, driver.ErrBadConn) || pgIsLockConflict(err) {
+		return true
+	}
+	switch pgErrCode(err) {
+	case pgErrAdminShutdown, pgErrCannotConnectNow:
+		return true
+	}
+	var netErr *net.OpError
+	return errors.As(err, &netErr)
+}
+
+// pgIsLockConflict reports whether err is from a lock conflict with a
+// concurrent transaction, so may succeed if the transaction is run again.
+func pgIsLockConflict(err error) bool {
+	switch pgErrCode(err) {
+	case pgErrSerializationFailure, pgErrDeadlockDetected, pgErrLockNotAvailable:
+		return true
+	}
+	return false
+}
+
+// pgIsTxConflict is isTxConflict, for Postgres.
+func pgIsTxConflict(err error) bool {
+	return pgIsLockConflict(err) || pgErrCode(err) == pgErrUniqueViolation
+}
+
+// retryRead runs op, retrying up to p.opts.ReadRetries times while it fails
+// with a transient error. op must be idempotent.
+func (p *PostgresImpl) retryRead(ctx context.Context, op func() error) error {
+	return p.opts.retry(ctx, p.opts.ReadRetries, pgIsTransient, op)
+}
+
+// withTx runs fn in a transaction, committing if fn succeeds. As with
+// SQLImpl.withTx, the transaction is run again if it conflicts with a
+// concurrent one.
+func (p *PostgresImpl) withTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	ctx, span := tracing.StartSpan(ctx, "PostgresImpl.Tx")
+	defer span.End()
+
+	err := p.opts.retry(ctx, maxTxAttempts-1, pgIsTxConflict, func() error {
+		tx, err := p.db.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		if err := fn(tx); err != nil {
+			tx.Rollback()
+			return err
+		}
+		return tx.Commit()
+	})
+	span.SetError(err)
+	return err
+}
+
+// pgQuery runs a query within a trace span, when tracing is enabled.
+func pgQuery(ctx context.Context, c sqlConn, query string, args ...interface{}) (*sql.Rows, error) {
+	ctx, span := tracing.StartSpan(ctx, "PostgresImpl.Query")
+	defer span.End()
+	span.SetAttribute("db.statement", query)
+
+	rows, err := c.QueryContext(ctx, query, args...)
+	span.SetError(err)
+	return rows, err
+}
+
+// pgExec runs a statement within a trace span, when tracing is enabled.
+func pgExec(ctx context.Context, c sqlConn, query string, args ...interface{}) (sql.Result, error) {
+	ctx, span := tracing.StartSpan(ctx, "PostgresImpl.Exec")
+	defer span.End()
+	span.SetAttribute("db.statement", query)
+
+	res, err := c.ExecContext(ctx, query, args...)
+	span.SetError(err)
+	return res, err
+}
+
+// CreateSnack is SQLImpl.CreateSnack, for Postgres.
+func (p *PostgresImpl) CreateSnack(ctx context.Context, snack *sipb.Snack) error {
+	barcode := snack.GetBarcode()
+	return p.withTx(ctx, func(tx *sql.Tx) error {
+		res, err := pgExec(ctx, tx,
+			"INSERT INTO SnackRegistry (barcode, name, brand, category, package_size) VALUES($1, $2, $3, $4, $5)"+
+				" ON CONFLICT (barcode) DO NOTHING",
+			barcode, snack.GetName(), snack.GetBrand(), snack.GetCategory(), snack.GetPackageSize())
+		if err != nil {
+			return err
+		}
+		n, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			existing, err := pgExistingBarcodes(ctx, tx, []string{barcode})
+			if err != nil {
+				return err
+			}
+			return snackExistsError(barcode, existing[barcode])
+		}
+		return pgRecordSnacks(ctx, tx, sipb.Change_CREATED, changeTime(), []string{barcode})
+	})
+}
+
+// ListSnacks is SQLImpl.ListSnacks, for Postgres.
+func (p *PostgresImpl) ListSnacks(ctx context.Context) ([]*sipb.Snack, error) {
+	var snacks []*sipb.Snack
+	err := p.retryRead(ctx, func() (err error) {
+		snacks, err = p.listSnacks(ctx)
+		return err
+	})
+	return snacks, err
+}
+
+func (p *PostgresImpl) listSnacks(ctx context.Context) ([]*sipb.Snack, error) {
+	var retVal []*sipb.Snack
+	rows, err := pgQuery(ctx, p.db, "SELECT barcode, name, brand, category, package_size FROM SnackRegistry WHERE deleted_at IS NULL ORDER BY barcode")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		snack := &sipb.Snack{}
+		if err = rows.Scan(&snack.Barcode, &snack.Name, &snack.Brand, &snack.Category, &snack.PackageSize); err != nil {
+			return nil, err
+		}
+		retVal = append(retVal, snack)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return retVal, nil
+}
+
+// UpdateSnack is SQLImpl.UpdateSnack, for Postgres.
+func (p *PostgresImpl) UpdateSnack(ctx context.Context, snack *sipb.Snack) error {
+	return p.withTx(ctx, func(tx *sql.Tx) error {
+		if _, err := pgExec(ctx, tx, pgUpdateSnackStatement, updateSnackArgs(snack)...); err != nil {
+			return err
+		}
+		return pgRecordSnacks(ctx, tx, sipb.Change_UPDATED, changeTime(), []string{snack.GetBarcode()})
+	})
+}
+
+// pgUpdateSnackStatement is updateSnackStatement, for Postgres.
+const pgUpdateSnackStatement = "UPDATE SnackRegistry SET name = $1, brand = $2, category = $3, package_size = $4" +
+	" WHERE barcode = $5 AND deleted_at IS NULL"
+
+// DeleteSnack is SQLImpl.DeleteSnack, for Postgres.
+func (p *PostgresImpl) DeleteSnack(ctx context.Context, barcode string) error {
+	return p.withTx(ctx, func(tx *sql.Tx) error {
+		now := changeTime()
+		res, err := pgExec(ctx, tx,
+			"UPDATE SnackRegistry SET deleted_at = $1 WHERE barcode = $2 AND deleted_at IS NULL", now, barcode)
+		if err != nil {
+			return err
+		}
+		// Snacks already in the trash are left as they are.
+		if n, err := res.RowsAffected(); err != nil || n == 0 {
+			return err
+		}
+		return pgRecordSnacks(ctx, tx, sipb.Change_DELETED, now, []string{barcode})
+	})
+}
+
+// CreateLocation is SQLImpl.CreateLocation, for Postgres.
+func (p *PostgresImpl) CreateLocation(ctx context.Context, location *sipb.Location) (int64, error) {
+	name, parent := location.GetName(), location.GetParent()
+	var id int64
+	err := p.withTx(ctx, func(tx *sql.Tx) error {
+		if err := pgCheckNameFree(ctx, tx, name); err != nil {
+			return err
+		}
+		if err := pgCheckParent(ctx, tx, name, parent); err != nil {
+			return err
+		}
+		rows, err := pgQuery(ctx, tx,
+			"INSERT INTO LocationRegistry (name, parent, description, type) VALUES($1, $2, $3, $4)"+
+				" ON CONFLICT (name) DO NOTHING RETURNING id",
+			name, parent, location.GetDescription(), location.GetType())
+		if err != nil {
+			return err
+		}
+		inserted := rows.Next()
+		if inserted {
+			err = rows.Scan(&id)
+		}
+		rows.Close()
+		if err != nil {
+			return err
+		}
+		// A concurrent transaction created it after the check.
+		if !inserted {
+			return pgCheckNameFree(ctx, tx, name)
+		}
+		return pgRecordLocations(ctx, tx, sipb.Change_CREATED, changeTime(), "id = $3", id)
+	})
+	if err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+// pgCheckNameFree is checkNameFree, for Postgres.
+func pgCheckNameFree(ctx context.Context, tx *sql.Tx, name string) error {
+	rows, err := pgQuery(ctx, tx,
+		"SELECT deleted_at IS NOT NULL FROM LocationRegistry WHERE name = $1 FOR UPDATE", name)
+	if err != nil {
+		return err
+	}
+	var deleted bool
+	exists := rows.Next()
+	if exists {
+		err = rows.Scan(&deleted)
+	}
+	rows.Close()
+	switch {
+	case err != nil:
+		return err
+	case exists && deleted:
+		return status.Errorf(codes.AlreadyExists, "name %q is in the trash; restore or purge it first", name)
+	case exists:
+		return status.Errorf(codes.AlreadyExists, "name %q already has an entry", name)
+	}
+	return nil
+}
+
+// UpdateLocation is SQLImpl.UpdateLocation, for Postgres.
+func (p *PostgresImpl) UpdateLocation(ctx context.Context, location *sipb.Location, fields []string) (*sipb.Location, error) {
+	updated := &sipb.Location{}
+	err := p.withTx(ctx, func(tx *sql.Tx) error {
+		rows, err := pgQuery(ctx, tx,
+			"SELECT id, name, parent, description, type FROM LocationRegistry WHERE id = $1 AND deleted_at IS NULL FOR UPDATE",
+			location.GetId())
+		if err != nil {
+			return err
+		}
+		found := rows.Next()
+		if found {
+			err = rows.Scan(&updated.Id, &updated.Name, &updated.Parent, &updated.Description, &updated.Type)
+		}
+		rows.Close()
+		if err != nil {
+			return err
+		}
+		if !found {
+			return status.Errorf(codes.NotFound, "location %d is not registered", location.GetId())
+		}
+		// Nothing changes, so no revision is recorded.
+		if len(fields) == 0 {
+			return nil
+		}
+
+		oldName, oldParent := updated.GetName(), updated.GetParent()
+		for _, field := range fields {
+			switch field {
+			case "name":
+				updated.Name = location.GetName()
+			case "parent":
+				updated.Parent = location.GetParent()
+			case "description":
+				updated.Description = location.GetDescription()
+			case "type":
+				updated.Type = location.GetType()
+			default:
+				return status.Errorf(codes.InvalidArgument, "unknown location field %q", field)
+			}
+		}
+		// Ancestors are still stored under the old name.
+		if updated.GetParent() != oldParent {
+			if err := pgCheckParent(ctx, tx, oldName, updated.GetParent()); err != nil {
+				return err
+			}
+		}
+		if updated.GetName() != oldName {
+			if err := pgCheckNameFree(ctx, tx, updated.GetName()); err != nil {
+				return err
+			}
+		}
+		if _, err := pgExec(ctx, tx,
+			"UPDATE LocationRegistry SET name = $1, parent = $2, description = $3, type = $4 WHERE id = $5",
+			updated.GetName(), updated.GetParent(), updated.GetDescription(), updated.GetType(), updated.GetId()); err != nil {
+			return err
+		}
+		now := changeTime()
+		if err := pgRecordLocations(ctx, tx, sipb.Change_UPDATED, now, "id = $3", updated.GetId()); err != nil {
+			return err
+		}
+		if updated.GetName() == oldName {
+			return nil
+		}
+		if _, err := pgExec(ctx, tx,
+			"UPDATE LocationRegistry SET parent = $1 WHERE parent = $2", updated.GetName(), oldName); err != nil {
+			return err
+		}
+		// Nested locations changed too, as they refer to their parent by name.
+		if err := pgRecordLocations(ctx, tx, sipb.Change_UPDATED, now, "parent = $3", updated.GetName()); err != nil {
+			return err
+		}
+		_, err = pgExec(ctx, tx, "UPDATE Stock SET location = $1 WHERE location = $2", updated.GetName(), oldName)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+// pgCheckParent is checkParent, for Postgres.
+func pgCheckParent(ctx context.Context, tx *sql.Tx, name, parent string) error {
+	if parent == "" {
+		return nil
+	}
+	seen := map[string]bool{}
+	for ancestor := parent; ancestor != "" && !seen[ancestor]; {
+		if ancestor == name {
+			return status.Errorf(codes.FailedPrecondition, "location %q can't be nested inside itself", name)
+		}
+		seen[ancestor] = true
+		rows, err := pgQuery(ctx, tx, "SELECT parent FROM LocationRegistry WHERE name = $1 AND deleted_at IS NULL FOR UPDATE", ancestor)
+		if err != nil {
+			return err
+		}
+		found := rows.Next()
+		if found {
+			err = rows.Scan(&ancestor)
+		}
+		rows.Close()
+		if err != nil {
+			return err
+		}
+		if !found {
+			if ancestor == parent {
+				return status.Errorf(codes.NotFound, "parent location %q is not registered", parent)
+			}
+			break
+		}
+	}
+	return nil
+}
+
+// ListLocations is SQLImpl.ListLocations, for Postgres.
+func (p *PostgresImpl) ListLocations(ctx context.Context) ([]*sipb.Location, error) {
+	var locations []*sipb.Location
+	err := p.retryRead(ctx, func() (err error) {
+		locations, err = p.listLocations(ctx)
+		return err
+	})
+	return locations, err
+}
+
+func (p *PostgresImpl) listLocations(ctx context.Context) ([]*sipb.Location, error) {
+	var retVal []*sipb.Location
+	rows, err := pgQuery(ctx, p.db, "SELECT id, name, parent, description, type FROM LocationRegistry WHERE deleted_at IS NULL ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		location := &sipb.Location{}
+		if err = rows.Scan(&location.Id, &location.Name, &location.Parent, &location.Description, &location.Type); err != nil {
+			return nil, err
+		}
+		retVal = append(retVal, location)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return retVal, nil
+}
+
+// DeleteLocation is SQLImpl.DeleteLocation, for Postgres.
+func (p *PostgresImpl) DeleteLocation(ctx context.Context, name string, contents sipb.ContentsPolicy, target string) ([]*sipb.StockChange, error) {
+	var changes []*sipb.StockChange
+	err := p.withTx(ctx, func(tx *sql.Tx) error {
+		rows, err := pgQuery(ctx, tx, "SELECT name FROM LocationRegistry WHERE parent = $1 AND deleted_at IS NULL LIMIT 1 FOR UPDATE", name)
+		if err != nil {
+			return err
+		}
+		var child string
+		nested := rows.Next()
+		if nested {
+			err = rows.Scan(&child)
+		}
+		rows.Close()
+		if err != nil {
+			return err
+		}
+		if nested {
+			return status.Errorf(codes.FailedPrecondition,
+				"location %q contains %q; move or delete it first", name, child)
+		}
+		if changes, err = pgClearStock(ctx, tx, name, contents, target); err != nil {
+			return err
+		}
+		now := changeTime()
+		res, err := pgExec(ctx, tx,
+			"UPDATE LocationRegistry SET deleted_at = $1 WHERE name = $2 AND deleted_at IS NULL", now, name)
+		if err != nil {
+			return err
+		}
+		if n, err := res.RowsAffected(); err != nil || n == 0 {
+			return err
+		}
+		return pgRecordLocations(ctx, tx, sipb.Change_DELETED, now, "name = $3", name)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
@@ -0,0 +1,163 @@
+/*
+Copyright 2020 Robert Barron
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package connector
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/lib/pq"
+	sipb "github.com/rmbarron/SnackInventory/src/proto/snackinventory"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Postgres takes lists of values as array parameters, so unlike SQLImpl,
+// batches aren't split across statements.
+
+// pgExistingBarcodes is existingBarcodes, for Postgres.
+func pgExistingBarcodes(ctx context.Context, tx *sql.Tx, barcodes []string) (map[string]bool, error) {
+	rows, err := pgQuery(ctx, tx,
+		"SELECT barcode, deleted_at IS NOT NULL FROM SnackRegistry WHERE barcode = ANY($1) FOR UPDATE", pq.Array(barcodes))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	existing := map[string]bool{}
+	for rows.Next() {
+		var b string
+		var deleted bool
+		if err := rows.Scan(&b, &deleted); err != nil {
+			return nil, err
+		}
+		existing[b] = deleted
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return existing, nil
+}
+
+// BatchCreateSnacks is SQLImpl.BatchCreateSnacks, for Postgres.
+func (p *PostgresImpl) BatchCreateSnacks(ctx context.Context, snacks []*sipb.Snack) error {
+	if len(snacks) == 0 {
+		return nil
+	}
+	var barcodes, names, brands, categories, packageSizes []string
+	seen := map[string]bool{}
+	for i, snack := range snacks {
+		b := snack.GetBarcode()
+		if seen[b] {
+			return &BatchError{i, status.Errorf(codes.AlreadyExists, "barcode %q is repeated in the batch", b)}
+		}
+		seen[b] = true
+		barcodes = append(barcodes, b)
+		names = append(names, snack.GetName())
+		brands = append(brands, snack.GetBrand())
+		categories = append(categories, snack.GetCategory())
+		packageSizes = append(packageSizes, snack.GetPackageSize())
+	}
+
+	return p.withTx(ctx, func(tx *sql.Tx) error {
+		existing, err := pgExistingBarcodes(ctx, tx, barcodes)
+		if err != nil {
+			return err
+		}
+		for i, b := range barcodes {
+			if deleted, ok := existing[b]; ok {
+				return &BatchError{i, snackExistsError(b, deleted)}
+			}
+		}
+
+		if _, err := pgExec(ctx, tx,
+			"INSERT INTO SnackRegistry (barcode, name, brand, category, package_size)"+
+				" SELECT * FROM unnest($1::VARCHAR[], $2::VARCHAR[], $3::VARCHAR[], $4::VARCHAR[], $5::VARCHAR[])",
+			pq.Array(barcodes), pq.Array(names), pq.Array(brands), pq.Array(categories), pq.Array(packageSizes)); err != nil {
+			return err
+		}
+		return pgRecordSnacks(ctx, tx, sipb.Change_CREATED, changeTime(), barcodes)
+	})
+}
+
+// BatchUpdateSnacks is SQLImpl.BatchUpdateSnacks, for Postgres.
+func (p *PostgresImpl) BatchUpdateSnacks(ctx context.Context, snacks []*sipb.Snack) error {
+	if len(snacks) == 0 {
+		return nil
+	}
+	barcodes := make([]string, len(snacks))
+	for i, snack := range snacks {
+		barcodes[i] = snack.GetBarcode()
+	}
+
+	return p.withTx(ctx, func(tx *sql.Tx) error {
+		existing, err := pgExistingBarcodes(ctx, tx, barcodes)
+		if err != nil {
+			return err
+		}
+		for i, b := range barcodes {
+			if deleted, ok := existing[b]; !ok || deleted {
+				return &BatchError{i, status.Errorf(codes.NotFound, "barcode %q is not registered", b)}
+			}
+		}
+
+		// Updated in order, so the last of a repeated barcode wins.
+		stmt, err := tx.PrepareContext(ctx, pgUpdateSnackStatement)
+		if err != nil {
+			return err
+		}
+		defer stmt.Close()
+		for i, snack := range snacks {
+			if _, err := stmt.ExecContext(ctx, updateSnackArgs(snack)...); err != nil {
+				return &BatchError{i, err}
+			}
+		}
+		return pgRecordSnacks(ctx, tx, sipb.Change_UPDATED, changeTime(), barcodes)
+	})
+}
+
+// BatchDeleteSnacks is SQLImpl.BatchDeleteSnacks, for Postgres.
+func (p *PostgresImpl) BatchDeleteSnacks(ctx context.Context, barcodes []string) error {
+	if len(barcodes) == 0 {
+		return nil
+	}
+	return p.withTx(ctx, func(tx *sql.Tx) error {
+		// Only snacks not already in the trash are deleted, & recorded as such.
+		existing, err := pgExistingBarcodes(ctx, tx, barcodes)
+		if err != nil {
+			return err
+		}
+		var live []string
+		for _, b := range barcodes {
+			if deleted, ok := existing[b]; ok && !deleted {
+				live = append(live, b)
+				// Repeated barcodes are only recorded once.
+				existing[b] = true
+			}
+		}
+		if len(live) == 0 {
+			return nil
+		}
+
+		now := changeTime()
+		if _, err := pgExec(ctx, tx,
+			"UPDATE SnackRegistry SET deleted_at = $1 WHERE barcode = ANY($2)", now, pq.Array(live)); err != nil {
+			return err
+		}
+		return pgRecordSnacks(ctx, tx, sipb.Change_DELETED, now, live)
+	})
+}
@@ -0,0 +1,163 @@
+/*
+Copyright 2020 Robert Barron
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package connector
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/lib/pq"
+	sipb "github.com/rmbarron/SnackInventory/src/proto/snackinventory"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// pgRecordSnacks is recordSnacks, for Postgres.
+func pgRecordSnacks(ctx context.Context, tx *sql.Tx, typ sipb.Change_Type, at time.Time, barcodes []string) error {
+	// Placeholders in a SELECT list are untyped, so are cast explicitly.
+	_, err := pgExec(ctx, tx,
+		"INSERT INTO SnackRevisions (barcode, name, brand, category, package_size, change_type, revision_time)"+
+			" SELECT barcode, name, brand, category, package_size, $1::INTEGER, $2::TIMESTAMPTZ FROM SnackRegistry"+
+			" WHERE barcode = ANY($3) AND "+deletedCondition(typ),
+		typ, at, pq.Array(barcodes))
+	return err
+}
+
+// pgRecordLocations is recordLocations, for Postgres. Placeholders in where
+// start at $3.
+func pgRecordLocations(ctx context.Context, tx *sql.Tx, typ sipb.Change_Type, at time.Time, where string, args ...interface{}) error {
+	_, err := pgExec(ctx, tx,
+		"INSERT INTO LocationRevisions (location_id, name, parent, description, type, change_type, revision_time)"+
+			" SELECT id, name, parent, description, type, $1::INTEGER, $2::TIMESTAMPTZ FROM LocationRegistry"+
+			" WHERE ("+where+") AND "+deletedCondition(typ),
+		append([]interface{}{typ, at}, args...)...)
+	return err
+}
+
+// GetSnackHistory is SQLImpl.GetSnackHistory, for Postgres.
+func (p *PostgresImpl) GetSnackHistory(ctx context.Context, barcode string) ([]*sipb.SnackRevision, error) {
+	var revisions []*sipb.SnackRevision
+	err := p.retryRead(ctx, func() (err error) {
+		revisions, err = p.getSnackHistory(ctx, barcode)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(revisions) == 0 {
+		return nil, status.Errorf(codes.NotFound, "barcode %q has no history", barcode)
+	}
+	return revisions, nil
+}
+
+func (p *PostgresImpl) getSnackHistory(ctx context.Context, barcode string) ([]*sipb.SnackRevision, error) {
+	var retVal []*sipb.SnackRevision
+	rows, err := pgQuery(ctx, p.db,
+		"SELECT barcode, name, brand, category, package_size, change_type, revision_time FROM SnackRevisions"+
+			" WHERE barcode = $1 ORDER BY id", barcode)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		snack := &sipb.Snack{}
+		rev := &sipb.SnackRevision{Snack: snack}
+		var revisionTime time.Time
+		if err = rows.Scan(&snack.Barcode, &snack.Name, &snack.Brand, &snack.Category, &snack.PackageSize, &rev.Type, &revisionTime); err != nil {
+			return nil, err
+		}
+		rev.RevisionTime = timestamppb.New(revisionTime)
+		retVal = append(retVal, rev)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return retVal, nil
+}
+
+// ListSnacksAsOf is SQLImpl.ListSnacksAsOf, for Postgres.
+func (p *PostgresImpl) ListSnacksAsOf(ctx context.Context, asOf time.Time) ([]*sipb.Snack, error) {
+	var snacks []*sipb.Snack
+	err := p.retryRead(ctx, func() (err error) {
+		snacks, err = p.listSnacksAsOf(ctx, asOf)
+		return err
+	})
+	return snacks, err
+}
+
+func (p *PostgresImpl) listSnacksAsOf(ctx context.Context, asOf time.Time) ([]*sipb.Snack, error) {
+	var retVal []*sipb.Snack
+	rows, err := pgQuery(ctx, p.db,
+		"SELECT r.barcode, r.name, r.brand, r.category, r.package_size FROM SnackRevisions r"+
+			" JOIN (SELECT MAX(id) AS id FROM SnackRevisions WHERE revision_time <= $1 GROUP BY barcode) latest"+
+			" ON r.id = latest.id WHERE r.change_type != $2 ORDER BY r.barcode",
+		asOf, sipb.Change_DELETED)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		snack := &sipb.Snack{}
+		if err = rows.Scan(&snack.Barcode, &snack.Name, &snack.Brand, &snack.Category, &snack.PackageSize); err != nil {
+			return nil, err
+		}
+		retVal = append(retVal, snack)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return retVal, nil
+}
+
+// ListLocationsAsOf is SQLImpl.ListLocationsAsOf, for Postgres.
+func (p *PostgresImpl) ListLocationsAsOf(ctx context.Context, asOf time.Time) ([]*sipb.Location, error) {
+	var locations []*sipb.Location
+	err := p.retryRead(ctx, func() (err error) {
+		locations, err = p.listLocationsAsOf(ctx, asOf)
+		return err
+	})
+	return locations, err
+}
+
+func (p *PostgresImpl) listLocationsAsOf(ctx context.Context, asOf time.Time) ([]*sipb.Location, error) {
+	var retVal []*sipb.Location
+	rows, err := pgQuery(ctx, p.db,
+		"SELECT r.location_id, r.name, r.parent, r.description, r.type FROM LocationRevisions r"+
+			" JOIN (SELECT MAX(id) AS id FROM LocationRevisions WHERE revision_time <= $1 GROUP BY location_id) latest"+
+			" ON r.id = latest.id WHERE r.change_type != $2 ORDER BY r.location_id",
+		asOf, sipb.Change_DELETED)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		location := &sipb.Location{}
+		if err = rows.Scan(&location.Id, &location.Name, &location.Parent, &location.Description, &location.Type); err != nil {
+			return nil, err
+		}
+		retVal = append(retVal, location)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return retVal, nil
+}
@@ -0,0 +1,196 @@
+/*
+Copyright 2020 Robert Barron
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package connector
+
+import (
+	"context"
+	"database/sql"
+
+	sipb "github.com/rmbarron/SnackInventory/src/proto/snackinventory"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// pgAddStockStatement adds $3 to the count of snack $1 at location $2,
+// returning the new count.
+const pgAddStockStatement = "INSERT INTO Stock (barcode, location, count) VALUES($1, $2, $3)" +
+	" ON CONFLICT (barcode, location) DO UPDATE SET count = Stock.count + EXCLUDED.count RETURNING count"
+
+// pgAddStock runs pgAddStockStatement.
+func pgAddStock(ctx context.Context, tx *sql.Tx, barcode, location string, delta int32) (int32, error) {
+	rows, err := pgQuery(ctx, tx, pgAddStockStatement, barcode, location, delta)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+	var count int32
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return 0, err
+		}
+		return 0, sql.ErrNoRows
+	}
+	err = rows.Scan(&count)
+	return count, err
+}
+
+// AdjustStock is SQLImpl.AdjustStock, for Postgres.
+func (p *PostgresImpl) AdjustStock(ctx context.Context, barcode, location string, delta int32) (int32, error) {
+	var count int32
+	err := p.withTx(ctx, func(tx *sql.Tx) error {
+		existing, err := pgExistingBarcodes(ctx, tx, []string{barcode})
+		if err != nil {
+			return err
+		}
+		if deleted, ok := existing[barcode]; !ok || deleted {
+			return status.Errorf(codes.NotFound, "barcode %q is not registered", barcode)
+		}
+		rows, err := pgQuery(ctx, tx, "SELECT name FROM LocationRegistry WHERE name = $1 AND deleted_at IS NULL FOR UPDATE", location)
+		if err != nil {
+			return err
+		}
+		found := rows.Next()
+		rows.Close()
+		if !found {
+			return status.Errorf(codes.NotFound, "location %q is not registered", location)
+		}
+
+		// The upsert applies delta atomically; a negative result is rolled back.
+		if count, err = pgAddStock(ctx, tx, barcode, location, delta); err != nil {
+			return err
+		}
+		switch {
+		case count < 0:
+			return status.Errorf(codes.FailedPrecondition,
+				"only %d of %q at %q, can't remove %d", count-delta, barcode, location, -delta)
+		case count == 0:
+			_, err = pgExec(ctx, tx, "DELETE FROM Stock WHERE barcode = $1 AND location = $2", barcode, location)
+		}
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// pgClearStock is clearStock, for Postgres.
+func pgClearStock(ctx context.Context, tx *sql.Tx, location string, contents sipb.ContentsPolicy, target string) ([]*sipb.StockChange, error) {
+	if contents == sipb.ContentsPolicy_MOVE {
+		if target == location {
+			return nil, status.Errorf(codes.InvalidArgument, "can't move stock from %q to itself", location)
+		}
+		rows, err := pgQuery(ctx, tx, "SELECT name FROM LocationRegistry WHERE name = $1 AND deleted_at IS NULL FOR UPDATE", target)
+		if err != nil {
+			return nil, err
+		}
+		found := rows.Next()
+		rows.Close()
+		if !found {
+			return nil, status.Errorf(codes.NotFound, "location %q is not registered", target)
+		}
+	}
+
+	rows, err := pgQuery(ctx, tx,
+		"SELECT barcode, count FROM Stock WHERE location = $1 ORDER BY barcode FOR UPDATE", location)
+	if err != nil {
+		return nil, err
+	}
+	var stock []*sipb.Stock
+	for rows.Next() {
+		st := &sipb.Stock{Location: location}
+		if err = rows.Scan(&st.Barcode, &st.Count); err != nil {
+			break
+		}
+		stock = append(stock, st)
+	}
+	if err == nil {
+		err = rows.Err()
+	}
+	rows.Close()
+	if err != nil || len(stock) == 0 {
+		return nil, err
+	}
+
+	reason := sipb.StockChange_DISCARDED
+	switch contents {
+	case sipb.ContentsPolicy_MOVE:
+		reason = sipb.StockChange_MOVED
+	case sipb.ContentsPolicy_DISCARD:
+	default:
+		return nil, status.Errorf(codes.FailedPrecondition,
+			"location %q holds %d snacks; move or discard them first", location, len(stock))
+	}
+	var changes []*sipb.StockChange
+	for _, st := range stock {
+		changes = append(changes, &sipb.StockChange{
+			Barcode: st.Barcode, Location: location, Delta: -st.Count, Reason: reason,
+		})
+		if reason != sipb.StockChange_MOVED {
+			continue
+		}
+		count, err := pgAddStock(ctx, tx, st.Barcode, target, st.Count)
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, &sipb.StockChange{
+			Barcode: st.Barcode, Location: target, Delta: st.Count, Count: count, Reason: reason,
+		})
+	}
+	if _, err := pgExec(ctx, tx, "DELETE FROM Stock WHERE location = $1", location); err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
+
+// ListStock is SQLImpl.ListStock, for Postgres.
+func (p *PostgresImpl) ListStock(ctx context.Context, location string) ([]*sipb.Stock, error) {
+	var stock []*sipb.Stock
+	err := p.retryRead(ctx, func() (err error) {
+		stock, err = p.listStock(ctx, location)
+		return err
+	})
+	return stock, err
+}
+
+func (p *PostgresImpl) listStock(ctx context.Context, location string) ([]*sipb.Stock, error) {
+	query := "SELECT barcode, location, count FROM Stock" +
+		" WHERE barcode NOT IN (SELECT barcode FROM SnackRegistry WHERE deleted_at IS NOT NULL)"
+	var args []interface{}
+	if location != "" {
+		query += " AND location = $1"
+		args = append(args, location)
+	}
+	rows, err := pgQuery(ctx, p.db, query+" ORDER BY location, barcode", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var retVal []*sipb.Stock
+	for rows.Next() {
+		st := &sipb.Stock{}
+		if err = rows.Scan(&st.Barcode, &st.Location, &st.Count); err != nil {
+			return nil, err
+		}
+		retVal = append(retVal, st)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return retVal, nil
+}
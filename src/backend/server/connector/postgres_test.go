@@ -0,0 +1,44 @@
+/*
+Copyright 2020 Robert Barron
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package connector_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/rmbarron/SnackInventory/src/backend/server/connector"
+	"github.com/rmbarron/SnackInventory/src/backend/server/connector/connectortest"
+	"github.com/rmbarron/SnackInventory/src/backend/server/testutils"
+)
+
+// TestPostgresConformance runs the conformance suite against PostgresImpl,
+// backed by a postgres instance.
+func TestPostgresConformance(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	db, close := testutils.StartPostgresT(ctx, t)
+	defer close()
+
+	connectortest.RunConformance(t, func(t *testing.T) connector.Store {
+		// Tables are recreated, so every Store starts empty.
+		if err := testutils.ResetPostgresTables(ctx, db); err != nil {
+			t.Fatalf("ResetPostgresTables() = got err %v, want err nil", err)
+		}
+		return connector.NewPostgresImplForTest(db)
+	})
+}
@@ -0,0 +1,209 @@
+/*
+Copyright 2020 Robert Barron
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package connector
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"time"
+
+	sipb "github.com/rmbarron/SnackInventory/src/proto/snackinventory"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// ListDeleted is SQLImpl.ListDeleted, for Postgres.
+func (p *PostgresImpl) ListDeleted(ctx context.Context) ([]*sipb.DeletedEntity, error) {
+	var deleted []*sipb.DeletedEntity
+	err := p.retryRead(ctx, func() (err error) {
+		deleted, err = p.listDeleted(ctx)
+		return err
+	})
+	return deleted, err
+}
+
+func (p *PostgresImpl) listDeleted(ctx context.Context) ([]*sipb.DeletedEntity, error) {
+	var retVal []*sipb.DeletedEntity
+	rows, err := pgQuery(ctx, p.db,
+		"SELECT barcode, name, brand, category, package_size, deleted_at FROM SnackRegistry"+
+			" WHERE deleted_at IS NOT NULL ORDER BY barcode")
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		snack := &sipb.Snack{}
+		var deletedAt time.Time
+		if err = rows.Scan(&snack.Barcode, &snack.Name, &snack.Brand, &snack.Category, &snack.PackageSize, &deletedAt); err != nil {
+			break
+		}
+		retVal = append(retVal, &sipb.DeletedEntity{
+			Entity:     &sipb.DeletedEntity_Snack{Snack: snack},
+			DeleteTime: timestamppb.New(deletedAt),
+		})
+	}
+	if err == nil {
+		err = rows.Err()
+	}
+	rows.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err = pgQuery(ctx, p.db,
+		"SELECT id, name, parent, description, type, deleted_at FROM LocationRegistry"+
+			" WHERE deleted_at IS NOT NULL ORDER BY name")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		location := &sipb.Location{}
+		var deletedAt time.Time
+		if err = rows.Scan(&location.Id, &location.Name, &location.Parent, &location.Description, &location.Type, &deletedAt); err != nil {
+			return nil, err
+		}
+		retVal = append(retVal, &sipb.DeletedEntity{
+			Entity:     &sipb.DeletedEntity_Location{Location: location},
+			DeleteTime: timestamppb.New(deletedAt),
+		})
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(retVal, func(i, j int) bool {
+		return retVal[i].GetDeleteTime().AsTime().After(retVal[j].GetDeleteTime().AsTime())
+	})
+	return retVal, nil
+}
+
+// UndeleteSnack is SQLImpl.UndeleteSnack, for Postgres.
+func (p *PostgresImpl) UndeleteSnack(ctx context.Context, barcode string) (*sipb.Snack, error) {
+	snack := &sipb.Snack{}
+	err := p.withTx(ctx, func(tx *sql.Tx) error {
+		rows, err := pgQuery(ctx, tx,
+			"SELECT barcode, name, brand, category, package_size FROM SnackRegistry"+
+				" WHERE barcode = $1 AND deleted_at IS NOT NULL FOR UPDATE", barcode)
+		if err != nil {
+			return err
+		}
+		found := rows.Next()
+		if found {
+			err = rows.Scan(&snack.Barcode, &snack.Name, &snack.Brand, &snack.Category, &snack.PackageSize)
+		}
+		rows.Close()
+		if err != nil {
+			return err
+		}
+		if !found {
+			return status.Errorf(codes.NotFound, "barcode %q is not in the trash", barcode)
+		}
+		if _, err := pgExec(ctx, tx, "UPDATE SnackRegistry SET deleted_at = NULL WHERE barcode = $1", barcode); err != nil {
+			return err
+		}
+		return pgRecordSnacks(ctx, tx, sipb.Change_CREATED, changeTime(), []string{barcode})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return snack, nil
+}
+
+// UndeleteLocation is SQLImpl.UndeleteLocation, for Postgres.
+func (p *PostgresImpl) UndeleteLocation(ctx context.Context, name string) (*sipb.Location, error) {
+	location := &sipb.Location{}
+	err := p.withTx(ctx, func(tx *sql.Tx) error {
+		rows, err := pgQuery(ctx, tx,
+			"SELECT id, name, parent, description, type FROM LocationRegistry"+
+				" WHERE name = $1 AND deleted_at IS NOT NULL FOR UPDATE", name)
+		if err != nil {
+			return err
+		}
+		found := rows.Next()
+		if found {
+			err = rows.Scan(&location.Id, &location.Name, &location.Parent, &location.Description, &location.Type)
+		}
+		rows.Close()
+		if err != nil {
+			return err
+		}
+		if !found {
+			return status.Errorf(codes.NotFound, "location %q is not in the trash", name)
+		}
+
+		if location.GetParent() != "" {
+			rows, err := pgQuery(ctx, tx,
+				"SELECT deleted_at IS NOT NULL FROM LocationRegistry WHERE name = $1 FOR UPDATE", location.GetParent())
+			if err != nil {
+				return err
+			}
+			var parentDeleted bool
+			parentFound := rows.Next()
+			if parentFound {
+				err = rows.Scan(&parentDeleted)
+			}
+			rows.Close()
+			switch {
+			case err != nil:
+				return err
+			case parentDeleted:
+				return status.Errorf(codes.FailedPrecondition,
+					"parent location %q of %q is in the trash; restore it first", location.GetParent(), name)
+			case !parentFound:
+				location.Parent = ""
+			}
+		}
+		if _, err := pgExec(ctx, tx,
+			"UPDATE LocationRegistry SET parent = $1, deleted_at = NULL WHERE id = $2", location.GetParent(), location.GetId()); err != nil {
+			return err
+		}
+		return pgRecordLocations(ctx, tx, sipb.Change_CREATED, changeTime(), "id = $3", location.GetId())
+	})
+	if err != nil {
+		return nil, err
+	}
+	return location, nil
+}
+
+// PurgeDeleted is SQLImpl.PurgeDeleted, for Postgres.
+func (p *PostgresImpl) PurgeDeleted(ctx context.Context, before time.Time) (snacks, locations int64, err error) {
+	err = p.withTx(ctx, func(tx *sql.Tx) error {
+		if _, err := pgExec(ctx, tx,
+			"DELETE FROM Stock WHERE barcode IN (SELECT barcode FROM SnackRegistry WHERE deleted_at < $1)", before); err != nil {
+			return err
+		}
+		res, err := pgExec(ctx, tx, "DELETE FROM SnackRegistry WHERE deleted_at < $1", before)
+		if err != nil {
+			return err
+		}
+		if snacks, err = res.RowsAffected(); err != nil {
+			return err
+		}
+		res, err = pgExec(ctx, tx, "DELETE FROM LocationRegistry WHERE deleted_at < $1", before)
+		if err != nil {
+			return err
+		}
+		locations, err = res.RowsAffected()
+		return err
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+	return snacks, locations, nil
+}
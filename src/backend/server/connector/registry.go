@@ -0,0 +1,79 @@
+/*
+Copyright 2020 Robert Barron
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package connector
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Factory configures & opens the Store of a storage backend.
+type Factory interface {
+	// RegisterFlags defines the flags configuring the backend in fs. Flag names
+	// are also the backend's config file keys & environment variables, so are
+	// prefixed to not collide with those of other backends (ex: sql_user).
+	// Keys ending in "password" are secrets; see config.SecretKey.
+	RegisterFlags(fs *flag.FlagSet)
+	// Validate checks the values of the flags, returning config.Errors.
+	Validate() error
+	// Open connects to the backend, per the flags.
+	Open(ctx context.Context) (Store, error)
+}
+
+var (
+	mu        sync.Mutex
+	factories = map[string]Factory{}
+)
+
+// Register makes a storage backend available under name, ex: to be selected
+// by the server's storage_architecture flag. It is meant to be called from the
+// init function of the backend's package, & panics if name is already
+// registered.
+func Register(name string, factory Factory) {
+	mu.Lock()
+	defer mu.Unlock()
+	if factory == nil {
+		panic(fmt.Sprintf("connector: Register of nil factory for backend %q", name))
+	}
+	if _, ok := factories[name]; ok {
+		panic(fmt.Sprintf("connector: Register called twice for backend %q", name))
+	}
+	factories[name] = factory
+}
+
+// Lookup returns the factory of the backend registered under name.
+func Lookup(name string) (Factory, bool) {
+	mu.Lock()
+	defer mu.Unlock()
+	f, ok := factories[name]
+	return f, ok
+}
+
+// Backends returns the names of the registered backends, sorted.
+func Backends() []string {
+	mu.Lock()
+	defer mu.Unlock()
+	names := make([]string, 0, len(factories))
+	for name := range factories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,168 @@
+/*
+Copyright 2020 Robert Barron
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package connector
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/rmbarron/SnackInventory/src/backend/server/config"
+)
+
+type fakeFactory struct{}
+
+func (fakeFactory) RegisterFlags(fs *flag.FlagSet)          {}
+func (fakeFactory) Validate() error                         { return nil }
+func (fakeFactory) Open(ctx context.Context) (Store, error) { return nil, errors.New("unimplemented") }
+
+func TestRegister(t *testing.T) {
+	Register("registry_test", fakeFactory{})
+	defer func() {
+		mu.Lock()
+		delete(factories, "registry_test")
+		mu.Unlock()
+	}()
+
+	if _, ok := Lookup("registry_test"); !ok {
+		t.Fatalf("Lookup(%q) = got ok false, want true", "registry_test")
+	}
+	if _, ok := Lookup("nope"); ok {
+		t.Fatalf("Lookup(%q) = got ok true, want false", "nope")
+	}
+	want := []string{"bolt", "mysql", "postgres", "registry_test"}
+	if diff := cmp.Diff(want, Backends()); diff != "" {
+		t.Errorf("Backends() = got diff (-want +got): %s", diff)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Register(%q, ...) again = got no panic, want panic", "registry_test")
+		}
+	}()
+	Register("registry_test", fakeFactory{})
+}
+
+// newSQLFactoryT returns a sqlFactory with its flags set per args.
+func newSQLFactoryT(t *testing.T, stdin string, args ...string) *sqlFactory {
+	t.Helper()
+	f := &sqlFactory{stdin: strings.NewReader(stdin)}
+	fs := flag.NewFlagSet("mysql", flag.ContinueOnError)
+	f.RegisterFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		t.Fatalf("fs.Parse(%q) = got err %v, want err nil", args, err)
+	}
+	return f
+}
+
+func TestSQLFactory_Validate(t *testing.T) {
+	f := newSQLFactoryT(t, "", "-sql_user=user", "-sql_address=localhost:3306")
+	if err := f.Validate(); err != nil {
+		t.Fatalf("f.Validate() = got err %v, want err nil", err)
+	}
+}
+
+func TestSQLFactory_ValidateReportsAllErrors(t *testing.T) {
+	f := newSQLFactoryT(t, "", "-sql_password=pass", "-sql_password_file=/pass.txt", "-sql_read_retries=-1")
+
+	err := f.Validate()
+	var errs config.Errors
+	if !errors.As(err, &errs) {
+		t.Fatalf("f.Validate() = got err %v, want config.Errors", err)
+	}
+	for _, key := range []string{"sql_user", "sql_address", "sql_password_file", "sql_read_retries"} {
+		if !strings.Contains(err.Error(), key+":") {
+			t.Errorf("f.Validate() = got err %q, want mention of %q", err, key)
+		}
+	}
+}
+
+func TestSQLFactory_ReadPassword(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pass.txt")
+	if err := ioutil.WriteFile(path, []byte("from_file\n"), 0600); err != nil {
+		t.Fatalf("ioutil.WriteFile(%q) = got err %v, want err nil", path, err)
+	}
+
+	tests := []struct {
+		desc  string
+		args  []string
+		stdin string
+		want  string
+	}{
+		{"file", []string{"-sql_password_file=" + path}, "from_stdin\n", "from_file"},
+		{"config", []string{"-sql_password=from_config"}, "from_stdin\n", "from_config"},
+		{"stdin", nil, "from_stdin\nignored\n", "from_stdin"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			got, err := newSQLFactoryT(t, tc.stdin, tc.args...).readPassword()
+			if err != nil {
+				t.Fatalf("f.readPassword() = got err %v, want err nil", err)
+			}
+			if got != tc.want {
+				t.Fatalf("f.readPassword() = got %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestSQLFactory_ReadPasswordMissingFile(t *testing.T) {
+	f := newSQLFactoryT(t, "", "-sql_password_file="+filepath.Join(os.TempDir(), "does-not-exist", "pass.txt"))
+	if _, err := f.readPassword(); err == nil {
+		t.Fatal("f.readPassword() = got err nil, want err")
+	}
+}
+
+// newPGFactoryT returns a pgFactory with its flags set per args.
+func newPGFactoryT(t *testing.T, args ...string) *pgFactory {
+	t.Helper()
+	f := &pgFactory{stdin: strings.NewReader("")}
+	fs := flag.NewFlagSet("postgres", flag.ContinueOnError)
+	f.RegisterFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		t.Fatalf("fs.Parse(%q) = got err %v, want err nil", args, err)
+	}
+	return f
+}
+
+func TestPGFactory_Validate(t *testing.T) {
+	f := newPGFactoryT(t, "-pg_user=user", "-pg_address=localhost:5432", "-pg_sslmode=verify-full")
+	if err := f.Validate(); err != nil {
+		t.Fatalf("f.Validate() = got err %v, want err nil", err)
+	}
+}
+
+func TestPGFactory_ValidateReportsAllErrors(t *testing.T) {
+	f := newPGFactoryT(t, "-pg_sslmode=prefer", "-pg_password=pass", "-pg_password_file=/pass.txt", "-pg_read_retries=-1")
+
+	err := f.Validate()
+	var errs config.Errors
+	if !errors.As(err, &errs) {
+		t.Fatalf("f.Validate() = got err %v, want config.Errors", err)
+	}
+	for _, key := range []string{"pg_user", "pg_address", "pg_sslmode", "pg_password_file", "pg_read_retries"} {
+		if !strings.Contains(err.Error(), key+":") {
+			t.Errorf("f.Validate() = got err %q, want mention of %q", err, key)
+		}
+	}
+}
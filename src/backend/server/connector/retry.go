@@ -0,0 +1,189 @@
+/*
+Copyright 2020 Robert Barron
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package connector
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"net"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// MySQL error numbers that indicate a transaction may succeed if retried.
+const (
+	mysqlErrDupEntry        = 1062
+	mysqlErrLockWaitTimeout = 1205
+	mysqlErrDeadlock        = 1213
+)
+
+// defaultRetryBackoff is the initial delay between retries when
+// SQLOptions.RetryBackoff is unset.
+const defaultRetryBackoff = 100 * time.Millisecond
+
+// maxRetryBackoff caps the exponentially growing delay between retries.
+const maxRetryBackoff = 5 * time.Second
+
+// maxTxAttempts bounds how many times a transaction is run while it is rolled
+// back by conflicts with concurrent transactions.
+const maxTxAttempts = 5
+
+// SQLOptions tunes the connection pool and retry behavior of SQLImpl.
+// The zero value keeps database/sql pool defaults and never retries.
+type SQLOptions struct {
+	// MaxOpenConns limits open connections to the DB. Unlimited if <= 0.
+	MaxOpenConns int
+	// MaxIdleConns limits idle connections kept in the pool. The
+	// database/sql default (2) is used if <= 0.
+	MaxIdleConns int
+	// ConnMaxLifetime closes connections after they have been open this long.
+	// Connections are reused forever if <= 0.
+	ConnMaxLifetime time.Duration
+
+	// StartupTimeout bounds how long NewSQLImpl waits for the DB to accept
+	// connections, retrying with backoff. A single attempt is made if <= 0.
+	StartupTimeout time.Duration
+	// ReadRetries is how many times an idempotent read (the List methods &
+	// GetSnackHistory) is retried after a transient error.
+	ReadRetries int
+	// RetryBackoff is the delay before the first retry, doubling for each
+	// subsequent retry. Defaults to 100ms if <= 0.
+	RetryBackoff time.Duration
+}
+
+// applyPool configures db's connection pool from o.
+func (o SQLOptions) applyPool(db *sql.DB) {
+	if o.MaxOpenConns > 0 {
+		db.SetMaxOpenConns(o.MaxOpenConns)
+	}
+	if o.MaxIdleConns > 0 {
+		db.SetMaxIdleConns(o.MaxIdleConns)
+	}
+	if o.ConnMaxLifetime > 0 {
+		db.SetConnMaxLifetime(o.ConnMaxLifetime)
+	}
+}
+
+// backoff returns the delay before retry number attempt (starting at 0).
+func (o SQLOptions) backoff(attempt int) time.Duration {
+	d := o.RetryBackoff
+	if d <= 0 {
+		d = defaultRetryBackoff
+	}
+	for i := 0; i < attempt && d < maxRetryBackoff; i++ {
+		d *= 2
+	}
+	if d > maxRetryBackoff {
+		d = maxRetryBackoff
+	}
+	return d
+}
+
+// isTransient reports whether err may succeed if the operation is retried:
+// dropped or refused connections (ex: the DB restarted) and lock conflicts.
+func isTransient(err error) bool {
+	if errors.Is(err, driver.ErrBadConn) || errors.Is(err, mysql.ErrInvalidConn) {
+		return true
+	}
+	if isLockConflict(err) {
+		return true
+	}
+	var netErr *net.OpError
+	return errors.As(err, &netErr)
+}
+
+// isLockConflict reports whether err is from a lock conflict with a concurrent
+// transaction (ex: a deadlock), so may succeed if the transaction is run again.
+func isLockConflict(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	return errors.As(err, &mysqlErr) &&
+		(mysqlErr.Number == mysqlErrDeadlock || mysqlErr.Number == mysqlErrLockWaitTimeout)
+}
+
+// sleepCtx waits for d, returning early with ctx's error if ctx is done first.
+func sleepCtx(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// retryRead runs op, retrying up to s.opts.ReadRetries times while it fails
+// with a transient error. op must be idempotent.
+func (s *SQLImpl) retryRead(ctx context.Context, op func() error) error {
+	return s.opts.retry(ctx, s.opts.ReadRetries, isTransient, op)
+}
+
+// retry runs op, retrying with backoff up to retries times while it fails with
+// an error for which retryable is true.
+func (o SQLOptions) retry(ctx context.Context, retries int, retryable func(error) bool, op func() error) error {
+	err := op()
+	for attempt := 0; attempt < retries && retryable(err); attempt++ {
+		if serr := sleepCtx(ctx, o.backoff(attempt)); serr != nil {
+			return err
+		}
+		err = op()
+	}
+	return err
+}
+
+// isTxConflict reports whether err is from a conflict with a concurrent
+// transaction: a lock conflict, or inserting a key that it inserted first
+// after this transaction checked for it. Run again, the transaction sees the
+// other's changes.
+func isTxConflict(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	return isLockConflict(err) || (errors.As(err, &mysqlErr) && mysqlErr.Number == mysqlErrDupEntry)
+}
+
+// retryTx runs tx, an attempt at a whole transaction, again while it fails
+// with a conflict, up to maxTxAttempts times. Unlike retryRead, other
+// transient errors aren't retried, as the transaction may have committed.
+func (s *SQLImpl) retryTx(ctx context.Context, tx func() error) error {
+	return s.opts.retry(ctx, maxTxAttempts-1, isTxConflict, tx)
+}
+
+// pingWithRetry pings s.db until it responds, per SQLOptions.pingWithRetry.
+func (s *SQLImpl) pingWithRetry(ctx context.Context) error {
+	return s.opts.pingWithRetry(ctx, s.db, isTransient)
+}
+
+// pingWithRetry pings db until it responds, fails with a non-transient error
+// (ex: bad credentials), ctx is done, or o.StartupTimeout elapses.
+func (o SQLOptions) pingWithRetry(ctx context.Context, db *sql.DB, transient func(error) bool) error {
+	if o.StartupTimeout <= 0 {
+		return db.PingContext(ctx)
+	}
+	ctx, cancel := context.WithTimeout(ctx, o.StartupTimeout)
+	defer cancel()
+
+	err := db.PingContext(ctx)
+	for attempt := 0; err != nil && transient(err); attempt++ {
+		if serr := sleepCtx(ctx, o.backoff(attempt)); serr != nil {
+			return err
+		}
+		err = db.PingContext(ctx)
+	}
+	return err
+}
@@ -0,0 +1,250 @@
+/*
+Copyright 2020 Robert Barron
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package connector
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/lib/pq"
+	"github.com/rmbarron/SnackInventory/src/backend/server/testutils"
+	sipb "github.com/rmbarron/SnackInventory/src/proto/snackinventory"
+)
+
+func TestIsTransient(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{driver.ErrBadConn, true},
+		{fmt.Errorf("wrapped: %w", driver.ErrBadConn), true},
+		{mysql.ErrInvalidConn, true},
+		{&mysql.MySQLError{Number: 1213, Message: "Deadlock found"}, true},
+		{&mysql.MySQLError{Number: 1205, Message: "Lock wait timeout exceeded"}, true},
+		{&net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}, true},
+		{&mysql.MySQLError{Number: 1146, Message: "Table doesn't exist"}, false},
+		{sql.ErrNoRows, false},
+		{nil, false},
+	}
+	for _, tc := range tests {
+		if got := isTransient(tc.err); got != tc.want {
+			t.Errorf("isTransient(%v) = got %t, want %t", tc.err, got, tc.want)
+		}
+	}
+}
+
+func TestPgIsTransient(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{driver.ErrBadConn, true},
+		{&pq.Error{Code: pgErrDeadlockDetected}, true},
+		{&pq.Error{Code: pgErrSerializationFailure}, true},
+		{&pq.Error{Code: pgErrCannotConnectNow}, true},
+		{&net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}, true},
+		{&pq.Error{Code: pgErrUniqueViolation}, false},
+		{&pq.Error{Code: "42P01"}, false}, // undefined_table
+		{sql.ErrNoRows, false},
+		{nil, false},
+	}
+	for _, tc := range tests {
+		if got := pgIsTransient(tc.err); got != tc.want {
+			t.Errorf("pgIsTransient(%v) = got %t, want %t", tc.err, got, tc.want)
+		}
+	}
+}
+
+func TestPgIsTxConflict(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{&pq.Error{Code: pgErrDeadlockDetected}, true},
+		{fmt.Errorf("wrapped: %w", &pq.Error{Code: pgErrUniqueViolation}), true},
+		{&pq.Error{Code: pgErrCannotConnectNow}, false},
+		{driver.ErrBadConn, false},
+		{nil, false},
+	}
+	for _, tc := range tests {
+		if got := pgIsTxConflict(tc.err); got != tc.want {
+			t.Errorf("pgIsTxConflict(%v) = got %t, want %t", tc.err, got, tc.want)
+		}
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	o := SQLOptions{RetryBackoff: time.Second}
+	for attempt, want := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, maxRetryBackoff, maxRetryBackoff} {
+		if got := o.backoff(attempt); got != want {
+			t.Errorf("o.backoff(%d) = got %v, want %v", attempt, got, want)
+		}
+	}
+	if got := (SQLOptions{}).backoff(0); got != defaultRetryBackoff {
+		t.Errorf("SQLOptions{}.backoff(0) = got %v, want %v", got, defaultRetryBackoff)
+	}
+}
+
+func TestRetryRead(t *testing.T) {
+	tests := []struct {
+		desc      string
+		retries   int
+		errs      []error
+		wantCalls int
+		wantErr   bool
+	}{
+		{"Success", 3, []error{nil}, 1, false},
+		{"TransientThenSuccess", 3, []error{driver.ErrBadConn, driver.ErrBadConn, nil}, 3, false},
+		{"RetriesExhausted", 2, []error{driver.ErrBadConn, driver.ErrBadConn, driver.ErrBadConn, nil}, 3, true},
+		{"NotTransient", 3, []error{sql.ErrNoRows, nil}, 1, true},
+		{"RetriesDisabled", 0, []error{driver.ErrBadConn, nil}, 1, true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			si := &SQLImpl{opts: SQLOptions{ReadRetries: tc.retries, RetryBackoff: time.Millisecond}}
+			calls := 0
+			err := si.retryRead(context.Background(), func() error {
+				calls++
+				return tc.errs[calls-1]
+			})
+			if gotErr := err != nil; gotErr != tc.wantErr {
+				t.Errorf("si.retryRead(ctx, op) = got err %v, want err? %t", err, tc.wantErr)
+			}
+			if calls != tc.wantCalls {
+				t.Errorf("si.retryRead(ctx, op) called op %d times, want %d", calls, tc.wantCalls)
+			}
+		})
+	}
+}
+
+func TestRetryRead_ContextDone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	si := &SQLImpl{opts: SQLOptions{ReadRetries: 3, RetryBackoff: time.Hour}}
+	calls := 0
+	if err := si.retryRead(ctx, func() error { calls++; return driver.ErrBadConn }); err == nil {
+		t.Fatal("si.retryRead(ctx, op) = got err nil, want err")
+	}
+	if calls != 1 {
+		t.Fatalf("si.retryRead(ctx, op) called op %d times, want 1", calls)
+	}
+}
+
+func TestRetryTx(t *testing.T) {
+	deadlock := &mysql.MySQLError{Number: 1213, Message: "Deadlock found"}
+	tests := []struct {
+		desc      string
+		errs      []error
+		wantCalls int
+		wantErr   bool
+	}{
+		{"Success", []error{nil}, 1, false},
+		{"ConflictThenSuccess", []error{deadlock, deadlock, nil}, 3, false},
+		{"AttemptsExhausted", []error{deadlock, deadlock, deadlock, deadlock, deadlock, nil}, maxTxAttempts, true},
+		{"WrappedConflict", []error{&BatchError{1, deadlock}, nil}, 2, false},
+		{"DuplicateKey", []error{&mysql.MySQLError{Number: 1062, Message: "Duplicate entry"}, nil}, 2, false},
+		// The transaction may have committed before the connection dropped.
+		{"BadConn", []error{driver.ErrBadConn, nil}, 1, true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			si := &SQLImpl{opts: SQLOptions{RetryBackoff: time.Millisecond}}
+			calls := 0
+			err := si.retryTx(context.Background(), func() error {
+				calls++
+				return tc.errs[calls-1]
+			})
+			if gotErr := err != nil; gotErr != tc.wantErr {
+				t.Errorf("si.retryTx(ctx, tx) = got err %v, want err? %t", err, tc.wantErr)
+			}
+			if calls != tc.wantCalls {
+				t.Errorf("si.retryTx(ctx, tx) called tx %d times, want %d", calls, tc.wantCalls)
+			}
+		})
+	}
+}
+
+// TestRestart is a parent test to create a restartable mariadb instance, to
+// verify SQLImpl recovers from the DB going away.
+func TestRestart(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	hostport, stop, restart := testutils.StartRestartableMysqldT(ctx, t)
+
+	db, err := sql.Open("mysql", fmt.Sprintf("root@tcp(%s)/", hostport))
+	if err != nil {
+		t.Fatalf("sql.Open(%q, %q) = got err %v, want err nil", "mysql", hostport, err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+	testutils.CreateDatabaseT(ctx, t, db)
+	testutils.CreateTablesT(ctx, t, db)
+	testutils.AddSnackT(ctx, t, db, &sipb.Snack{Barcode: "123", Name: "testsnack"})
+
+	t.Run("StartupRetry", func(t *testing.T) {
+		stop()
+		go func() {
+			time.Sleep(time.Second)
+			restart()
+		}()
+
+		opts := SQLOptions{StartupTimeout: time.Minute}
+		si, err := NewSQLImpl(ctx, "root", "", hostport, "SnackInventory", opts)
+		if err != nil {
+			t.Fatalf("NewSQLImpl(ctx, ..., %+v) = got err %v, want err nil", opts, err)
+		}
+		si.db.Close()
+	})
+
+	t.Run("ReadRetry", func(t *testing.T) {
+		opts := SQLOptions{ReadRetries: 10, RetryBackoff: 500 * time.Millisecond}
+		si, err := NewSQLImpl(ctx, "root", "", hostport, "SnackInventory", opts)
+		if err != nil {
+			t.Fatalf("NewSQLImpl(ctx, ..., %+v) = got err %v, want err nil", opts, err)
+		}
+		defer si.db.Close()
+		// Warm the pool, so the restart leaves stale connections behind.
+		if _, err := si.ListSnacks(ctx); err != nil {
+			t.Fatalf("si.ListSnacks(ctx) = got err %v, want err nil", err)
+		}
+
+		stop()
+		go func() {
+			time.Sleep(time.Second)
+			restart()
+		}()
+
+		got, err := si.ListSnacks(ctx)
+		if err != nil {
+			t.Fatalf("si.ListSnacks(ctx) across restart = got err %v, want err nil", err)
+		}
+		want := []*sipb.Snack{{Barcode: "123", Name: "testsnack"}}
+		if diff := cmp.Diff(got, want, cmpopts.IgnoreUnexported(sipb.Snack{})); diff != "" {
+			t.Fatalf("si.ListSnacks(ctx) = got diff (-got +want): %s", diff)
+		}
+	})
+}
@@ -0,0 +1,126 @@
+/*
+Copyright 2020 Robert Barron
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package connector
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/rmbarron/SnackInventory/src/backend/server/config"
+)
+
+func init() {
+	Register("mysql", &sqlFactory{stdin: os.Stdin})
+}
+
+// sqlFactory opens a SQLImpl, configured by flags prefixed "sql_".
+type sqlFactory struct {
+	user, address, database string
+	// The password is read from passwordFile, taken from password, or read as
+	// the first line of stdin, in that order of preference.
+	password, passwordFile string
+	stdin                  io.Reader
+
+	opts SQLOptions
+}
+
+func (f *sqlFactory) RegisterFlags(fs *flag.FlagSet) {
+	fs.StringVar(&f.user, "sql_user", "", "Username for connecting to MySQL.")
+	fs.StringVar(&f.address, "sql_address", "", "host:port address for connecting to MySQL.")
+	fs.StringVar(&f.database, "sql_database", "SnackInventory", "MySQL database name to connect to.")
+	fs.StringVar(&f.password, "sql_password", "", "MySQL password. Prefer sql_password_file.")
+	fs.StringVar(
+		&f.passwordFile, "sql_password_file", "",
+		"File containing the MySQL password. If unset, the password is read from the first line of stdin.")
+	fs.IntVar(&f.opts.MaxOpenConns, "sql_max_open_conns", 10, "Maximum open connections to MySQL. Unlimited if 0.")
+	fs.IntVar(&f.opts.MaxIdleConns, "sql_max_idle_conns", 5, "Maximum idle connections kept open to MySQL.")
+	fs.DurationVar(
+		&f.opts.ConnMaxLifetime, "sql_conn_max_lifetime", 5*time.Minute,
+		"Maximum time a MySQL connection is reused. Forever if 0.")
+	fs.DurationVar(
+		&f.opts.StartupTimeout, "sql_startup_timeout", time.Minute,
+		"How long to wait, retrying with backoff, for MySQL to accept connections at startup.")
+	fs.IntVar(
+		&f.opts.ReadRetries, "sql_read_retries", 3,
+		"Times to retry idempotent reads after transient MySQL errors (ex: dropped connections, deadlocks).")
+}
+
+func (f *sqlFactory) Validate() error {
+	var errs config.Errors
+	if f.user == "" {
+		errs = append(errs, "sql_user: required for mysql storage")
+	}
+	if f.address == "" {
+		errs = append(errs, "sql_address: required for mysql storage")
+	}
+	if f.database == "" {
+		errs = append(errs, "sql_database: required for mysql storage")
+	}
+	if f.password != "" && f.passwordFile != "" {
+		errs = append(errs, "sql_password, sql_password_file: at most one may be set")
+	}
+	if f.opts.MaxOpenConns > 0 && f.opts.MaxIdleConns > f.opts.MaxOpenConns {
+		errs = append(errs, fmt.Sprintf("sql_max_idle_conns: %d exceeds sql_max_open_conns %d", f.opts.MaxIdleConns, f.opts.MaxOpenConns))
+	}
+	if f.opts.ReadRetries < 0 {
+		errs = append(errs, fmt.Sprintf("sql_read_retries: %d must not be negative", f.opts.ReadRetries))
+	}
+	return errs.Err()
+}
+
+func (f *sqlFactory) Open(ctx context.Context) (Store, error) {
+	pwd, err := f.readPassword()
+	if err != nil {
+		return nil, fmt.Errorf("could not read SQL password: %w", err)
+	}
+	s, err := NewSQLImpl(ctx, f.user, pwd, f.address, f.database, f.opts)
+	if err != nil {
+		return nil, fmt.Errorf("could not connect to SQL: %w", err)
+	}
+	return s, nil
+}
+
+// readPassword returns the MySQL password.
+func (f *sqlFactory) readPassword() (string, error) {
+	return readPassword(f.passwordFile, "sql_password_file", f.password, f.stdin)
+}
+
+// readPassword returns a password read from file, named by the flag fileFlag,
+// taken from password, or read as the first line of stdin, in that order of
+// preference.
+func readPassword(file, fileFlag, password string, stdin io.Reader) (string, error) {
+	if file != "" {
+		b, err := ioutil.ReadFile(file)
+		if err != nil {
+			return "", fmt.Errorf("could not read %s: %w", fileFlag, err)
+		}
+		return strings.TrimRight(string(b), "\r\n"), nil
+	}
+	if password != "" {
+		return password, nil
+	}
+	scanner := bufio.NewScanner(stdin)
+	scanner.Scan()
+	return scanner.Text(), scanner.Err()
+}
@@ -0,0 +1,217 @@
+/*
+Copyright 2020 Robert Barron
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package connector
+
+import (
+	"context"
+	"database/sql"
+
+	sipb "github.com/rmbarron/SnackInventory/src/proto/snackinventory"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// AdjustStock changes the count of a snack at a location by delta, returning
+// the new count. Rows are only kept for counts above zero.
+// Returns a NotFound error if the snack or location is not registered, and a
+// FailedPrecondition error if the count would drop below zero.
+func (s *SQLImpl) AdjustStock(ctx context.Context, barcode, location string, delta int32) (int32, error) {
+	var count int32
+	err := s.withTx(ctx, func(tx *sql.Tx) error {
+		existing, err := existingBarcodes(ctx, tx, []string{barcode})
+		if err != nil {
+			return err
+		}
+		if deleted, ok := existing[barcode]; !ok || deleted {
+			return status.Errorf(codes.NotFound, "barcode %q is not registered", barcode)
+		}
+		rows, err := tracedQuery(ctx, tx, "SELECT name FROM LocationRegistry WHERE name IN (?) AND deleted_at IS NULL FOR UPDATE", location)
+		if err != nil {
+			return err
+		}
+		found := rows.Next()
+		rows.Close()
+		if !found {
+			return status.Errorf(codes.NotFound, "location %q is not registered", location)
+		}
+
+		var old int32
+		rows, err = tracedQuery(ctx, tx,
+			"SELECT count FROM Stock WHERE barcode = ? AND location = ? FOR UPDATE", barcode, location)
+		if err != nil {
+			return err
+		}
+		stocked := rows.Next()
+		if stocked {
+			err = rows.Scan(&old)
+		}
+		rows.Close()
+		if err != nil {
+			return err
+		}
+		count = old + delta
+		switch {
+		case count < 0:
+			return status.Errorf(codes.FailedPrecondition,
+				"only %d of %q at %q, can't remove %d", old, barcode, location, -delta)
+		case count == 0 && stocked:
+			_, err = tracedExec(ctx, tx, "DELETE FROM Stock WHERE barcode = ? AND location = ?", barcode, location)
+		case count == 0:
+		case stocked:
+			_, err = tracedExec(ctx, tx,
+				"UPDATE Stock SET count = ? WHERE barcode = ? AND location = ?", count, barcode, location)
+		default:
+			_, err = tracedExec(ctx, tx,
+				"INSERT INTO Stock (barcode, location, count) VALUES(?, ?, ?)", barcode, location, count)
+		}
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// clearStock removes all stock at location, moving it to target or discarding
+// it per contents, & returns the changes made.
+func clearStock(ctx context.Context, tx *sql.Tx, location string, contents sipb.ContentsPolicy, target string) ([]*sipb.StockChange, error) {
+	if contents == sipb.ContentsPolicy_MOVE {
+		if target == location {
+			return nil, status.Errorf(codes.InvalidArgument, "can't move stock from %q to itself", location)
+		}
+		rows, err := tracedQuery(ctx, tx, "SELECT name FROM LocationRegistry WHERE name IN (?) AND deleted_at IS NULL FOR UPDATE", target)
+		if err != nil {
+			return nil, err
+		}
+		found := rows.Next()
+		rows.Close()
+		if !found {
+			return nil, status.Errorf(codes.NotFound, "location %q is not registered", target)
+		}
+	}
+
+	rows, err := tracedQuery(ctx, tx,
+		"SELECT barcode, count FROM Stock WHERE location = ? ORDER BY barcode FOR UPDATE", location)
+	if err != nil {
+		return nil, err
+	}
+	var stock []*sipb.Stock
+	for rows.Next() {
+		st := &sipb.Stock{Location: location}
+		if err = rows.Scan(&st.Barcode, &st.Count); err != nil {
+			break
+		}
+		stock = append(stock, st)
+	}
+	if err == nil {
+		err = rows.Err()
+	}
+	rows.Close()
+	if err != nil || len(stock) == 0 {
+		return nil, err
+	}
+
+	reason := sipb.StockChange_DISCARDED
+	switch contents {
+	case sipb.ContentsPolicy_MOVE:
+		reason = sipb.StockChange_MOVED
+	case sipb.ContentsPolicy_DISCARD:
+	default:
+		return nil, status.Errorf(codes.FailedPrecondition,
+			"location %q holds %d snacks; move or discard them first", location, len(stock))
+	}
+	var changes []*sipb.StockChange
+	for _, st := range stock {
+		changes = append(changes, &sipb.StockChange{
+			Barcode: st.Barcode, Location: location, Delta: -st.Count, Reason: reason,
+		})
+		if reason != sipb.StockChange_MOVED {
+			continue
+		}
+		var old int32
+		rows, err := tracedQuery(ctx, tx,
+			"SELECT count FROM Stock WHERE barcode = ? AND location = ? FOR UPDATE", st.Barcode, target)
+		if err != nil {
+			return nil, err
+		}
+		stocked := rows.Next()
+		if stocked {
+			err = rows.Scan(&old)
+		}
+		rows.Close()
+		if err != nil {
+			return nil, err
+		}
+		if stocked {
+			_, err = tracedExec(ctx, tx,
+				"UPDATE Stock SET count = ? WHERE barcode = ? AND location = ?", old+st.Count, st.Barcode, target)
+		} else {
+			_, err = tracedExec(ctx, tx,
+				"INSERT INTO Stock (barcode, location, count) VALUES(?, ?, ?)", st.Barcode, target, st.Count)
+		}
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, &sipb.StockChange{
+			Barcode: st.Barcode, Location: target, Delta: st.Count, Count: old + st.Count, Reason: reason,
+		})
+	}
+	if _, err := tracedExec(ctx, tx, "DELETE FROM Stock WHERE location = ?", location); err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
+
+// ListStock reads the stock of all snacks, or only those at location if set,
+// ordered by location & barcode.
+// Stock of snacks in the trash is skipped.
+func (s *SQLImpl) ListStock(ctx context.Context, location string) ([]*sipb.Stock, error) {
+	var stock []*sipb.Stock
+	err := s.retryRead(ctx, func() (err error) {
+		stock, err = s.listStock(ctx, location)
+		return err
+	})
+	return stock, err
+}
+
+func (s *SQLImpl) listStock(ctx context.Context, location string) ([]*sipb.Stock, error) {
+	query := "SELECT barcode, location, count FROM Stock" +
+		" WHERE barcode NOT IN (SELECT barcode FROM SnackRegistry WHERE deleted_at IS NOT NULL)"
+	var args []interface{}
+	if location != "" {
+		query += " AND location = ?"
+		args = append(args, location)
+	}
+	rows, err := s.queryContext(ctx, query+" ORDER BY location, barcode", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var retVal []*sipb.Stock
+	for rows.Next() {
+		st := &sipb.Stock{}
+		if err = rows.Scan(&st.Barcode, &st.Location, &st.Count); err != nil {
+			return nil, err
+		}
+		retVal = append(retVal, st)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return retVal, nil
+}
@@ -0,0 +1,210 @@
+/*
+Copyright 2020 Robert Barron
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package connector
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/rmbarron/SnackInventory/src/backend/server/testutils"
+	sipb "github.com/rmbarron/SnackInventory/src/proto/snackinventory"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// TestStock is a parent test to create a mariadb instance for subtests.
+func TestStock(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	db, close := testutils.StartMysqldT(ctx, t)
+	defer close()
+
+	testutils.CreateDatabaseT(ctx, t, db)
+
+	// setUpT registers snack "123" & locations "pantry" & "fridge".
+	setUpT := func(t *testing.T) {
+		t.Helper()
+		testutils.CreateTablesT(ctx, t, db)
+		testutils.AddSnackT(ctx, t, db, &sipb.Snack{Barcode: "123", Name: "chips"})
+		testutils.AddLocationT(ctx, t, db, &sipb.Location{Name: "pantry"})
+		testutils.AddLocationT(ctx, t, db, &sipb.Location{Name: "fridge"})
+	}
+	adjustT := func(t *testing.T, si *SQLImpl, location string, delta, want int32) {
+		t.Helper()
+		got, err := si.AdjustStock(ctx, "123", location, delta)
+		if err != nil {
+			t.Fatalf("si.AdjustStock(ctx, %q, %q, %d) = got err %v, want err nil", "123", location, delta, err)
+		}
+		if got != want {
+			t.Fatalf("si.AdjustStock(ctx, %q, %q, %d) = got count %d, want %d", "123", location, delta, got, want)
+		}
+	}
+
+	t.Run("AdjustStock", func(t *testing.T) {
+		setUpT(t)
+		defer testutils.DropTablesT(ctx, t, db)
+
+		si := &SQLImpl{db: db}
+		adjustT(t, si, "pantry", 2, 2)
+		adjustT(t, si, "pantry", 1, 3)
+		adjustT(t, si, "fridge", 1, 1)
+		adjustT(t, si, "fridge", -1, 0)
+
+		want := []*sipb.Stock{{Barcode: "123", Location: "pantry", Count: 3}}
+		got, err := si.ListStock(ctx, "")
+		if err != nil {
+			t.Fatalf("si.ListStock(ctx, %q) = got err %v, want err nil", "", err)
+		}
+		if diff := cmp.Diff(want, got, cmpopts.IgnoreUnexported(sipb.Stock{})); diff != "" {
+			t.Fatalf("si.ListStock(ctx, %q) = got diff (-want +got): %s", "", diff)
+		}
+	})
+
+	t.Run("ListStock_Location", func(t *testing.T) {
+		setUpT(t)
+		defer testutils.DropTablesT(ctx, t, db)
+
+		si := &SQLImpl{db: db}
+		adjustT(t, si, "pantry", 2, 2)
+		adjustT(t, si, "fridge", 1, 1)
+
+		want := []*sipb.Stock{{Barcode: "123", Location: "fridge", Count: 1}}
+		got, err := si.ListStock(ctx, "fridge")
+		if err != nil {
+			t.Fatalf("si.ListStock(ctx, %q) = got err %v, want err nil", "fridge", err)
+		}
+		if diff := cmp.Diff(want, got, cmpopts.IgnoreUnexported(sipb.Stock{})); diff != "" {
+			t.Fatalf("si.ListStock(ctx, %q) = got diff (-want +got): %s", "fridge", diff)
+		}
+	})
+
+	t.Run("AdjustStock_Errors", func(t *testing.T) {
+		setUpT(t)
+		defer testutils.DropTablesT(ctx, t, db)
+
+		si := &SQLImpl{db: db}
+		adjustT(t, si, "pantry", 1, 1)
+		for _, tc := range []struct {
+			barcode, location string
+			delta             int32
+			want              codes.Code
+		}{
+			{"999", "pantry", 1, codes.NotFound},
+			{"123", "garage", 1, codes.NotFound},
+			{"123", "pantry", -2, codes.FailedPrecondition},
+			{"123", "fridge", -1, codes.FailedPrecondition},
+		} {
+			_, err := si.AdjustStock(ctx, tc.barcode, tc.location, tc.delta)
+			if got := status.Code(err); got != tc.want {
+				t.Errorf("si.AdjustStock(ctx, %q, %q, %d) = got code %v, want %v",
+					tc.barcode, tc.location, tc.delta, got, tc.want)
+			}
+		}
+		// Failed adjustments leave the stock as is.
+		adjustT(t, si, "pantry", 0, 1)
+	})
+	t.Run("DeleteLocation_Contents", func(t *testing.T) {
+		tests := []struct {
+			desc        string
+			contents    sipb.ContentsPolicy
+			target      string
+			wantChanges []*sipb.StockChange
+			wantStock   []*sipb.Stock
+		}{
+			{
+				desc:     "Move",
+				contents: sipb.ContentsPolicy_MOVE,
+				target:   "fridge",
+				wantChanges: []*sipb.StockChange{
+					{Barcode: "123", Location: "pantry", Delta: -2, Reason: sipb.StockChange_MOVED},
+					{Barcode: "123", Location: "fridge", Delta: 2, Count: 3, Reason: sipb.StockChange_MOVED},
+					{Barcode: "456", Location: "pantry", Delta: -4, Reason: sipb.StockChange_MOVED},
+					{Barcode: "456", Location: "fridge", Delta: 4, Count: 4, Reason: sipb.StockChange_MOVED},
+				},
+				wantStock: []*sipb.Stock{
+					{Barcode: "123", Location: "fridge", Count: 3},
+					{Barcode: "456", Location: "fridge", Count: 4},
+				},
+			},
+			{
+				desc:     "Discard",
+				contents: sipb.ContentsPolicy_DISCARD,
+				wantChanges: []*sipb.StockChange{
+					{Barcode: "123", Location: "pantry", Delta: -2, Reason: sipb.StockChange_DISCARDED},
+					{Barcode: "456", Location: "pantry", Delta: -4, Reason: sipb.StockChange_DISCARDED},
+				},
+				wantStock: []*sipb.Stock{{Barcode: "123", Location: "fridge", Count: 1}},
+			},
+		}
+		for _, tc := range tests {
+			t.Run(tc.desc, func(t *testing.T) {
+				setUpT(t)
+				defer testutils.DropTablesT(ctx, t, db)
+				testutils.AddSnackT(ctx, t, db, &sipb.Snack{Barcode: "456", Name: "cookies"})
+
+				si := &SQLImpl{db: db}
+				adjustT(t, si, "pantry", 2, 2)
+				adjustT(t, si, "fridge", 1, 1)
+				if _, err := si.AdjustStock(ctx, "456", "pantry", 4); err != nil {
+					t.Fatalf("si.AdjustStock(ctx, %q, %q, %d) = got err %v, want err nil", "456", "pantry", 4, err)
+				}
+
+				got, err := si.DeleteLocation(ctx, "pantry", tc.contents, tc.target)
+				if err != nil {
+					t.Fatalf("si.DeleteLocation(ctx, %q, %v, %q) = got err %v, want err nil", "pantry", tc.contents, tc.target, err)
+				}
+				if diff := cmp.Diff(tc.wantChanges, got, cmpopts.IgnoreUnexported(sipb.StockChange{})); diff != "" {
+					t.Errorf("si.DeleteLocation(ctx, %q, %v, %q) = got diff (-want +got): %s", "pantry", tc.contents, tc.target, diff)
+				}
+				gotStock, err := si.ListStock(ctx, "")
+				if err != nil {
+					t.Fatalf("si.ListStock(ctx, %q) = got err %v, want err nil", "", err)
+				}
+				if diff := cmp.Diff(tc.wantStock, gotStock, cmpopts.IgnoreUnexported(sipb.Stock{})); diff != "" {
+					t.Errorf("si.ListStock(ctx, %q) = got diff (-want +got): %s", "", diff)
+				}
+			})
+		}
+	})
+
+	t.Run("DeleteLocation_ContentsErrors", func(t *testing.T) {
+		setUpT(t)
+		defer testutils.DropTablesT(ctx, t, db)
+
+		si := &SQLImpl{db: db}
+		adjustT(t, si, "pantry", 2, 2)
+		for _, tc := range []struct {
+			contents sipb.ContentsPolicy
+			target   string
+			want     codes.Code
+		}{
+			{sipb.ContentsPolicy_CONTENTS_POLICY_UNSPECIFIED, "", codes.FailedPrecondition},
+			{sipb.ContentsPolicy_REFUSE, "", codes.FailedPrecondition},
+			{sipb.ContentsPolicy_MOVE, "garage", codes.NotFound},
+			{sipb.ContentsPolicy_MOVE, "pantry", codes.InvalidArgument},
+		} {
+			_, err := si.DeleteLocation(ctx, "pantry", tc.contents, tc.target)
+			if got := status.Code(err); got != tc.want {
+				t.Errorf("si.DeleteLocation(ctx, %q, %v, %q) = got code %v, want %v", "pantry", tc.contents, tc.target, got, tc.want)
+			}
+		}
+		// Refused deletions leave the location & its stock as is.
+		adjustT(t, si, "pantry", 0, 2)
+	})
+}
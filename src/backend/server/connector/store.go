@@ -0,0 +1,75 @@
+/*
+Copyright 2020 Robert Barron
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package connector
+
+import (
+	"context"
+	"time"
+
+	sipb "github.com/rmbarron/SnackInventory/src/proto/snackinventory"
+)
+
+// Store is the interface to backing storage, implemented by each backend.
+// Backends share the semantics of SQLImpl, including errors & the order of
+// listed items, as checked by connectortest.RunConformance.
+type Store interface {
+	// Snack Registry Operations
+	CreateSnack(ctx context.Context, snack *sipb.Snack) error
+	ListSnacks(ctx context.Context) ([]*sipb.Snack, error)
+	UpdateSnack(ctx context.Context, snack *sipb.Snack) error
+	DeleteSnack(ctx context.Context, barcode string) error
+
+	// Batch Snack Operations
+	// Each applies all items in a single transaction, or none of them. The
+	// failing item is reported via *BatchError.
+	BatchCreateSnacks(ctx context.Context, snacks []*sipb.Snack) error
+	BatchUpdateSnacks(ctx context.Context, snacks []*sipb.Snack) error
+	BatchDeleteSnacks(ctx context.Context, barcodes []string) error
+
+	// Location Registry Operations
+	// Locations refer to their parent by name.
+	// CreateLocation returns the id of the new location.
+	CreateLocation(ctx context.Context, location *sipb.Location) (int64, error)
+	ListLocations(ctx context.Context) ([]*sipb.Location, error)
+	// UpdateLocation sets fields of the location with the id of location,
+	// renaming its references too, & returns the updated location.
+	UpdateLocation(ctx context.Context, location *sipb.Location, fields []string) (*sipb.Location, error)
+	// DeleteLocation moves or discards stock at the location per contents, &
+	// returns the resulting changes in stock.
+	DeleteLocation(ctx context.Context, name string, contents sipb.ContentsPolicy, target string) ([]*sipb.StockChange, error)
+
+	// Stock Operations
+	// AdjustStock returns the count after the change.
+	AdjustStock(ctx context.Context, barcode, location string, delta int32) (int32, error)
+	ListStock(ctx context.Context, location string) ([]*sipb.Stock, error)
+
+	// Trash Operations
+	// Deleted snacks & locations are kept until purged.
+	ListDeleted(ctx context.Context) ([]*sipb.DeletedEntity, error)
+	UndeleteSnack(ctx context.Context, barcode string) (*sipb.Snack, error)
+	UndeleteLocation(ctx context.Context, name string) (*sipb.Location, error)
+	// PurgeDeleted returns the number of snacks & locations purged.
+	PurgeDeleted(ctx context.Context, before time.Time) (snacks, locations int64, err error)
+
+	// History Operations
+	// Every change to a snack or location is kept as a revision.
+	GetSnackHistory(ctx context.Context, barcode string) ([]*sipb.SnackRevision, error)
+	ListSnacksAsOf(ctx context.Context, asOf time.Time) ([]*sipb.Snack, error)
+	ListLocationsAsOf(ctx context.Context, asOf time.Time) ([]*sipb.Location, error)
+}
+
+var _ Store = (*SQLImpl)(nil)
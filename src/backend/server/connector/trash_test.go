@@ -0,0 +1,238 @@
+/*
+Copyright 2020 Robert Barron
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package connector
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/rmbarron/SnackInventory/src/backend/server/testutils"
+	sipb "github.com/rmbarron/SnackInventory/src/proto/snackinventory"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// TestTrash is a parent test to create a mariadb instance for subtests.
+func TestTrash(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	db, close := testutils.StartMysqldT(ctx, t)
+	defer close()
+
+	testutils.CreateDatabaseT(ctx, t, db)
+
+	// setUpT registers snack "123" with 2 in stock at "pantry", and locations
+	// "garage" & "freezer" inside it.
+	setUpT := func(t *testing.T) *SQLImpl {
+		t.Helper()
+		testutils.CreateTablesT(ctx, t, db)
+		testutils.AddSnackT(ctx, t, db, &sipb.Snack{Barcode: "123", Name: "chips"})
+		testutils.AddLocationT(ctx, t, db, &sipb.Location{Name: "pantry"})
+		testutils.AddLocationT(ctx, t, db, &sipb.Location{Name: "garage"})
+		testutils.AddLocationT(ctx, t, db, &sipb.Location{Name: "freezer", Parent: "garage"})
+		si := &SQLImpl{db: db}
+		if _, err := si.AdjustStock(ctx, "123", "pantry", 2); err != nil {
+			t.Fatalf("si.AdjustStock(ctx, %q, %q, %d) = got err %v, want err nil", "123", "pantry", 2, err)
+		}
+		return si
+	}
+	deleteSnackT := func(t *testing.T, si *SQLImpl, barcode string) {
+		t.Helper()
+		if err := si.DeleteSnack(ctx, barcode); err != nil {
+			t.Fatalf("si.DeleteSnack(ctx, %q) = got err %v, want err nil", barcode, err)
+		}
+	}
+	deleteLocationT := func(t *testing.T, si *SQLImpl, name string) {
+		t.Helper()
+		if _, err := si.DeleteLocation(ctx, name, sipb.ContentsPolicy_REFUSE, ""); err != nil {
+			t.Fatalf("si.DeleteLocation(ctx, %q, REFUSE, \"\") = got err %v, want err nil", name, err)
+		}
+	}
+
+	t.Run("UndeleteSnack", func(t *testing.T) {
+		si := setUpT(t)
+		defer testutils.DropTablesT(ctx, t, db)
+
+		deleteSnackT(t, si, "123")
+		snacks, err := si.ListSnacks(ctx)
+		if err != nil {
+			t.Fatalf("si.ListSnacks(ctx) = got err %v, want err nil", err)
+		}
+		if len(snacks) != 0 {
+			t.Fatalf("si.ListSnacks(ctx) after delete = got %v, want none", snacks)
+		}
+		stock, err := si.ListStock(ctx, "")
+		if err != nil {
+			t.Fatalf("si.ListStock(ctx, %q) = got err %v, want err nil", "", err)
+		}
+		if len(stock) != 0 {
+			t.Fatalf("si.ListStock(ctx, %q) after delete = got %v, want none", "", stock)
+		}
+		// Trashed snacks can't be used, nor their barcodes reused.
+		if err := si.CreateSnack(ctx, &sipb.Snack{Barcode: "123"}); status.Code(err) != codes.AlreadyExists {
+			t.Fatalf("si.CreateSnack(ctx, %q) = got err %v, want code %v", "123", err, codes.AlreadyExists)
+		}
+		if _, err := si.AdjustStock(ctx, "123", "pantry", 1); status.Code(err) != codes.NotFound {
+			t.Fatalf("si.AdjustStock(ctx, %q, %q, %d) = got err %v, want code %v", "123", "pantry", 1, err, codes.NotFound)
+		}
+
+		got, err := si.UndeleteSnack(ctx, "123")
+		if err != nil {
+			t.Fatalf("si.UndeleteSnack(ctx, %q) = got err %v, want err nil", "123", err)
+		}
+		want := &sipb.Snack{Barcode: "123", Name: "chips"}
+		if diff := cmp.Diff(want, got, cmpopts.IgnoreUnexported(sipb.Snack{})); diff != "" {
+			t.Errorf("si.UndeleteSnack(ctx, %q) = got diff (-want +got): %s", "123", diff)
+		}
+		// Stock comes back with the snack.
+		stock, err = si.ListStock(ctx, "")
+		if err != nil {
+			t.Fatalf("si.ListStock(ctx, %q) = got err %v, want err nil", "", err)
+		}
+		wantStock := []*sipb.Stock{{Barcode: "123", Location: "pantry", Count: 2}}
+		if diff := cmp.Diff(wantStock, stock, cmpopts.IgnoreUnexported(sipb.Stock{})); diff != "" {
+			t.Errorf("si.ListStock(ctx, %q) after undelete = got diff (-want +got): %s", "", diff)
+		}
+		if _, err := si.UndeleteSnack(ctx, "123"); status.Code(err) != codes.NotFound {
+			t.Fatalf("si.UndeleteSnack(ctx, %q) again = got err %v, want code %v", "123", err, codes.NotFound)
+		}
+	})
+
+	t.Run("UndeleteLocation", func(t *testing.T) {
+		si := setUpT(t)
+		defer testutils.DropTablesT(ctx, t, db)
+
+		deleteLocationT(t, si, "freezer")
+		deleteLocationT(t, si, "garage")
+		if _, err := si.CreateLocation(ctx, &sipb.Location{Name: "garage"}); status.Code(err) != codes.AlreadyExists {
+			t.Fatalf("si.CreateLocation(ctx, %q) = got err %v, want code %v", "garage", err, codes.AlreadyExists)
+		}
+		if _, err := si.UndeleteLocation(ctx, "freezer"); status.Code(err) != codes.FailedPrecondition {
+			t.Fatalf("si.UndeleteLocation(ctx, %q) = got err %v, want code %v", "freezer", err, codes.FailedPrecondition)
+		}
+		for _, name := range []string{"garage", "freezer"} {
+			if _, err := si.UndeleteLocation(ctx, name); err != nil {
+				t.Fatalf("si.UndeleteLocation(ctx, %q) = got err %v, want err nil", name, err)
+			}
+		}
+
+		got, err := si.ListLocations(ctx)
+		if err != nil {
+			t.Fatalf("si.ListLocations(ctx) = got err %v, want err nil", err)
+		}
+		want := []*sipb.Location{
+			{Id: 1, Name: "pantry"},
+			{Id: 2, Name: "garage"},
+			{Id: 3, Name: "freezer", Parent: "garage"},
+		}
+		if diff := cmp.Diff(want, got, cmpopts.IgnoreUnexported(sipb.Location{})); diff != "" {
+			t.Errorf("si.ListLocations(ctx) after undelete = got diff (-want +got): %s", diff)
+		}
+		if _, err := si.UndeleteLocation(ctx, "pantry"); status.Code(err) != codes.NotFound {
+			t.Fatalf("si.UndeleteLocation(ctx, %q) = got err %v, want code %v", "pantry", err, codes.NotFound)
+		}
+	})
+
+	t.Run("ListDeleted", func(t *testing.T) {
+		si := setUpT(t)
+		defer testutils.DropTablesT(ctx, t, db)
+
+		deleteSnackT(t, si, "123")
+		deleteLocationT(t, si, "freezer")
+		// Order the deletions, which may otherwise fall within the same second.
+		if _, err := db.ExecContext(ctx, "UPDATE SnackRegistry SET deleted_at = '2020-01-02 00:00:00'"); err != nil {
+			t.Fatalf("db.ExecContext(ctx, ...) = got err %v, want err nil", err)
+		}
+		if _, err := db.ExecContext(ctx, "UPDATE LocationRegistry SET deleted_at = '2020-01-01 00:00:00' WHERE name = 'freezer'"); err != nil {
+			t.Fatalf("db.ExecContext(ctx, ...) = got err %v, want err nil", err)
+		}
+
+		got, err := si.ListDeleted(ctx)
+		if err != nil {
+			t.Fatalf("si.ListDeleted(ctx) = got err %v, want err nil", err)
+		}
+		want := []*sipb.DeletedEntity{
+			{
+				Entity:     &sipb.DeletedEntity_Snack{Snack: &sipb.Snack{Barcode: "123", Name: "chips"}},
+				DeleteTime: timestamppb.New(time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC)),
+			},
+			{
+				Entity:     &sipb.DeletedEntity_Location{Location: &sipb.Location{Id: 3, Name: "freezer", Parent: "garage"}},
+				DeleteTime: timestamppb.New(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)),
+			},
+		}
+		opts := cmpopts.IgnoreUnexported(sipb.DeletedEntity{}, sipb.Snack{}, sipb.Location{}, timestamppb.Timestamp{})
+		if diff := cmp.Diff(want, got, opts); diff != "" {
+			t.Errorf("si.ListDeleted(ctx) = got diff (-want +got): %s", diff)
+		}
+	})
+
+	t.Run("PurgeDeleted", func(t *testing.T) {
+		si := setUpT(t)
+		defer testutils.DropTablesT(ctx, t, db)
+
+		deleteSnackT(t, si, "123")
+		deleteLocationT(t, si, "freezer")
+		deleteLocationT(t, si, "garage")
+		if _, err := db.ExecContext(ctx, "UPDATE LocationRegistry SET deleted_at = '2020-01-01 00:00:00' WHERE name = 'garage'"); err != nil {
+			t.Fatalf("db.ExecContext(ctx, ...) = got err %v, want err nil", err)
+		}
+
+		// Only garage was deleted before the cutoff.
+		cutoff := time.Now().Add(-time.Hour)
+		snacks, locations, err := si.PurgeDeleted(ctx, cutoff)
+		if err != nil {
+			t.Fatalf("si.PurgeDeleted(ctx, %v) = got err %v, want err nil", cutoff, err)
+		}
+		if snacks != 0 || locations != 1 {
+			t.Fatalf("si.PurgeDeleted(ctx, %v) = got (%d, %d), want (0, 1)", cutoff, snacks, locations)
+		}
+		// With its parent gone, freezer is restored at the top level.
+		got, err := si.UndeleteLocation(ctx, "freezer")
+		if err != nil {
+			t.Fatalf("si.UndeleteLocation(ctx, %q) = got err %v, want err nil", "freezer", err)
+		}
+		if got.GetParent() != "" {
+			t.Errorf("si.UndeleteLocation(ctx, %q) = got parent %q, want none", "freezer", got.GetParent())
+		}
+
+		cutoff = time.Now().Add(time.Hour)
+		snacks, locations, err = si.PurgeDeleted(ctx, cutoff)
+		if err != nil {
+			t.Fatalf("si.PurgeDeleted(ctx, %v) = got err %v, want err nil", cutoff, err)
+		}
+		if snacks != 1 || locations != 0 {
+			t.Fatalf("si.PurgeDeleted(ctx, %v) = got (%d, %d), want (1, 0)", cutoff, snacks, locations)
+		}
+		// Purged barcodes are free again, without the old stock.
+		if err := si.CreateSnack(ctx, &sipb.Snack{Barcode: "123"}); err != nil {
+			t.Fatalf("si.CreateSnack(ctx, %q) = got err %v, want err nil", "123", err)
+		}
+		stock, err := si.ListStock(ctx, "")
+		if err != nil {
+			t.Fatalf("si.ListStock(ctx, %q) = got err %v, want err nil", "", err)
+		}
+		if len(stock) != 0 {
+			t.Errorf("si.ListStock(ctx, %q) after purge = got %v, want none", "", stock)
+		}
+	})
+}
@@ -0,0 +1,204 @@
+/*
+Copyright 2020 Robert Barron
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package locations arranges the flat list of stored locations into their
+// hierarchy, ex: Garage/Chest Freezer/Top Basket. Storage only records the
+// parent of each location; paths, depths & subtree stock counts are derived
+// here.
+package locations
+
+import (
+	"sort"
+	"strings"
+
+	sipb "github.com/rmbarron/SnackInventory/src/proto/snackinventory"
+	"google.golang.org/protobuf/proto"
+)
+
+// Separator joins the names of a location path.
+const Separator = "/"
+
+// IsPath returns whether ref is a path, rather than a plain location name.
+func IsPath(ref string) bool {
+	return strings.Contains(ref, Separator)
+}
+
+// Tree is the hierarchy of a set of locations.
+type Tree struct {
+	byName map[string]*sipb.Location
+	// parents holds the parent of each location within the tree, "" for
+	// top-level locations.
+	parents map[string]string
+	// children holds the names of the locations inside each location, sorted.
+	// Top-level locations are under "".
+	children map[string][]string
+}
+
+// New arranges locations into a Tree. Locations with an unknown parent are
+// treated as top-level, as is the first by name of any cycle, so every
+// location is reachable.
+func New(locations []*sipb.Location) *Tree {
+	t := &Tree{
+		byName:   make(map[string]*sipb.Location, len(locations)),
+		parents:  make(map[string]string, len(locations)),
+		children: map[string][]string{},
+	}
+	for _, l := range locations {
+		t.byName[l.GetName()] = l
+		t.parents[l.GetName()] = l.GetParent()
+	}
+	for name, parent := range t.parents {
+		if _, ok := t.parents[parent]; !ok {
+			t.parents[name] = ""
+		}
+	}
+	for name, parent := range t.parents {
+		t.children[parent] = append(t.children[parent], name)
+	}
+	for _, names := range t.children {
+		sort.Strings(names)
+	}
+
+	// Locations in a cycle can't be reached from the top level.
+	reached := map[string]bool{}
+	t.walk("", func(name string, _ int) { reached[name] = true })
+	var names []string
+	for name := range t.parents {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if reached[name] {
+			continue
+		}
+		t.detach(name)
+		t.parents[name] = ""
+		t.children[""] = append(t.children[""], name)
+		t.walk(name, func(name string, _ int) { reached[name] = true })
+	}
+	sort.Strings(t.children[""])
+	return t
+}
+
+// detach removes name from the children of its parent.
+func (t *Tree) detach(name string) {
+	parent := t.parents[name]
+	siblings := t.children[parent]
+	for i, sibling := range siblings {
+		if sibling == name {
+			t.children[parent] = append(siblings[:i:i], siblings[i+1:]...)
+			return
+		}
+	}
+}
+
+// walk calls fn for each location inside name, depth first, with its depth
+// below name.
+func (t *Tree) walk(name string, fn func(name string, depth int)) {
+	var visit func(name string, depth int)
+	visit = func(name string, depth int) {
+		for _, child := range t.children[name] {
+			fn(child, depth)
+			visit(child, depth+1)
+		}
+	}
+	visit(name, 0)
+}
+
+// Path returns the path of the location name, from its top-level location.
+func (t *Tree) Path(name string) string {
+	var names []string
+	for n := name; n != ""; n = t.parents[n] {
+		names = append(names, n)
+	}
+	for i, j := 0, len(names)-1; i < j; i, j = i+1, j-1 {
+		names[i], names[j] = names[j], names[i]
+	}
+	return strings.Join(names, Separator)
+}
+
+// Resolve returns the name of the location referred to by ref, either a name
+// or a path from a top-level location. ok is false if there is none.
+func (t *Tree) Resolve(ref string) (name string, ok bool) {
+	if !IsPath(ref) {
+		_, ok := t.parents[ref]
+		return ref, ok
+	}
+	for _, n := range strings.Split(strings.Trim(ref, Separator), Separator) {
+		found := false
+		for _, child := range t.children[name] {
+			if child == n {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return "", false
+		}
+		name = n
+	}
+	return name, true
+}
+
+// List returns root & every location inside it, or all locations if root is
+// "", depth first & ordered by name. Returned locations are copies with their
+// path, depth & counts of stock set.
+func (t *Tree) List(root string, stock []*sipb.Stock) []*sipb.Location {
+	counts := map[string]int32{}
+	for _, st := range stock {
+		counts[st.GetLocation()] += st.GetCount()
+	}
+
+	var names []string
+	depths := map[string]int32{}
+	add := func(name string, depth int) {
+		names = append(names, name)
+		depths[name] = int32(depth)
+	}
+	if root != "" {
+		add(root, 0)
+		t.walk(root, func(name string, depth int) { add(name, depth+1) })
+	} else {
+		t.walk("", add)
+	}
+
+	// Locations are listed before those inside them, so totals are summed in
+	// reverse.
+	totals := map[string]int32{}
+	for i := len(names) - 1; i >= 0; i-- {
+		name := names[i]
+		totals[name] += counts[name]
+		if parent := t.parents[name]; parent != "" {
+			totals[parent] += totals[name]
+		}
+	}
+
+	rootDepth := int32(0)
+	if root != "" {
+		rootDepth = int32(strings.Count(t.Path(root), Separator))
+	}
+	ret := make([]*sipb.Location, 0, len(names))
+	for _, name := range names {
+		l := proto.Clone(t.byName[name]).(*sipb.Location)
+		l.Parent = t.parents[name]
+		l.Path = t.Path(name)
+		l.Depth = rootDepth + depths[name]
+		l.Count = counts[name]
+		l.TotalCount = totals[name]
+		ret = append(ret, l)
+	}
+	return ret
+}
@@ -0,0 +1,126 @@
+/*
+Copyright 2020 Robert Barron
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package locations
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	sipb "github.com/rmbarron/SnackInventory/src/proto/snackinventory"
+)
+
+var garage = []*sipb.Location{
+	{Name: "top", Parent: "freezer"},
+	{Name: "garage"},
+	{Name: "pantry", Type: sipb.LocationType_PANTRY},
+	{Name: "freezer", Parent: "garage"},
+	{Name: "bottom", Parent: "freezer"},
+}
+
+func TestResolve(t *testing.T) {
+	tree := New(garage)
+	tests := []struct {
+		ref      string
+		wantName string
+		wantOK   bool
+	}{
+		{"top", "top", true},
+		{"garage/freezer/top", "top", true},
+		{"/garage/freezer/", "freezer", true},
+		{"garage", "garage", true},
+		{"cellar", "cellar", false},
+		{"freezer/top", "", false},
+		{"garage/top", "", false},
+		{"pantry/freezer/top", "", false},
+	}
+	for _, tc := range tests {
+		name, ok := tree.Resolve(tc.ref)
+		if name != tc.wantName || ok != tc.wantOK {
+			t.Errorf("Resolve(%q) = got (%q, %t), want (%q, %t)", tc.ref, name, ok, tc.wantName, tc.wantOK)
+		}
+	}
+}
+
+func TestList(t *testing.T) {
+	stock := []*sipb.Stock{
+		{Barcode: "123", Location: "top", Count: 2},
+		{Barcode: "456", Location: "top", Count: 1},
+		{Barcode: "123", Location: "freezer", Count: 4},
+		{Barcode: "123", Location: "pantry", Count: 5},
+	}
+	tests := []struct {
+		desc string
+		root string
+		want []*sipb.Location
+	}{
+		{
+			desc: "All",
+			want: []*sipb.Location{
+				{Name: "garage", Path: "garage", TotalCount: 7},
+				{Name: "freezer", Parent: "garage", Path: "garage/freezer", Depth: 1, Count: 4, TotalCount: 7},
+				{Name: "bottom", Parent: "freezer", Path: "garage/freezer/bottom", Depth: 2},
+				{Name: "top", Parent: "freezer", Path: "garage/freezer/top", Depth: 2, Count: 3, TotalCount: 3},
+				{Name: "pantry", Type: sipb.LocationType_PANTRY, Path: "pantry", Count: 5, TotalCount: 5},
+			},
+		},
+		{
+			desc: "Subtree",
+			root: "freezer",
+			want: []*sipb.Location{
+				{Name: "freezer", Parent: "garage", Path: "garage/freezer", Depth: 1, Count: 4, TotalCount: 7},
+				{Name: "bottom", Parent: "freezer", Path: "garage/freezer/bottom", Depth: 2},
+				{Name: "top", Parent: "freezer", Path: "garage/freezer/top", Depth: 2, Count: 3, TotalCount: 3},
+			},
+		},
+		{
+			desc: "Leaf",
+			root: "pantry",
+			want: []*sipb.Location{
+				{Name: "pantry", Type: sipb.LocationType_PANTRY, Path: "pantry", Count: 5, TotalCount: 5},
+			},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			got := New(garage).List(tc.root, stock)
+			if diff := cmp.Diff(tc.want, got, cmpopts.IgnoreUnexported(sipb.Location{})); diff != "" {
+				t.Errorf("List(%q, ...) = got diff (-want +got): %s", tc.root, diff)
+			}
+		})
+	}
+}
+
+func TestList_Broken(t *testing.T) {
+	// Storage shouldn't hold these, but every location is still listed.
+	locations := []*sipb.Location{
+		{Name: "a", Parent: "b"},
+		{Name: "b", Parent: "a"},
+		{Name: "self", Parent: "self"},
+		{Name: "orphan", Parent: "deleted"},
+	}
+	want := []*sipb.Location{
+		{Name: "a", Path: "a"},
+		{Name: "b", Parent: "a", Path: "a/b", Depth: 1},
+		{Name: "orphan", Path: "orphan"},
+		{Name: "self", Path: "self"},
+	}
+	got := New(locations).List("", nil)
+	if diff := cmp.Diff(want, got, cmpopts.IgnoreUnexported(sipb.Location{})); diff != "" {
+		t.Errorf("List(\"\", nil) = got diff (-want +got): %s", diff)
+	}
+}
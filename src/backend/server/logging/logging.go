@@ -0,0 +1,211 @@
+/*
+Copyright 2020 Robert Barron
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package logging provides structured, JSON-lines request logging for the
+// SnackInventory server.
+package logging
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	sipb "github.com/rmbarron/SnackInventory/src/proto/snackinventory"
+	"github.com/rmbarron/SnackInventory/src/tracing"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// Level is the severity of a log entry.
+type Level int
+
+// Supported log levels, in increasing severity.
+const (
+	Debug Level = iota
+	Info
+	Warn
+	Error
+)
+
+var levelNames = map[Level]string{
+	Debug: "debug",
+	Info:  "info",
+	Warn:  "warn",
+	Error: "error",
+}
+
+// String implements fmt.Stringer.
+func (l Level) String() string {
+	if n, ok := levelNames[l]; ok {
+		return n
+	}
+	return fmt.Sprintf("Level(%d)", int(l))
+}
+
+// ParseLevel parses a level name, as given by Level.String.
+func ParseLevel(s string) (Level, error) {
+	for l, n := range levelNames {
+		if strings.EqualFold(s, n) {
+			return l, nil
+		}
+	}
+	return Info, fmt.Errorf("unknown log level %q", s)
+}
+
+// Fields are the key/value pairs of a structured log entry.
+type Fields map[string]interface{}
+
+// Logger writes structured log entries as JSON lines.
+type Logger struct {
+	mu    sync.Mutex
+	w     io.Writer
+	level Level
+	now   func() time.Time
+}
+
+// New creates a Logger writing entries at or above level to w.
+func New(w io.Writer, level Level) *Logger {
+	return &Logger{w: w, level: level, now: time.Now}
+}
+
+// Log writes a single entry if level is enabled. Errors writing are dropped,
+// as there is nowhere left to report them.
+func (l *Logger) Log(level Level, msg string, fields Fields) {
+	if level < l.level {
+		return
+	}
+	entry := make(Fields, len(fields)+3)
+	for k, v := range fields {
+		entry[k] = v
+	}
+	entry["time"] = l.now().UTC().Format(time.RFC3339Nano)
+	entry["level"] = level.String()
+	entry["msg"] = msg
+
+	b, err := json.Marshal(entry)
+	if err != nil {
+		b, _ = json.Marshal(Fields{"level": Error.String(), "msg": "could not marshal log entry", "error": err.Error()})
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.w.Write(append(b, '\n'))
+}
+
+// UnaryServerInterceptor logs one entry per RPC, including the method, caller,
+// duration, status code, and any barcode or location named by the request.
+// Failed RPCs are logged at Warn for client errors and Error for server errors.
+// At Debug, the full request is logged too.
+func (l *Logger) UnaryServerInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	start := time.Now()
+	res, err := handler(ctx, req)
+
+	fields := rpcFields(ctx, info.FullMethod, start, err)
+	for k, v := range requestFields(req) {
+		fields[k] = v
+	}
+	if l.level <= Debug {
+		fields["request"] = fmt.Sprint(req)
+	}
+	l.Log(levelForCode(status.Code(err)), "handled RPC", fields)
+	return res, err
+}
+
+// StreamServerInterceptor logs one entry per streaming RPC, when the stream
+// ends, as UnaryServerInterceptor but without request fields.
+func (l *Logger) StreamServerInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	start := time.Now()
+	err := handler(srv, ss)
+
+	l.Log(levelForCode(status.Code(err)), "handled RPC", rpcFields(ss.Context(), info.FullMethod, start, err))
+	return err
+}
+
+// rpcFields returns the fields logged for every RPC to method, started at
+// start & completing with err.
+func rpcFields(ctx context.Context, method string, start time.Time, err error) Fields {
+	fields := Fields{
+		"method":      method,
+		"duration_ms": float64(time.Since(start).Microseconds()) / 1000,
+		"code":        status.Code(err).String(),
+	}
+	if p, ok := peer.FromContext(ctx); ok {
+		fields["caller"] = p.Addr.String()
+	}
+	if id := tracing.SpanFromContext(ctx).TraceID(); id != "" {
+		fields["trace_id"] = id
+	}
+	if err != nil {
+		fields["error"] = status.Convert(err).Message()
+	}
+	return fields
+}
+
+// requestFields extracts the identifiers of the snack or location a request
+// operates on.
+func requestFields(req interface{}) Fields {
+	f := Fields{}
+	if r, ok := req.(interface{ GetSnack() *sipb.Snack }); ok && r.GetSnack() != nil {
+		f["barcode"] = r.GetSnack().GetBarcode()
+	}
+	if r, ok := req.(interface{ GetLocation() *sipb.Location }); ok && r.GetLocation() != nil {
+		f["location"] = r.GetLocation().GetName()
+		// Updates identify the location by id.
+		if id := r.GetLocation().GetId(); id != 0 {
+			f["location_id"] = id
+		}
+	}
+	switch r := req.(type) {
+	case *sipb.DeleteSnackRequest:
+		f["barcode"] = r.GetBarcode()
+	case *sipb.DeleteLocationRequest:
+		f["location"] = r.GetName()
+		// Keeps a record of stock moved or discarded with the location.
+		if r.GetContents() != sipb.ContentsPolicy_CONTENTS_POLICY_UNSPECIFIED {
+			f["contents"] = r.GetContents().String()
+		}
+		if r.GetTarget() != "" {
+			f["target"] = r.GetTarget()
+		}
+	case *sipb.UndeleteSnackRequest:
+		f["barcode"] = r.GetBarcode()
+	case *sipb.UndeleteLocationRequest:
+		f["location"] = r.GetName()
+	case *sipb.GetSnackHistoryRequest:
+		f["barcode"] = r.GetBarcode()
+	case *sipb.AdjustStockRequest:
+		f["barcode"] = r.GetBarcode()
+		f["location"] = r.GetLocation()
+	}
+	return f
+}
+
+// levelForCode picks the log level for an RPC completing with code.
+func levelForCode(code codes.Code) Level {
+	switch code {
+	case codes.OK:
+		return Info
+	case codes.Internal, codes.Unknown, codes.DataLoss, codes.Unavailable, codes.Unimplemented:
+		return Error
+	default:
+		return Warn
+	}
+}
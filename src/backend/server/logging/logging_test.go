@@ -0,0 +1,159 @@
+/*
+Copyright 2020 Robert Barron
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net"
+	"testing"
+
+	sipb "github.com/rmbarron/SnackInventory/src/proto/snackinventory"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	buf := &bytes.Buffer{}
+	l := New(buf, Info)
+
+	ctx := peer.NewContext(context.Background(), &peer.Peer{
+		Addr: &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 1234},
+	})
+	req := &sipb.CreateSnackRequest{Snack: &sipb.Snack{Barcode: "123"}}
+	info := &grpc.UnaryServerInfo{FullMethod: "/snackinventory.SnackInventory/CreateSnack"}
+	handler := func(context.Context, interface{}) (interface{}, error) {
+		return nil, status.Error(codes.AlreadyExists, "already exists")
+	}
+
+	if _, err := l.UnaryServerInterceptor(ctx, req, info, handler); status.Code(err) != codes.AlreadyExists {
+		t.Fatalf("l.UnaryServerInterceptor(...) = got err %v, want code %v", err, codes.AlreadyExists)
+	}
+
+	got := Fields{}
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("json.Unmarshal(%q) = got err %v, want err nil", buf.String(), err)
+	}
+	for k, want := range map[string]string{
+		"level":   "warn",
+		"method":  info.FullMethod,
+		"caller":  "127.0.0.1:1234",
+		"code":    "AlreadyExists",
+		"barcode": "123",
+		"error":   "already exists",
+	} {
+		if got[k] != want {
+			t.Errorf("entry[%q] = got %v, want %q", k, got[k], want)
+		}
+	}
+	if _, ok := got["duration_ms"]; !ok {
+		t.Error("entry[\"duration_ms\"] = got missing, want present")
+	}
+	if _, ok := got["request"]; ok {
+		t.Error("entry[\"request\"] = got present at level info, want missing")
+	}
+}
+
+// fakeServerStream is a grpc.ServerStream with only a context.
+type fakeServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *fakeServerStream) Context() context.Context { return s.ctx }
+
+func TestStreamServerInterceptor(t *testing.T) {
+	buf := &bytes.Buffer{}
+	l := New(buf, Info)
+
+	ctx := peer.NewContext(context.Background(), &peer.Peer{
+		Addr: &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 1234},
+	})
+	info := &grpc.StreamServerInfo{FullMethod: "/snackinventory.SnackInventory/WatchChanges", IsServerStream: true}
+	handler := func(interface{}, grpc.ServerStream) error {
+		return status.Error(codes.Unavailable, "shutting down")
+	}
+
+	if err := l.StreamServerInterceptor(nil, &fakeServerStream{ctx: ctx}, info, handler); status.Code(err) != codes.Unavailable {
+		t.Fatalf("l.StreamServerInterceptor(...) = got err %v, want code %v", err, codes.Unavailable)
+	}
+
+	got := Fields{}
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("json.Unmarshal(%q) = got err %v, want err nil", buf.String(), err)
+	}
+	for k, want := range map[string]string{
+		"level":  "error",
+		"method": info.FullMethod,
+		"caller": "127.0.0.1:1234",
+		"code":   "Unavailable",
+		"error":  "shutting down",
+	} {
+		if got[k] != want {
+			t.Errorf("entry[%q] = got %v, want %q", k, got[k], want)
+		}
+	}
+}
+
+func TestUnaryServerInterceptor_LocationField(t *testing.T) {
+	buf := &bytes.Buffer{}
+	l := New(buf, Debug)
+
+	req := &sipb.DeleteLocationRequest{Name: "fridge", Contents: sipb.ContentsPolicy_MOVE, Target: "pantry"}
+	info := &grpc.UnaryServerInfo{FullMethod: "/snackinventory.SnackInventory/DeleteLocation"}
+	handler := func(context.Context, interface{}) (interface{}, error) { return nil, nil }
+	l.UnaryServerInterceptor(context.Background(), req, info, handler)
+
+	got := Fields{}
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("json.Unmarshal(%q) = got err %v, want err nil", buf.String(), err)
+	}
+	for k, want := range map[string]string{"location": "fridge", "contents": "MOVE", "target": "pantry"} {
+		if got[k] != want {
+			t.Errorf("entry[%q] = got %v, want %q", k, got[k], want)
+		}
+	}
+	if _, ok := got["request"]; !ok {
+		t.Error("entry[\"request\"] = got missing at level debug, want present")
+	}
+}
+
+func TestLog_BelowLevel(t *testing.T) {
+	buf := &bytes.Buffer{}
+	l := New(buf, Warn)
+	l.Log(Info, "dropped", nil)
+	if buf.Len() != 0 {
+		t.Fatalf("l.Log(Info, ...) at level Warn = wrote %q, want nothing", buf.String())
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	for s, want := range map[string]Level{"debug": Debug, "INFO": Info, "warn": Warn, "error": Error} {
+		got, err := ParseLevel(s)
+		if err != nil {
+			t.Fatalf("ParseLevel(%q) = got err %v, want err nil", s, err)
+		}
+		if got != want {
+			t.Errorf("ParseLevel(%q) = got %v, want %v", s, got, want)
+		}
+	}
+	if _, err := ParseLevel("verbose"); err == nil {
+		t.Error("ParseLevel(\"verbose\") = got err nil, want err")
+	}
+}
@@ -0,0 +1,169 @@
+/*
+Copyright 2020 Robert Barron
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package lookup
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	sipb "github.com/rmbarron/SnackInventory/src/proto/snackinventory"
+	"google.golang.org/protobuf/proto"
+)
+
+// Dump is a ProductLookup over an Open Food Facts export, indexed in memory.
+// Only the fields used for snacks are kept.
+type Dump struct {
+	products map[string]*sipb.Snack
+}
+
+// LoadDump indexes the Open Food Facts export at path. The format is chosen by
+// extension: JSON lines (.jsonl or .json) or tab separated CSV (.csv or .tsv),
+// optionally gzipped (.gz), as downloaded from Open Food Facts.
+func LoadDump(path string) (*Dump, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not open product dump: %w", err)
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	ext := filepath.Ext(path)
+	if ext == ".gz" {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return nil, fmt.Errorf("could not read product dump %s: %w", path, err)
+		}
+		defer gz.Close()
+		r = gz
+		ext = filepath.Ext(strings.TrimSuffix(path, ext))
+	}
+
+	var d *Dump
+	switch ext {
+	case ".jsonl", ".json":
+		d, err = readJSONL(r)
+	case ".csv", ".tsv":
+		d, err = readCSV(r)
+	default:
+		return nil, fmt.Errorf("product dump %s: unsupported extension %q; want .jsonl or .csv", path, ext)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not read product dump %s: %w", path, err)
+	}
+	return d, nil
+}
+
+// add indexes p, if it has useful metadata.
+func (d *Dump) add(p *product) {
+	if s := p.snack(); s != nil {
+		d.products[s.GetBarcode()] = s
+	}
+}
+
+// readJSONL indexes a JSON lines export, one product per line.
+func readJSONL(r io.Reader) (*Dump, error) {
+	d := &Dump{products: map[string]*sipb.Snack{}}
+	// Lines can be too long for a bufio.Scanner, so read them whole.
+	br := bufio.NewReader(r)
+	for n := 1; ; n++ {
+		line, err := br.ReadBytes('\n')
+		if len(strings.TrimSpace(string(line))) > 0 {
+			p := &product{}
+			if jerr := json.Unmarshal(line, p); jerr != nil {
+				return nil, fmt.Errorf("line %d: %w", n, jerr)
+			}
+			d.add(p)
+		}
+		if errors.Is(err, io.EOF) {
+			return d, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// readCSV indexes a tab separated export with a header row.
+func readCSV(r io.Reader) (*Dump, error) {
+	cr := csv.NewReader(r)
+	cr.Comma = '\t'
+	// Open Food Facts doesn't quote fields, so quotes are just text.
+	cr.LazyQuotes = true
+	cr.FieldsPerRecord = -1
+	cr.ReuseRecord = true
+
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("could not read header: %w", err)
+	}
+	cols := map[string]int{}
+	for i, name := range header {
+		cols[name] = i
+	}
+	if _, ok := cols["code"]; !ok {
+		return nil, errors.New(`header has no "code" column`)
+	}
+	field := func(record []string, name string) string {
+		if i, ok := cols[name]; ok && i < len(record) {
+			return record[i]
+		}
+		return ""
+	}
+
+	d := &Dump{products: map[string]*sipb.Snack{}}
+	for {
+		record, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			return d, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		d.add(&product{
+			Code:        field(record, "code"),
+			ProductName: field(record, "product_name"),
+			GenericName: field(record, "generic_name"),
+			Brands:      field(record, "brands"),
+			Categories:  field(record, "categories"),
+			Quantity:    field(record, "quantity"),
+		})
+	}
+}
+
+// Len returns the number of products indexed.
+func (d *Dump) Len() int {
+	return len(d.products)
+}
+
+// Lookup implements ProductLookup.
+func (d *Dump) Lookup(_ context.Context, barcode string) (*sipb.Snack, error) {
+	for _, code := range candidates(barcode) {
+		if s, ok := d.products[code]; ok {
+			return proto.Clone(s).(*sipb.Snack), nil
+		}
+	}
+	return nil, fmt.Errorf("barcode %q: %w", barcode, ErrNotFound)
+}
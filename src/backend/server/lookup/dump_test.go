@@ -0,0 +1,133 @@
+/*
+Copyright 2020 Robert Barron
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package lookup
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	sipb "github.com/rmbarron/SnackInventory/src/proto/snackinventory"
+)
+
+const (
+	testJSONL = `{"code": "0012345678905", "product_name": "Salted Chips", "brands": "Crunchy Co", "categories": "Snacks, Chips", "quantity": "150 g", "nutriments": {"fat": 30}}
+
+{"code": "5449000000996", "product_name": "Cola", "quantity": "330 ml"}
+{"code": "999"}
+`
+	testCSV = "code\tproduct_name\tbrands\tcategories\tquantity\n" +
+		"0012345678905\tSalted Chips\tCrunchy Co\tSnacks, Chips\t150 g\n" +
+		"5449000000996\tCola\t\t\t330 ml\n" +
+		"999\t\t\t\t\n"
+)
+
+// writeDumpT writes contents to a file named name, returning its path.
+func writeDumpT(t *testing.T, name string, contents []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := ioutil.WriteFile(path, contents, 0600); err != nil {
+		t.Fatalf("ioutil.WriteFile(%q) = got err %v, want err nil", path, err)
+	}
+	return path
+}
+
+func gzipT(t *testing.T, s string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write([]byte(s)); err != nil {
+		t.Fatalf("w.Write(...) = got err %v, want err nil", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("w.Close() = got err %v, want err nil", err)
+	}
+	return buf.Bytes()
+}
+
+func TestLoadDump(t *testing.T) {
+	tests := []struct {
+		name     string
+		contents []byte
+	}{
+		{"products.jsonl", []byte(testJSONL)},
+		{"products.jsonl.gz", gzipT(t, testJSONL)},
+		{"products.csv", []byte(testCSV)},
+		{"products.csv.gz", gzipT(t, testCSV)},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			path := writeDumpT(t, tc.name, tc.contents)
+			d, err := LoadDump(path)
+			if err != nil {
+				t.Fatalf("LoadDump(%q) = got err %v, want err nil", path, err)
+			}
+			// The product without metadata is skipped.
+			if got := d.Len(); got != 2 {
+				t.Errorf("LoadDump(%q).Len() = got %d, want 2", path, got)
+			}
+
+			// Scanned as UPC-A, without the leading zero.
+			got, err := d.Lookup(context.Background(), "012345678905")
+			if err != nil {
+				t.Fatalf("d.Lookup(ctx, %q) = got err %v, want err nil", "012345678905", err)
+			}
+			want := &sipb.Snack{Barcode: "0012345678905", Name: "Salted Chips", Brand: "Crunchy Co", Category: "Chips", PackageSize: "150 g"}
+			if diff := cmp.Diff(want, got, cmpopts.IgnoreUnexported(sipb.Snack{})); diff != "" {
+				t.Errorf("d.Lookup(ctx, %q) = got diff (-want +got): %s", "012345678905", diff)
+			}
+		})
+	}
+}
+
+func TestLoadDump_Errors(t *testing.T) {
+	tests := []struct {
+		name     string
+		contents string
+	}{
+		{"products.xml", "<products/>"},
+		{"products.jsonl", "{\"code\": \"1\"}\nnot json\n"},
+		{"products.csv", "barcode\tname\n1\tchips\n"},
+		{"products.csv.gz", "not gzipped"},
+	}
+	for _, tc := range tests {
+		path := writeDumpT(t, tc.name, []byte(tc.contents))
+		if _, err := LoadDump(path); err == nil {
+			t.Errorf("LoadDump(%q) with contents %q = got err nil, want err", path, tc.contents)
+		}
+	}
+	if _, err := LoadDump(filepath.Join(t.TempDir(), "missing.jsonl")); err == nil {
+		t.Error("LoadDump(missing file) = got err nil, want err")
+	}
+}
+
+func TestDumpLookup_NotFound(t *testing.T) {
+	d, err := readJSONL(bytes.NewBufferString(testJSONL))
+	if err != nil {
+		t.Fatalf("readJSONL(...) = got err %v, want err nil", err)
+	}
+	for _, barcode := range []string{"123", "999"} {
+		if _, err := d.Lookup(context.Background(), barcode); !errors.Is(err, ErrNotFound) {
+			t.Errorf("d.Lookup(ctx, %q) = got err %v, want ErrNotFound", barcode, err)
+		}
+	}
+}
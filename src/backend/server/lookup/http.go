@@ -0,0 +1,95 @@
+/*
+Copyright 2020 Robert Barron
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package lookup
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	sipb "github.com/rmbarron/SnackInventory/src/proto/snackinventory"
+)
+
+// HTTP is a ProductLookup using the Open Food Facts API at a configurable
+// URL, ex: a local mirror.
+type HTTP struct {
+	baseURL string
+	client  *http.Client
+}
+
+// NewHTTP looks products up from the API at baseURL, ex:
+// "https://world.openfoodfacts.org". Requests are made with client, which
+// should have a timeout.
+func NewHTTP(baseURL string, client *http.Client) *HTTP {
+	return &HTTP{baseURL: strings.TrimSuffix(baseURL, "/"), client: client}
+}
+
+// productResponse is the response of the product API.
+type productResponse struct {
+	// Status is 1 if the product was found.
+	Status  int     `json:"status"`
+	Product product `json:"product"`
+}
+
+// Lookup implements ProductLookup.
+func (h *HTTP) Lookup(ctx context.Context, barcode string) (*sipb.Snack, error) {
+	for _, code := range candidates(barcode) {
+		s, err := h.get(ctx, code)
+		if err != nil {
+			return nil, fmt.Errorf("could not look up barcode %q: %w", barcode, err)
+		}
+		if s != nil {
+			return s, nil
+		}
+	}
+	return nil, fmt.Errorf("barcode %q: %w", barcode, ErrNotFound)
+}
+
+// get fetches the product with code, returning nil if it is not found.
+func (h *HTTP) get(ctx context.Context, code string) (*sipb.Snack, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+		fmt.Sprintf("%s/api/v0/product/%s.json", h.baseURL, url.PathEscape(code)), nil)
+	if err != nil {
+		return nil, err
+	}
+	res, err := h.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: %s", req.URL, res.Status)
+	}
+
+	var pr productResponse
+	if err := json.NewDecoder(res.Body).Decode(&pr); err != nil {
+		return nil, fmt.Errorf("%s: could not decode response: %w", req.URL, err)
+	}
+	if pr.Status != 1 {
+		return nil, nil
+	}
+	if pr.Product.Code == "" {
+		pr.Product.Code = code
+	}
+	return pr.Product.snack(), nil
+}
@@ -0,0 +1,82 @@
+/*
+Copyright 2020 Robert Barron
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package lookup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	sipb "github.com/rmbarron/SnackInventory/src/proto/snackinventory"
+)
+
+// newMirrorT serves the product API for products in JSON, keyed by code.
+func newMirrorT(t *testing.T, products map[string]string) *HTTP {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v0/product/", func(w http.ResponseWriter, r *http.Request) {
+		code := r.URL.Path[len("/api/v0/product/") : len(r.URL.Path)-len(".json")]
+		switch p, ok := products[code]; {
+		case code == "500":
+			http.Error(w, "mirror is down", http.StatusInternalServerError)
+		case ok:
+			fmt.Fprintf(w, `{"status": 1, "code": %q, "product": %s}`, code, p)
+		default:
+			fmt.Fprintf(w, `{"status": 0, "code": %q, "status_verbose": "product not found"}`, code)
+		}
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return NewHTTP(srv.URL+"/", &http.Client{Timeout: 5 * time.Second})
+}
+
+func TestHTTPLookup(t *testing.T) {
+	h := newMirrorT(t, map[string]string{
+		"0012345678905": `{"product_name": "Salted Chips", "brands": "Crunchy Co", "categories": "Snacks, Chips", "quantity": "150 g"}`,
+	})
+
+	got, err := h.Lookup(context.Background(), "012345678905")
+	if err != nil {
+		t.Fatalf("h.Lookup(ctx, %q) = got err %v, want err nil", "012345678905", err)
+	}
+	want := &sipb.Snack{Barcode: "0012345678905", Name: "Salted Chips", Brand: "Crunchy Co", Category: "Chips", PackageSize: "150 g"}
+	if diff := cmp.Diff(want, got, cmpopts.IgnoreUnexported(sipb.Snack{})); diff != "" {
+		t.Errorf("h.Lookup(ctx, %q) = got diff (-want +got): %s", "012345678905", diff)
+	}
+}
+
+func TestHTTPLookup_NotFound(t *testing.T) {
+	h := newMirrorT(t, map[string]string{"123": `{}`})
+	for _, barcode := range []string{"999", "123"} {
+		if _, err := h.Lookup(context.Background(), barcode); !errors.Is(err, ErrNotFound) {
+			t.Errorf("h.Lookup(ctx, %q) = got err %v, want ErrNotFound", barcode, err)
+		}
+	}
+}
+
+func TestHTTPLookup_ServerError(t *testing.T) {
+	h := newMirrorT(t, nil)
+	_, err := h.Lookup(context.Background(), "500")
+	if err == nil || errors.Is(err, ErrNotFound) {
+		t.Errorf("h.Lookup(ctx, %q) = got err %v, want a non-ErrNotFound err", "500", err)
+	}
+}
@@ -0,0 +1,127 @@
+/*
+Copyright 2020 Robert Barron
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package lookup resolves product metadata (name, brand, category & package
+// size) from barcodes, so snacks don't need it filled in by hand. Metadata
+// comes from Open Food Facts, either a locally downloaded dump or an HTTP API
+// such as a local mirror.
+package lookup
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/rmbarron/SnackInventory/src/limits"
+	sipb "github.com/rmbarron/SnackInventory/src/proto/snackinventory"
+)
+
+// ErrNotFound is returned by lookups of unknown barcodes.
+var ErrNotFound = errors.New("product not found")
+
+// ProductLookup resolves product metadata from barcodes.
+type ProductLookup interface {
+	// Lookup returns the product with barcode as a Snack, or an error wrapping
+	// ErrNotFound if there is none.
+	Lookup(ctx context.Context, barcode string) (*sipb.Snack, error)
+}
+
+// product holds the fields used from an Open Food Facts product, as named in
+// both their JSON & CSV exports.
+type product struct {
+	Code        string `json:"code"`
+	ProductName string `json:"product_name"`
+	GenericName string `json:"generic_name"`
+	Brands      string `json:"brands"`
+	Categories  string `json:"categories"`
+	Quantity    string `json:"quantity"`
+}
+
+// snack converts p to a Snack, or returns nil if p has no useful metadata.
+// Fields longer than the schema allows are cut short, so the Snack can be
+// stored.
+func (p *product) snack() *sipb.Snack {
+	name := strings.TrimSpace(p.ProductName)
+	if name == "" {
+		name = strings.TrimSpace(p.GenericName)
+	}
+	s := &sipb.Snack{
+		Barcode: strings.TrimSpace(p.Code),
+		Name:    clamp(name, limits.MaxNameLen),
+		// Brands are listed by importance, categories from general to specific.
+		Brand:       clamp(firstItem(p.Brands), limits.MaxBrandLen),
+		Category:    clamp(lastItem(p.Categories), limits.MaxCategoryLen),
+		PackageSize: clamp(p.Quantity, limits.MaxPackageSizeLen),
+	}
+	if s.Barcode == "" || s.Name == "" && s.Brand == "" && s.Category == "" && s.PackageSize == "" {
+		return nil
+	}
+	return s
+}
+
+// clamp trims the spaces around s, then cuts it to at most n characters.
+func clamp(s string, n int) string {
+	s = strings.TrimSpace(s)
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return strings.TrimSpace(string([]rune(s)[:n]))
+}
+
+// firstItem returns the first of a comma separated list.
+func firstItem(list string) string {
+	if i := strings.Index(list, ","); i >= 0 {
+		return list[:i]
+	}
+	return list
+}
+
+// lastItem returns the last of a comma separated list, without any language
+// prefix, ex: "Snacks, en:chips" gives "chips".
+func lastItem(list string) string {
+	item := list[strings.LastIndex(list, ",")+1:]
+	if i := strings.Index(item, ":"); i >= 0 {
+		item = item[i+1:]
+	}
+	return item
+}
+
+// candidates returns the codes a product with barcode may be listed under.
+// Open Food Facts lists UPC-A products by their EAN-13 code, which adds a
+// leading zero that scanners may omit.
+func candidates(barcode string) []string {
+	codes := []string{barcode}
+	if !isDigits(barcode) {
+		return codes
+	}
+	switch {
+	case len(barcode) == 12:
+		codes = append(codes, "0"+barcode)
+	case len(barcode) == 13 && barcode[0] == '0':
+		codes = append(codes, barcode[1:])
+	}
+	return codes
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
@@ -0,0 +1,101 @@
+/*
+Copyright 2020 Robert Barron
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package lookup
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/rmbarron/SnackInventory/src/limits"
+	sipb "github.com/rmbarron/SnackInventory/src/proto/snackinventory"
+)
+
+func TestCandidates(t *testing.T) {
+	tests := []struct {
+		barcode string
+		want    []string
+	}{
+		{"012345678905", []string{"012345678905", "0012345678905"}},
+		{"0012345678905", []string{"0012345678905", "012345678905"}},
+		{"5449000000996", []string{"5449000000996"}},
+		{"abcdefghijkl", []string{"abcdefghijkl"}},
+	}
+	for _, tc := range tests {
+		if diff := cmp.Diff(tc.want, candidates(tc.barcode)); diff != "" {
+			t.Errorf("candidates(%q) = got diff (-want +got): %s", tc.barcode, diff)
+		}
+	}
+}
+
+func TestProductSnack(t *testing.T) {
+	tests := []struct {
+		desc string
+		p    *product
+		want *sipb.Snack
+	}{
+		{
+			desc: "AllFields",
+			p: &product{
+				Code:        "123",
+				ProductName: " Salted Chips ",
+				Brands:      "Crunchy Co, Parent Corp",
+				Categories:  "Snacks, Salty snacks, en:chips",
+				Quantity:    "150 g",
+			},
+			want: &sipb.Snack{Barcode: "123", Name: "Salted Chips", Brand: "Crunchy Co", Category: "chips", PackageSize: "150 g"},
+		},
+		{
+			desc: "GenericName",
+			p:    &product{Code: "123", GenericName: "Chips"},
+			want: &sipb.Snack{Barcode: "123", Name: "Chips"},
+		},
+		{
+			desc: "Oversized",
+			p: &product{
+				Code:        "123",
+				ProductName: strings.Repeat("é", limits.MaxNameLen+1),
+				Brands:      strings.Repeat("b", limits.MaxBrandLen+1),
+				Categories:  "en:" + strings.Repeat("c", limits.MaxCategoryLen+1),
+				// Cut short after the space, which is trimmed.
+				Quantity: strings.Repeat("ü", limits.MaxPackageSizeLen-1) + " g",
+			},
+			want: &sipb.Snack{
+				Barcode:     "123",
+				Name:        strings.Repeat("é", limits.MaxNameLen),
+				Brand:       strings.Repeat("b", limits.MaxBrandLen),
+				Category:    strings.Repeat("c", limits.MaxCategoryLen),
+				PackageSize: strings.Repeat("ü", limits.MaxPackageSizeLen-1),
+			},
+		},
+		{
+			desc: "NoMetadata",
+			p:    &product{Code: "123"},
+		},
+		{
+			desc: "NoCode",
+			p:    &product{ProductName: "Chips"},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			if diff := cmp.Diff(tc.want, tc.p.snack(), cmpopts.IgnoreUnexported(sipb.Snack{})); diff != "" {
+				t.Errorf("%+v.snack() = got diff (-want +got): %s", tc.p, diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,273 @@
+/*
+Copyright 2020 Robert Barron
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package metrics provides Prometheus collectors for the SnackInventory server.
+// Three groups of metrics are offered:
+//   - RPC counts, latencies, and status codes, recorded via interceptor.
+//   - Connection pool statistics for SQL backed storage.
+//   - Inventory gauges, read from storage at scrape time.
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	sipb "github.com/rmbarron/SnackInventory/src/proto/snackinventory"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+const namespace = "snackinventory"
+
+// RPCMetrics records the outcome of every RPC handled by the server.
+// RPCMetrics is a prometheus.Collector, and must be registered to be exported.
+type RPCMetrics struct {
+	handled *prometheus.CounterVec
+	latency *prometheus.HistogramVec
+}
+
+// NewRPCMetrics creates an RPCMetrics with no RPCs recorded.
+func NewRPCMetrics() *RPCMetrics {
+	return &RPCMetrics{
+		handled: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "grpc",
+			Name:      "server_handled_total",
+			Help:      "Total number of RPCs completed, by method and status code.",
+		}, []string{"method", "code"}),
+		latency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "grpc",
+			Name:      "server_handling_seconds",
+			Help:      "Latency of RPCs handled by the server, by method.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method"}),
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (m *RPCMetrics) Describe(ch chan<- *prometheus.Desc) {
+	m.handled.Describe(ch)
+	m.latency.Describe(ch)
+}
+
+// Collect implements prometheus.Collector.
+func (m *RPCMetrics) Collect(ch chan<- prometheus.Metric) {
+	m.handled.Collect(ch)
+	m.latency.Collect(ch)
+}
+
+// UnaryServerInterceptor records the latency and status code of each unary RPC.
+// Install with `grpc.UnaryInterceptor(m.UnaryServerInterceptor)`.
+func (m *RPCMetrics) UnaryServerInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	start := time.Now()
+	res, err := handler(ctx, req)
+	m.latency.WithLabelValues(info.FullMethod).Observe(time.Since(start).Seconds())
+	m.handled.WithLabelValues(info.FullMethod, status.Code(err).String()).Inc()
+	return res, err
+}
+
+// StreamServerInterceptor records the duration and status code of each
+// streaming RPC, as UnaryServerInterceptor.
+// Install with `grpc.StreamInterceptor(m.StreamServerInterceptor)`.
+func (m *RPCMetrics) StreamServerInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	start := time.Now()
+	err := handler(srv, ss)
+	m.latency.WithLabelValues(info.FullMethod).Observe(time.Since(start).Seconds())
+	m.handled.WithLabelValues(info.FullMethod, status.Code(err).String()).Inc()
+	return err
+}
+
+// dbStatsCollector exports sql.DBStats as Prometheus metrics.
+type dbStatsCollector struct {
+	stats func() sql.DBStats
+
+	maxOpen           *prometheus.Desc
+	open              *prometheus.Desc
+	inUse             *prometheus.Desc
+	idle              *prometheus.Desc
+	waitCount         *prometheus.Desc
+	waitDuration      *prometheus.Desc
+	maxIdleClosed     *prometheus.Desc
+	maxLifetimeClosed *prometheus.Desc
+}
+
+// NewDBStatsCollector creates a collector for SQL connection pool statistics.
+// stats is called on every scrape, so should be cheap (ex: `(*sql.DB).Stats`).
+func NewDBStatsCollector(stats func() sql.DBStats) prometheus.Collector {
+	desc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(namespace, "sql", name), help, nil, nil)
+	}
+	return &dbStatsCollector{
+		stats:             stats,
+		maxOpen:           desc("max_open_connections", "Maximum number of open connections to the database."),
+		open:              desc("open_connections", "Number of established connections, both in use and idle."),
+		inUse:             desc("in_use_connections", "Number of connections currently in use."),
+		idle:              desc("idle_connections", "Number of idle connections."),
+		waitCount:         desc("wait_count_total", "Total number of connections waited for."),
+		waitDuration:      desc("wait_duration_seconds_total", "Total time blocked waiting for a new connection."),
+		maxIdleClosed:     desc("max_idle_closed_total", "Total number of connections closed due to SetMaxIdleConns."),
+		maxLifetimeClosed: desc("max_lifetime_closed_total", "Total number of connections closed due to SetConnMaxLifetime."),
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (c *dbStatsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.maxOpen
+	ch <- c.open
+	ch <- c.inUse
+	ch <- c.idle
+	ch <- c.waitCount
+	ch <- c.waitDuration
+	ch <- c.maxIdleClosed
+	ch <- c.maxLifetimeClosed
+}
+
+// Collect implements prometheus.Collector.
+func (c *dbStatsCollector) Collect(ch chan<- prometheus.Metric) {
+	s := c.stats()
+	ch <- prometheus.MustNewConstMetric(c.maxOpen, prometheus.GaugeValue, float64(s.MaxOpenConnections))
+	ch <- prometheus.MustNewConstMetric(c.open, prometheus.GaugeValue, float64(s.OpenConnections))
+	ch <- prometheus.MustNewConstMetric(c.inUse, prometheus.GaugeValue, float64(s.InUse))
+	ch <- prometheus.MustNewConstMetric(c.idle, prometheus.GaugeValue, float64(s.Idle))
+	ch <- prometheus.MustNewConstMetric(c.waitCount, prometheus.CounterValue, float64(s.WaitCount))
+	ch <- prometheus.MustNewConstMetric(c.waitDuration, prometheus.CounterValue, s.WaitDuration.Seconds())
+	ch <- prometheus.MustNewConstMetric(c.maxIdleClosed, prometheus.CounterValue, float64(s.MaxIdleClosed))
+	ch <- prometheus.MustNewConstMetric(c.maxLifetimeClosed, prometheus.CounterValue, float64(s.MaxLifetimeClosed))
+}
+
+// cacheStatsCollector exports the lookups of a storage cache as Prometheus
+// metrics.
+type cacheStatsCollector struct {
+	stats func() (hits, misses uint64)
+
+	hits   *prometheus.Desc
+	misses *prometheus.Desc
+}
+
+// NewCacheStatsCollector creates a collector for storage cache statistics.
+// stats is called on every scrape, so should be cheap (ex: a wrapper of
+// `(*connector.CachedStore).Stats`).
+func NewCacheStatsCollector(stats func() (hits, misses uint64)) prometheus.Collector {
+	desc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(namespace, "cache", name), help, nil, nil)
+	}
+	return &cacheStatsCollector{
+		stats:  stats,
+		hits:   desc("hits_total", "Total number of storage reads served from the cache."),
+		misses: desc("misses_total", "Total number of storage reads missing the cache, so read from storage."),
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (c *cacheStatsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.hits
+	ch <- c.misses
+}
+
+// Collect implements prometheus.Collector.
+func (c *cacheStatsCollector) Collect(ch chan<- prometheus.Metric) {
+	hits, misses := c.stats()
+	ch <- prometheus.MustNewConstMetric(c.hits, prometheus.CounterValue, float64(hits))
+	ch <- prometheus.MustNewConstMetric(c.misses, prometheus.CounterValue, float64(misses))
+}
+
+// InventoryLister is the subset of storage operations needed to export
+// inventory gauges.
+type InventoryLister interface {
+	ListSnacks(ctx context.Context) ([]*sipb.Snack, error)
+	ListLocations(ctx context.Context) ([]*sipb.Location, error)
+	ListStock(ctx context.Context, location string) ([]*sipb.Stock, error)
+}
+
+// inventoryCollector reads the current inventory from storage on every scrape.
+type inventoryCollector struct {
+	l       InventoryLister
+	timeout time.Duration
+
+	snacks    *prometheus.Desc
+	locations *prometheus.Desc
+	stock     *prometheus.Desc
+}
+
+// NewInventoryCollector creates a collector exporting the current contents of
+// storage. Storage is read on every scrape, bounded by timeout.
+func NewInventoryCollector(l InventoryLister, timeout time.Duration) prometheus.Collector {
+	return &inventoryCollector{
+		l:       l,
+		timeout: timeout,
+		snacks: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "inventory", "snacks"),
+			"Number of snacks registered to SnackInventory.", nil, nil),
+		locations: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "inventory", "locations"),
+			"Number of locations registered to SnackInventory.", nil, nil),
+		stock: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "inventory", "stock"),
+			"Number of each snack in stock, by location.", []string{"barcode", "location"}, nil),
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (c *inventoryCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.snacks
+	ch <- c.locations
+	ch <- c.stock
+}
+
+// Collect implements prometheus.Collector.
+// Storage errors are reported as invalid metrics, failing the scrape for only
+// the affected gauge.
+func (c *inventoryCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	if snacks, err := c.l.ListSnacks(ctx); err != nil {
+		ch <- prometheus.NewInvalidMetric(c.snacks, fmt.Errorf("could not list snacks: %w", err))
+	} else {
+		ch <- prometheus.MustNewConstMetric(c.snacks, prometheus.GaugeValue, float64(len(snacks)))
+	}
+
+	if locations, err := c.l.ListLocations(ctx); err != nil {
+		ch <- prometheus.NewInvalidMetric(c.locations, fmt.Errorf("could not list locations: %w", err))
+	} else {
+		ch <- prometheus.MustNewConstMetric(c.locations, prometheus.GaugeValue, float64(len(locations)))
+	}
+
+	stock, err := c.l.ListStock(ctx, "")
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(c.stock, fmt.Errorf("could not list stock: %w", err))
+		return
+	}
+	// Stock has a row per snack & location, so no two rows share labels.
+	for _, st := range stock {
+		ch <- prometheus.MustNewConstMetric(
+			c.stock, prometheus.GaugeValue, float64(st.GetCount()), st.GetBarcode(), st.GetLocation())
+	}
+}
+
+// ListenAndServe serves metrics gathered from g over HTTP at addr/metrics.
+// Always returns a non-nil error, like http.ListenAndServe.
+func ListenAndServe(addr string, g prometheus.Gatherer) error {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(g, promhttp.HandlerOpts{}))
+	return http.ListenAndServe(addr, mux)
+}
@@ -0,0 +1,156 @@
+/*
+Copyright 2020 Robert Barron
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/rmbarron/SnackInventory/src/backend/fakes/fakedbconnector"
+	sipb "github.com/rmbarron/SnackInventory/src/proto/snackinventory"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	m := NewRPCMetrics()
+	info := &grpc.UnaryServerInfo{FullMethod: "/snackinventory.SnackInventory/CreateSnack"}
+
+	okHandler := func(context.Context, interface{}) (interface{}, error) { return nil, nil }
+	errHandler := func(context.Context, interface{}) (interface{}, error) {
+		return nil, status.Error(codes.AlreadyExists, "already exists")
+	}
+
+	for _, h := range []grpc.UnaryHandler{okHandler, okHandler, errHandler} {
+		m.UnaryServerInterceptor(context.Background(), nil, info, h)
+	}
+
+	if got := testutil.ToFloat64(m.handled.WithLabelValues(info.FullMethod, "OK")); got != 2 {
+		t.Errorf("handled{code=OK} = got %v, want 2", got)
+	}
+	if got := testutil.ToFloat64(m.handled.WithLabelValues(info.FullMethod, "AlreadyExists")); got != 1 {
+		t.Errorf("handled{code=AlreadyExists} = got %v, want 1", got)
+	}
+	if got := testutil.CollectAndCount(m.latency); got != 1 {
+		t.Errorf("testutil.CollectAndCount(latency) = got %d, want 1", got)
+	}
+}
+
+func TestUnaryServerInterceptor_PassesThroughError(t *testing.T) {
+	m := NewRPCMetrics()
+	info := &grpc.UnaryServerInfo{FullMethod: "/snackinventory.SnackInventory/ListSnacks"}
+	handler := func(context.Context, interface{}) (interface{}, error) {
+		return nil, status.Error(codes.Internal, "something failed")
+	}
+
+	if _, err := m.UnaryServerInterceptor(context.Background(), nil, info, handler); status.Code(err) != codes.Internal {
+		t.Fatalf("m.UnaryServerInterceptor(...) = got err %v, want code %v", err, codes.Internal)
+	}
+}
+
+func TestStreamServerInterceptor(t *testing.T) {
+	m := NewRPCMetrics()
+	info := &grpc.StreamServerInfo{FullMethod: "/snackinventory.SnackInventory/WatchChanges"}
+	handler := func(interface{}, grpc.ServerStream) error {
+		return status.Error(codes.Canceled, "client went away")
+	}
+
+	if err := m.StreamServerInterceptor(nil, nil, info, handler); status.Code(err) != codes.Canceled {
+		t.Fatalf("m.StreamServerInterceptor(...) = got err %v, want code %v", err, codes.Canceled)
+	}
+	if got := testutil.ToFloat64(m.handled.WithLabelValues(info.FullMethod, "Canceled")); got != 1 {
+		t.Errorf("handled{method=%q, code=Canceled} = got %v, want 1", info.FullMethod, got)
+	}
+}
+
+func TestDBStatsCollector(t *testing.T) {
+	c := NewDBStatsCollector(func() sql.DBStats {
+		return sql.DBStats{MaxOpenConnections: 10, OpenConnections: 3, InUse: 1, Idle: 2}
+	})
+
+	want := `
+# HELP snackinventory_sql_open_connections Number of established connections, both in use and idle.
+# TYPE snackinventory_sql_open_connections gauge
+snackinventory_sql_open_connections 3
+# HELP snackinventory_sql_in_use_connections Number of connections currently in use.
+# TYPE snackinventory_sql_in_use_connections gauge
+snackinventory_sql_in_use_connections 1
+`
+	if err := testutil.CollectAndCompare(c, strings.NewReader(want),
+		"snackinventory_sql_open_connections", "snackinventory_sql_in_use_connections"); err != nil {
+		t.Fatalf("testutil.CollectAndCompare(...) = got err %v, want err nil", err)
+	}
+}
+
+func TestCacheStatsCollector(t *testing.T) {
+	c := NewCacheStatsCollector(func() (hits, misses uint64) { return 5, 2 })
+
+	want := `
+# HELP snackinventory_cache_hits_total Total number of storage reads served from the cache.
+# TYPE snackinventory_cache_hits_total counter
+snackinventory_cache_hits_total 5
+# HELP snackinventory_cache_misses_total Total number of storage reads missing the cache, so read from storage.
+# TYPE snackinventory_cache_misses_total counter
+snackinventory_cache_misses_total 2
+`
+	if err := testutil.CollectAndCompare(c, strings.NewReader(want)); err != nil {
+		t.Fatalf("testutil.CollectAndCompare(...) = got err %v, want err nil", err)
+	}
+}
+
+func TestInventoryCollector(t *testing.T) {
+	fdbc := &fakedbconnector.FakeDBConnector{
+		ListSnacksRes:    []*sipb.Snack{{Barcode: "1"}, {Barcode: "2"}},
+		ListLocationsRes: []*sipb.Location{{Name: "fridge"}},
+		ListStockRes: []*sipb.Stock{
+			{Barcode: "1", Location: "fridge", Count: 2},
+			{Barcode: "2", Location: "fridge", Count: 1},
+		},
+	}
+	c := NewInventoryCollector(fdbc, time.Second)
+
+	want := `
+# HELP snackinventory_inventory_locations Number of locations registered to SnackInventory.
+# TYPE snackinventory_inventory_locations gauge
+snackinventory_inventory_locations 1
+# HELP snackinventory_inventory_snacks Number of snacks registered to SnackInventory.
+# TYPE snackinventory_inventory_snacks gauge
+snackinventory_inventory_snacks 2
+# HELP snackinventory_inventory_stock Number of each snack in stock, by location.
+# TYPE snackinventory_inventory_stock gauge
+snackinventory_inventory_stock{barcode="1",location="fridge"} 2
+snackinventory_inventory_stock{barcode="2",location="fridge"} 1
+`
+	if err := testutil.CollectAndCompare(c, strings.NewReader(want)); err != nil {
+		t.Fatalf("testutil.CollectAndCompare(...) = got err %v, want err nil", err)
+	}
+}
+
+func TestInventoryCollector_StorageError(t *testing.T) {
+	fdbc := &fakedbconnector.FakeDBConnector{
+		ListSnacksErr: status.Error(codes.Internal, "something failed"),
+	}
+	c := NewInventoryCollector(fdbc, time.Second)
+
+	if err := testutil.CollectAndCompare(c, strings.NewReader("")); err == nil {
+		t.Fatal("testutil.CollectAndCompare(...) = got err nil, want err")
+	}
+}
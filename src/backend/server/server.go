@@ -18,66 +18,155 @@ limitations under the License.
 package main
 
 import (
-	"bufio"
 	"context"
+	"database/sql"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"log"
 	"net"
+	"net/http"
 	"os"
+	"strings"
+	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/rmbarron/SnackInventory/src/backend/gateway"
+	"github.com/rmbarron/SnackInventory/src/backend/server/config"
 	"github.com/rmbarron/SnackInventory/src/backend/server/connector"
+	"github.com/rmbarron/SnackInventory/src/backend/server/locations"
+	"github.com/rmbarron/SnackInventory/src/backend/server/logging"
+	"github.com/rmbarron/SnackInventory/src/backend/server/lookup"
+	"github.com/rmbarron/SnackInventory/src/backend/server/metrics"
+	"github.com/rmbarron/SnackInventory/src/backend/server/watch"
+	"github.com/rmbarron/SnackInventory/src/backup"
 	sipb "github.com/rmbarron/SnackInventory/src/proto/snackinventory"
+	"github.com/rmbarron/SnackInventory/src/tracing"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/keepalive"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 var (
-	portFlag        = flag.Int("port", 10000, "Port for SnackInventory to listen on.")
-	storageImplFlag = flag.String(
-		"storage_architecture", "mysql",
-		`Architecture to use for backing storage. Valid values include:
-		 - mysql`)
-
-	// Flags for connector.SQLImpl.
-	sqlUserFlag = flag.String("sql_user", "", "Username for connecting to MySQL.")
-	sqlAddrFlag = flag.String(
-		"sql_address", "", "host:port address for connecting to MySQL.")
-	sqlDBNameFlag = flag.String(
-		"sql_database", "SnackInventory", "MySQL database name to connect to.")
+	configFlag = flag.String(
+		"config", "", "Path to a YAML config file. Keys match flag names; set flags take precedence.")
+	printConfigFlag = flag.Bool(
+		"print_config", false, "Print the effective config, with secrets redacted, and exit.")
+
+	// storageFlags holds the flags of every registered storage backend, which
+	// are also config keys.
+	storageFlags = flag.NewFlagSet("storage", flag.ExitOnError)
 )
 
-// Interface for connecting to backing storage.
-type dbConnector interface {
-	// Snack Registry Operations
-	CreateSnack(ctx context.Context, barcode, name string) error
-	ListSnacks(ctx context.Context) ([]*sipb.Snack, error)
-	UpdateSnack(ctx context.Context, barcode, name string) error
-	DeleteSnack(ctx context.Context, barcode string) error
+// Flags overriding keys of config.Config. Only explicitly set flags are
+// applied, so the config file & environment are not clobbered by defaults.
+func init() {
+	d := config.Default()
+	flag.Int("port", d.Port, "Port for SnackInventory to listen on.")
+	flag.String(
+		"storage_architecture", d.StorageArchitecture,
+		"Architecture to use for backing storage. One of the registered backends: "+
+			strings.Join(connector.Backends(), ", ")+".")
+	flag.Int(
+		"metrics_port", d.MetricsPort,
+		"Port to serve Prometheus metrics on at /metrics. Metrics are disabled if 0.")
+	flag.Int(
+		"http_port", d.HTTPPort,
+		"Port to serve the REST/JSON gateway on. The gateway is disabled if 0.")
+	flag.Int(
+		"watch_history", d.WatchHistory,
+		"Number of recent changes retained so WatchChanges clients can resume after disconnecting.")
+	flag.String(
+		"log_level", d.LogLevel,
+		"Minimum level of request logs written to stderr. One of debug, info, warn, error.")
+	flag.String(
+		"trace_file", d.TraceFile, "If set, finished trace spans are appended to this file as JSON lines.")
+	flag.String(
+		"trace_collector_url", d.TraceCollectorURL, "If set, finished trace spans are posted as JSON to this URL.")
+	flag.Duration(
+		"cache_ttl", d.CacheTTL,
+		"How long to cache storage reads (ex: ListSnacks) for. Writes empty the cache. Caching is disabled if 0.")
+
+	// Flags for product metadata lookup.
+	flag.String(
+		"product_dump", d.ProductDump,
+		"Open Food Facts export (.jsonl or .csv, optionally .gz) to look up snacks created by barcode only.")
+	flag.String(
+		"product_lookup_url", d.ProductLookupURL,
+		"Base URL of an Open Food Facts compatible API to look up snacks created by barcode only.")
+	flag.Duration(
+		"product_lookup_timeout", d.ProductLookupTimeout, "Timeout of requests to product_lookup_url.")
 
-	// Location Registry Operations
-	CreateLocation(ctx context.Context, name string) error
-	ListLocations(ctx context.Context) ([]*sipb.Location, error)
-	DeleteLocation(ctx context.Context, name string) error
+	// Flags of storage backends, registered with the connector package.
+	// Secrets are only set by the config file or environment, to keep them out
+	// of shell history.
+	for _, name := range connector.Backends() {
+		factory, _ := connector.Lookup(name)
+		fs := flag.NewFlagSet(name, flag.ExitOnError)
+		factory.RegisterFlags(fs)
+		fs.VisitAll(func(f *flag.Flag) {
+			storageFlags.Var(f.Value, f.Name, f.Usage)
+			if !config.SecretKey(f.Name) {
+				flag.Var(f.Value, f.Name, fmt.Sprintf("[%s storage] %s", name, f.Usage))
+			}
+		})
+	}
 }
 
 type snackInventoryServer struct {
-	c dbConnector
+	c connector.Store
+	// hub receives a change for every successful mutation, for WatchChanges.
+	hub *watch.Hub
+	// lookup, if set, fills in the metadata of snacks created by barcode only.
+	lookup lookup.ProductLookup
 }
 
 func (s *snackInventoryServer) CreateSnack(ctx context.Context, req *sipb.CreateSnackRequest) (*sipb.CreateSnackResponse, error) {
-	if err := s.c.CreateSnack(ctx, req.GetSnack().GetBarcode(), req.GetSnack().GetName()); err != nil {
+	snack := req.GetSnack()
+	if s.lookup != nil && snack.GetBarcode() != "" && proto.Equal(snack, &sipb.Snack{Barcode: snack.GetBarcode()}) {
+		snack = s.lookupSnack(ctx, snack.GetBarcode())
+	}
+	if err := s.c.CreateSnack(ctx, snack); err != nil {
 		if c := status.Code(err); c == codes.AlreadyExists {
 			return nil, err
 		}
 		return nil, status.Errorf(codes.Internal, "could not create snack: %v", err)
 	}
-	return &sipb.CreateSnackResponse{}, nil
+	s.hub.PublishSnack(sipb.Change_CREATED, snack)
+	return &sipb.CreateSnackResponse{Snack: snack}, nil
+}
+
+// lookupSnack returns the snack with barcode, with metadata from s.lookup if
+// found. Lookup failures don't fail snack creation; the metadata can be
+// filled in later.
+func (s *snackInventoryServer) lookupSnack(ctx context.Context, barcode string) *sipb.Snack {
+	snack, err := s.lookup.Lookup(ctx, barcode)
+	if err != nil {
+		if !errors.Is(err, lookup.ErrNotFound) {
+			log.Printf("could not look up product: %v", err)
+		}
+		return &sipb.Snack{Barcode: barcode}
+	}
+	// The product may be listed under an equivalent code.
+	snack.Barcode = barcode
+	return snack
 }
 
 func (s *snackInventoryServer) ListSnacks(ctx context.Context, req *sipb.ListSnacksRequest) (*sipb.ListSnacksResponse, error) {
-	snacks, err := s.c.ListSnacks(ctx)
+	var snacks []*sipb.Snack
+	var err error
+	if req.GetAsOf() != nil {
+		if err := req.GetAsOf().CheckValid(); err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid as_of: %v", err)
+		}
+		snacks, err = s.c.ListSnacksAsOf(ctx, req.GetAsOf().AsTime())
+	} else {
+		snacks, err = s.c.ListSnacks(ctx)
+	}
 	if err != nil {
 		// TODO: Translate storage errors to corresponding canonical code.
 		return nil, status.Errorf(codes.Internal, "could not list snacks: %v", err)
@@ -88,85 +177,812 @@ func (s *snackInventoryServer) ListSnacks(ctx context.Context, req *sipb.ListSna
 }
 
 func (s *snackInventoryServer) UpdateSnack(ctx context.Context, req *sipb.UpdateSnackRequest) (*sipb.UpdateSnackResponse, error) {
-	if err := s.c.UpdateSnack(ctx, req.GetSnack().GetBarcode(), req.GetSnack().GetName()); err != nil {
+	// As in BatchUpdateSnacks, the update goes through the batch path for its
+	// NotFound check.
+	if err := s.c.BatchUpdateSnacks(ctx, []*sipb.Snack{req.GetSnack()}); err != nil {
+		var be *connector.BatchError
+		if errors.As(err, &be) {
+			err = be.Err
+		}
+		if status.Code(err) == codes.NotFound {
+			return nil, err
+		}
 		return nil, status.Errorf(codes.Internal, "could not update snack: %v", err)
 	}
+	s.hub.PublishSnack(sipb.Change_UPDATED, req.GetSnack())
 	return &sipb.UpdateSnackResponse{}, nil
 }
 
 func (s *snackInventoryServer) DeleteSnack(ctx context.Context, req *sipb.DeleteSnackRequest) (*sipb.DeleteSnackResponse, error) {
+	if err := s.checkSnack(ctx, req.GetBarcode()); err != nil {
+		return nil, err
+	}
 	if err := s.c.DeleteSnack(ctx, req.GetBarcode()); err != nil {
 		return nil, status.Errorf(codes.Internal, "could not delete snack: %v", err)
 	}
+	s.hub.PublishSnack(sipb.Change_DELETED, &sipb.Snack{Barcode: req.GetBarcode()})
 	return &sipb.DeleteSnackResponse{}, nil
 }
 
+// checkSnack returns a NotFound error if no snack with barcode is registered,
+// since the Store ignores deletes of such snacks.
+func (s *snackInventoryServer) checkSnack(ctx context.Context, barcode string) error {
+	snacks, err := s.c.ListSnacks(ctx)
+	if err != nil {
+		return status.Errorf(codes.Internal, "could not list snacks: %v", err)
+	}
+	for _, snack := range snacks {
+		if snack.GetBarcode() == barcode {
+			return nil
+		}
+	}
+	return status.Errorf(codes.NotFound, "barcode %q is not registered", barcode)
+}
+
+// maxBatchSize limits the items in a single batch RPC.
+const maxBatchSize = 1000
+
+// runBatch applies n batch items. In ATOMIC mode, all are applied by atomic,
+// and a failure fails the RPC. In PER_ITEM mode, each is applied independently
+// by single, and failures are only reported in the results.
+func runBatch(mode sipb.BatchMode, n int, atomic func() error, single func(i int) error) ([]*sipb.BatchResult, error) {
+	if n > maxBatchSize {
+		return nil, status.Errorf(codes.InvalidArgument, "batch of %d items exceeds the limit of %d", n, maxBatchSize)
+	}
+	results := make([]*sipb.BatchResult, n)
+	switch mode {
+	case sipb.BatchMode_BATCH_MODE_UNSPECIFIED, sipb.BatchMode_ATOMIC:
+		if err := atomic(); err != nil {
+			var be *connector.BatchError
+			if errors.As(err, &be) {
+				return nil, batchItemStatus(be.Index, be.Err).Err()
+			}
+			return nil, status.Errorf(codes.Internal, "could not apply batch: %v", err)
+		}
+		for i := range results {
+			results[i] = &sipb.BatchResult{Index: int32(i), Status: status.New(codes.OK, "").Proto()}
+		}
+	case sipb.BatchMode_PER_ITEM:
+		for i := range results {
+			results[i] = &sipb.BatchResult{Index: int32(i), Status: batchItemStatus(i, single(i)).Proto()}
+		}
+	default:
+		return nil, status.Errorf(codes.InvalidArgument, "unsupported batch mode %v", mode)
+	}
+	return results, nil
+}
+
+// batchItemStatus converts the error of item i to a status, keeping client
+// error codes & translating others to Internal.
+func batchItemStatus(i int, err error) *status.Status {
+	if err == nil {
+		return status.New(codes.OK, "")
+	}
+	switch c := status.Code(err); c {
+	case codes.AlreadyExists, codes.NotFound, codes.InvalidArgument:
+		return status.Newf(c, "item %d: %s", i, status.Convert(err).Message())
+	}
+	return status.Newf(codes.Internal, "item %d: %v", i, err)
+}
+
+// batchOK reports whether item i of results was applied.
+func batchOK(results []*sipb.BatchResult, i int) bool {
+	return codes.Code(results[i].GetStatus().GetCode()) == codes.OK
+}
+
+func (s *snackInventoryServer) BatchCreateSnacks(ctx context.Context, req *sipb.BatchCreateSnacksRequest) (*sipb.BatchCreateSnacksResponse, error) {
+	snacks := req.GetSnacks()
+	results, err := runBatch(req.GetMode(), len(snacks),
+		func() error { return s.c.BatchCreateSnacks(ctx, snacks) },
+		func(i int) error { return s.c.CreateSnack(ctx, snacks[i]) })
+	if err != nil {
+		return nil, err
+	}
+	for i, snack := range snacks {
+		if batchOK(results, i) {
+			s.hub.PublishSnack(sipb.Change_CREATED, snack)
+		}
+	}
+	return &sipb.BatchCreateSnacksResponse{Results: results}, nil
+}
+
+func (s *snackInventoryServer) BatchUpdateSnacks(ctx context.Context, req *sipb.BatchUpdateSnacksRequest) (*sipb.BatchUpdateSnacksResponse, error) {
+	snacks := req.GetSnacks()
+	results, err := runBatch(req.GetMode(), len(snacks),
+		func() error { return s.c.BatchUpdateSnacks(ctx, snacks) },
+		// Single updates go through the batch path too, for its NotFound check.
+		func(i int) error { return s.c.BatchUpdateSnacks(ctx, snacks[i:i+1]) })
+	if err != nil {
+		return nil, err
+	}
+	for i, snack := range snacks {
+		if batchOK(results, i) {
+			s.hub.PublishSnack(sipb.Change_UPDATED, snack)
+		}
+	}
+	return &sipb.BatchUpdateSnacksResponse{Results: results}, nil
+}
+
+func (s *snackInventoryServer) BatchDeleteSnacks(ctx context.Context, req *sipb.BatchDeleteSnacksRequest) (*sipb.BatchDeleteSnacksResponse, error) {
+	barcodes := req.GetBarcodes()
+	results, err := runBatch(req.GetMode(), len(barcodes),
+		func() error { return s.c.BatchDeleteSnacks(ctx, barcodes) },
+		func(i int) error { return s.c.DeleteSnack(ctx, barcodes[i]) })
+	if err != nil {
+		return nil, err
+	}
+	for i, barcode := range barcodes {
+		if batchOK(results, i) {
+			s.hub.PublishSnack(sipb.Change_DELETED, &sipb.Snack{Barcode: barcode})
+		}
+	}
+	return &sipb.BatchDeleteSnacksResponse{Results: results}, nil
+}
+
 func (s *snackInventoryServer) CreateLocation(ctx context.Context, req *sipb.CreateLocationRequest) (*sipb.CreateLocationResponse, error) {
-	if err := s.c.CreateLocation(ctx, req.GetLocation().GetName()); err != nil {
-		if c := status.Code(err); c == codes.AlreadyExists {
+	if err := checkLocationName(req.GetLocation().GetName()); err != nil {
+		return nil, err
+	}
+	location := &sipb.Location{
+		Name:        req.GetLocation().GetName(),
+		Description: req.GetLocation().GetDescription(),
+		Type:        req.GetLocation().GetType(),
+	}
+	if parent := req.GetLocation().GetParent(); parent != "" {
+		var err error
+		if location.Parent, err = s.resolveLocation(ctx, parent); err != nil {
+			return nil, err
+		}
+	}
+	id, err := s.c.CreateLocation(ctx, location)
+	if err != nil {
+		switch status.Code(err) {
+		case codes.AlreadyExists, codes.NotFound, codes.FailedPrecondition:
 			return nil, err
 		}
 		return nil, status.Errorf(codes.Internal, "could not create location: %v", err)
 	}
-	return &sipb.CreateLocationResponse{}, nil
+	location.Id = id
+	s.hub.PublishLocation(sipb.Change_CREATED, location)
+	return &sipb.CreateLocationResponse{Location: location}, nil
+}
+
+// checkLocationName returns an InvalidArgument error if name can't name a
+// location.
+func checkLocationName(name string) error {
+	if name == "" || locations.IsPath(name) {
+		return status.Errorf(codes.InvalidArgument, "name is required & may not contain %q", locations.Separator)
+	}
+	return nil
+}
+
+// resolveLocation returns the name of the location referred to by ref, either
+// a name or a path. Names are returned as is, whether registered or not.
+func (s *snackInventoryServer) resolveLocation(ctx context.Context, ref string) (string, error) {
+	if !locations.IsPath(ref) {
+		return ref, nil
+	}
+	tree, err := s.locationTree(ctx)
+	if err != nil {
+		return "", err
+	}
+	name, ok := tree.Resolve(ref)
+	if !ok {
+		return "", status.Errorf(codes.NotFound, "location %q is not registered", ref)
+	}
+	return name, nil
+}
+
+func (s *snackInventoryServer) locationTree(ctx context.Context) (*locations.Tree, error) {
+	locs, err := s.c.ListLocations(ctx)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "could not list locations: %v", err)
+	}
+	return locations.New(locs), nil
 }
 
 func (s *snackInventoryServer) ListLocations(ctx context.Context, req *sipb.ListLocationsRequest) (*sipb.ListLocationsResponse, error) {
-	locations, err := s.c.ListLocations(ctx)
+	if req.GetAsOf() != nil {
+		return s.listLocationsAsOf(ctx, req)
+	}
+	tree, err := s.locationTree(ctx)
+	if err != nil {
+		return nil, err
+	}
+	root, err := resolveRoot(tree, req.GetRoot())
+	if err != nil {
+		return nil, err
+	}
+	stock, err := s.c.ListStock(ctx, "")
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "could not list stock: %v", err)
+	}
+	return &sipb.ListLocationsResponse{Locations: tree.List(root, stock)}, nil
+}
+
+// listLocationsAsOf lists locations as they were at req.as_of, without counts
+// of stock, which isn't versioned.
+func (s *snackInventoryServer) listLocationsAsOf(ctx context.Context, req *sipb.ListLocationsRequest) (*sipb.ListLocationsResponse, error) {
+	if err := req.GetAsOf().CheckValid(); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid as_of: %v", err)
+	}
+	locs, err := s.c.ListLocationsAsOf(ctx, req.GetAsOf().AsTime())
 	if err != nil {
 		return nil, status.Errorf(codes.Internal, "could not list locations: %v", err)
 	}
-	return &sipb.ListLocationsResponse{Locations: locations}, nil
+	tree := locations.New(locs)
+	root, err := resolveRoot(tree, req.GetRoot())
+	if err != nil {
+		return nil, err
+	}
+	return &sipb.ListLocationsResponse{Locations: tree.List(root, nil)}, nil
+}
+
+// resolveRoot returns the name of the location ref refers to in tree, or "" if
+// ref is "". Returns a NotFound error if there is no such location.
+func resolveRoot(tree *locations.Tree, ref string) (string, error) {
+	if ref == "" {
+		return "", nil
+	}
+	root, ok := tree.Resolve(ref)
+	if !ok {
+		return "", status.Errorf(codes.NotFound, "location %q is not registered", ref)
+	}
+	return root, nil
+}
+
+// locationFields are the fields of a Location set by UpdateLocation.
+var locationFields = []string{"name", "parent", "description", "type"}
+
+func (s *snackInventoryServer) UpdateLocation(ctx context.Context, req *sipb.UpdateLocationRequest) (*sipb.UpdateLocationResponse, error) {
+	if req.GetLocation().GetId() == 0 {
+		return nil, status.Error(codes.InvalidArgument, "location.id is required")
+	}
+	location := proto.Clone(req.GetLocation()).(*sipb.Location)
+	fields := req.GetUpdateMask().GetPaths()
+	if len(fields) == 0 {
+		fields = locationFields
+	}
+	for _, field := range fields {
+		switch field {
+		case "name":
+			if err := checkLocationName(location.GetName()); err != nil {
+				return nil, err
+			}
+		case "parent":
+			var err error
+			if location.Parent, err = s.resolveLocation(ctx, location.GetParent()); err != nil {
+				return nil, err
+			}
+		case "description", "type":
+		default:
+			return nil, status.Errorf(codes.InvalidArgument, "can't update location field %q", field)
+		}
+	}
+
+	updated, err := s.c.UpdateLocation(ctx, location, fields)
+	if err != nil {
+		switch status.Code(err) {
+		case codes.NotFound, codes.AlreadyExists, codes.FailedPrecondition:
+			return nil, err
+		}
+		return nil, status.Errorf(codes.Internal, "could not update location: %v", err)
+	}
+	s.hub.PublishLocation(sipb.Change_UPDATED, updated)
+	return &sipb.UpdateLocationResponse{Location: updated}, nil
 }
 
 func (s *snackInventoryServer) DeleteLocation(ctx context.Context, req *sipb.DeleteLocationRequest) (*sipb.DeleteLocationResponse, error) {
-	if err := s.c.DeleteLocation(ctx, req.GetName()); err != nil {
+	name, err := s.resolveLocation(ctx, req.GetName())
+	if err != nil {
+		return nil, err
+	}
+	var target string
+	switch req.GetContents() {
+	case sipb.ContentsPolicy_MOVE:
+		if req.GetTarget() == "" {
+			return nil, status.Error(codes.InvalidArgument, "target is required to move stock")
+		}
+		if target, err = s.resolveLocation(ctx, req.GetTarget()); err != nil {
+			return nil, err
+		}
+	case sipb.ContentsPolicy_CONTENTS_POLICY_UNSPECIFIED, sipb.ContentsPolicy_REFUSE, sipb.ContentsPolicy_DISCARD:
+		if req.GetTarget() != "" {
+			return nil, status.Error(codes.InvalidArgument, "target is only used to move stock")
+		}
+	default:
+		return nil, status.Errorf(codes.InvalidArgument, "unknown contents policy %v", req.GetContents())
+	}
+	changes, err := s.c.DeleteLocation(ctx, name, req.GetContents(), target)
+	if err != nil {
+		switch status.Code(err) {
+		case codes.FailedPrecondition, codes.NotFound, codes.InvalidArgument:
+			return nil, err
+		}
 		return nil, status.Errorf(codes.Internal, "could not delete location: %v", err)
 	}
-	return &sipb.DeleteLocationResponse{}, nil
+	res := &sipb.DeleteLocationResponse{}
+	for _, c := range changes {
+		s.hub.PublishStock(c)
+		if c.GetLocation() == name {
+			res.Stock = append(res.Stock, &sipb.Stock{Barcode: c.GetBarcode(), Location: name, Count: -c.GetDelta()})
+		}
+	}
+	s.hub.PublishLocation(sipb.Change_DELETED, &sipb.Location{Name: name})
+	return res, nil
 }
 
-func main() {
-	flag.Parse()
+func (s *snackInventoryServer) AdjustStock(ctx context.Context, req *sipb.AdjustStockRequest) (*sipb.AdjustStockResponse, error) {
+	if req.GetBarcode() == "" || req.GetLocation() == "" {
+		return nil, status.Error(codes.InvalidArgument, "barcode and location are required")
+	}
+	if req.GetDelta() == 0 {
+		return nil, status.Error(codes.InvalidArgument, "delta must be non-zero")
+	}
+	location, err := s.resolveLocation(ctx, req.GetLocation())
+	if err != nil {
+		return nil, err
+	}
+	count, err := s.c.AdjustStock(ctx, req.GetBarcode(), location, req.GetDelta())
+	if err != nil {
+		switch status.Code(err) {
+		case codes.NotFound, codes.FailedPrecondition:
+			return nil, err
+		}
+		return nil, status.Errorf(codes.Internal, "could not adjust stock: %v", err)
+	}
+	s.hub.PublishStock(&sipb.StockChange{
+		Barcode:  req.GetBarcode(),
+		Location: location,
+		Delta:    req.GetDelta(),
+		Count:    count,
+	})
+	return &sipb.AdjustStockResponse{
+		Stock: &sipb.Stock{Barcode: req.GetBarcode(), Location: location, Count: count},
+	}, nil
+}
 
-	var c dbConnector
-	var err error
-	switch si := *storageImplFlag; si {
-	case "mysql":
-		scanner := bufio.NewScanner(os.Stdin)
-		scanner.Scan()
-		pwd := scanner.Text()
+func (s *snackInventoryServer) ListStock(ctx context.Context, req *sipb.ListStockRequest) (*sipb.ListStockResponse, error) {
+	location, err := s.resolveLocation(ctx, req.GetLocation())
+	if err != nil {
+		return nil, err
+	}
+	stock, err := s.c.ListStock(ctx, location)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "could not list stock: %v", err)
+	}
+	return &sipb.ListStockResponse{Stock: stock}, nil
+}
 
-		if *sqlUserFlag == "" {
-			log.Fatal("--sql_user is required.")
+func (s *snackInventoryServer) WatchChanges(req *sipb.WatchChangesRequest, stream sipb.SnackInventory_WatchChangesServer) error {
+	if s.hub == nil {
+		return status.Error(codes.Unimplemented, "change notifications are not enabled")
+	}
+	sub, err := s.hub.Subscribe(req.GetResumeToken())
+	switch {
+	case errors.Is(err, watch.ErrTokenExpired):
+		return status.Errorf(codes.OutOfRange, "could not resume: %v", err)
+	case err != nil:
+		return status.Errorf(codes.InvalidArgument, "could not watch changes: %v", err)
+	}
+	defer sub.Close()
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return status.FromContextError(stream.Context().Err()).Err()
+		case <-sub.Done():
+			return status.Errorf(codes.Aborted, "watch ended: %v; resume from the last resume_token", sub.Err())
+		case c := <-sub.Changes():
+			if err := stream.Send(c); err != nil {
+				return err
+			}
 		}
+	}
+}
 
-		if *sqlAddrFlag == "" {
-			log.Fatal("--sql_address is required.")
+func (s *snackInventoryServer) ListDeleted(ctx context.Context, req *sipb.ListDeletedRequest) (*sipb.ListDeletedResponse, error) {
+	deleted, err := s.c.ListDeleted(ctx)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "could not list deleted: %v", err)
+	}
+	return &sipb.ListDeletedResponse{Deleted: deleted}, nil
+}
+
+func (s *snackInventoryServer) UndeleteSnack(ctx context.Context, req *sipb.UndeleteSnackRequest) (*sipb.UndeleteSnackResponse, error) {
+	if req.GetBarcode() == "" {
+		return nil, status.Error(codes.InvalidArgument, "barcode is required")
+	}
+	snack, err := s.c.UndeleteSnack(ctx, req.GetBarcode())
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return nil, err
 		}
+		return nil, status.Errorf(codes.Internal, "could not undelete snack: %v", err)
+	}
+	s.hub.PublishSnack(sipb.Change_CREATED, snack)
+	return &sipb.UndeleteSnackResponse{Snack: snack}, nil
+}
 
-		if *sqlDBNameFlag == "" {
-			log.Fatal("--sql_database is required.")
+func (s *snackInventoryServer) UndeleteLocation(ctx context.Context, req *sipb.UndeleteLocationRequest) (*sipb.UndeleteLocationResponse, error) {
+	if req.GetName() == "" {
+		return nil, status.Error(codes.InvalidArgument, "name is required")
+	}
+	location, err := s.c.UndeleteLocation(ctx, req.GetName())
+	if err != nil {
+		switch status.Code(err) {
+		case codes.NotFound, codes.FailedPrecondition:
+			return nil, err
+		}
+		return nil, status.Errorf(codes.Internal, "could not undelete location: %v", err)
+	}
+	s.hub.PublishLocation(sipb.Change_CREATED, location)
+	return &sipb.UndeleteLocationResponse{Location: location}, nil
+}
+
+func (s *snackInventoryServer) PurgeDeleted(ctx context.Context, req *sipb.PurgeDeletedRequest) (*sipb.PurgeDeletedResponse, error) {
+	var olderThan time.Duration
+	if req.GetOlderThan() != nil {
+		if err := req.GetOlderThan().CheckValid(); err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid older_than: %v", err)
+		}
+		olderThan = req.GetOlderThan().AsDuration()
+	}
+	if olderThan < 0 {
+		return nil, status.Error(codes.InvalidArgument, "older_than must not be negative")
+	}
+	snacks, locations, err := s.c.PurgeDeleted(ctx, time.Now().Add(-olderThan))
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "could not purge deleted: %v", err)
+	}
+	return &sipb.PurgeDeletedResponse{Snacks: int32(snacks), Locations: int32(locations)}, nil
+}
+
+func (s *snackInventoryServer) GetSnackHistory(ctx context.Context, req *sipb.GetSnackHistoryRequest) (*sipb.GetSnackHistoryResponse, error) {
+	if req.GetBarcode() == "" {
+		return nil, status.Error(codes.InvalidArgument, "barcode is required")
+	}
+	revisions, err := s.c.GetSnackHistory(ctx, req.GetBarcode())
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return nil, err
 		}
+		return nil, status.Errorf(codes.Internal, "could not get snack history: %v", err)
+	}
+	return &sipb.GetSnackHistoryResponse{Revisions: revisions}, nil
+}
 
-		c, err = connector.NewSQLImpl(context.Background(), *sqlUserFlag, pwd, *sqlAddrFlag, *sqlDBNameFlag)
+func (s *snackInventoryServer) ExportAll(req *sipb.ExportAllRequest, stream sipb.SnackInventory_ExportAllServer) error {
+	ctx := stream.Context()
+	snacks, err := s.c.ListSnacks(ctx)
+	if err != nil {
+		return status.Errorf(codes.Internal, "could not list snacks: %v", err)
+	}
+	locs, err := s.c.ListLocations(ctx)
+	if err != nil {
+		return status.Errorf(codes.Internal, "could not list locations: %v", err)
+	}
+	stock, err := s.c.ListStock(ctx, "")
+	if err != nil {
+		return status.Errorf(codes.Internal, "could not list stock: %v", err)
+	}
+
+	header := backup.Header()
+	header.CreateTime = timestamppb.Now()
+	if err := stream.Send(&sipb.BackupRecord{Record: &sipb.BackupRecord_Header{Header: header}}); err != nil {
+		return err
+	}
+	// Locations are restored in order, so each is sent after its parent.
+	for _, l := range locations.New(locs).List("", nil) {
+		// Paths & counts are derived, so aren't backed up.
+		l.Path, l.Depth, l.Count, l.TotalCount = "", 0, 0, 0
+		if err := stream.Send(&sipb.BackupRecord{Record: &sipb.BackupRecord_Location{Location: l}}); err != nil {
+			return err
+		}
+	}
+	for _, snack := range snacks {
+		if err := stream.Send(&sipb.BackupRecord{Record: &sipb.BackupRecord_Snack{Snack: snack}}); err != nil {
+			return err
+		}
+	}
+	// Stock refers to snacks & locations, so is restored after them.
+	for _, st := range stock {
+		if err := stream.Send(&sipb.BackupRecord{Record: &sipb.BackupRecord_Stock{Stock: st}}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ImportAll restores a backup streamed by the client. Existing snacks are
+// overwritten, existing locations kept & stock counts set to those backed up,
+// so a backup may be restored more than once. Records applied before an error
+// are not rolled back.
+func (s *snackInventoryServer) ImportAll(stream sipb.SnackInventory_ImportAllServer) error {
+	ctx := stream.Context()
+	first, err := stream.Recv()
+	if errors.Is(err, io.EOF) {
+		return status.Error(codes.InvalidArgument, "backup is empty; the first record must be a header")
+	}
+	if err != nil {
+		return err
+	}
+	if err := backup.CheckHeader(first.GetHeader()); err != nil {
+		return status.Errorf(codes.InvalidArgument, "could not restore: %v", err)
+	}
+
+	res := &sipb.ImportAllResponse{}
+	// counts holds the current stock, loaded on the first stock record.
+	var counts map[string]int32
+	stockKey := func(barcode, location string) string { return barcode + "\x00" + location }
+	for {
+		rec, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return stream.SendAndClose(res)
+		}
 		if err != nil {
-			log.Fatalf("could not connect to SQL: %v", err)
+			return err
 		}
-	default:
-		log.Fatal("unsupported storage implementation requested.")
+
+		switch r := rec.GetRecord().(type) {
+		case *sipb.BackupRecord_Snack:
+			change := sipb.Change_CREATED
+			err := s.c.CreateSnack(ctx, r.Snack)
+			if status.Code(err) == codes.AlreadyExists {
+				change = sipb.Change_UPDATED
+				// Snacks in the trash are restored, then overwritten.
+				if _, err = s.c.UndeleteSnack(ctx, r.Snack.GetBarcode()); status.Code(err) == codes.NotFound {
+					err = nil
+				}
+				if err == nil {
+					err = s.c.UpdateSnack(ctx, r.Snack)
+				}
+			}
+			if err != nil {
+				return status.Errorf(codes.Internal, "could not restore snack %q: %v", r.Snack.GetBarcode(), err)
+			}
+			s.hub.PublishSnack(change, r.Snack)
+			res.Snacks++
+		case *sipb.BackupRecord_Location:
+			// Ids are assigned anew.
+			location := &sipb.Location{
+				Name:        r.Location.GetName(),
+				Parent:      r.Location.GetParent(),
+				Description: r.Location.GetDescription(),
+				Type:        r.Location.GetType(),
+			}
+			id, err := s.c.CreateLocation(ctx, location)
+			if status.Code(err) == codes.AlreadyExists {
+				// Locations in the trash are restored; parents come first, so
+				// are restored already.
+				restored, err := s.c.UndeleteLocation(ctx, location.GetName())
+				switch status.Code(err) {
+				case codes.OK:
+					s.hub.PublishLocation(sipb.Change_CREATED, restored)
+				case codes.NotFound:
+				default:
+					return status.Errorf(codes.Internal, "could not restore location %q: %v", r.Location.GetName(), err)
+				}
+				res.Locations++
+				continue
+			}
+			if err != nil {
+				return status.Errorf(codes.Internal, "could not restore location %q: %v", r.Location.GetName(), err)
+			}
+			location.Id = id
+			s.hub.PublishLocation(sipb.Change_CREATED, location)
+			res.Locations++
+		case *sipb.BackupRecord_Stock:
+			if counts == nil {
+				stock, err := s.c.ListStock(ctx, "")
+				if err != nil {
+					return status.Errorf(codes.Internal, "could not list stock: %v", err)
+				}
+				counts = map[string]int32{}
+				for _, st := range stock {
+					counts[stockKey(st.GetBarcode(), st.GetLocation())] = st.GetCount()
+				}
+			}
+			key := stockKey(r.Stock.GetBarcode(), r.Stock.GetLocation())
+			res.Stock++
+			delta := r.Stock.GetCount() - counts[key]
+			if delta == 0 {
+				continue
+			}
+			count, err := s.c.AdjustStock(ctx, r.Stock.GetBarcode(), r.Stock.GetLocation(), delta)
+			if err != nil {
+				return status.Errorf(codes.Internal, "could not restore stock of %q at %q: %v",
+					r.Stock.GetBarcode(), r.Stock.GetLocation(), err)
+			}
+			counts[key] = count
+			s.hub.PublishStock(&sipb.StockChange{
+				Barcode:  r.Stock.GetBarcode(),
+				Location: r.Stock.GetLocation(),
+				Delta:    delta,
+				Count:    count,
+			})
+		case *sipb.BackupRecord_Header:
+			return status.Error(codes.InvalidArgument, "backup has more than one header")
+		default:
+			return status.Errorf(codes.InvalidArgument, "unknown backup record %v", rec)
+		}
+	}
+}
+
+// loadConfig builds the config from defaults, the config file, environment &
+// the flags explicitly set in fs, then validates it along with the flags of
+// the selected storage backend, which it returns. Every error found, whether
+// in loading or validating, is returned at once as config.Errors.
+func loadConfig(fs *flag.FlagSet) (*config.Config, connector.Factory, error) {
+	// Storage flags share their values with config keys, so the explicitly set
+	// flags are read before the config file & environment overwrite them.
+	set := map[string]string{}
+	fs.Visit(func(f *flag.Flag) { set[f.Name] = f.Value.String() })
+
+	var errs config.Errors
+	add := func(err error) {
+		var cfgErrs config.Errors
+		switch {
+		case errors.As(err, &cfgErrs):
+			errs = append(errs, cfgErrs...)
+		case err != nil:
+			errs = append(errs, err.Error())
+		}
+	}
+
+	cfg := config.Default()
+	cfg.AddStorageFlags(storageFlags)
+	if *configFlag != "" {
+		add(cfg.LoadFile(*configFlag))
+	}
+	add(cfg.ApplyEnv(os.LookupEnv))
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == "config" || f.Name == "print_config" {
+			return
+		}
+		add(cfg.Set(f.Name, set[f.Name]))
+	})
+
+	factory, ok := connector.Lookup(cfg.StorageArchitecture)
+	var storageErr error
+	if ok {
+		storageErr = factory.Validate()
+	} else {
+		storageErr = config.Errors{fmt.Sprintf("storage_architecture: unsupported value %q; registered backends: %s",
+			cfg.StorageArchitecture, strings.Join(connector.Backends(), ", "))}
+	}
+	add(cfg.Validate(storageErr))
+	return cfg, factory, errs.Err()
+}
+
+func main() {
+	flag.Parse()
+
+	cfg, factory, err := loadConfig(flag.CommandLine)
+	if *printConfigFlag {
+		fmt.Print(cfg)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *printConfigFlag {
+		return
+	}
+
+	logLevel, err := logging.ParseLevel(cfg.LogLevel)
+	if err != nil {
+		log.Fatalf("invalid log_level: %v", err)
+	}
+
+	var exporter tracing.Exporter
+	switch {
+	case cfg.TraceFile != "":
+		f, err := os.OpenFile(cfg.TraceFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		if err != nil {
+			log.Fatalf("could not open trace_file: %v", err)
+		}
+		defer f.Close()
+		exporter = tracing.NewJSONExporter(f)
+	case cfg.TraceCollectorURL != "":
+		e := tracing.NewHTTPExporter(cfg.TraceCollectorURL)
+		defer e.Shutdown()
+		exporter = e
+	}
+
+	store, err := factory.Open(context.Background())
+	if err != nil {
+		log.Fatalf("could not open %s storage: %v", cfg.StorageArchitecture, err)
+	}
+	c := store
+	var cache *connector.CachedStore
+	if cfg.CacheTTL > 0 {
+		cache = connector.NewCachedStore(store, cfg.CacheTTL)
+		c = cache
 	}
 
 	si := &snackInventoryServer{
-		c: c,
+		c:   c,
+		hub: watch.NewHub(cfg.WatchHistory),
+	}
+	switch {
+	case cfg.ProductDump != "":
+		d, err := lookup.LoadDump(cfg.ProductDump)
+		if err != nil {
+			log.Fatal(err)
+		}
+		log.Printf("indexed %d products from %s", d.Len(), cfg.ProductDump)
+		si.lookup = d
+	case cfg.ProductLookupURL != "":
+		si.lookup = lookup.NewHTTP(cfg.ProductLookupURL, &http.Client{Timeout: cfg.ProductLookupTimeout})
 	}
-	lis, err := net.Listen("tcp", fmt.Sprintf(":%d", *portFlag))
+	lis, err := net.Listen("tcp", fmt.Sprintf(":%d", cfg.Port))
 	if err != nil {
 		log.Fatalf("failed to listen: %v", err)
 	}
 
+	// Interceptors run in order: tracing first, so later interceptors and the
+	// handler see the RPC's span. Streaming RPCs (ex: WatchChanges) get the
+	// same, in the same order.
+	tracer := tracing.NewTracer(exporter)
+	logger := logging.New(os.Stderr, logLevel)
+	interceptors := []grpc.UnaryServerInterceptor{
+		tracing.UnaryServerInterceptor(tracer),
+		logger.UnaryServerInterceptor,
+	}
+	streamInterceptors := []grpc.StreamServerInterceptor{
+		tracing.StreamServerInterceptor(tracer),
+		logger.StreamServerInterceptor,
+	}
+	if cfg.MetricsPort != 0 {
+		reg := prometheus.NewRegistry()
+		rpcMetrics := metrics.NewRPCMetrics()
+		reg.MustRegister(
+			prometheus.NewGoCollector(),
+			rpcMetrics,
+			// Inventory is read from storage, so cache stats only count RPCs.
+			metrics.NewInventoryCollector(store, 10*time.Second))
+		if dbc, ok := store.(interface{ Stats() sql.DBStats }); ok {
+			reg.MustRegister(metrics.NewDBStatsCollector(dbc.Stats))
+		}
+		if cache != nil {
+			reg.MustRegister(metrics.NewCacheStatsCollector(func() (hits, misses uint64) {
+				s := cache.Stats()
+				return s.Hits, s.Misses
+			}))
+		}
+		interceptors = append(interceptors, rpcMetrics.UnaryServerInterceptor)
+		streamInterceptors = append(streamInterceptors, rpcMetrics.StreamServerInterceptor)
+
+		go func() {
+			log.Fatalf("failed to serve metrics: %v",
+				metrics.ListenAndServe(fmt.Sprintf(":%d", cfg.MetricsPort), reg))
+		}()
+	}
+
+	if cfg.HTTPPort != 0 {
+		// The gateway forwards to this server over loopback, so its requests
+		// pass through the same interceptors as native gRPC calls.
+		conn, err := grpc.Dial(fmt.Sprintf("localhost:%d", cfg.Port), grpc.WithInsecure())
+		if err != nil {
+			log.Fatalf("could not dial gRPC server for gateway: %v", err)
+		}
+		gw, err := gateway.NewHandler(conn)
+		if err != nil {
+			log.Fatalf("could not create gateway: %v", err)
+		}
+		go func() {
+			log.Fatalf("failed to serve gateway: %v",
+				http.ListenAndServe(fmt.Sprintf(":%d", cfg.HTTPPort), gw))
+		}()
+	}
+
 	// TODO: Serve with TLS.
-	grpcServer := grpc.NewServer()
+	grpcServer := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(interceptors...),
+		grpc.ChainStreamInterceptor(streamInterceptors...),
+		// Allow the keepalive pings of src/client, which are more frequent than
+		// gRPC's default policy of one per 5 minutes.
+		grpc.KeepaliveEnforcementPolicy(keepalive.EnforcementPolicy{MinTime: 30 * time.Second}))
 	svc := sipb.NewSnackInventoryService(si)
 	sipb.RegisterSnackInventoryService(grpcServer, svc)
 	grpcServer.Serve(lis)
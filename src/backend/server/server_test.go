@@ -17,16 +17,56 @@ package main
 
 import (
 	"context"
+	"errors"
+	"flag"
+	"io"
+	"os"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
 	"github.com/rmbarron/SnackInventory/src/backend/fakes/fakedbconnector"
+	"github.com/rmbarron/SnackInventory/src/backend/server/config"
+	"github.com/rmbarron/SnackInventory/src/backend/server/connector"
+	"github.com/rmbarron/SnackInventory/src/backend/server/lookup"
+	"github.com/rmbarron/SnackInventory/src/backend/server/watch"
+	"github.com/rmbarron/SnackInventory/src/backup"
 	sipb "github.com/rmbarron/SnackInventory/src/proto/snackinventory"
+	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
+func TestLoadConfig_ReportsAllErrors(t *testing.T) {
+	os.Setenv("SNACKINVENTORY_WATCH_HISTORY", "many")
+	defer os.Unsetenv("SNACKINVENTORY_WATCH_HISTORY")
+	fs := flag.NewFlagSet("server", flag.ContinueOnError)
+	fs.String("log_level", "", "")
+	fs.String("storage_architecture", "", "")
+	if err := fs.Parse([]string{"-log_level=loud", "-storage_architecture=tape"}); err != nil {
+		t.Fatalf("fs.Parse(...) = got err %v, want err nil", err)
+	}
+
+	// A bad environment variable doesn't hide errors in flags, or in
+	// validation.
+	_, _, err := loadConfig(fs)
+	var errs config.Errors
+	if !errors.As(err, &errs) {
+		t.Fatalf("loadConfig(...) = got err %v, want config.Errors", err)
+	}
+	for _, key := range []string{"SNACKINVENTORY_WATCH_HISTORY", "log_level", "storage_architecture"} {
+		if !strings.Contains(err.Error(), key+":") {
+			t.Errorf("loadConfig(...) = got err %q, want mention of %q", err, key)
+		}
+	}
+}
+
 func TestCreateSnack(t *testing.T) {
 	fdbc := &fakedbconnector.FakeDBConnector{}
 
@@ -40,6 +80,64 @@ func TestCreateSnack(t *testing.T) {
 	}
 }
 
+// fakeLookup looks products up from a map of snacks by barcode.
+type fakeLookup map[string]*sipb.Snack
+
+func (f fakeLookup) Lookup(_ context.Context, barcode string) (*sipb.Snack, error) {
+	if barcode == "error" {
+		return nil, errors.New("lookup failed")
+	}
+	if s, ok := f[barcode]; ok {
+		return proto.Clone(s).(*sipb.Snack), nil
+	}
+	return nil, lookup.ErrNotFound
+}
+
+func TestCreateSnack_Lookup(t *testing.T) {
+	fl := fakeLookup{
+		"012345678905": {Barcode: "0012345678905", Name: "Salted Chips", Brand: "Crunchy Co", Category: "Chips", PackageSize: "150 g"},
+	}
+	tests := []struct {
+		desc  string
+		snack *sipb.Snack
+		want  *sipb.Snack
+	}{
+		{
+			desc:  "Found",
+			snack: &sipb.Snack{Barcode: "012345678905"},
+			want:  &sipb.Snack{Barcode: "012345678905", Name: "Salted Chips", Brand: "Crunchy Co", Category: "Chips", PackageSize: "150 g"},
+		},
+		{
+			desc:  "NotFound",
+			snack: &sipb.Snack{Barcode: "2"},
+			want:  &sipb.Snack{Barcode: "2"},
+		},
+		{
+			desc:  "LookupError",
+			snack: &sipb.Snack{Barcode: "error"},
+			want:  &sipb.Snack{Barcode: "error"},
+		},
+		{
+			desc:  "NameGiven",
+			snack: &sipb.Snack{Barcode: "012345678905", Name: "chips"},
+			want:  &sipb.Snack{Barcode: "012345678905", Name: "chips"},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			si := snackInventoryServer{c: &fakedbconnector.FakeDBConnector{}, lookup: fl}
+			req := &sipb.CreateSnackRequest{Snack: tc.snack}
+			res, err := si.CreateSnack(context.Background(), req)
+			if err != nil {
+				t.Fatalf("si.CreateSnack(ctx, %v) = got err %v, want err nil", req, err)
+			}
+			if diff := cmp.Diff(res.GetSnack(), tc.want, cmpopts.IgnoreUnexported(sipb.Snack{})); diff != "" {
+				t.Fatalf("si.CreateSnack(ctx, %v) = got diff (-got +want): %s", req, diff)
+			}
+		})
+	}
+}
+
 func TestCreateSnack_AlreadyExists(t *testing.T) {
 	fdbc := &fakedbconnector.FakeDBConnector{
 		CreateSnackErr: status.Error(codes.AlreadyExists, "already exists"),
@@ -124,7 +222,7 @@ func TestUpdateSnack(t *testing.T) {
 
 func TestUpdateSnack_Error(t *testing.T) {
 	fdbc := &fakedbconnector.FakeDBConnector{
-		UpdateSnackErr: status.Error(codes.Internal, "something failed"),
+		BatchUpdateSnacksErr: status.Error(codes.Internal, "something failed"),
 	}
 
 	si := snackInventoryServer{c: fdbc}
@@ -140,7 +238,7 @@ func TestUpdateSnack_Error(t *testing.T) {
 }
 
 func TestDeleteSnack(t *testing.T) {
-	fdbc := &fakedbconnector.FakeDBConnector{}
+	fdbc := &fakedbconnector.FakeDBConnector{ListSnacksRes: []*sipb.Snack{{Barcode: "123"}}}
 
 	si := snackInventoryServer{c: fdbc}
 	req := &sipb.DeleteSnackRequest{Barcode: "123"}
@@ -151,6 +249,7 @@ func TestDeleteSnack(t *testing.T) {
 
 func TestDeleteSnack_Error(t *testing.T) {
 	fdbc := &fakedbconnector.FakeDBConnector{
+		ListSnacksRes:  []*sipb.Snack{{Barcode: "123"}},
 		DeleteSnackErr: status.Error(codes.Internal, "something failed"),
 	}
 
@@ -161,17 +260,100 @@ func TestDeleteSnack_Error(t *testing.T) {
 	}
 }
 
+// TestSnack_NotFound checks updates & deletes of unknown barcodes fail, and
+// publish no change.
+func TestSnack_NotFound(t *testing.T) {
+	notFound := &connector.BatchError{Index: 0, Err: status.Error(codes.NotFound, "barcode \"123\" is not registered")}
+	hub := watch.NewHub(10)
+	si := snackInventoryServer{c: &fakedbconnector.FakeDBConnector{BatchUpdateSnacksErr: notFound}, hub: hub}
+	sub, err := hub.Subscribe("")
+	if err != nil {
+		t.Fatalf("hub.Subscribe(%q) = got err %v, want err nil", "", err)
+	}
+	defer sub.Close()
+
+	updateReq := &sipb.UpdateSnackRequest{Snack: &sipb.Snack{Barcode: "123", Name: "testsnack"}}
+	if _, err := si.UpdateSnack(context.Background(), updateReq); status.Code(err) != codes.NotFound {
+		t.Errorf("si.UpdateSnack(ctx, %v) = got err %v, want code %v", updateReq, err, codes.NotFound)
+	}
+	deleteReq := &sipb.DeleteSnackRequest{Barcode: "123"}
+	if _, err := si.DeleteSnack(context.Background(), deleteReq); status.Code(err) != codes.NotFound {
+		t.Errorf("si.DeleteSnack(ctx, %v) = got err %v, want code %v", deleteReq, err, codes.NotFound)
+	}
+	select {
+	case c := <-sub.Changes():
+		t.Errorf("si.UpdateSnack & si.DeleteSnack of an unknown barcode published %v, want nothing", c)
+	default:
+	}
+}
+
 func TestCreateLocation(t *testing.T) {
-	fdbc := &fakedbconnector.FakeDBConnector{}
+	fdbc := &fakedbconnector.FakeDBConnector{CreateLocationRes: 4}
 
 	req := &sipb.CreateLocationRequest{
-		Location: &sipb.Location{Name: "fridge"},
+		Location: &sipb.Location{Name: "fridge", Type: sipb.LocationType_FRIDGE},
 	}
 
 	si := snackInventoryServer{c: fdbc}
+	got, err := si.CreateLocation(context.Background(), req)
+	if err != nil {
+		t.Fatalf("si.CreateLocation(ctx, %v) = got err %v, want err nil", req, err)
+	}
+	want := &sipb.CreateLocationResponse{Location: &sipb.Location{Id: 4, Name: "fridge", Type: sipb.LocationType_FRIDGE}}
+	if diff := cmp.Diff(got, want, cmpopts.IgnoreUnexported(sipb.CreateLocationResponse{}, sipb.Location{})); diff != "" {
+		t.Fatalf("si.CreateLocation(ctx, %v) = got diff (-got +want): %s", req, diff)
+	}
+}
+
+func TestCreateLocation_Parent(t *testing.T) {
+	hub := watch.NewHub(10)
+	si := snackInventoryServer{c: &fakedbconnector.FakeDBConnector{ListLocationsRes: nestedLocations}, hub: hub}
+	sub, err := hub.Subscribe("")
+	if err != nil {
+		t.Fatalf("hub.Subscribe(%q) = got err %v, want err nil", "", err)
+	}
+	defer sub.Close()
+
+	// Parents given by path are stored by name.
+	req := &sipb.CreateLocationRequest{
+		Location: &sipb.Location{Name: "bottom", Parent: "garage/freezer"},
+	}
 	if _, err := si.CreateLocation(context.Background(), req); err != nil {
 		t.Fatalf("si.CreateLocation(ctx, %v) = got err %v, want err nil", req, err)
 	}
+
+	wantChange := &sipb.Change{
+		Type:   sipb.Change_CREATED,
+		Entity: &sipb.Change_Location{Location: &sipb.Location{Name: "bottom", Parent: "freezer"}},
+	}
+	opts := []cmp.Option{
+		cmpopts.IgnoreUnexported(sipb.Change{}, sipb.Location{}),
+		cmpopts.IgnoreFields(sipb.Change{}, "ChangeTime", "ResumeToken"),
+	}
+	if diff := cmp.Diff(<-sub.Changes(), wantChange, opts...); diff != "" {
+		t.Fatalf("si.CreateLocation(ctx, %v) published diff (-got +want): %s", req, diff)
+	}
+}
+
+func TestCreateLocation_Invalid(t *testing.T) {
+	tests := []struct {
+		desc     string
+		location *sipb.Location
+		want     codes.Code
+	}{
+		{"NoName", &sipb.Location{}, codes.InvalidArgument},
+		{"PathName", &sipb.Location{Name: "garage/shelf"}, codes.InvalidArgument},
+		{"UnknownParentPath", &sipb.Location{Name: "shelf", Parent: "garage/cellar"}, codes.NotFound},
+	}
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			si := snackInventoryServer{c: &fakedbconnector.FakeDBConnector{ListLocationsRes: nestedLocations}}
+			req := &sipb.CreateLocationRequest{Location: tc.location}
+			if _, err := si.CreateLocation(context.Background(), req); status.Code(err) != tc.want {
+				t.Fatalf("si.CreateLocation(ctx, %v) = got err %v, want code %v", req, err, tc.want)
+			}
+		})
+	}
 }
 
 func TestCreateLocation_AlreadyExists(t *testing.T) {
@@ -204,34 +386,74 @@ func TestCreateLocation_Internal(t *testing.T) {
 	}
 }
 
+// nestedLocations are stored locations, with the freezer in the garage.
+var nestedLocations = []*sipb.Location{
+	{Name: "top", Parent: "freezer"},
+	{Name: "garage"},
+	{Name: "freezer", Parent: "garage"},
+	{Name: "fridge"},
+}
+
 func TestListLocations(t *testing.T) {
 	fdbc := &fakedbconnector.FakeDBConnector{
-		ListLocationsRes: []*sipb.Location{
-			{
-				Name: "fridge",
-			},
+		ListLocationsRes: nestedLocations,
+		ListStockRes: []*sipb.Stock{
+			{Barcode: "1", Location: "top", Count: 2},
+			{Barcode: "2", Location: "freezer", Count: 1},
 		},
 	}
 
-	req := &sipb.ListLocationsRequest{}
-	si := snackInventoryServer{c: fdbc}
-	got, err := si.ListLocations(context.Background(), req)
-	if err != nil {
-		t.Fatalf("si.ListLocations(ctx, %v) = got err %v, want err nil", req, err)
-	}
-
-	want := &sipb.ListLocationsResponse{
-		Locations: []*sipb.Location{
-			{
-				Name: "fridge",
+	tests := []struct {
+		req  *sipb.ListLocationsRequest
+		want []*sipb.Location
+	}{
+		{
+			req: &sipb.ListLocationsRequest{},
+			want: []*sipb.Location{
+				{Name: "fridge", Path: "fridge"},
+				{Name: "garage", Path: "garage", TotalCount: 3},
+				{Name: "freezer", Parent: "garage", Path: "garage/freezer", Depth: 1, Count: 1, TotalCount: 3},
+				{Name: "top", Parent: "freezer", Path: "garage/freezer/top", Depth: 2, Count: 2, TotalCount: 2},
+			},
+		},
+		{
+			req: &sipb.ListLocationsRequest{Root: "garage/freezer"},
+			want: []*sipb.Location{
+				{Name: "freezer", Parent: "garage", Path: "garage/freezer", Depth: 1, Count: 1, TotalCount: 3},
+				{Name: "top", Parent: "freezer", Path: "garage/freezer/top", Depth: 2, Count: 2, TotalCount: 2},
+			},
+		},
+		{
+			req: &sipb.ListLocationsRequest{Root: "top"},
+			want: []*sipb.Location{
+				{Name: "top", Parent: "freezer", Path: "garage/freezer/top", Depth: 2, Count: 2, TotalCount: 2},
 			},
 		},
 	}
-	if diff := cmp.Diff(
-		got, want,
-		cmpopts.IgnoreUnexported(sipb.ListLocationsResponse{}),
-		cmpopts.IgnoreUnexported(sipb.Location{})); diff != "" {
-		t.Fatalf("si.ListLocations(ctx, %v) = got diff (-got +want): %s", req, diff)
+	for _, tc := range tests {
+		si := snackInventoryServer{c: fdbc}
+		got, err := si.ListLocations(context.Background(), tc.req)
+		if err != nil {
+			t.Fatalf("si.ListLocations(ctx, %v) = got err %v, want err nil", tc.req, err)
+		}
+
+		want := &sipb.ListLocationsResponse{Locations: tc.want}
+		if diff := cmp.Diff(
+			got, want,
+			cmpopts.IgnoreUnexported(sipb.ListLocationsResponse{}),
+			cmpopts.IgnoreUnexported(sipb.Location{})); diff != "" {
+			t.Fatalf("si.ListLocations(ctx, %v) = got diff (-got +want): %s", tc.req, diff)
+		}
+	}
+}
+
+func TestListLocations_RootNotFound(t *testing.T) {
+	si := snackInventoryServer{c: &fakedbconnector.FakeDBConnector{ListLocationsRes: nestedLocations}}
+	for _, root := range []string{"cellar", "fridge/freezer"} {
+		req := &sipb.ListLocationsRequest{Root: root}
+		if _, err := si.ListLocations(context.Background(), req); status.Code(err) != codes.NotFound {
+			t.Errorf("si.ListLocations(ctx, %v) = got err %v, want code %v", req, err, codes.NotFound)
+		}
 	}
 }
 
@@ -247,6 +469,98 @@ func TestListLocationsError(t *testing.T) {
 	}
 }
 
+func TestUpdateLocation(t *testing.T) {
+	updated := &sipb.Location{Id: 2, Name: "cupboard", Type: sipb.LocationType_CUPBOARD}
+	hub := watch.NewHub(10)
+	si := snackInventoryServer{c: &fakedbconnector.FakeDBConnector{UpdateLocationRes: updated}, hub: hub}
+	sub, err := hub.Subscribe("")
+	if err != nil {
+		t.Fatalf("hub.Subscribe(%q) = got err %v, want err nil", "", err)
+	}
+	defer sub.Close()
+
+	req := &sipb.UpdateLocationRequest{
+		Location:   &sipb.Location{Id: 2, Name: "cupboard"},
+		UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"name"}},
+	}
+	got, err := si.UpdateLocation(context.Background(), req)
+	if err != nil {
+		t.Fatalf("si.UpdateLocation(ctx, %v) = got err %v, want err nil", req, err)
+	}
+	want := &sipb.UpdateLocationResponse{Location: updated}
+	if diff := cmp.Diff(got, want, cmpopts.IgnoreUnexported(sipb.UpdateLocationResponse{}, sipb.Location{})); diff != "" {
+		t.Fatalf("si.UpdateLocation(ctx, %v) = got diff (-got +want): %s", req, diff)
+	}
+
+	wantChange := &sipb.Change{
+		Type:   sipb.Change_UPDATED,
+		Entity: &sipb.Change_Location{Location: updated},
+	}
+	opts := []cmp.Option{
+		cmpopts.IgnoreUnexported(sipb.Change{}, sipb.Location{}),
+		cmpopts.IgnoreFields(sipb.Change{}, "ChangeTime", "ResumeToken"),
+	}
+	if diff := cmp.Diff(<-sub.Changes(), wantChange, opts...); diff != "" {
+		t.Fatalf("si.UpdateLocation(ctx, %v) published diff (-got +want): %s", req, diff)
+	}
+}
+
+func TestUpdateLocation_Errors(t *testing.T) {
+	mask := func(paths ...string) *fieldmaskpb.FieldMask { return &fieldmaskpb.FieldMask{Paths: paths} }
+	tests := []struct {
+		desc string
+		c    *fakedbconnector.FakeDBConnector
+		req  *sipb.UpdateLocationRequest
+		want codes.Code
+	}{
+		{
+			"NoID",
+			&fakedbconnector.FakeDBConnector{},
+			&sipb.UpdateLocationRequest{Location: &sipb.Location{Name: "cupboard"}},
+			codes.InvalidArgument,
+		},
+		{
+			// An empty mask updates every field, so the name is required.
+			"EmptyName",
+			&fakedbconnector.FakeDBConnector{},
+			&sipb.UpdateLocationRequest{Location: &sipb.Location{Id: 1, Description: "by the sink"}},
+			codes.InvalidArgument,
+		},
+		{
+			"UnknownField",
+			&fakedbconnector.FakeDBConnector{},
+			&sipb.UpdateLocationRequest{Location: &sipb.Location{Id: 1}, UpdateMask: mask("depth")},
+			codes.InvalidArgument,
+		},
+		{
+			"UnknownParentPath",
+			&fakedbconnector.FakeDBConnector{ListLocationsRes: nestedLocations},
+			&sipb.UpdateLocationRequest{Location: &sipb.Location{Id: 1, Parent: "garage/cellar"}, UpdateMask: mask("parent")},
+			codes.NotFound,
+		},
+		{
+			"Cycle",
+			&fakedbconnector.FakeDBConnector{UpdateLocationErr: status.Error(codes.FailedPrecondition, "cycle")},
+			&sipb.UpdateLocationRequest{Location: &sipb.Location{Id: 1, Parent: "top"}, UpdateMask: mask("parent")},
+			codes.FailedPrecondition,
+		},
+		{
+			"StorageError",
+			&fakedbconnector.FakeDBConnector{UpdateLocationErr: errors.New("storage error")},
+			&sipb.UpdateLocationRequest{Location: &sipb.Location{Id: 1}, UpdateMask: mask("description")},
+			codes.Internal,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			si := snackInventoryServer{c: tc.c}
+			if _, err := si.UpdateLocation(context.Background(), tc.req); status.Code(err) != tc.want {
+				t.Fatalf("si.UpdateLocation(ctx, %v) = got err %v, want code %v", tc.req, err, tc.want)
+			}
+		})
+	}
+}
+
 func TestDeleteLocation(t *testing.T) {
 	fdbc := &fakedbconnector.FakeDBConnector{}
 
@@ -268,3 +582,797 @@ func TestDeleteLocationError(t *testing.T) {
 		t.Fatalf("si.DeleteLocation(ctx, %v) = got err nil, want err", req)
 	}
 }
+
+func TestDeleteLocation_Discard(t *testing.T) {
+	hub := watch.NewHub(10)
+	fdbc := &fakedbconnector.FakeDBConnector{
+		DeleteLocationRes: []*sipb.StockChange{
+			{Barcode: "1", Location: "fridge", Delta: -2, Reason: sipb.StockChange_DISCARDED},
+		},
+	}
+	si := snackInventoryServer{c: fdbc, hub: hub}
+	sub, err := hub.Subscribe("")
+	if err != nil {
+		t.Fatalf("hub.Subscribe(%q) = got err %v, want err nil", "", err)
+	}
+	defer sub.Close()
+
+	req := &sipb.DeleteLocationRequest{Name: "fridge", Contents: sipb.ContentsPolicy_DISCARD}
+	got, err := si.DeleteLocation(context.Background(), req)
+	if err != nil {
+		t.Fatalf("si.DeleteLocation(ctx, %v) = got err %v, want err nil", req, err)
+	}
+	want := &sipb.DeleteLocationResponse{Stock: []*sipb.Stock{{Barcode: "1", Location: "fridge", Count: 2}}}
+	if diff := cmp.Diff(got, want, cmpopts.IgnoreUnexported(sipb.DeleteLocationResponse{}, sipb.Stock{})); diff != "" {
+		t.Fatalf("si.DeleteLocation(ctx, %v) = got diff (-got +want): %s", req, diff)
+	}
+
+	// The discard is published before the deletion.
+	gotChanges := []*sipb.Change{<-sub.Changes(), <-sub.Changes()}
+	wantChanges := []*sipb.Change{
+		{Type: sipb.Change_UPDATED, Entity: &sipb.Change_Stock{Stock: fdbc.DeleteLocationRes[0]}},
+		{Type: sipb.Change_DELETED, Entity: &sipb.Change_Location{Location: &sipb.Location{Name: "fridge"}}},
+	}
+	opts := []cmp.Option{
+		cmpopts.IgnoreUnexported(sipb.Change{}, sipb.StockChange{}, sipb.Location{}),
+		cmpopts.IgnoreFields(sipb.Change{}, "ChangeTime", "ResumeToken"),
+	}
+	if diff := cmp.Diff(gotChanges, wantChanges, opts...); diff != "" {
+		t.Fatalf("si.DeleteLocation(ctx, %v) published diff (-got +want): %s", req, diff)
+	}
+}
+
+func TestDeleteLocation_Contents(t *testing.T) {
+	tests := []struct {
+		desc string
+		req  *sipb.DeleteLocationRequest
+		err  error
+		want codes.Code
+	}{
+		{
+			desc: "MoveWithoutTarget",
+			req:  &sipb.DeleteLocationRequest{Name: "fridge", Contents: sipb.ContentsPolicy_MOVE},
+			want: codes.InvalidArgument,
+		},
+		{
+			desc: "MoveToUnknownPath",
+			req:  &sipb.DeleteLocationRequest{Name: "fridge", Contents: sipb.ContentsPolicy_MOVE, Target: "garage/freezer"},
+			want: codes.NotFound,
+		},
+		{
+			desc: "TargetWithoutMove",
+			req:  &sipb.DeleteLocationRequest{Name: "fridge", Contents: sipb.ContentsPolicy_DISCARD, Target: "pantry"},
+			want: codes.InvalidArgument,
+		},
+		{
+			desc: "UnknownPolicy",
+			req:  &sipb.DeleteLocationRequest{Name: "fridge", Contents: 9},
+			want: codes.InvalidArgument,
+		},
+		{
+			desc: "HoldsStock",
+			req:  &sipb.DeleteLocationRequest{Name: "fridge"},
+			err:  status.Error(codes.FailedPrecondition, "location \"fridge\" holds 1 snacks"),
+			want: codes.FailedPrecondition,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			fdbc := &fakedbconnector.FakeDBConnector{DeleteLocationErr: tc.err}
+			si := snackInventoryServer{c: fdbc}
+			if _, err := si.DeleteLocation(context.Background(), tc.req); status.Code(err) != tc.want {
+				t.Fatalf("si.DeleteLocation(ctx, %v) = got err %v, want code %v", tc.req, err, tc.want)
+			}
+		})
+	}
+}
+
+func TestAdjustStock(t *testing.T) {
+	hub := watch.NewHub(10)
+	si := snackInventoryServer{c: &fakedbconnector.FakeDBConnector{AdjustStockRes: 3}, hub: hub}
+	sub, err := hub.Subscribe("")
+	if err != nil {
+		t.Fatalf("hub.Subscribe(%q) = got err %v, want err nil", "", err)
+	}
+	defer sub.Close()
+
+	req := &sipb.AdjustStockRequest{Barcode: "1", Location: "pantry", Delta: 1}
+	got, err := si.AdjustStock(context.Background(), req)
+	if err != nil {
+		t.Fatalf("si.AdjustStock(ctx, %v) = got err %v, want err nil", req, err)
+	}
+	want := &sipb.AdjustStockResponse{Stock: &sipb.Stock{Barcode: "1", Location: "pantry", Count: 3}}
+	if diff := cmp.Diff(got, want, cmpopts.IgnoreUnexported(sipb.AdjustStockResponse{}, sipb.Stock{})); diff != "" {
+		t.Fatalf("si.AdjustStock(ctx, %v) = got diff (-got +want): %s", req, diff)
+	}
+
+	wantChange := &sipb.Change{
+		Type:   sipb.Change_UPDATED,
+		Entity: &sipb.Change_Stock{Stock: &sipb.StockChange{Barcode: "1", Location: "pantry", Delta: 1, Count: 3}},
+	}
+	opts := []cmp.Option{
+		cmpopts.IgnoreUnexported(sipb.Change{}, sipb.StockChange{}),
+		cmpopts.IgnoreFields(sipb.Change{}, "ChangeTime", "ResumeToken"),
+	}
+	if diff := cmp.Diff(<-sub.Changes(), wantChange, opts...); diff != "" {
+		t.Fatalf("si.AdjustStock(ctx, %v) published diff (-got +want): %s", req, diff)
+	}
+}
+
+func TestAdjustStock_Errors(t *testing.T) {
+	valid := &sipb.AdjustStockRequest{Barcode: "1", Location: "pantry", Delta: -1}
+	tests := []struct {
+		desc string
+		c    *fakedbconnector.FakeDBConnector
+		req  *sipb.AdjustStockRequest
+		want codes.Code
+	}{
+		{"NoBarcode", &fakedbconnector.FakeDBConnector{}, &sipb.AdjustStockRequest{Location: "pantry", Delta: 1}, codes.InvalidArgument},
+		{"NoLocation", &fakedbconnector.FakeDBConnector{}, &sipb.AdjustStockRequest{Barcode: "1", Delta: 1}, codes.InvalidArgument},
+		{"NoDelta", &fakedbconnector.FakeDBConnector{}, &sipb.AdjustStockRequest{Barcode: "1", Location: "pantry"}, codes.InvalidArgument},
+		{
+			"UnknownPath",
+			&fakedbconnector.FakeDBConnector{ListLocationsRes: nestedLocations},
+			&sipb.AdjustStockRequest{Barcode: "1", Location: "garage/pantry", Delta: 1},
+			codes.NotFound,
+		},
+		{
+			"NotFound",
+			&fakedbconnector.FakeDBConnector{AdjustStockErr: status.Error(codes.NotFound, "no such snack")},
+			valid,
+			codes.NotFound,
+		},
+		{
+			"OutOfStock",
+			&fakedbconnector.FakeDBConnector{AdjustStockErr: status.Error(codes.FailedPrecondition, "out of stock")},
+			valid,
+			codes.FailedPrecondition,
+		},
+		{
+			"StorageError",
+			&fakedbconnector.FakeDBConnector{AdjustStockErr: errors.New("storage error")},
+			valid,
+			codes.Internal,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			si := snackInventoryServer{c: tc.c}
+			if _, err := si.AdjustStock(context.Background(), tc.req); status.Code(err) != tc.want {
+				t.Fatalf("si.AdjustStock(ctx, %v) = got err %v, want code %v", tc.req, err, tc.want)
+			}
+		})
+	}
+}
+
+func TestListStock(t *testing.T) {
+	stock := []*sipb.Stock{{Barcode: "1", Location: "pantry", Count: 2}}
+	si := snackInventoryServer{c: &fakedbconnector.FakeDBConnector{ListStockRes: stock}}
+	req := &sipb.ListStockRequest{}
+	got, err := si.ListStock(context.Background(), req)
+	if err != nil {
+		t.Fatalf("si.ListStock(ctx, %v) = got err %v, want err nil", req, err)
+	}
+	want := &sipb.ListStockResponse{Stock: stock}
+	if diff := cmp.Diff(got, want, cmpopts.IgnoreUnexported(sipb.ListStockResponse{}, sipb.Stock{})); diff != "" {
+		t.Fatalf("si.ListStock(ctx, %v) = got diff (-got +want): %s", req, diff)
+	}
+}
+
+func TestListStock_StorageError(t *testing.T) {
+	si := snackInventoryServer{c: &fakedbconnector.FakeDBConnector{ListStockErr: errors.New("storage error")}}
+	req := &sipb.ListStockRequest{}
+	if _, err := si.ListStock(context.Background(), req); status.Code(err) != codes.Internal {
+		t.Fatalf("si.ListStock(ctx, %v) = got err %v, want code %v", req, err, codes.Internal)
+	}
+}
+
+func TestBatchCreateSnacks(t *testing.T) {
+	tests := []struct {
+		desc     string
+		fdbc     *fakedbconnector.FakeDBConnector
+		mode     sipb.BatchMode
+		wantCode codes.Code
+		// wantResults are the codes of each result, if the RPC succeeds.
+		wantResults []codes.Code
+	}{
+		{
+			desc:        "Atomic",
+			fdbc:        &fakedbconnector.FakeDBConnector{},
+			mode:        sipb.BatchMode_ATOMIC,
+			wantResults: []codes.Code{codes.OK, codes.OK},
+		},
+		{
+			desc:     "DefaultsToAtomic",
+			fdbc:     &fakedbconnector.FakeDBConnector{BatchCreateSnacksErr: errors.New("db down")},
+			mode:     sipb.BatchMode_BATCH_MODE_UNSPECIFIED,
+			wantCode: codes.Internal,
+		},
+		{
+			desc: "Atomic_ItemError",
+			fdbc: &fakedbconnector.FakeDBConnector{
+				BatchCreateSnacksErr: &connector.BatchError{Index: 1, Err: status.Error(codes.AlreadyExists, "exists")},
+			},
+			mode:     sipb.BatchMode_ATOMIC,
+			wantCode: codes.AlreadyExists,
+		},
+		{
+			desc:        "PerItem",
+			fdbc:        &fakedbconnector.FakeDBConnector{CreateSnackErr: status.Error(codes.AlreadyExists, "exists")},
+			mode:        sipb.BatchMode_PER_ITEM,
+			wantResults: []codes.Code{codes.AlreadyExists, codes.AlreadyExists},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			hub := watch.NewHub(10)
+			sub, err := hub.Subscribe("")
+			if err != nil {
+				t.Fatalf("hub.Subscribe(\"\") = got err %v, want err nil", err)
+			}
+			defer sub.Close()
+
+			si := snackInventoryServer{c: tc.fdbc, hub: hub}
+			req := &sipb.BatchCreateSnacksRequest{
+				Snacks: []*sipb.Snack{{Barcode: "1"}, {Barcode: "2"}},
+				Mode:   tc.mode,
+			}
+			res, err := si.BatchCreateSnacks(context.Background(), req)
+			if got := status.Code(err); got != tc.wantCode {
+				t.Fatalf("si.BatchCreateSnacks(ctx, %v) = got err %v, want code %v", req, err, tc.wantCode)
+			}
+			var got []codes.Code
+			wantChanges := 0
+			for _, r := range res.GetResults() {
+				got = append(got, codes.Code(r.GetStatus().GetCode()))
+				if codes.Code(r.GetStatus().GetCode()) == codes.OK {
+					wantChanges++
+				}
+			}
+			if diff := cmp.Diff(got, tc.wantResults); diff != "" {
+				t.Fatalf("si.BatchCreateSnacks(ctx, %v) result codes = got diff (-got +want): %s", req, diff)
+			}
+			if n := len(sub.Changes()); n != wantChanges {
+				t.Fatalf("si.BatchCreateSnacks(ctx, %v) published %d changes, want %d", req, n, wantChanges)
+			}
+		})
+	}
+}
+
+func TestBatchCreateSnacks_TooLarge(t *testing.T) {
+	si := snackInventoryServer{c: &fakedbconnector.FakeDBConnector{}}
+	req := &sipb.BatchCreateSnacksRequest{Snacks: make([]*sipb.Snack, maxBatchSize+1)}
+	if _, err := si.BatchCreateSnacks(context.Background(), req); status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("si.BatchCreateSnacks(ctx, %d snacks) = got err %v, want code %v", len(req.GetSnacks()), err, codes.InvalidArgument)
+	}
+}
+
+func TestBatchUpdateSnacks(t *testing.T) {
+	fdbc := &fakedbconnector.FakeDBConnector{}
+
+	req := &sipb.BatchUpdateSnacksRequest{Snacks: []*sipb.Snack{{Barcode: "1", Name: "chips"}}}
+	si := snackInventoryServer{c: fdbc}
+	if _, err := si.BatchUpdateSnacks(context.Background(), req); err != nil {
+		t.Fatalf("si.BatchUpdateSnacks(ctx, %v) = got err %v, want err nil", req, err)
+	}
+}
+
+func TestBatchDeleteSnacks_Error(t *testing.T) {
+	fdbc := &fakedbconnector.FakeDBConnector{
+		BatchDeleteSnacksErr: status.Error(codes.Internal, "something went wrong"),
+	}
+
+	req := &sipb.BatchDeleteSnacksRequest{Barcodes: []string{"1"}}
+	si := snackInventoryServer{c: fdbc}
+	if _, err := si.BatchDeleteSnacks(context.Background(), req); err == nil {
+		t.Fatalf("si.BatchDeleteSnacks(ctx, %v) = got err nil, want err", req)
+	}
+}
+
+// fakeWatchStream implements sipb.SnackInventory_WatchChangesServer.
+type fakeWatchStream struct {
+	grpc.ServerStream
+	ctx  context.Context
+	sent chan *sipb.Change
+}
+
+func (f *fakeWatchStream) Context() context.Context { return f.ctx }
+
+func (f *fakeWatchStream) Send(c *sipb.Change) error {
+	f.sent <- c
+	return nil
+}
+
+func TestWatchChanges(t *testing.T) {
+	hub := watch.NewHub(10)
+	si := snackInventoryServer{c: &fakedbconnector.FakeDBConnector{}, hub: hub}
+	ctx, cancel := context.WithCancel(context.Background())
+	stream := &fakeWatchStream{ctx: ctx, sent: make(chan *sipb.Change, 10)}
+
+	errc := make(chan error)
+	go func() { errc <- si.WatchChanges(&sipb.WatchChangesRequest{}, stream) }()
+	for hub.Subscribers() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	snack := &sipb.Snack{Barcode: "1", Name: "chips"}
+	if _, err := si.CreateSnack(ctx, &sipb.CreateSnackRequest{Snack: snack}); err != nil {
+		t.Fatalf("si.CreateSnack(ctx, ...) = got err %v, want err nil", err)
+	}
+	if _, err := si.DeleteLocation(ctx, &sipb.DeleteLocationRequest{Name: "fridge"}); err != nil {
+		t.Fatalf("si.DeleteLocation(ctx, ...) = got err %v, want err nil", err)
+	}
+
+	got := []*sipb.Change{<-stream.sent, <-stream.sent}
+	want := []*sipb.Change{
+		{Type: sipb.Change_CREATED, Entity: &sipb.Change_Snack{Snack: snack}},
+		{Type: sipb.Change_DELETED, Entity: &sipb.Change_Location{Location: &sipb.Location{Name: "fridge"}}},
+	}
+	opts := []cmp.Option{
+		cmpopts.IgnoreUnexported(sipb.Change{}, sipb.Snack{}, sipb.Location{}),
+		cmpopts.IgnoreFields(sipb.Change{}, "ChangeTime", "ResumeToken"),
+	}
+	if diff := cmp.Diff(got, want, opts...); diff != "" {
+		t.Fatalf("si.WatchChanges(...) sent diff (-got +want): %s", diff)
+	}
+
+	cancel()
+	if err := <-errc; status.Code(err) != codes.Canceled {
+		t.Fatalf("si.WatchChanges(...) after cancel = got err %v, want code %v", err, codes.Canceled)
+	}
+}
+
+func TestWatchChanges_ExpiredToken(t *testing.T) {
+	si := snackInventoryServer{c: &fakedbconnector.FakeDBConnector{}, hub: watch.NewHub(10)}
+	stream := &fakeWatchStream{ctx: context.Background()}
+
+	req := &sipb.WatchChangesRequest{ResumeToken: "1.1"}
+	if err := si.WatchChanges(req, stream); status.Code(err) != codes.OutOfRange {
+		t.Fatalf("si.WatchChanges(%v, ...) = got err %v, want code %v", req, err, codes.OutOfRange)
+	}
+}
+
+func TestListDeleted(t *testing.T) {
+	deleted := []*sipb.DeletedEntity{
+		{Entity: &sipb.DeletedEntity_Snack{Snack: &sipb.Snack{Barcode: "1"}}},
+		{Entity: &sipb.DeletedEntity_Location{Location: &sipb.Location{Name: "fridge"}}},
+	}
+	si := snackInventoryServer{c: &fakedbconnector.FakeDBConnector{ListDeletedRes: deleted}}
+	got, err := si.ListDeleted(context.Background(), &sipb.ListDeletedRequest{})
+	if err != nil {
+		t.Fatalf("si.ListDeleted(ctx, ...) = got err %v, want err nil", err)
+	}
+	want := &sipb.ListDeletedResponse{Deleted: deleted}
+	if !proto.Equal(got, want) {
+		t.Fatalf("si.ListDeleted(ctx, ...) = got %v, want %v", got, want)
+	}
+
+	si = snackInventoryServer{c: &fakedbconnector.FakeDBConnector{ListDeletedErr: errors.New("db down")}}
+	if _, err := si.ListDeleted(context.Background(), &sipb.ListDeletedRequest{}); status.Code(err) != codes.Internal {
+		t.Fatalf("si.ListDeleted(ctx, ...) = got err %v, want code %v", err, codes.Internal)
+	}
+}
+
+func TestUndeleteSnack(t *testing.T) {
+	hub := watch.NewHub(10)
+	snack := &sipb.Snack{Barcode: "1", Name: "chips"}
+	si := snackInventoryServer{c: &fakedbconnector.FakeDBConnector{UndeleteSnackRes: snack}, hub: hub}
+	sub, err := hub.Subscribe("")
+	if err != nil {
+		t.Fatalf("hub.Subscribe(%q) = got err %v, want err nil", "", err)
+	}
+	defer sub.Close()
+
+	req := &sipb.UndeleteSnackRequest{Barcode: "1"}
+	got, err := si.UndeleteSnack(context.Background(), req)
+	if err != nil {
+		t.Fatalf("si.UndeleteSnack(ctx, %v) = got err %v, want err nil", req, err)
+	}
+	if want := (&sipb.UndeleteSnackResponse{Snack: snack}); !proto.Equal(got, want) {
+		t.Fatalf("si.UndeleteSnack(ctx, %v) = got %v, want %v", req, got, want)
+	}
+
+	// Restored snacks reappear to watchers.
+	wantChange := &sipb.Change{Type: sipb.Change_CREATED, Entity: &sipb.Change_Snack{Snack: snack}}
+	opts := []cmp.Option{
+		cmpopts.IgnoreUnexported(sipb.Change{}, sipb.Snack{}),
+		cmpopts.IgnoreFields(sipb.Change{}, "ChangeTime", "ResumeToken"),
+	}
+	if diff := cmp.Diff(<-sub.Changes(), wantChange, opts...); diff != "" {
+		t.Fatalf("si.UndeleteSnack(ctx, %v) published diff (-got +want): %s", req, diff)
+	}
+}
+
+func TestUndelete_Errors(t *testing.T) {
+	tests := []struct {
+		desc string
+		c    *fakedbconnector.FakeDBConnector
+		call func(si *snackInventoryServer) error
+		want codes.Code
+	}{
+		{
+			desc: "SnackNoBarcode",
+			c:    &fakedbconnector.FakeDBConnector{},
+			call: func(si *snackInventoryServer) error {
+				_, err := si.UndeleteSnack(context.Background(), &sipb.UndeleteSnackRequest{})
+				return err
+			},
+			want: codes.InvalidArgument,
+		},
+		{
+			desc: "SnackNotInTrash",
+			c:    &fakedbconnector.FakeDBConnector{UndeleteSnackErr: status.Error(codes.NotFound, "not in trash")},
+			call: func(si *snackInventoryServer) error {
+				_, err := si.UndeleteSnack(context.Background(), &sipb.UndeleteSnackRequest{Barcode: "1"})
+				return err
+			},
+			want: codes.NotFound,
+		},
+		{
+			desc: "LocationNoName",
+			c:    &fakedbconnector.FakeDBConnector{},
+			call: func(si *snackInventoryServer) error {
+				_, err := si.UndeleteLocation(context.Background(), &sipb.UndeleteLocationRequest{})
+				return err
+			},
+			want: codes.InvalidArgument,
+		},
+		{
+			desc: "LocationParentInTrash",
+			c:    &fakedbconnector.FakeDBConnector{UndeleteLocationErr: status.Error(codes.FailedPrecondition, "parent in trash")},
+			call: func(si *snackInventoryServer) error {
+				_, err := si.UndeleteLocation(context.Background(), &sipb.UndeleteLocationRequest{Name: "freezer"})
+				return err
+			},
+			want: codes.FailedPrecondition,
+		},
+		{
+			desc: "LocationStorageError",
+			c:    &fakedbconnector.FakeDBConnector{UndeleteLocationErr: errors.New("db down")},
+			call: func(si *snackInventoryServer) error {
+				_, err := si.UndeleteLocation(context.Background(), &sipb.UndeleteLocationRequest{Name: "freezer"})
+				return err
+			},
+			want: codes.Internal,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			si := &snackInventoryServer{c: tc.c}
+			if err := tc.call(si); status.Code(err) != tc.want {
+				t.Fatalf("got err %v, want code %v", err, tc.want)
+			}
+		})
+	}
+}
+
+func TestPurgeDeleted(t *testing.T) {
+	fdbc := &fakedbconnector.FakeDBConnector{PurgeDeletedSnacks: 2, PurgeDeletedLocations: 1}
+	si := snackInventoryServer{c: fdbc}
+
+	req := &sipb.PurgeDeletedRequest{OlderThan: durationpb.New(24 * time.Hour)}
+	start := time.Now()
+	got, err := si.PurgeDeleted(context.Background(), req)
+	if err != nil {
+		t.Fatalf("si.PurgeDeleted(ctx, %v) = got err %v, want err nil", req, err)
+	}
+	if want := (&sipb.PurgeDeletedResponse{Snacks: 2, Locations: 1}); !proto.Equal(got, want) {
+		t.Fatalf("si.PurgeDeleted(ctx, %v) = got %v, want %v", req, got, want)
+	}
+	if before := fdbc.PurgeDeletedBefore; before.Before(start.Add(-24*time.Hour)) || before.After(time.Now().Add(-24*time.Hour)) {
+		t.Fatalf("si.PurgeDeleted(ctx, %v) purged before %v, want a day before %v", req, before, start)
+	}
+
+	// Everything is purged by default.
+	if _, err := si.PurgeDeleted(context.Background(), &sipb.PurgeDeletedRequest{}); err != nil {
+		t.Fatalf("si.PurgeDeleted(ctx, {}) = got err %v, want err nil", err)
+	}
+	if before := fdbc.PurgeDeletedBefore; before.Before(start) {
+		t.Fatalf("si.PurgeDeleted(ctx, {}) purged before %v, want after %v", before, start)
+	}
+
+	req = &sipb.PurgeDeletedRequest{OlderThan: durationpb.New(-time.Hour)}
+	if _, err := si.PurgeDeleted(context.Background(), req); status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("si.PurgeDeleted(ctx, %v) = got err %v, want code %v", req, err, codes.InvalidArgument)
+	}
+}
+
+// fakeExportStream implements sipb.SnackInventory_ExportAllServer.
+type fakeExportStream struct {
+	grpc.ServerStream
+	sent []*sipb.BackupRecord
+}
+
+func (f *fakeExportStream) Context() context.Context { return context.Background() }
+
+func (f *fakeExportStream) Send(rec *sipb.BackupRecord) error {
+	f.sent = append(f.sent, rec)
+	return nil
+}
+
+func TestGetSnackHistory(t *testing.T) {
+	revisions := []*sipb.SnackRevision{
+		{Snack: &sipb.Snack{Barcode: "1", Name: "chips"}, Type: sipb.Change_CREATED},
+		{Snack: &sipb.Snack{Barcode: "1", Name: "salty chips"}, Type: sipb.Change_UPDATED},
+	}
+	si := snackInventoryServer{c: &fakedbconnector.FakeDBConnector{GetSnackHistoryRes: revisions}}
+
+	req := &sipb.GetSnackHistoryRequest{Barcode: "1"}
+	got, err := si.GetSnackHistory(context.Background(), req)
+	if err != nil {
+		t.Fatalf("si.GetSnackHistory(ctx, %v) = got err %v, want err nil", req, err)
+	}
+	if want := (&sipb.GetSnackHistoryResponse{Revisions: revisions}); !proto.Equal(got, want) {
+		t.Fatalf("si.GetSnackHistory(ctx, %v) = got %v, want %v", req, got, want)
+	}
+}
+
+func TestGetSnackHistory_Errors(t *testing.T) {
+	tests := []struct {
+		desc string
+		req  *sipb.GetSnackHistoryRequest
+		err  error
+		want codes.Code
+	}{
+		{
+			desc: "NoBarcode",
+			req:  &sipb.GetSnackHistoryRequest{},
+			want: codes.InvalidArgument,
+		},
+		{
+			desc: "NotFound",
+			req:  &sipb.GetSnackHistoryRequest{Barcode: "1"},
+			err:  status.Error(codes.NotFound, "no history"),
+			want: codes.NotFound,
+		},
+		{
+			desc: "StorageError",
+			req:  &sipb.GetSnackHistoryRequest{Barcode: "1"},
+			err:  errors.New("connection refused"),
+			want: codes.Internal,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			si := snackInventoryServer{c: &fakedbconnector.FakeDBConnector{GetSnackHistoryErr: tc.err}}
+			if _, err := si.GetSnackHistory(context.Background(), tc.req); status.Code(err) != tc.want {
+				t.Fatalf("si.GetSnackHistory(ctx, %v) = got err %v, want code %v", tc.req, err, tc.want)
+			}
+		})
+	}
+}
+
+func TestListSnacks_AsOf(t *testing.T) {
+	asOf := time.Date(2020, 1, 5, 0, 0, 0, 0, time.UTC)
+	snacks := []*sipb.Snack{{Barcode: "1", Name: "chips"}}
+	fdbc := &fakedbconnector.FakeDBConnector{
+		ListSnacksAsOfRes: snacks,
+		ListSnacksErr:     errors.New("current snacks listed"),
+	}
+
+	si := snackInventoryServer{c: fdbc}
+	req := &sipb.ListSnacksRequest{AsOf: timestamppb.New(asOf)}
+	got, err := si.ListSnacks(context.Background(), req)
+	if err != nil {
+		t.Fatalf("si.ListSnacks(ctx, %v) = got err %v, want err nil", req, err)
+	}
+	if want := (&sipb.ListSnacksResponse{Snacks: snacks}); !proto.Equal(got, want) {
+		t.Fatalf("si.ListSnacks(ctx, %v) = got %v, want %v", req, got, want)
+	}
+	if !fdbc.ListSnacksAsOfTime.Equal(asOf) {
+		t.Errorf("si.ListSnacks(ctx, %v) read as of %v, want %v", req, fdbc.ListSnacksAsOfTime, asOf)
+	}
+
+	// Timestamps out of range are rejected.
+	req = &sipb.ListSnacksRequest{AsOf: &timestamppb.Timestamp{Seconds: -1 << 60}}
+	if _, err := si.ListSnacks(context.Background(), req); status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("si.ListSnacks(ctx, %v) = got err %v, want code %v", req, err, codes.InvalidArgument)
+	}
+}
+
+func TestListLocations_AsOf(t *testing.T) {
+	asOf := time.Date(2020, 1, 5, 0, 0, 0, 0, time.UTC)
+	fdbc := &fakedbconnector.FakeDBConnector{
+		ListLocationsAsOfRes: nestedLocations,
+		ListLocationsErr:     errors.New("current locations listed"),
+		ListStockRes:         []*sipb.Stock{{Barcode: "1", Location: "top", Count: 2}},
+	}
+
+	si := snackInventoryServer{c: fdbc}
+	req := &sipb.ListLocationsRequest{Root: "garage/freezer", AsOf: timestamppb.New(asOf)}
+	got, err := si.ListLocations(context.Background(), req)
+	if err != nil {
+		t.Fatalf("si.ListLocations(ctx, %v) = got err %v, want err nil", req, err)
+	}
+	// Stock isn't versioned, so isn't counted.
+	want := &sipb.ListLocationsResponse{Locations: []*sipb.Location{
+		{Name: "freezer", Parent: "garage", Path: "garage/freezer", Depth: 1},
+		{Name: "top", Parent: "freezer", Path: "garage/freezer/top", Depth: 2},
+	}}
+	if !proto.Equal(got, want) {
+		t.Fatalf("si.ListLocations(ctx, %v) = got %v, want %v", req, got, want)
+	}
+	if !fdbc.ListLocationsAsOfTime.Equal(asOf) {
+		t.Errorf("si.ListLocations(ctx, %v) read as of %v, want %v", req, fdbc.ListLocationsAsOfTime, asOf)
+	}
+
+	req = &sipb.ListLocationsRequest{Root: "cellar", AsOf: timestamppb.New(asOf)}
+	if _, err := si.ListLocations(context.Background(), req); status.Code(err) != codes.NotFound {
+		t.Fatalf("si.ListLocations(ctx, %v) = got err %v, want code %v", req, err, codes.NotFound)
+	}
+}
+
+func TestExportAll(t *testing.T) {
+	snacks := []*sipb.Snack{{Barcode: "1", Name: "chips"}}
+	locations := []*sipb.Location{{Name: "pantry"}}
+	stock := []*sipb.Stock{{Barcode: "1", Location: "pantry", Count: 2}}
+	si := snackInventoryServer{c: &fakedbconnector.FakeDBConnector{
+		ListSnacksRes:    snacks,
+		ListLocationsRes: locations,
+		ListStockRes:     stock,
+	}}
+	stream := &fakeExportStream{}
+	if err := si.ExportAll(&sipb.ExportAllRequest{}, stream); err != nil {
+		t.Fatalf("si.ExportAll(...) = got err %v, want err nil", err)
+	}
+
+	want := []*sipb.BackupRecord{
+		{Record: &sipb.BackupRecord_Header{Header: &sipb.BackupHeader{Version: backup.Version}}},
+		{Record: &sipb.BackupRecord_Location{Location: locations[0]}},
+		{Record: &sipb.BackupRecord_Snack{Snack: snacks[0]}},
+		{Record: &sipb.BackupRecord_Stock{Stock: stock[0]}},
+	}
+	opts := []cmp.Option{
+		cmpopts.IgnoreUnexported(sipb.BackupRecord{}, sipb.BackupHeader{}, sipb.Snack{}, sipb.Location{}, sipb.Stock{}),
+		cmpopts.IgnoreFields(sipb.BackupHeader{}, "CreateTime"),
+	}
+	if diff := cmp.Diff(stream.sent, want, opts...); diff != "" {
+		t.Fatalf("si.ExportAll(...) sent diff (-got +want): %s", diff)
+	}
+}
+
+func TestExportAll_StorageError(t *testing.T) {
+	si := snackInventoryServer{c: &fakedbconnector.FakeDBConnector{ListSnacksErr: errors.New("storage error")}}
+	stream := &fakeExportStream{}
+	if err := si.ExportAll(&sipb.ExportAllRequest{}, stream); status.Code(err) != codes.Internal {
+		t.Fatalf("si.ExportAll(...) = got err %v, want code %v", err, codes.Internal)
+	}
+	if len(stream.sent) != 0 {
+		t.Fatalf("si.ExportAll(...) sent %v, want nothing", stream.sent)
+	}
+}
+
+// fakeImportStream implements sipb.SnackInventory_ImportAllServer.
+type fakeImportStream struct {
+	grpc.ServerStream
+	recs []*sipb.BackupRecord
+	res  *sipb.ImportAllResponse
+}
+
+func (f *fakeImportStream) Context() context.Context { return context.Background() }
+
+func (f *fakeImportStream) Recv() (*sipb.BackupRecord, error) {
+	if len(f.recs) == 0 {
+		return nil, io.EOF
+	}
+	rec := f.recs[0]
+	f.recs = f.recs[1:]
+	return rec, nil
+}
+
+func (f *fakeImportStream) SendAndClose(res *sipb.ImportAllResponse) error {
+	f.res = res
+	return nil
+}
+
+func TestImportAll(t *testing.T) {
+	header := &sipb.BackupRecord{Record: &sipb.BackupRecord_Header{Header: backup.Header()}}
+	snack := &sipb.BackupRecord{Record: &sipb.BackupRecord_Snack{Snack: &sipb.Snack{Barcode: "1"}}}
+	location := &sipb.BackupRecord{Record: &sipb.BackupRecord_Location{Location: &sipb.Location{Name: "pantry"}}}
+	stock := &sipb.BackupRecord{Record: &sipb.BackupRecord_Stock{Stock: &sipb.Stock{Barcode: "1", Location: "pantry", Count: 2}}}
+
+	tests := []struct {
+		desc string
+		c    *fakedbconnector.FakeDBConnector
+		recs []*sipb.BackupRecord
+		want *sipb.ImportAllResponse
+	}{
+		{
+			desc: "Empty",
+			c:    &fakedbconnector.FakeDBConnector{},
+			recs: []*sipb.BackupRecord{header},
+			want: &sipb.ImportAllResponse{},
+		},
+		{
+			desc: "Records",
+			c:    &fakedbconnector.FakeDBConnector{},
+			recs: []*sipb.BackupRecord{header, location, snack, snack},
+			want: &sipb.ImportAllResponse{Snacks: 2, Locations: 1},
+		},
+		{
+			desc: "Existing",
+			c: &fakedbconnector.FakeDBConnector{
+				CreateSnackErr:      status.Error(codes.AlreadyExists, "snack exists"),
+				CreateLocationErr:   status.Error(codes.AlreadyExists, "location exists"),
+				UndeleteSnackErr:    status.Error(codes.NotFound, "snack not in trash"),
+				UndeleteLocationErr: status.Error(codes.NotFound, "location not in trash"),
+			},
+			recs: []*sipb.BackupRecord{header, location, snack},
+			want: &sipb.ImportAllResponse{Snacks: 1, Locations: 1},
+		},
+		{
+			desc: "Trashed",
+			c: &fakedbconnector.FakeDBConnector{
+				CreateSnackErr:      status.Error(codes.AlreadyExists, "snack in trash"),
+				CreateLocationErr:   status.Error(codes.AlreadyExists, "location in trash"),
+				UndeleteSnackRes:    &sipb.Snack{Barcode: "1"},
+				UndeleteLocationRes: &sipb.Location{Id: 1, Name: "pantry"},
+			},
+			recs: []*sipb.BackupRecord{header, location, snack},
+			want: &sipb.ImportAllResponse{Snacks: 1, Locations: 1},
+		},
+		{
+			desc: "Stock",
+			c:    &fakedbconnector.FakeDBConnector{AdjustStockRes: 2},
+			recs: []*sipb.BackupRecord{header, location, snack, stock},
+			want: &sipb.ImportAllResponse{Snacks: 1, Locations: 1, Stock: 1},
+		},
+		{
+			desc: "StockUnchanged",
+			c: &fakedbconnector.FakeDBConnector{
+				ListStockRes:   []*sipb.Stock{{Barcode: "1", Location: "pantry", Count: 2}},
+				AdjustStockErr: errors.New("unexpected AdjustStock"),
+			},
+			recs: []*sipb.BackupRecord{header, stock},
+			want: &sipb.ImportAllResponse{Stock: 1},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			si := snackInventoryServer{c: tc.c}
+			stream := &fakeImportStream{recs: tc.recs}
+			if err := si.ImportAll(stream); err != nil {
+				t.Fatalf("si.ImportAll(...) = got err %v, want err nil", err)
+			}
+			if diff := cmp.Diff(stream.res, tc.want, cmpopts.IgnoreUnexported(sipb.ImportAllResponse{})); diff != "" {
+				t.Fatalf("si.ImportAll(...) response diff (-got +want): %s", diff)
+			}
+		})
+	}
+}
+
+func TestImportAll_Errors(t *testing.T) {
+	header := &sipb.BackupRecord{Record: &sipb.BackupRecord_Header{Header: backup.Header()}}
+	snack := &sipb.BackupRecord{Record: &sipb.BackupRecord_Snack{Snack: &sipb.Snack{Barcode: "1"}}}
+
+	tests := []struct {
+		desc string
+		c    *fakedbconnector.FakeDBConnector
+		recs []*sipb.BackupRecord
+		want codes.Code
+	}{
+		{"NoRecords", &fakedbconnector.FakeDBConnector{}, nil, codes.InvalidArgument},
+		{"NoHeader", &fakedbconnector.FakeDBConnector{}, []*sipb.BackupRecord{snack}, codes.InvalidArgument},
+		{
+			"FutureVersion",
+			&fakedbconnector.FakeDBConnector{},
+			[]*sipb.BackupRecord{{Record: &sipb.BackupRecord_Header{Header: &sipb.BackupHeader{Version: backup.Version + 1}}}},
+			codes.InvalidArgument,
+		},
+		{"RepeatedHeader", &fakedbconnector.FakeDBConnector{}, []*sipb.BackupRecord{header, header}, codes.InvalidArgument},
+		{
+			"StorageError",
+			&fakedbconnector.FakeDBConnector{CreateSnackErr: errors.New("storage error")},
+			[]*sipb.BackupRecord{header, snack},
+			codes.Internal,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			si := snackInventoryServer{c: tc.c}
+			if err := si.ImportAll(&fakeImportStream{recs: tc.recs}); status.Code(err) != tc.want {
+				t.Fatalf("si.ImportAll(...) = got err %v, want code %v", err, tc.want)
+			}
+		})
+	}
+}
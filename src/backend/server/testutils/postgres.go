@@ -0,0 +1,170 @@
+/*
+Copyright 2020 Robert Barron
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package testutils
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	_ "github.com/lib/pq" // Postgres driver.
+)
+
+// pgStartTimeout bounds how long StartPostgresT waits for postgres to accept
+// connections.
+const pgStartTimeout = 30 * time.Second
+
+// findPostgresBin returns the path of the postgres program name, from PATH or
+// a Debian style /usr/lib/postgresql/<version>/bin, preferring the latest.
+func findPostgresBin(name string) (string, error) {
+	if path, err := exec.LookPath(name); err == nil {
+		return path, nil
+	}
+	matches, _ := filepath.Glob(filepath.Join("/usr/lib/postgresql", "*", "bin", name))
+	if len(matches) == 0 {
+		return "", fmt.Errorf("could not find %s", name)
+	}
+	sort.Strings(matches)
+	return matches[len(matches)-1], nil
+}
+
+// freePort returns a TCP port on localhost that is free, for now.
+func freePort() (int, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return 0, err
+	}
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port, nil
+}
+
+// StartPostgresT starts a local instance of postgres, as StartMysqldT does for
+// mysqld, with an empty snackinventory database & no tables written. Returns
+// the DB, connected to the database, and a close function.
+//
+// db, close := testutils.StartPostgresT(ctx, t)
+// defer close()
+func StartPostgresT(ctx context.Context, t *testing.T) (*sql.DB, func()) {
+	t.Helper()
+
+	initdb, err := findPostgresBin("initdb")
+	if err != nil {
+		t.Fatalf("findPostgresBin(%q) = got err %v, want err nil", "initdb", err)
+	}
+	postgres, err := findPostgresBin("postgres")
+	if err != nil {
+		t.Fatalf("findPostgresBin(%q) = got err %v, want err nil", "postgres", err)
+	}
+
+	dir := t.TempDir()
+	data := filepath.Join(dir, "data")
+	// Listed text is ordered bytewise, as with COLLATE "C".
+	if out, err := exec.CommandContext(ctx, initdb, "-D", data, "-U", "postgres", "-A", "trust",
+		"-E", "UTF8", "--no-locale").CombinedOutput(); err != nil {
+		t.Fatalf("initdb -D %s = got err %v, want err nil: %s", data, err, out)
+	}
+	port, err := freePort()
+	if err != nil {
+		t.Fatalf("freePort() = got err %v, want err nil", err)
+	}
+	cmd := exec.Command(postgres, "-D", data, "-p", fmt.Sprint(port), "-k", dir,
+		"-c", "listen_addresses=127.0.0.1", "-F")
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("cmd.Start() for postgres = got err %v, want err nil", err)
+	}
+	stop := func() {
+		// SIGINT is a fast shutdown, aborting open transactions.
+		cmd.Process.Signal(os.Interrupt)
+		cmd.Wait()
+	}
+
+	dsn := fmt.Sprintf("host=127.0.0.1 port=%d user=postgres sslmode=disable dbname=", port)
+	setup, err := sql.Open("postgres", dsn+"postgres")
+	if err != nil {
+		stop()
+		t.Fatalf("sql.Open(%q, %q) = got err %v, want err nil", "postgres", dsn+"postgres", err)
+	}
+	defer setup.Close()
+	deadline := time.Now().Add(pgStartTimeout)
+	for err = setup.PingContext(ctx); err != nil && time.Now().Before(deadline); err = setup.PingContext(ctx) {
+		time.Sleep(100 * time.Millisecond)
+	}
+	if err != nil {
+		stop()
+		t.Fatalf("setup.PingContext(ctx) = got err %v, want err nil", err)
+	}
+	if _, err := setup.ExecContext(ctx, createPostgresDatabase); err != nil {
+		stop()
+		t.Fatalf("setup.ExecContext(ctx, %q) = got err %v, want err nil", createPostgresDatabase, err)
+	}
+
+	db, err := sql.Open("postgres", dsn+"snackinventory")
+	if err != nil {
+		stop()
+		t.Fatalf("sql.Open(%q, %q) = got err %v, want err nil", "postgres", dsn+"snackinventory", err)
+	}
+	if err = db.PingContext(ctx); err != nil {
+		stop()
+		t.Fatalf("db.PingContext(ctx) = got err %v, want err nil", err)
+	}
+
+	return db, func() {
+		db.Close()
+		stop()
+	}
+}
+
+// createPostgresDatabase creates the snackinventory database.
+const createPostgresDatabase = "CREATE DATABASE snackinventory ENCODING 'UTF8'"
+
+// ResetPostgresTables is ResetTables, for the Postgres storage model.
+func ResetPostgresTables(ctx context.Context, db *sql.DB) error {
+	const drop = "DROP TABLE IF EXISTS SnackRegistry, LocationRegistry, Stock, SnackRevisions, LocationRevisions"
+	for _, stmt := range append([]string{drop}, createPostgresTables...) {
+		if _, err := db.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("db.ExecContext(ctx, %q) = got err %v, want err nil", stmt, err)
+		}
+	}
+	return nil
+}
+
+// createPostgresTables holds the statements creating each table of the
+// Postgres storage model, as listed in the README.
+var createPostgresTables = []string{
+	"CREATE TABLE SnackRegistry ( barcode VARCHAR(20) COLLATE \"C\" PRIMARY KEY, name VARCHAR(255)," +
+		" brand VARCHAR(255) NOT NULL DEFAULT '', category VARCHAR(255) NOT NULL DEFAULT '', package_size VARCHAR(64) NOT NULL DEFAULT ''," +
+		" deleted_at TIMESTAMPTZ NULL DEFAULT NULL)",
+	"CREATE TABLE LocationRegistry ( id BIGSERIAL PRIMARY KEY, name VARCHAR(30) COLLATE \"C\" NOT NULL UNIQUE," +
+		" parent VARCHAR(30) COLLATE \"C\" NOT NULL DEFAULT '', description VARCHAR(255) NOT NULL DEFAULT '', type INT NOT NULL DEFAULT 0," +
+		" deleted_at TIMESTAMPTZ NULL DEFAULT NULL)",
+	"CREATE TABLE Stock ( barcode VARCHAR(20) COLLATE \"C\", location VARCHAR(30) COLLATE \"C\", count INT, PRIMARY KEY (barcode, location))",
+	"CREATE TABLE SnackRevisions ( id BIGSERIAL PRIMARY KEY, barcode VARCHAR(20) COLLATE \"C\" NOT NULL," +
+		" name VARCHAR(255), brand VARCHAR(255), category VARCHAR(255), package_size VARCHAR(64)," +
+		" change_type INT NOT NULL, revision_time TIMESTAMPTZ NOT NULL)",
+	"CREATE INDEX ON SnackRevisions (barcode)",
+	"CREATE TABLE LocationRevisions ( id BIGSERIAL PRIMARY KEY, location_id BIGINT NOT NULL," +
+		" name VARCHAR(30) COLLATE \"C\", parent VARCHAR(30) COLLATE \"C\", description VARCHAR(255), type INT," +
+		" change_type INT NOT NULL, revision_time TIMESTAMPTZ NOT NULL)",
+	"CREATE INDEX ON LocationRevisions (location_id)",
+}
@@ -22,6 +22,7 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"os"
 	"testing"
 
 	mysqltest "github.com/lestrrat-go/test-mysqld"
@@ -41,9 +42,11 @@ func StartMysqldT(ctx context.Context, t *testing.T) (*sql.DB, func()) {
 		t.Fatalf("mysqltest.NewMysqld(nil) = got err %v, want err nil", err)
 	}
 
-	db, err := sql.Open("mysql", mysqld.DSN())
+	// Deletion times are scanned into time.Time.
+	dsn := mysqld.DSN(mysqltest.WithParseTime(true))
+	db, err := sql.Open("mysql", dsn)
 	if err != nil {
-		t.Fatalf("sql.Open(%q, %q) = got err %v, want err nil", "mysql", mysqld.DSN(), err)
+		t.Fatalf("sql.Open(%q, %q) = got err %v, want err nil", "mysql", dsn, err)
 	}
 
 	if err = db.PingContext(ctx); err != nil {
@@ -53,38 +56,149 @@ func StartMysqldT(ctx context.Context, t *testing.T) (*sql.DB, func()) {
 	return db, mysqld.Stop
 }
 
+// StartMysqldDatabaseT starts a local instance of mysqld with an empty
+// SnackInventory database, & no tables written. Every connection of the
+// returned DB uses the database, so unlike with CreateDatabaseT, it may be used
+// from concurrent goroutines. Returns the DB and a close function.
+//
+// db, close := testutils.StartMysqldDatabaseT(ctx, t)
+// defer close()
+func StartMysqldDatabaseT(ctx context.Context, t *testing.T) (*sql.DB, func()) {
+	t.Helper()
+
+	mysqld, err := mysqltest.NewMysqld(nil)
+	if err != nil {
+		t.Fatalf("mysqltest.NewMysqld(nil) = got err %v, want err nil", err)
+	}
+
+	dsn := mysqld.DSN()
+	setup, err := sql.Open("mysql", dsn)
+	if err != nil {
+		t.Fatalf("sql.Open(%q, %q) = got err %v, want err nil", "mysql", dsn, err)
+	}
+	defer setup.Close()
+	if _, err := setup.ExecContext(ctx, createDatabase); err != nil {
+		t.Fatalf("setup.ExecContext(ctx, %q) = got err %v, want err nil", createDatabase, err)
+	}
+
+	dsn = mysqld.DSN(mysqltest.WithParseTime(true), mysqltest.WithDbname("SnackInventory"))
+	db, err := sql.Open("mysql", dsn)
+	if err != nil {
+		t.Fatalf("sql.Open(%q, %q) = got err %v, want err nil", "mysql", dsn, err)
+	}
+	if err = db.PingContext(ctx); err != nil {
+		t.Fatalf("db.PingContext(ctx) = got err %v, want err nil", err)
+	}
+
+	return db, func() {
+		db.Close()
+		mysqld.Stop()
+	}
+}
+
+// StartRestartableMysqldT starts a local instance of mysqld listening on TCP.
+// Returns its host:port address, and functions to stop & restart it to
+// simulate a DB outage. Data is kept across restarts. restart blocks until
+// mysqld accepts connections, and is safe to call from other goroutines.
+// Cleanup is registered with t.
+//
+// hostport, stop, restart := testutils.StartRestartableMysqldT(ctx, t)
+func StartRestartableMysqldT(ctx context.Context, t *testing.T) (hostport string, stop, restart func()) {
+	t.Helper()
+
+	cfg := mysqltest.NewConfig()
+	cfg.BaseDir = t.TempDir()
+	cfg.SkipNetworking = false
+	cfg.BindAddress = "127.0.0.1"
+	mysqld, err := mysqltest.NewMysqld(cfg)
+	if err != nil {
+		t.Fatalf("mysqltest.NewMysqld(cfg) = got err %v, want err nil", err)
+	}
+	t.Cleanup(mysqld.Stop)
+
+	restart = func() {
+		// A killed mysqld leaves its pid file behind, which blocks Start.
+		os.Remove(cfg.PidFile)
+		if err := mysqld.Start(); err != nil {
+			t.Errorf("mysqld.Start() = got err %v, want err nil", err)
+		}
+	}
+	return fmt.Sprintf("%s:%d", cfg.BindAddress, cfg.Port), mysqld.Stop, restart
+}
+
 // CreateDatabaseT creates a database and moves the cursor into it.
 // Assumes DB cursor is not on a database.
 func CreateDatabaseT(ctx context.Context, t *testing.T, db *sql.DB) {
 	t.Helper()
 
-	if _, err := db.ExecContext(ctx, "CREATE DATABASE SnackInventory"); err != nil {
-		t.Fatalf("db.ExecContext(ctx, %q) = got err %v, want err nil", "CREATE DATABASE SnackInventory", err)
+	if _, err := db.ExecContext(ctx, createDatabase); err != nil {
+		t.Fatalf("db.ExecContext(ctx, %q) = got err %v, want err nil", createDatabase, err)
 	}
 	if _, err := db.ExecContext(ctx, "USE SnackInventory"); err != nil {
 		t.Fatalf("db.ExecContext(ctx, %q) = got err %v, want err nil", "USE SnackInventory", err)
 	}
 }
 
+// createDatabase creates the SnackInventory database. Names may hold any
+// unicode, ex: emoji.
+const createDatabase = "CREATE DATABASE SnackInventory CHARACTER SET utf8mb4"
+
 // CreateTablesT creates tables to satisfy SnackInventory storage model.
 // Assumes cursor is in database.
 func CreateTablesT(ctx context.Context, t *testing.T, db *sql.DB) {
-	if _, err := db.ExecContext(ctx, "CREATE TABLE SnackRegistry ( barcode VARCHAR(20) PRIMARY KEY, name VARCHAR(255))"); err != nil {
-		t.Fatalf("db.ExecContext(ctx, %q) = got err %v, want err nil",
-			"CREATE TABLE SnackRegistry ( barcode VARCHAR(20) PRIMARY KEY, name VARCHAR(255))", err)
+	for _, stmt := range createTables {
+		if _, err := db.ExecContext(ctx, stmt); err != nil {
+			t.Fatalf("db.ExecContext(ctx, %q) = got err %v, want err nil", stmt, err)
+		}
 	}
-	if _, err := db.ExecContext(ctx, "CREATE TABLE LocationRegistry ( name VARCHAR(30) PRIMARY KEY)"); err != nil {
-		t.Fatalf("db.ExecContext(ctx, %q) = got err %v, want err nil",
-			"CREATE TABLE LocationRegistry ( name VARCHAR(30) PRIMARY KEY)", err)
+}
+
+// ResetTables drops any tables of the SnackInventory storage model, & creates
+// them again, empty. Unlike CreateTablesT, it may be called outside of a test's
+// goroutine. Assumes cursor is in database.
+func ResetTables(ctx context.Context, db *sql.DB) error {
+	const drop = "DROP TABLE IF EXISTS SnackRegistry, LocationRegistry, Stock, SnackRevisions, LocationRevisions"
+	for _, stmt := range append([]string{drop}, createTables...) {
+		if _, err := db.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("db.ExecContext(ctx, %q) = got err %v, want err nil", stmt, err)
+		}
 	}
+	return nil
+}
+
+// createTables holds the statements creating each table.
+var createTables = []string{
+	createSnackTable,
+	createLocationTable,
+	createStockTable,
+	createSnackRevisionsTable,
+	createLocationRevisionsTable,
 }
 
+const createSnackTable = "CREATE TABLE SnackRegistry ( barcode VARCHAR(20) PRIMARY KEY, name VARCHAR(255)," +
+	" brand VARCHAR(255) NOT NULL DEFAULT '', category VARCHAR(255) NOT NULL DEFAULT '', package_size VARCHAR(64) NOT NULL DEFAULT ''," +
+	" deleted_at DATETIME NULL DEFAULT NULL)"
+
+const createLocationTable = "CREATE TABLE LocationRegistry ( id BIGINT AUTO_INCREMENT PRIMARY KEY, name VARCHAR(30) NOT NULL UNIQUE," +
+	" parent VARCHAR(30) NOT NULL DEFAULT '', description VARCHAR(255) NOT NULL DEFAULT '', type INT NOT NULL DEFAULT 0," +
+	" deleted_at DATETIME NULL DEFAULT NULL)"
+
+const createStockTable = "CREATE TABLE Stock ( barcode VARCHAR(20), location VARCHAR(30), count INT, PRIMARY KEY (barcode, location))"
+
+const createSnackRevisionsTable = "CREATE TABLE SnackRevisions ( id BIGINT AUTO_INCREMENT PRIMARY KEY, barcode VARCHAR(20) NOT NULL," +
+	" name VARCHAR(255), brand VARCHAR(255), category VARCHAR(255), package_size VARCHAR(64)," +
+	" change_type INT NOT NULL, revision_time DATETIME(6) NOT NULL, INDEX (barcode))"
+
+const createLocationRevisionsTable = "CREATE TABLE LocationRevisions ( id BIGINT AUTO_INCREMENT PRIMARY KEY, location_id BIGINT NOT NULL," +
+	" name VARCHAR(30), parent VARCHAR(30), description VARCHAR(255), type INT," +
+	" change_type INT NOT NULL, revision_time DATETIME(6) NOT NULL, INDEX (location_id))"
+
 // DropTablesT drops tables in the current database corresponding to
 // SnackInventory's storage model. Assumes cursor is in database.
 func DropTablesT(ctx context.Context, t *testing.T, db *sql.DB) {
-	if _, err := db.ExecContext(ctx, "DROP TABLE SnackRegistry, LocationRegistry"); err != nil {
-		t.Fatalf("db.ExecContext(ctx, %q) = got err %v, want err nil",
-			"DROP TABLE SnackRegistry, LocationRegistry", err)
+	const stmt = "DROP TABLE SnackRegistry, LocationRegistry, Stock, SnackRevisions, LocationRevisions"
+	if _, err := db.ExecContext(ctx, stmt); err != nil {
+		t.Fatalf("db.ExecContext(ctx, %q) = got err %v, want err nil", stmt, err)
 	}
 }
 
@@ -109,8 +223,9 @@ func AddLocationT(ctx context.Context, t *testing.T, db *sql.DB, location *sipb.
 	t.Helper()
 
 	name := location.GetName()
+	parent := location.GetParent()
 
-	query := fmt.Sprintf("INSERT INTO LocationRegistry (name) VALUES(%q)", name)
+	query := fmt.Sprintf("INSERT INTO LocationRegistry (name, parent) VALUES(%q, %q)", name, parent)
 
 	if _, err := db.ExecContext(ctx, query); err != nil {
 		t.Fatalf("db.ExecContext(ctx, %q) = got err %v, want err nil", query, err)
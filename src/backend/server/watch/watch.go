@@ -0,0 +1,232 @@
+/*
+Copyright 2020 Robert Barron
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package watch provides an in-process pub/sub hub of SnackInventory changes,
+// backing the WatchChanges RPC.
+//
+// Every published change is assigned a sequence number, encoded with the hub's
+// start time as an opaque resume token. The hub retains a bounded history of
+// recent changes so subscribers can resume after a dropped connection without
+// missing changes.
+package watch
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	sipb "github.com/rmbarron/SnackInventory/src/proto/snackinventory"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+var (
+	// ErrTokenExpired is returned by Subscribe when changes after the resume
+	// token are no longer retained, or the token is from another hub (ex: before
+	// a server restart).
+	ErrTokenExpired = errors.New("resume token expired")
+	// ErrLagged ends a Subscription that fell too far behind publishers.
+	// The subscriber may resubscribe from the last token it received.
+	ErrLagged = errors.New("subscriber fell behind")
+
+	errClosed = errors.New("subscription closed")
+)
+
+// subscriberBuffer is how many changes may be queued for a subscriber before
+// it is considered lagged.
+const subscriberBuffer = 256
+
+// Hub fans out published changes to subscribers. It is safe for concurrent use.
+type Hub struct {
+	epoch int64
+	// now is the clock used for change times.
+	now func() time.Time
+
+	mu sync.Mutex
+	// seq is the sequence number of the last published change.
+	seq uint64
+	// history is a ring buffer of the last len(history) changes; change n is
+	// at history[n%len(history)].
+	history []*sipb.Change
+	subs    map[*Subscription]struct{}
+}
+
+// NewHub creates a Hub retaining the last historySize changes for resumption.
+func NewHub(historySize int) *Hub {
+	if historySize < 1 {
+		historySize = 1
+	}
+	return &Hub{
+		epoch:   time.Now().UnixNano(),
+		now:     time.Now,
+		history: make([]*sipb.Change, historySize),
+		subs:    map[*Subscription]struct{}{},
+	}
+}
+
+// Publish records c, setting its change time & resume token, and delivers it
+// to every subscriber. c must not be modified afterwards.
+// Publishing to a nil Hub is a no-op.
+func (h *Hub) Publish(c *sipb.Change) {
+	if h == nil {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.seq++
+	c.ChangeTime = timestamppb.New(h.now())
+	c.ResumeToken = h.token(h.seq)
+	h.history[h.seq%uint64(len(h.history))] = c
+
+	for s := range h.subs {
+		select {
+		case s.ch <- c:
+		default:
+			h.removeLocked(s, ErrLagged)
+		}
+	}
+}
+
+// PublishSnack publishes a change of type t to snack.
+func (h *Hub) PublishSnack(t sipb.Change_Type, snack *sipb.Snack) {
+	h.Publish(&sipb.Change{Type: t, Entity: &sipb.Change_Snack{Snack: snack}})
+}
+
+// PublishLocation publishes a change of type t to location.
+func (h *Hub) PublishLocation(t sipb.Change_Type, location *sipb.Location) {
+	h.Publish(&sipb.Change{Type: t, Entity: &sipb.Change_Location{Location: location}})
+}
+
+// PublishStock publishes a change in stock.
+func (h *Hub) PublishStock(stock *sipb.StockChange) {
+	h.Publish(&sipb.Change{Type: sipb.Change_UPDATED, Entity: &sipb.Change_Stock{Stock: stock}})
+}
+
+// Subscribe starts a subscription to changes published after resumeToken, or
+// after now if resumeToken is empty. Close the Subscription when done.
+func (h *Hub) Subscribe(resumeToken string) (*Subscription, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	start := h.seq
+	if resumeToken != "" {
+		seq, err := h.parseToken(resumeToken)
+		if err != nil {
+			return nil, err
+		}
+		if seq > h.seq {
+			return nil, fmt.Errorf("%w: token %q is from the future", ErrTokenExpired, resumeToken)
+		}
+		if h.seq-seq > uint64(len(h.history)) {
+			return nil, fmt.Errorf("%w: changes after %q are no longer retained", ErrTokenExpired, resumeToken)
+		}
+		start = seq
+	}
+
+	backlog := int(h.seq - start)
+	s := &Subscription{
+		h:    h,
+		ch:   make(chan *sipb.Change, subscriberBuffer+backlog),
+		done: make(chan struct{}),
+	}
+	for seq := start + 1; seq <= h.seq; seq++ {
+		s.ch <- h.history[seq%uint64(len(h.history))]
+	}
+	h.subs[s] = struct{}{}
+	return s, nil
+}
+
+// Subscribers returns the number of active subscriptions.
+func (h *Hub) Subscribers() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.subs)
+}
+
+// token encodes seq as a resume token.
+func (h *Hub) token(seq uint64) string {
+	return fmt.Sprintf("%d.%d", h.epoch, seq)
+}
+
+// parseToken decodes a resume token from this hub into its sequence number.
+func (h *Hub) parseToken(token string) (uint64, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 2 {
+		return 0, fmt.Errorf("malformed resume token %q", token)
+	}
+	epoch, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("malformed resume token %q", token)
+	}
+	seq, err := strconv.ParseUint(parts[1], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("malformed resume token %q", token)
+	}
+	if epoch != h.epoch {
+		return 0, fmt.Errorf("%w: token %q is from a previous server", ErrTokenExpired, token)
+	}
+	return seq, nil
+}
+
+// removeLocked ends s with err. h.mu must be held.
+func (h *Hub) removeLocked(s *Subscription, err error) {
+	if _, ok := h.subs[s]; !ok {
+		return
+	}
+	delete(h.subs, s)
+	s.err = err
+	close(s.done)
+}
+
+// Subscription receives changes from a Hub.
+type Subscription struct {
+	h    *Hub
+	ch   chan *sipb.Change
+	done chan struct{}
+	// err is set before done is closed.
+	err error
+}
+
+// Changes returns the channel changes are delivered on, in publish order.
+func (s *Subscription) Changes() <-chan *sipb.Change {
+	return s.ch
+}
+
+// Done is closed when the Subscription ends, after which Err is non-nil.
+// Changes queued before the end remain readable from Changes.
+func (s *Subscription) Done() <-chan struct{} {
+	return s.done
+}
+
+// Err returns why the Subscription ended, or nil if it has not.
+func (s *Subscription) Err() error {
+	select {
+	case <-s.done:
+		return s.err
+	default:
+		return nil
+	}
+}
+
+// Close ends the Subscription. It is safe to call more than once.
+func (s *Subscription) Close() {
+	s.h.mu.Lock()
+	defer s.h.mu.Unlock()
+	s.h.removeLocked(s, errClosed)
+}
@@ -0,0 +1,175 @@
+/*
+Copyright 2020 Robert Barron
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package watch
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	sipb "github.com/rmbarron/SnackInventory/src/proto/snackinventory"
+)
+
+// recvT receives n changes from s, returning their snack barcodes.
+func recvT(t *testing.T, s *Subscription, n int) []string {
+	t.Helper()
+	var got []string
+	for i := 0; i < n; i++ {
+		select {
+		case c := <-s.Changes():
+			got = append(got, c.GetSnack().GetBarcode())
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for change %d of %d; got %v", i+1, n, got)
+		}
+	}
+	return got
+}
+
+func TestPublishSubscribe(t *testing.T) {
+	h := NewHub(10)
+	now := time.Date(2020, 5, 1, 0, 0, 0, 0, time.UTC)
+	h.now = func() time.Time { return now }
+
+	h.PublishSnack(sipb.Change_CREATED, &sipb.Snack{Barcode: "before"})
+	s, err := h.Subscribe("")
+	if err != nil {
+		t.Fatalf("h.Subscribe(\"\") = got err %v, want err nil", err)
+	}
+	defer s.Close()
+	h.PublishSnack(sipb.Change_CREATED, &sipb.Snack{Barcode: "1"})
+	h.PublishSnack(sipb.Change_DELETED, &sipb.Snack{Barcode: "2"})
+
+	c1, c2 := <-s.Changes(), <-s.Changes()
+	if c1.GetSnack().GetBarcode() != "1" || c2.GetSnack().GetBarcode() != "2" {
+		t.Fatalf("s.Changes() = got %v, %v, want snacks 1, 2", c1, c2)
+	}
+	if c2.GetType() != sipb.Change_DELETED {
+		t.Errorf("c2.GetType() = got %v, want %v", c2.GetType(), sipb.Change_DELETED)
+	}
+	if got := c1.GetChangeTime().AsTime(); !got.Equal(now) {
+		t.Errorf("c1.GetChangeTime() = got %v, want %v", got, now)
+	}
+	if c1.GetResumeToken() == "" || c1.GetResumeToken() == c2.GetResumeToken() {
+		t.Errorf("resume tokens = got %q, %q, want distinct non-empty", c1.GetResumeToken(), c2.GetResumeToken())
+	}
+}
+
+func TestSubscribe_Resume(t *testing.T) {
+	h := NewHub(10)
+	s, err := h.Subscribe("")
+	if err != nil {
+		t.Fatalf("h.Subscribe(\"\") = got err %v, want err nil", err)
+	}
+	h.PublishSnack(sipb.Change_CREATED, &sipb.Snack{Barcode: "1"})
+	token := (<-s.Changes()).GetResumeToken()
+	s.Close()
+
+	h.PublishSnack(sipb.Change_CREATED, &sipb.Snack{Barcode: "2"})
+	h.PublishSnack(sipb.Change_CREATED, &sipb.Snack{Barcode: "3"})
+
+	s, err = h.Subscribe(token)
+	if err != nil {
+		t.Fatalf("h.Subscribe(%q) = got err %v, want err nil", token, err)
+	}
+	defer s.Close()
+	h.PublishSnack(sipb.Change_CREATED, &sipb.Snack{Barcode: "4"})
+
+	got := recvT(t, s, 3)
+	want := []string{"2", "3", "4"}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("h.Subscribe(%q) changes = got %v, want %v", token, got, want)
+		}
+	}
+}
+
+func TestSubscribe_BadToken(t *testing.T) {
+	h := NewHub(2)
+	s, err := h.Subscribe("")
+	if err != nil {
+		t.Fatalf("h.Subscribe(\"\") = got err %v, want err nil", err)
+	}
+	defer s.Close()
+	for i := 0; i < 4; i++ {
+		h.PublishSnack(sipb.Change_CREATED, &sipb.Snack{})
+	}
+	first := (<-s.Changes()).GetResumeToken()
+	other := NewHub(2)
+	other.epoch = h.epoch + 1
+	other.PublishSnack(sipb.Change_CREATED, &sipb.Snack{})
+
+	tests := []struct {
+		desc        string
+		token       string
+		wantExpired bool
+	}{
+		{"Malformed", "not-a-token", false},
+		{"TooOld", first, true},
+		{"OtherHub", other.history[1].GetResumeToken(), true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			_, err := h.Subscribe(tc.token)
+			if err == nil {
+				t.Fatalf("h.Subscribe(%q) = got err nil, want err", tc.token)
+			}
+			if got := errors.Is(err, ErrTokenExpired); got != tc.wantExpired {
+				t.Fatalf("h.Subscribe(%q) = got err %v, want ErrTokenExpired? %t", tc.token, err, tc.wantExpired)
+			}
+		})
+	}
+}
+
+func TestSubscription_Lagged(t *testing.T) {
+	h := NewHub(1)
+	s, err := h.Subscribe("")
+	if err != nil {
+		t.Fatalf("h.Subscribe(\"\") = got err %v, want err nil", err)
+	}
+	for i := 0; i <= subscriberBuffer; i++ {
+		h.PublishSnack(sipb.Change_CREATED, &sipb.Snack{})
+	}
+
+	select {
+	case <-s.Done():
+	default:
+		t.Fatal("s.Done() not closed after overflowing subscriber buffer")
+	}
+	if !errors.Is(s.Err(), ErrLagged) {
+		t.Fatalf("s.Err() = got %v, want %v", s.Err(), ErrLagged)
+	}
+	if n := h.Subscribers(); n != 0 {
+		t.Fatalf("h.Subscribers() = got %d, want 0", n)
+	}
+}
+
+func TestSubscription_Close(t *testing.T) {
+	h := NewHub(1)
+	s, err := h.Subscribe("")
+	if err != nil {
+		t.Fatalf("h.Subscribe(\"\") = got err %v, want err nil", err)
+	}
+	if s.Err() != nil {
+		t.Fatalf("s.Err() before Close = got %v, want nil", s.Err())
+	}
+	s.Close()
+	s.Close()
+	if s.Err() == nil {
+		t.Fatal("s.Err() after Close = got nil, want err")
+	}
+	// Publishing after Close must not block or panic.
+	h.PublishSnack(sipb.Change_CREATED, &sipb.Snack{})
+}
@@ -0,0 +1,158 @@
+/*
+Copyright 2020 Robert Barron
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package backup reads & writes SnackInventory backup files.
+//
+// A backup file is gzip-compressed. The uncompressed stream starts with Magic,
+// followed by sipb.BackupRecord messages, each prefixed by its length as a
+// uvarint. The first record is always a header, holding the format version.
+// Since records are self-describing protos, a backup taken from one storage
+// backend can be restored to any other.
+package backup
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	sipb "github.com/rmbarron/SnackInventory/src/proto/snackinventory"
+	"google.golang.org/protobuf/proto"
+)
+
+// Version is the newest backup format version this package understands.
+const Version = 2
+
+// Magic identifies SnackInventory backup files.
+const Magic = "SNACKINVENTORY-BACKUP\n"
+
+// maxRecordSize guards against allocating huge buffers for corrupt files.
+const maxRecordSize = 16 << 20
+
+// Header returns the header record for a backup in the current version.
+func Header() *sipb.BackupHeader {
+	return &sipb.BackupHeader{Version: Version}
+}
+
+// CheckHeader returns an error if h is not readable by this package.
+func CheckHeader(h *sipb.BackupHeader) error {
+	if h == nil {
+		return errors.New("backup is missing its header")
+	}
+	if h.GetVersion() < 1 || h.GetVersion() > Version {
+		return fmt.Errorf("unsupported backup version %d; this build supports up to %d", h.GetVersion(), Version)
+	}
+	return nil
+}
+
+// Writer writes a backup file.
+type Writer struct {
+	gz  *gzip.Writer
+	buf [binary.MaxVarintLen64]byte
+}
+
+// NewWriter starts a backup file on w, writing header as the first record.
+// Close the Writer to flush the file; w itself is not closed.
+func NewWriter(w io.Writer, header *sipb.BackupHeader) (*Writer, error) {
+	if err := CheckHeader(header); err != nil {
+		return nil, err
+	}
+	bw := &Writer{gz: gzip.NewWriter(w)}
+	if _, err := io.WriteString(bw.gz, Magic); err != nil {
+		return nil, err
+	}
+	if err := bw.Write(&sipb.BackupRecord{Record: &sipb.BackupRecord_Header{Header: header}}); err != nil {
+		return nil, err
+	}
+	return bw, nil
+}
+
+// Write appends rec to the backup.
+func (w *Writer) Write(rec *sipb.BackupRecord) error {
+	b, err := proto.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	n := binary.PutUvarint(w.buf[:], uint64(len(b)))
+	if _, err := w.gz.Write(w.buf[:n]); err != nil {
+		return err
+	}
+	_, err = w.gz.Write(b)
+	return err
+}
+
+// Close flushes the backup.
+func (w *Writer) Close() error {
+	return w.gz.Close()
+}
+
+// Reader reads a backup file.
+type Reader struct {
+	r      *bufio.Reader
+	header *sipb.BackupHeader
+}
+
+// NewReader opens the backup file read from r, checking its header.
+func NewReader(r io.Reader) (*Reader, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("not a backup file: %w", err)
+	}
+	br := &Reader{r: bufio.NewReader(gz)}
+	magic := make([]byte, len(Magic))
+	if _, err := io.ReadFull(br.r, magic); err != nil || string(magic) != Magic {
+		return nil, errors.New("not a backup file: bad magic")
+	}
+	rec, err := br.Next()
+	if err != nil {
+		return nil, fmt.Errorf("could not read backup header: %w", err)
+	}
+	if err := CheckHeader(rec.GetHeader()); err != nil {
+		return nil, err
+	}
+	br.header = rec.GetHeader()
+	return br, nil
+}
+
+// Header returns the header of the backup.
+func (r *Reader) Header() *sipb.BackupHeader {
+	return r.header
+}
+
+// Next returns the next record, or io.EOF after the last one.
+func (r *Reader) Next() (*sipb.BackupRecord, error) {
+	n, err := binary.ReadUvarint(r.r)
+	if errors.Is(err, io.EOF) {
+		return nil, io.EOF
+	}
+	if err != nil {
+		return nil, fmt.Errorf("corrupt backup: %w", err)
+	}
+	if n > maxRecordSize {
+		return nil, fmt.Errorf("corrupt backup: record of %d bytes exceeds limit", n)
+	}
+	b := make([]byte, n)
+	if _, err := io.ReadFull(r.r, b); err != nil {
+		return nil, fmt.Errorf("corrupt backup: %w", io.ErrUnexpectedEOF)
+	}
+	rec := &sipb.BackupRecord{}
+	if err := proto.Unmarshal(b, rec); err != nil {
+		return nil, fmt.Errorf("corrupt backup: %w", err)
+	}
+	return rec, nil
+}
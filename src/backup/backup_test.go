@@ -0,0 +1,161 @@
+/*
+Copyright 2020 Robert Barron
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package backup
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"io/ioutil"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	sipb "github.com/rmbarron/SnackInventory/src/proto/snackinventory"
+	"google.golang.org/protobuf/testing/protocmp"
+)
+
+func TestRoundTrip(t *testing.T) {
+	records := []*sipb.BackupRecord{
+		{Record: &sipb.BackupRecord_Snack{Snack: &sipb.Snack{Barcode: "123", Name: "chips"}}},
+		{Record: &sipb.BackupRecord_Location{Location: &sipb.Location{Name: "pantry"}}},
+		{Record: &sipb.BackupRecord_Snack{Snack: &sipb.Snack{Barcode: "456"}}},
+	}
+
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, Header())
+	if err != nil {
+		t.Fatalf("NewWriter(&buf, Header()) = got err %v, want err nil", err)
+	}
+	for _, rec := range records {
+		if err := w.Write(rec); err != nil {
+			t.Fatalf("w.Write(%v) = got err %v, want err nil", rec, err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("w.Close() = got err %v, want err nil", err)
+	}
+
+	r, err := NewReader(&buf)
+	if err != nil {
+		t.Fatalf("NewReader(&buf) = got err %v, want err nil", err)
+	}
+	if got := r.Header().GetVersion(); got != Version {
+		t.Errorf("r.Header().GetVersion() = got %d, want %d", got, Version)
+	}
+	var got []*sipb.BackupRecord
+	for {
+		rec, err := r.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("r.Next() = got err %v, want err nil", err)
+		}
+		got = append(got, rec)
+	}
+	if diff := cmp.Diff(got, records, protocmp.Transform()); diff != "" {
+		t.Fatalf("records read = got diff (-got +want): %s", diff)
+	}
+}
+
+func TestNewWriter_BadHeader(t *testing.T) {
+	for _, h := range []*sipb.BackupHeader{nil, {}, {Version: Version + 1}} {
+		if _, err := NewWriter(ioutil.Discard, h); err == nil {
+			t.Errorf("NewWriter(w, %v) = got err nil, want err", h)
+		}
+	}
+}
+
+// gzipT compresses b.
+func gzipT(t *testing.T, b []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	if _, err := gz.Write(b); err != nil {
+		t.Fatalf("gz.Write(...) = got err %v, want err nil", err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatalf("gz.Close() = got err %v, want err nil", err)
+	}
+	return buf.Bytes()
+}
+
+func TestNewReader_Errors(t *testing.T) {
+	var newer bytes.Buffer
+	w, err := NewWriter(&newer, Header())
+	if err != nil {
+		t.Fatalf("NewWriter(&buf, Header()) = got err %v, want err nil", err)
+	}
+	w.Close()
+	// Rewrite the version of a valid file to one from the future.
+	b, err := ioutil.ReadAll(mustGunzipT(t, newer.Bytes()))
+	if err != nil {
+		t.Fatalf("ioutil.ReadAll(...) = got err %v, want err nil", err)
+	}
+	future := bytes.Replace(b, []byte{0x08, Version}, []byte{0x08, Version + 1}, 1)
+
+	tests := []struct {
+		desc string
+		file []byte
+	}{
+		{"NotGzip", []byte("barcode,name\n")},
+		{"BadMagic", gzipT(t, []byte("SNACKS\n"))},
+		{"NoHeader", gzipT(t, []byte(Magic))},
+		{"TruncatedHeader", gzipT(t, append([]byte(Magic), 0x10, 0x0a))},
+		{"FutureVersion", gzipT(t, future)},
+	}
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			if _, err := NewReader(bytes.NewReader(tc.file)); err == nil {
+				t.Fatal("NewReader(...) = got err nil, want err")
+			}
+		})
+	}
+}
+
+// mustGunzipT returns a reader of the decompressed b.
+func mustGunzipT(t *testing.T, b []byte) io.Reader {
+	t.Helper()
+	gz, err := gzip.NewReader(bytes.NewReader(b))
+	if err != nil {
+		t.Fatalf("gzip.NewReader(...) = got err %v, want err nil", err)
+	}
+	return gz
+}
+
+func TestNext_Truncated(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, Header())
+	if err != nil {
+		t.Fatalf("NewWriter(&buf, Header()) = got err %v, want err nil", err)
+	}
+	w.Close()
+	b, err := ioutil.ReadAll(mustGunzipT(t, buf.Bytes()))
+	if err != nil {
+		t.Fatalf("ioutil.ReadAll(...) = got err %v, want err nil", err)
+	}
+	// A record claiming 5 bytes, with only 1 present.
+	b = append(b, 0x05, 0x12)
+
+	r, err := NewReader(bytes.NewReader(gzipT(t, b)))
+	if err != nil {
+		t.Fatalf("NewReader(...) = got err %v, want err nil", err)
+	}
+	if _, err := r.Next(); err == nil || errors.Is(err, io.EOF) {
+		t.Fatalf("r.Next() = got err %v, want corrupt backup error", err)
+	}
+}
@@ -0,0 +1,121 @@
+/*
+Copyright 2020 Robert Barron
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package cmd provides the various subcommands of the SnackInventory CLI.
+// This file implements a call to the `ExportAll` RPC.
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/rmbarron/SnackInventory/src/backup"
+	sipb "github.com/rmbarron/SnackInventory/src/proto/snackinventory"
+	"github.com/spf13/cobra"
+)
+
+var (
+	backupOut string
+
+	backupCmd = &cobra.Command{
+		Use:   "backup --out=file",
+		Short: "Write every snack, location & stock count to a backup file.",
+		Long: `Write every snack, location & stock count to a backup file,
+    restorable with the restore command. Backups are independent of the storage backend, so
+    they can also be used to migrate between backends. The file is only
+    replaced once the backup is complete.`,
+		RunE: backupAll,
+	}
+)
+
+func init() {
+	backupCmd.Flags().StringVar(&backupOut, "out", "", "Path of the backup file to write.")
+	backupCmd.MarkFlagRequired("out")
+}
+
+func backupAll(_ *cobra.Command, _ []string) error {
+	ctx, cancel := commandContext()
+	defer cancel()
+	client, err := newClient(ctx)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	// Write to a temporary file in the same directory, so a failed backup never
+	// clobbers an existing one & the rename is atomic.
+	tmp, err := ioutil.TempFile(filepath.Dir(backupOut), filepath.Base(backupOut)+".tmp*")
+	if err != nil {
+		return fmt.Errorf("could not create backup: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	snacks, locations, stock, err := exportAll(ctx, client, tmp)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return fmt.Errorf("could not back up: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), backupOut); err != nil {
+		return fmt.Errorf("could not write %s: %w", backupOut, err)
+	}
+	fmt.Printf("Backed up %d snacks, %d locations and %d stock counts to %s\n", snacks, locations, stock, backupOut)
+	return nil
+}
+
+// exportAll writes the records streamed by ExportAll to w as a backup file,
+// returning the number of snacks, locations & stock counts written.
+func exportAll(ctx context.Context, client sipb.SnackInventoryClient, w io.Writer) (snacks, locations, stock int, err error) {
+	stream, err := client.ExportAll(ctx, &sipb.ExportAllRequest{})
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	first, err := stream.Recv()
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	bw, err := backup.NewWriter(w, first.GetHeader())
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("unexpected first record from server: %w", err)
+	}
+	for {
+		rec, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return 0, 0, 0, err
+		}
+		switch rec.GetRecord().(type) {
+		case *sipb.BackupRecord_Snack:
+			snacks++
+		case *sipb.BackupRecord_Location:
+			locations++
+		case *sipb.BackupRecord_Stock:
+			stock++
+		}
+		if err := bw.Write(rec); err != nil {
+			return 0, 0, 0, err
+		}
+	}
+	return snacks, locations, stock, bw.Close()
+}
@@ -0,0 +1,97 @@
+/*
+Copyright 2020 Robert Barron
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/rmbarron/SnackInventory/src/backend/fakes/fakeserver"
+	"github.com/rmbarron/SnackInventory/src/backup"
+	"github.com/rmbarron/SnackInventory/src/cli/testutils"
+	sipb "github.com/rmbarron/SnackInventory/src/proto/snackinventory"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// backupRecords are the records of a small backup.
+var backupRecords = []*sipb.BackupRecord{
+	{Record: &sipb.BackupRecord_Header{Header: backup.Header()}},
+	{Record: &sipb.BackupRecord_Location{Location: &sipb.Location{Name: "pantry"}}},
+	{Record: &sipb.BackupRecord_Snack{Snack: &sipb.Snack{Barcode: "123", Name: "chips"}}},
+}
+
+func TestBackupAll(t *testing.T) {
+	fsi := &fakeserver.FakeSnackInventoryServer{ExportAllRes: backupRecords}
+	addr, close := testutils.StartTestServer(t, fsi)
+	defer close()
+
+	// Inject the address of our fake server to the address flag variable.
+	tmpAddr := address
+	address = addr
+	defer func() { address = tmpAddr }()
+
+	tmpOut := backupOut
+	backupOut = filepath.Join(t.TempDir(), "snacks.backup")
+	defer func() { backupOut = tmpOut }()
+
+	if err := backupAll(nil, nil); err != nil {
+		t.Fatalf("backupAll(nil, nil) = got err %v, want err nil", err)
+	}
+	f, err := os.Open(backupOut)
+	if err != nil {
+		t.Fatalf("os.Open(%q) = got err %v, want err nil", backupOut, err)
+	}
+	defer f.Close()
+	if _, err := backup.NewReader(f); err != nil {
+		t.Fatalf("backup.NewReader(%q) = got err %v, want err nil", backupOut, err)
+	}
+}
+
+func TestBackupAll_ServerError(t *testing.T) {
+	fsi := &fakeserver.FakeSnackInventoryServer{
+		ExportAllRes: backupRecords[:2],
+		ExportAllErr: status.Error(codes.Internal, "storage error"),
+	}
+	addr, close := testutils.StartTestServer(t, fsi)
+	defer close()
+
+	// Inject the address of our fake server to the address flag variable.
+	tmpAddr := address
+	address = addr
+	defer func() { address = tmpAddr }()
+
+	dir := t.TempDir()
+	tmpOut := backupOut
+	backupOut = filepath.Join(dir, "snacks.backup")
+	defer func() { backupOut = tmpOut }()
+	if err := ioutil.WriteFile(backupOut, []byte("previous backup"), 0600); err != nil {
+		t.Fatalf("ioutil.WriteFile(%q) = got err %v, want err nil", backupOut, err)
+	}
+
+	if err := backupAll(nil, nil); err == nil {
+		t.Fatal("backupAll(nil, nil) = got err nil, want err")
+	}
+	// The previous backup is untouched, and no temporary files remain.
+	if b, err := ioutil.ReadFile(backupOut); err != nil || string(b) != "previous backup" {
+		t.Errorf("ioutil.ReadFile(%q) = got (%q, %v), want previous backup", backupOut, b, err)
+	}
+	if entries, _ := ioutil.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("ioutil.ReadDir(%q) = got %d entries, want 1", dir, len(entries))
+	}
+}
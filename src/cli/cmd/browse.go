@@ -0,0 +1,294 @@
+/*
+Copyright 2020 Robert Barron
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package cmd provides the various subcommands of the SnackInventory CLI.
+// This file implements the full-screen snack browser of `shell`.
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gdamore/tcell"
+	sipb "github.com/rmbarron/SnackInventory/src/proto/snackinventory"
+	"google.golang.org/protobuf/proto"
+)
+
+const browseHelp = "j/k move  enter rename  d delete  r refresh  q quit"
+
+// browseMode is what keys currently do in the browser.
+type browseMode int
+
+const (
+	browseNormal browseMode = iota
+	// browseEdit edits the name of the selected snack.
+	browseEdit
+	// browseConfirmDelete asks before deleting the selected snack.
+	browseConfirmDelete
+)
+
+// browser is a full-screen table of snacks, editable inline.
+type browser struct {
+	screen tcell.Screen
+	client sipb.SnackInventoryClient
+	snacks []*sipb.Snack
+	// row is the selected snack; top is the first one on screen.
+	row, top int
+	mode     browseMode
+	input    []rune
+	status   string
+}
+
+// browseSnacks runs the browser on the terminal until the user quits.
+func browseSnacks(ctx context.Context, client sipb.SnackInventoryClient) error {
+	screen, err := tcell.NewScreen()
+	if err != nil {
+		return fmt.Errorf("could not open terminal: %w", err)
+	}
+	if err := screen.Init(); err != nil {
+		return fmt.Errorf("could not open terminal: %w", err)
+	}
+	defer screen.Fini()
+	return (&browser{screen: screen, client: client}).run(ctx)
+}
+
+// run handles events until the user quits, or the screen is closed.
+func (b *browser) run(ctx context.Context) error {
+	if err := b.load(ctx); err != nil {
+		return err
+	}
+	for {
+		b.draw()
+		switch ev := b.screen.PollEvent().(type) {
+		case nil:
+			return nil
+		case *tcell.EventResize:
+			b.screen.Sync()
+		case *tcell.EventKey:
+			if b.handleKey(ctx, ev) {
+				return nil
+			}
+		}
+	}
+}
+
+// load fetches the snacks, keeping the selection in range.
+func (b *browser) load(ctx context.Context) error {
+	res, err := b.client.ListSnacks(ctx, &sipb.ListSnacksRequest{})
+	if err != nil {
+		return fmt.Errorf("could not list snacks: %w", err)
+	}
+	b.snacks = res.GetSnacks()
+	b.moveTo(b.row)
+	return nil
+}
+
+// moveTo selects row, clamped to the snacks.
+func (b *browser) moveTo(row int) {
+	if row >= len(b.snacks) {
+		row = len(b.snacks) - 1
+	}
+	if row < 0 {
+		row = 0
+	}
+	b.row = row
+}
+
+// handleKey applies ev, returning true if the user quit.
+func (b *browser) handleKey(ctx context.Context, ev *tcell.EventKey) bool {
+	switch b.mode {
+	case browseEdit:
+		b.handleEditKey(ctx, ev)
+		return false
+	case browseConfirmDelete:
+		b.mode = browseNormal
+		if ev.Key() == tcell.KeyRune && (ev.Rune() == 'y' || ev.Rune() == 'Y') {
+			b.deleteSelected(ctx)
+		} else {
+			b.status = "Delete cancelled."
+		}
+		return false
+	}
+
+	b.status = ""
+	_, h := b.screen.Size()
+	page := h - 2
+	switch ev.Key() {
+	case tcell.KeyUp:
+		b.moveTo(b.row - 1)
+	case tcell.KeyDown:
+		b.moveTo(b.row + 1)
+	case tcell.KeyPgUp:
+		b.moveTo(b.row - page)
+	case tcell.KeyPgDn:
+		b.moveTo(b.row + page)
+	case tcell.KeyHome:
+		b.moveTo(0)
+	case tcell.KeyEnd:
+		b.moveTo(len(b.snacks) - 1)
+	case tcell.KeyEnter:
+		b.startEdit()
+	case tcell.KeyDelete:
+		b.startDelete()
+	case tcell.KeyEscape, tcell.KeyCtrlC:
+		return true
+	case tcell.KeyRune:
+		switch ev.Rune() {
+		case 'k':
+			b.moveTo(b.row - 1)
+		case 'j':
+			b.moveTo(b.row + 1)
+		case 'g':
+			b.moveTo(0)
+		case 'G':
+			b.moveTo(len(b.snacks) - 1)
+		case 'e':
+			b.startEdit()
+		case 'd':
+			b.startDelete()
+		case 'r':
+			if err := b.load(ctx); err != nil {
+				b.status = fmt.Sprintf("Error: %v", err)
+			}
+		case 'q':
+			return true
+		}
+	}
+	return false
+}
+
+func (b *browser) startEdit() {
+	if len(b.snacks) == 0 {
+		return
+	}
+	b.mode = browseEdit
+	b.input = []rune(b.snacks[b.row].GetName())
+}
+
+func (b *browser) startDelete() {
+	if len(b.snacks) == 0 {
+		return
+	}
+	b.mode = browseConfirmDelete
+}
+
+// handleEditKey edits the name being entered, saving it on Enter.
+func (b *browser) handleEditKey(ctx context.Context, ev *tcell.EventKey) {
+	switch ev.Key() {
+	case tcell.KeyRune:
+		b.input = append(b.input, ev.Rune())
+	case tcell.KeyBackspace, tcell.KeyBackspace2:
+		if len(b.input) > 0 {
+			b.input = b.input[:len(b.input)-1]
+		}
+	case tcell.KeyCtrlU:
+		b.input = nil
+	case tcell.KeyEscape, tcell.KeyCtrlC:
+		b.mode = browseNormal
+		b.status = "Rename cancelled."
+	case tcell.KeyEnter:
+		b.mode = browseNormal
+		// Updates write every field, so keep the rest of the snack as is.
+		snack := proto.Clone(b.snacks[b.row]).(*sipb.Snack)
+		snack.Name = string(b.input)
+		if _, err := b.client.UpdateSnack(ctx, &sipb.UpdateSnackRequest{Snack: snack}); err != nil {
+			b.status = fmt.Sprintf("Error: could not update snack: %v", err)
+			return
+		}
+		b.snacks[b.row] = snack
+		b.status = fmt.Sprintf("Renamed %s.", snack.GetBarcode())
+	}
+}
+
+func (b *browser) deleteSelected(ctx context.Context) {
+	barcode := b.snacks[b.row].GetBarcode()
+	if _, err := b.client.DeleteSnack(ctx, &sipb.DeleteSnackRequest{Barcode: barcode}); err != nil {
+		b.status = fmt.Sprintf("Error: could not delete snack: %v", err)
+		return
+	}
+	b.snacks = append(b.snacks[:b.row:b.row], b.snacks[b.row+1:]...)
+	b.moveTo(b.row)
+	b.status = fmt.Sprintf("Deleted %s.", barcode)
+}
+
+// drawText draws s at x, y, returning the column after it.
+func (b *browser) drawText(x, y int, s string, style tcell.Style) int {
+	for _, r := range s {
+		b.screen.SetContent(x, y, r, nil, style)
+		x++
+	}
+	return x
+}
+
+func (b *browser) draw() {
+	b.screen.Clear()
+	b.screen.HideCursor()
+	w, h := b.screen.Size()
+
+	barcodeWidth := len("BARCODE")
+	for _, s := range b.snacks {
+		if n := len([]rune(s.GetBarcode())); n > barcodeWidth {
+			barcodeWidth = n
+		}
+	}
+	// drawRow draws a full width row, padding the barcode column.
+	drawRow := func(y int, barcode, name string, style tcell.Style) {
+		x := b.drawText(0, y, barcode, style)
+		for ; x < barcodeWidth+2; x++ {
+			b.screen.SetContent(x, y, ' ', nil, style)
+		}
+		x = b.drawText(x, y, name, style)
+		for ; x < w; x++ {
+			b.screen.SetContent(x, y, ' ', nil, style)
+		}
+	}
+	drawRow(0, "BARCODE", "NAME", tcell.StyleDefault.Bold(true).Underline(true))
+
+	// Scroll to keep the selection on screen.
+	visible := h - 2
+	if b.row < b.top {
+		b.top = b.row
+	}
+	if visible > 0 && b.row >= b.top+visible {
+		b.top = b.row - visible + 1
+	}
+	for i := b.top; i < len(b.snacks) && i-b.top < visible; i++ {
+		style := tcell.StyleDefault
+		if i == b.row {
+			style = style.Reverse(true)
+		}
+		drawRow(1+i-b.top, b.snacks[i].GetBarcode(), b.snacks[i].GetName(), style)
+	}
+	if len(b.snacks) == 0 {
+		b.drawText(0, 1, "No snacks registered.", tcell.StyleDefault)
+	}
+
+	switch b.mode {
+	case browseEdit:
+		x := b.drawText(0, h-1, fmt.Sprintf("New name for %s: %s", b.snacks[b.row].GetBarcode(), string(b.input)), tcell.StyleDefault)
+		b.screen.ShowCursor(x, h-1)
+	case browseConfirmDelete:
+		s := b.snacks[b.row]
+		b.drawText(0, h-1, fmt.Sprintf("Delete %s (%s)? y/n", s.GetBarcode(), s.GetName()), tcell.StyleDefault)
+	default:
+		status := b.status
+		if status == "" {
+			status = browseHelp
+		}
+		b.drawText(0, h-1, status, tcell.StyleDefault)
+	}
+	b.screen.Show()
+}
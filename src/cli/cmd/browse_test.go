@@ -0,0 +1,144 @@
+/*
+Copyright 2020 Robert Barron
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/gdamore/tcell"
+	"github.com/google/go-cmp/cmp"
+)
+
+// newSimulationScreenT returns an initialized 80x25 simulated terminal,
+// finalized when t completes.
+func newSimulationScreenT(t *testing.T) tcell.SimulationScreen {
+	t.Helper()
+	screen := tcell.NewSimulationScreen("UTF-8")
+	if err := screen.Init(); err != nil {
+		t.Fatalf("screen.Init() = got err %v, want err nil", err)
+	}
+	screen.SetSize(80, 25)
+	t.Cleanup(screen.Fini)
+	return screen
+}
+
+// browseKeysT runs a browser over c, typing keys then quitting.
+func browseKeysT(t *testing.T, c *stubClient, keys ...*tcell.EventKey) *browser {
+	t.Helper()
+	screen := newSimulationScreenT(t)
+	go func() {
+		// The event queue is small, so wait for room rather than drop keys.
+		for _, k := range keys {
+			screen.PostEventWait(k)
+		}
+		screen.PostEventWait(tcell.NewEventKey(tcell.KeyRune, 'q', tcell.ModNone))
+	}()
+	b := &browser{screen: screen, client: c}
+	if err := b.run(context.Background()); err != nil {
+		t.Fatalf("b.run(ctx) = got err %v, want err nil", err)
+	}
+	return b
+}
+
+// keys returns the key events typing s.
+func keys(s string) []*tcell.EventKey {
+	var evs []*tcell.EventKey
+	for _, r := range s {
+		evs = append(evs, tcell.NewEventKey(tcell.KeyRune, r, tcell.ModNone))
+	}
+	return evs
+}
+
+// screenLine returns the text on row y of screen, without trailing spaces.
+func screenLine(screen tcell.SimulationScreen, y int) string {
+	cells, w, _ := screen.GetContents()
+	var sb strings.Builder
+	for _, c := range cells[y*w : (y+1)*w] {
+		if len(c.Runes) == 0 {
+			sb.WriteRune(' ')
+			continue
+		}
+		sb.WriteRune(c.Runes[0])
+	}
+	return strings.TrimRight(sb.String(), " ")
+}
+
+func TestBrowse_Rename(t *testing.T) {
+	c := newStubClient()
+	var evs []*tcell.EventKey
+	evs = append(evs,
+		tcell.NewEventKey(tcell.KeyDown, 0, tcell.ModNone),
+		tcell.NewEventKey(tcell.KeyEnter, 0, tcell.ModNone),
+		tcell.NewEventKey(tcell.KeyCtrlU, 0, tcell.ModNone))
+	evs = append(evs, keys("crisps")...)
+	evs = append(evs, tcell.NewEventKey(tcell.KeyEnter, 0, tcell.ModNone))
+	b := browseKeysT(t, c, evs...)
+
+	if diff := cmp.Diff([]string{"UpdateSnack 124 crisps"}, c.calls); diff != "" {
+		t.Errorf("browse = got diff in calls (-want +got):\n%s", diff)
+	}
+	if got := b.snacks[1].GetName(); got != "crisps" {
+		t.Errorf("browse = got snack name %q, want %q", got, "crisps")
+	}
+}
+
+func TestBrowse_RenameCancelled(t *testing.T) {
+	c := newStubClient()
+	evs := []*tcell.EventKey{tcell.NewEventKey(tcell.KeyEnter, 0, tcell.ModNone)}
+	evs = append(evs, keys("xyz")...)
+	evs = append(evs, tcell.NewEventKey(tcell.KeyEscape, 0, tcell.ModNone))
+	browseKeysT(t, c, evs...)
+
+	if len(c.calls) != 0 {
+		t.Errorf("browse = got calls %v, want none", c.calls)
+	}
+}
+
+func TestBrowse_Delete(t *testing.T) {
+	c := newStubClient()
+	// Decline the first delete, then confirm one on the last snack.
+	b := browseKeysT(t, c, keys("dnGdy")...)
+
+	if diff := cmp.Diff([]string{"DeleteSnack 200"}, c.calls); diff != "" {
+		t.Errorf("browse = got diff in calls (-want +got):\n%s", diff)
+	}
+	if len(b.snacks) != 2 || b.row != 1 {
+		t.Errorf("browse = got %d snacks with row %d selected, want 2 snacks with row 1", len(b.snacks), b.row)
+	}
+}
+
+func TestBrowse_Draw(t *testing.T) {
+	screen := newSimulationScreenT(t)
+	b := &browser{screen: screen, client: newStubClient()}
+	if err := b.load(context.Background()); err != nil {
+		t.Fatalf("b.load(ctx) = got err %v, want err nil", err)
+	}
+	b.moveTo(2)
+	b.draw()
+
+	for y, want := range map[int]string{
+		0:  "BARCODE  NAME",
+		1:  "123      chips",
+		3:  "200      soda",
+		24: browseHelp,
+	} {
+		if got := screenLine(screen, y); got != want {
+			t.Errorf("b.draw() = got line %d %q, want %q", y, got, want)
+		}
+	}
+}
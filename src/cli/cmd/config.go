@@ -0,0 +1,144 @@
+/*
+Copyright 2020 Robert Barron
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package cmd provides the various subcommands of the SnackInventory CLI.
+// This file implements the `config` subcommands, editing the config file.
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/rmbarron/SnackInventory/src/cli/config"
+	"github.com/spf13/cobra"
+)
+
+var (
+	configCmd = &cobra.Command{
+		Use:   "config subcommand",
+		Short: "View & edit the CLI config file.",
+		Long: `View & edit profiles in the CLI config file. Subcommands act on
+    the profile selected by --profile, $` + config.ProfileEnv + `, or the current
+    profile, in that order.`,
+		// Skip loading the profile, so a broken profile can still be fixed.
+		PersistentPreRunE: func(_ *cobra.Command, _ []string) error { return nil },
+	}
+
+	configGetCmd = &cobra.Command{
+		Use:   "get [key]",
+		Short: "Print the effective value of key, or of every key.",
+		Long: `Print the effective value of key, or of every key, including
+    environment overrides. Secrets are redacted.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: configGet,
+	}
+
+	configSetCmd = &cobra.Command{
+		Use:   "set key value",
+		Short: "Set key of the profile to value, creating the profile if needed.",
+		Long: `Set key of the profile to value, creating the profile if needed.
+    Valid keys are: ` + fmt.Sprint(config.Keys()),
+		Args: cobra.ExactArgs(2),
+		RunE: configSet,
+	}
+
+	configUseProfileCmd = &cobra.Command{
+		Use:   "use-profile name",
+		Short: "Make name the current profile.",
+		Long:  "Make name the current profile, used when --profile is not given.",
+		Args:  cobra.ExactArgs(1),
+		RunE:  configUseProfile,
+	}
+)
+
+func init() {
+	configCmd.AddCommand(configGetCmd)
+	configCmd.AddCommand(configSetCmd)
+	configCmd.AddCommand(configUseProfileCmd)
+}
+
+// loadConfigFile returns the path & contents of the config file.
+func loadConfigFile() (string, *config.File, error) {
+	path, err := configFile()
+	if err != nil {
+		return "", nil, err
+	}
+	f, err := config.Load(path)
+	return path, f, err
+}
+
+func configGet(_ *cobra.Command, args []string) error {
+	_, f, err := loadConfigFile()
+	if err != nil {
+		return err
+	}
+	name := f.ProfileName(profileName, os.LookupEnv)
+	p, err := f.Resolve(name)
+	if err != nil {
+		return err
+	}
+	if err := p.ApplyEnv(os.LookupEnv); err != nil {
+		return err
+	}
+	p = p.Redacted()
+
+	if len(args) == 1 {
+		v, err := p.Get(args[0])
+		if err != nil {
+			return err
+		}
+		fmt.Println(v)
+		return nil
+	}
+	fmt.Printf("# profile: %s\n", name)
+	for _, key := range config.Keys() {
+		v, _ := p.Get(key)
+		fmt.Printf("%s: %s\n", key, v)
+	}
+	return nil
+}
+
+func configSet(_ *cobra.Command, args []string) error {
+	path, f, err := loadConfigFile()
+	if err != nil {
+		return err
+	}
+	name := f.ProfileName(profileName, os.LookupEnv)
+	if err := f.Set(name, args[0], args[1]); err != nil {
+		return err
+	}
+	if err := f.Save(path); err != nil {
+		return err
+	}
+	fmt.Printf("Set %s of profile %q\n", args[0], name)
+	return nil
+}
+
+func configUseProfile(_ *cobra.Command, args []string) error {
+	path, f, err := loadConfigFile()
+	if err != nil {
+		return err
+	}
+	if _, ok := f.Profiles[args[0]]; !ok {
+		return fmt.Errorf("unknown profile %q; create it with: config set --profile=%s address host:port", args[0], args[0])
+	}
+	f.CurrentProfile = args[0]
+	if err := f.Save(path); err != nil {
+		return err
+	}
+	fmt.Printf("Switched to profile %q\n", args[0])
+	return nil
+}
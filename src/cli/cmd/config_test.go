@@ -0,0 +1,157 @@
+/*
+Copyright 2020 Robert Barron
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/rmbarron/SnackInventory/src/cli/config"
+	"github.com/spf13/cobra"
+)
+
+// setConfigT points --config at a new file with contents, if any, & selects
+// the profile called name. Globals set up by loadProfile are restored when t
+// completes.
+func setConfigT(t *testing.T, contents, name string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if contents != "" {
+		if err := ioutil.WriteFile(path, []byte(contents), 0600); err != nil {
+			t.Fatalf("ioutil.WriteFile(%q) = got err %v, want err nil", path, err)
+		}
+	}
+	tmpPath, tmpName := configPath, profileName
+	tmpAddr, tmpTimeout, tmpRPCTimeout, tmpOutput := address, connTimeout, rpcTimeout, outputFormat
+	tmpProfile, tmpTransport := profile, transportOptions
+	configPath, profileName = path, name
+	t.Cleanup(func() {
+		configPath, profileName = tmpPath, tmpName
+		address, connTimeout, rpcTimeout, outputFormat = tmpAddr, tmpTimeout, tmpRPCTimeout, tmpOutput
+		profile, transportOptions = tmpProfile, tmpTransport
+	})
+	return path
+}
+
+func TestConfigSetAndUseProfile(t *testing.T) {
+	path := setConfigT(t, "", "work")
+
+	if err := configSet(nil, []string{"address", "office:10000"}); err != nil {
+		t.Fatalf("configSet(nil, ...) = got err %v, want err nil", err)
+	}
+	if err := configSet(nil, []string{"adress", "office:10000"}); err == nil {
+		t.Fatal("configSet(nil, ...) with unknown key = got err nil, want err")
+	}
+	if err := configUseProfile(nil, []string{"home"}); err == nil {
+		t.Fatal("configUseProfile(nil, [home]) for missing profile = got err nil, want err")
+	}
+	if err := configUseProfile(nil, []string{"work"}); err != nil {
+		t.Fatalf("configUseProfile(nil, [work]) = got err %v, want err nil", err)
+	}
+	if err := configGet(nil, []string{"address"}); err != nil {
+		t.Fatalf("configGet(nil, [address]) = got err %v, want err nil", err)
+	}
+
+	f, err := config.Load(path)
+	if err != nil {
+		t.Fatalf("config.Load(%q) = got err %v, want err nil", path, err)
+	}
+	if f.CurrentProfile != "work" || f.Profiles["work"].Address != "office:10000" {
+		t.Fatalf("config.Load(%q) = got %+v, want current profile work at office:10000", path, f)
+	}
+}
+
+// newFlagsCmdT returns a command with the profile flags of rootCmd, setting
+// those in flags as if given on the command line.
+func newFlagsCmdT(t *testing.T, flags map[string]string) *cobra.Command {
+	t.Helper()
+	d := config.Default()
+	cmd := &cobra.Command{}
+	cmd.Flags().String("address", d.Address, "")
+	cmd.Flags().Duration("dial_timeout", d.DialTimeout, "")
+	cmd.Flags().String("output", d.Output, "")
+	for name, value := range flags {
+		if err := cmd.Flags().Set(name, value); err != nil {
+			t.Fatalf("cmd.Flags().Set(%q, %q) = got err %v, want err nil", name, value, err)
+		}
+	}
+	return cmd
+}
+
+func TestLoadProfile(t *testing.T) {
+	setConfigT(t, `
+current_profile: home
+profiles:
+  home:
+    address: pantry:10000
+    dial_timeout: 5s
+    rpc_timeout: 2s
+    output: yaml
+`, "")
+
+	cmd := newFlagsCmdT(t, map[string]string{"output": "json"})
+	if err := loadProfile(cmd); err != nil {
+		t.Fatalf("loadProfile(cmd) = got err %v, want err nil", err)
+	}
+	if address != "pantry:10000" || connTimeout != 5*time.Second || rpcTimeout != 2*time.Second || outputFormat != "json" {
+		t.Fatalf("loadProfile(cmd) = got address %q, dial_timeout %v, rpc_timeout %v, output %q; want pantry:10000, 5s, 2s, json",
+			address, connTimeout, rpcTimeout, outputFormat)
+	}
+}
+
+func TestLoadProfile_Errors(t *testing.T) {
+	tests := []struct {
+		desc, contents, name string
+	}{
+		{"UnknownProfile", "", "work"},
+		{"BadFile", "profiles: [", ""},
+		{"Invalid", "profiles:\n  default:\n    token: secret\n", ""},
+		{"MissingCAFile", "profiles:\n  default:\n    tls: true\n    tls_ca_file: /does/not/exist\n", ""},
+	}
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			setConfigT(t, tc.contents, tc.name)
+			if err := loadProfile(newFlagsCmdT(t, nil)); err == nil {
+				t.Fatal("loadProfile(cmd) = got err nil, want err")
+			}
+		})
+	}
+}
+
+func TestTransportDialOptions(t *testing.T) {
+	tests := []struct {
+		desc     string
+		p        *config.Profile
+		wantOpts int
+	}{
+		{"Insecure", &config.Profile{}, 1},
+		{"TLS", &config.Profile{TLS: true, TLSServerName: "pantry"}, 1},
+		{"Token", &config.Profile{TLS: true, Token: "secret"}, 2},
+	}
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			opts, err := transportDialOptions(tc.p)
+			if err != nil {
+				t.Fatalf("transportDialOptions(%+v) = got err %v, want err nil", tc.p, err)
+			}
+			if len(opts) != tc.wantOpts {
+				t.Errorf("transportDialOptions(%+v) = got %d options, want %d", tc.p, len(opts), tc.wantOpts)
+			}
+		})
+	}
+}
@@ -19,23 +19,26 @@ limitations under the License.
 package cmd
 
 import (
-	"context"
 	"fmt"
+	"strings"
 
 	"github.com/spf13/cobra"
-	"google.golang.org/grpc"
 
 	sipb "github.com/rmbarron/SnackInventory/src/proto/snackinventory"
 )
 
 var (
-	createLocationName string
+	createLocationName        string
+	createLocationParent      string
+	createLocationDescription string
+	createLocationType        string
 
 	createLocationCmd = &cobra.Command{
 		Use:   "createlocation [--flags]",
 		Short: "Create a new location in SnackInventory",
 		Long: `Creates a new location in SnackInventory.
-    --name is required, as that is the unique identifier for each location.`,
+    --name is required, as that is the unique identifier for each location.
+    --parent nests the location inside another, ex: a shelf in the pantry.`,
 		RunE: createLocation,
 	}
 )
@@ -43,24 +46,39 @@ var (
 func init() {
 	createLocationCmd.Flags().StringVar(&createLocationName, "name", "",
 		"Name of location to add to SnackInventory")
+	createLocationCmd.Flags().StringVar(&createLocationParent, "parent", "",
+		"Name or path (ex: Garage/Freezer) of the location to nest this one inside.")
+	createLocationCmd.Flags().StringVar(&createLocationDescription, "description", "",
+		"Description of the location, ex: left of the sink.")
+	createLocationCmd.Flags().StringVar(&createLocationType, "type", "",
+		"Type of the location, one of: "+strings.Join(locationTypes(), ", ")+".")
 	createLocationCmd.MarkFlagRequired("name")
 }
 
 func createLocation(_ *cobra.Command, _ []string) error {
-	conn, err := grpc.Dial(address, grpc.WithInsecure(), grpc.WithBlock(), grpc.WithTimeout(connTimeout))
+	locationType, err := parseLocationType(createLocationType)
 	if err != nil {
-		return fmt.Errorf("could not dial %s: %w", address, err)
+		return err
 	}
-	defer conn.Close()
 
-	client := sipb.NewSnackInventoryClient(conn)
+	ctx, cancel := commandContext()
+	defer cancel()
+	client, err := newClient(ctx)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
 	req := &sipb.CreateLocationRequest{
 		Location: &sipb.Location{
-			Name: createLocationName,
+			Name:        createLocationName,
+			Parent:      createLocationParent,
+			Description: createLocationDescription,
+			Type:        locationType,
 		},
 	}
 
-	if _, err = client.CreateLocation(context.Background(), req); err != nil {
+	if _, err = client.CreateLocation(ctx, req); err != nil {
 		return fmt.Errorf("could not create snack: %w", err)
 	}
 	fmt.Println("Successfully create location!")
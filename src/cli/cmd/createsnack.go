@@ -19,24 +19,27 @@ limitations under the License.
 package cmd
 
 import (
-	"context"
 	"fmt"
 
 	"github.com/spf13/cobra"
-	"google.golang.org/grpc"
 
 	sipb "github.com/rmbarron/SnackInventory/src/proto/snackinventory"
 )
 
 var (
-	createSnackBarcode string
-	createSnackName    string
+	createSnackBarcode     string
+	createSnackName        string
+	createSnackBrand       string
+	createSnackCategory    string
+	createSnackPackageSize string
 
 	createSnackCmd = &cobra.Command{
 		Use:   "createsnack [--flags]",
 		Short: "Create a new snack in SnackInventory.",
 		Long: `Creates a new snack in SnackInventory.
-    --barcode is required, as that is the unique identifier for snacks.`,
+    --barcode is required, as that is the unique identifier for snacks. If
+    only --barcode is given, the server fills in the other fields from its
+    product lookup, if it has one.`,
 		RunE: createSnack,
 	}
 )
@@ -44,27 +47,39 @@ var (
 func init() {
 	createSnackCmd.Flags().StringVar(&createSnackBarcode, "barcode", "", "Barcode of the snack to create.")
 	createSnackCmd.Flags().StringVar(&createSnackName, "name", "", "Name of the snack to create.")
+	createSnackCmd.Flags().StringVar(&createSnackBrand, "brand", "", "Brand of the snack to create.")
+	createSnackCmd.Flags().StringVar(&createSnackCategory, "category", "", "Category of the snack to create.")
+	createSnackCmd.Flags().StringVar(&createSnackPackageSize, "package_size", "", "Package size of the snack to create, ex: \"330 ml\".")
 	createSnackCmd.MarkFlagRequired("barcode")
 }
 
 func createSnack(_ *cobra.Command, _ []string) error {
-	conn, err := grpc.Dial(address, grpc.WithInsecure(), grpc.WithBlock(), grpc.WithTimeout(connTimeout))
+	ctx, cancel := commandContext()
+	defer cancel()
+	client, err := newClient(ctx)
 	if err != nil {
-		return fmt.Errorf("could not dial %s: %w", address, err)
+		return err
 	}
-	defer conn.Close()
+	defer client.Close()
 
-	client := sipb.NewSnackInventoryClient(conn)
 	req := &sipb.CreateSnackRequest{
 		Snack: &sipb.Snack{
-			Barcode: createSnackBarcode,
-			Name:    createSnackName,
+			Barcode:     createSnackBarcode,
+			Name:        createSnackName,
+			Brand:       createSnackBrand,
+			Category:    createSnackCategory,
+			PackageSize: createSnackPackageSize,
 		},
 	}
 
-	if _, err = client.CreateSnack(context.Background(), req); err != nil {
+	res, err := client.CreateSnack(ctx, req)
+	if err != nil {
 		return fmt.Errorf("could not create snack: %w", err)
 	}
+	if name := res.GetSnack().GetName(); name != "" && createSnackName == "" {
+		fmt.Printf("Successfully created snack %q from product lookup!\n", name)
+		return nil
+	}
 	fmt.Println("Successfully created snack!")
 	return nil
 }
@@ -19,47 +19,134 @@ limitations under the License.
 package cmd
 
 import (
+	"bufio"
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"os"
+	"strings"
 
 	"github.com/spf13/cobra"
-	"google.golang.org/grpc"
 
 	sipb "github.com/rmbarron/SnackInventory/src/proto/snackinventory"
 )
 
 var (
-	deleteLocationName string
+	deleteLocationName    string
+	deleteLocationMoveTo  string
+	deleteLocationDiscard bool
+	deleteLocationYes     bool
 
 	deleteLocationCmd = &cobra.Command{
 		Use:   "deletelocation [--flags]",
 		Short: "Delete a location from SnackInventory",
 		Long: `Deletes a location from SnackInventory.
-    --name is required, as that is the unique identifier for locations.`,
+    --name is required, as that is the unique identifier for locations.
+    A location holding stock is only deleted if --move_to or --discard says
+    what to do with the stock. Asks for confirmation unless --yes is given.`,
 		RunE: deleteLocation,
 	}
 )
 
 func init() {
-	deleteLocationCmd.Flags().StringVar(&deleteLocationName, "name", "", "Name of location to remove from SnackInventory.")
+	deleteLocationCmd.Flags().StringVar(&deleteLocationName, "name", "", "Name or path of location to remove from SnackInventory.")
+	deleteLocationCmd.Flags().StringVar(
+		&deleteLocationMoveTo, "move_to", "", "Name or path of a location to move stock held at the deleted location to.")
+	deleteLocationCmd.Flags().BoolVar(
+		&deleteLocationDiscard, "discard", false, "Discard stock held at the deleted location.")
+	deleteLocationCmd.Flags().BoolVar(&deleteLocationYes, "yes", false, "Delete without asking for confirmation.")
 	deleteLocationCmd.MarkFlagRequired("name")
 }
 
 func deleteLocation(_ *cobra.Command, _ []string) error {
-	conn, err := grpc.Dial(address, grpc.WithInsecure(), grpc.WithBlock(), grpc.WithTimeout(connTimeout))
+	req := &sipb.DeleteLocationRequest{
+		Name:     deleteLocationName,
+		Contents: sipb.ContentsPolicy_REFUSE,
+	}
+	switch {
+	case deleteLocationMoveTo != "" && deleteLocationDiscard:
+		return errors.New("only one of --move_to and --discard may be given")
+	case deleteLocationMoveTo != "":
+		req.Contents = sipb.ContentsPolicy_MOVE
+		req.Target = deleteLocationMoveTo
+	case deleteLocationDiscard:
+		req.Contents = sipb.ContentsPolicy_DISCARD
+	}
+
+	ctx, cancel := commandContext()
+	defer cancel()
+	client, err := newClient(ctx)
 	if err != nil {
-		return fmt.Errorf("could not dial %s: %w", address, err)
+		return err
 	}
-	defer conn.Close()
+	defer client.Close()
 
-	client := sipb.NewSnackInventoryClient(conn)
-	req := &sipb.DeleteLocationRequest{
-		Name: deleteLocationName,
+	if !deleteLocationYes {
+		ok, err := confirmDeleteLocation(ctx, client, req, os.Stdin, os.Stdout)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			fmt.Println("Location not deleted.")
+			return nil
+		}
 	}
 
-	if _, err = client.DeleteLocation(context.Background(), req); err != nil {
+	res, err := client.DeleteLocation(ctx, req)
+	if err != nil {
 		return fmt.Errorf("could not delete location: %w", err)
 	}
+	if count := stockCount(res.GetStock()); count > 0 {
+		verb := "Discarded"
+		if req.GetContents() == sipb.ContentsPolicy_MOVE {
+			verb = "Moved"
+		}
+		fmt.Printf("%s %d snacks.\n", verb, count)
+	}
 	fmt.Println("Successfully deleted location!")
 	return nil
 }
+
+// confirmDeleteLocation describes what req will do with the stock at the
+// location to out, & asks for confirmation from in.
+func confirmDeleteLocation(ctx context.Context, client sipb.SnackInventoryClient, req *sipb.DeleteLocationRequest, in io.Reader, out io.Writer) (bool, error) {
+	res, err := client.ListStock(ctx, &sipb.ListStockRequest{Location: req.GetName()})
+	if err != nil {
+		return false, fmt.Errorf("could not list stock at location: %w", err)
+	}
+	if count := stockCount(res.GetStock()); count > 0 {
+		held := fmt.Sprintf("%s holds %d snacks (%d barcodes)", req.GetName(), count, len(res.GetStock()))
+		switch req.GetContents() {
+		case sipb.ContentsPolicy_MOVE:
+			fmt.Fprintf(out, "%s, which will be moved to %s.\n", held, req.GetTarget())
+		case sipb.ContentsPolicy_DISCARD:
+			fmt.Fprintf(out, "%s, which will be discarded.\n", held)
+		default:
+			// The server would refuse; say so before asking.
+			return false, fmt.Errorf("could not delete location: %s; pass --move_to or --discard", held)
+		}
+	}
+	return confirm(in, out, fmt.Sprintf("Delete location %s?", req.GetName()))
+}
+
+// confirm asks prompt on out, & returns whether the answer read from in is
+// yes. No answer is taken as no.
+func confirm(in io.Reader, out io.Writer, prompt string) (bool, error) {
+	fmt.Fprintf(out, "%s [y/N] ", prompt)
+	s := bufio.NewScanner(in)
+	if !s.Scan() {
+		return false, s.Err()
+	}
+	answer := strings.ToLower(strings.TrimSpace(s.Text()))
+	return answer == "y" || answer == "yes", nil
+}
+
+// stockCount sums the counts of stock.
+func stockCount(stock []*sipb.Stock) int32 {
+	var count int32
+	for _, st := range stock {
+		count += st.GetCount()
+	}
+	return count
+}
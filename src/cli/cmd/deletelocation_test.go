@@ -16,6 +16,9 @@ limitations under the License.
 package cmd
 
 import (
+	"bytes"
+	"context"
+	"strings"
 	"testing"
 
 	"github.com/rmbarron/SnackInventory/src/backend/fakes/fakeserver"
@@ -37,6 +40,8 @@ func TestDeleteLocation(t *testing.T) {
 	tmpAddr := address
 	address = addr
 	defer func() { address = tmpAddr }()
+	deleteLocationYes = true
+	defer func() { deleteLocationYes = false }()
 
 	if err := deleteLocation(nil, nil); err != nil {
 		t.Fatalf("deleteSnack(nil, nil) = got err %v, want err nil", err)
@@ -55,8 +60,88 @@ func TestDeleteLocation_ServerError(t *testing.T) {
 	tmpAddr := address
 	address = addr
 	defer func() { address = tmpAddr }()
+	deleteLocationYes = true
+	defer func() { deleteLocationYes = false }()
 
 	if err := deleteLocation(nil, nil); err == nil {
 		t.Fatal("deleteLocation(nil, nil) = got err nil, want err")
 	}
 }
+
+func TestDeleteLocation_MoveAndDiscard(t *testing.T) {
+	deleteLocationMoveTo, deleteLocationDiscard = "fridge", true
+	defer func() { deleteLocationMoveTo, deleteLocationDiscard = "", false }()
+
+	if err := deleteLocation(nil, nil); err == nil {
+		t.Fatal("deleteLocation(nil, nil) with --move_to & --discard = got err nil, want err")
+	}
+}
+
+func TestConfirmDeleteLocation(t *testing.T) {
+	tests := []struct {
+		desc     string
+		req      *sipb.DeleteLocationRequest
+		stock    map[string]int32
+		input    string
+		want     bool
+		wantOut  string
+		wantFail bool
+	}{
+		{
+			desc:    "Empty",
+			req:     &sipb.DeleteLocationRequest{Name: "pantry"},
+			input:   "y\n",
+			want:    true,
+			wantOut: "Delete location pantry? [y/N] ",
+		},
+		{
+			desc:    "Declined",
+			req:     &sipb.DeleteLocationRequest{Name: "pantry"},
+			input:   "\n",
+			wantOut: "Delete location pantry? [y/N] ",
+		},
+		{
+			desc:    "NoInput",
+			req:     &sipb.DeleteLocationRequest{Name: "pantry"},
+			wantOut: "Delete location pantry? [y/N] ",
+		},
+		{
+			desc:    "Move",
+			req:     &sipb.DeleteLocationRequest{Name: "pantry", Contents: sipb.ContentsPolicy_MOVE, Target: "fridge"},
+			stock:   map[string]int32{"123@pantry": 2, "456@pantry": 1, "123@fridge": 4},
+			input:   "Yes\n",
+			want:    true,
+			wantOut: "pantry holds 3 snacks (2 barcodes), which will be moved to fridge.\nDelete location pantry? [y/N] ",
+		},
+		{
+			desc:    "Discard",
+			req:     &sipb.DeleteLocationRequest{Name: "pantry", Contents: sipb.ContentsPolicy_DISCARD},
+			stock:   map[string]int32{"123@pantry": 2},
+			input:   "n\n",
+			wantOut: "pantry holds 2 snacks (1 barcodes), which will be discarded.\nDelete location pantry? [y/N] ",
+		},
+		{
+			desc:     "Refuse",
+			req:      &sipb.DeleteLocationRequest{Name: "pantry", Contents: sipb.ContentsPolicy_REFUSE},
+			stock:    map[string]int32{"123@pantry": 2},
+			input:    "y\n",
+			wantFail: true,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			c := &stubClient{stock: tc.stock}
+			var out bytes.Buffer
+			got, err := confirmDeleteLocation(context.Background(), c, tc.req, strings.NewReader(tc.input), &out)
+			if (err != nil) != tc.wantFail {
+				t.Fatalf("confirmDeleteLocation(ctx, c, %v, ...) = got err %v, want err %t", tc.req, err, tc.wantFail)
+			}
+			if got != tc.want {
+				t.Errorf("confirmDeleteLocation(ctx, c, %v, ...) = got %t, want %t", tc.req, got, tc.want)
+			}
+			if out.String() != tc.wantOut {
+				t.Errorf("confirmDeleteLocation(ctx, c, %v, ...) printed %q, want %q", tc.req, out.String(), tc.wantOut)
+			}
+		})
+	}
+}
@@ -19,12 +19,10 @@ limitations under the License.
 package cmd
 
 import (
-	"context"
 	"fmt"
 
 	sipb "github.com/rmbarron/SnackInventory/src/proto/snackinventory"
 	"github.com/spf13/cobra"
-	"google.golang.org/grpc"
 )
 
 var (
@@ -46,18 +44,19 @@ func init() {
 }
 
 func deleteSnack(_ *cobra.Command, _ []string) error {
-	conn, err := grpc.Dial(address, grpc.WithInsecure(), grpc.WithBlock(), grpc.WithTimeout(connTimeout))
+	ctx, cancel := commandContext()
+	defer cancel()
+	client, err := newClient(ctx)
 	if err != nil {
-		return fmt.Errorf("could not dial %s: %w", address, err)
+		return err
 	}
-	defer conn.Close()
+	defer client.Close()
 
-	client := sipb.NewSnackInventoryClient(conn)
 	req := &sipb.DeleteSnackRequest{
 		Barcode: deleteSnackBarcode,
 	}
 
-	if _, err = client.DeleteSnack(context.Background(), req); err != nil {
+	if _, err = client.DeleteSnack(ctx, req); err != nil {
 		return fmt.Errorf("could not delete snack: %w", err)
 	}
 	fmt.Println("Successfully deleted snack!")
@@ -0,0 +1,122 @@
+/*
+Copyright 2020 Robert Barron
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package cmd provides the various subcommands of the SnackInventory CLI.
+// This file implements a call to the `GetSnackHistory` RPC, & the --as_of
+// flag of the list commands.
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	sipb "github.com/rmbarron/SnackInventory/src/proto/snackinventory"
+)
+
+var (
+	historyBarcode string
+
+	historyCmd = &cobra.Command{
+		Use:   "history --barcode=barcode",
+		Short: "List the changes made to a snack.",
+		Long: `List each change made to a snack, oldest first, with the snack as it
+    was after the change. Includes changes from before it was deleted.`,
+		Args: cobra.NoArgs,
+		RunE: snackHistory,
+	}
+)
+
+func init() {
+	historyCmd.Flags().StringVar(&historyBarcode, "barcode", "", "Barcode of the snack to list the history of.")
+	historyCmd.MarkFlagRequired("barcode")
+}
+
+// asOfUsage describes the --as_of flag of the list commands.
+const asOfUsage = "If set, list what was registered at this time, as RFC 3339 (ex: 2020-01-05T18:00:00Z)" +
+	" or a date, meaning its start in local time (ex: 2020-01-05)."
+
+// parseAsOf parses the value of an --as_of flag, returning nil if it is "".
+func parseAsOf(v string) (*timestamppb.Timestamp, error) {
+	if v == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		if t, err = time.ParseInLocation("2006-01-02", v, time.Local); err != nil {
+			return nil, fmt.Errorf("invalid --as_of %q: want RFC 3339 or YYYY-MM-DD", v)
+		}
+	}
+	return timestamppb.New(t), nil
+}
+
+func snackHistory(_ *cobra.Command, _ []string) error {
+	// The default table output is one human readable line per revision.
+	var p printer
+	if outputFormat != outputTable {
+		var err error
+		if p, err = newPrinter(os.Stdout, (&sipb.SnackRevision{}).ProtoReflect().Descriptor(), false); err != nil {
+			return err
+		}
+	}
+
+	ctx, cancel := commandContext()
+	defer cancel()
+	client, err := newClient(ctx)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	res, err := client.GetSnackHistory(ctx, &sipb.GetSnackHistoryRequest{Barcode: historyBarcode})
+	if err != nil {
+		return fmt.Errorf("could not get snack history: %w", err)
+	}
+	if p == nil {
+		for _, rev := range res.GetRevisions() {
+			fmt.Println(formatRevision(rev))
+		}
+		return nil
+	}
+	for _, rev := range res.GetRevisions() {
+		if err := p.Print(rev); err != nil {
+			return err
+		}
+	}
+	return p.Flush()
+}
+
+// formatRevision returns a single line describing a snack revision, with the
+// metadata that is set.
+func formatRevision(rev *sipb.SnackRevision) string {
+	snack := rev.GetSnack()
+	fields := []string{fmt.Sprintf("%q", snack.GetName())}
+	for _, f := range []struct{ name, value string }{
+		{"brand", snack.GetBrand()},
+		{"category", snack.GetCategory()},
+		{"package_size", snack.GetPackageSize()},
+	} {
+		if f.value != "" {
+			fields = append(fields, fmt.Sprintf("%s=%q", f.name, f.value))
+		}
+	}
+	return fmt.Sprintf("%s %-7s %s",
+		rev.GetRevisionTime().AsTime().Local().Format(time.RFC3339), rev.GetType(), strings.Join(fields, " "))
+}
@@ -0,0 +1,148 @@
+/*
+Copyright 2020 Robert Barron
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/rmbarron/SnackInventory/src/backend/fakes/fakeserver"
+	"github.com/rmbarron/SnackInventory/src/cli/testutils"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	sipb "github.com/rmbarron/SnackInventory/src/proto/snackinventory"
+)
+
+func TestParseAsOf(t *testing.T) {
+	tests := []struct {
+		v        string
+		want     time.Time
+		wantNil  bool
+		wantFail bool
+	}{
+		{v: "", wantNil: true},
+		{v: "2020-01-05T18:00:00Z", want: time.Date(2020, 1, 5, 18, 0, 0, 0, time.UTC)},
+		{v: "2020-01-05", want: time.Date(2020, 1, 5, 0, 0, 0, 0, time.Local)},
+		{v: "last sunday", wantFail: true},
+	}
+	for _, tc := range tests {
+		got, err := parseAsOf(tc.v)
+		if (err != nil) != tc.wantFail {
+			t.Fatalf("parseAsOf(%q) = got err %v, want err %t", tc.v, err, tc.wantFail)
+		}
+		if tc.wantFail {
+			continue
+		}
+		if (got == nil) != tc.wantNil {
+			t.Fatalf("parseAsOf(%q) = got %v, want nil %t", tc.v, got, tc.wantNil)
+		}
+		if got != nil && !got.AsTime().Equal(tc.want) {
+			t.Errorf("parseAsOf(%q) = got %v, want %v", tc.v, got.AsTime(), tc.want)
+		}
+	}
+}
+
+func TestListSnacks_BadAsOf(t *testing.T) {
+	// No server is started; a bad --as_of must fail before dialing.
+	tmpAddr := address
+	address = "localhost:0"
+	defer func() { address = tmpAddr }()
+	listSnacksAsOf = "yesterday"
+	defer func() { listSnacksAsOf = "" }()
+
+	if err := listSnacks(nil, nil); err == nil {
+		t.Fatal("listSnacks(nil, nil) with --as_of=yesterday = got err nil, want err")
+	}
+}
+
+func TestSnackHistory(t *testing.T) {
+	for _, format := range []string{outputTable, "json"} {
+		t.Run(format, func(t *testing.T) {
+			setOutputT(t, format, "")
+			fsi := &fakeserver.FakeSnackInventoryServer{
+				GetSnackHistoryRes: &sipb.GetSnackHistoryResponse{
+					Revisions: []*sipb.SnackRevision{
+						{Snack: &sipb.Snack{Barcode: "123", Name: "chips"}, Type: sipb.Change_CREATED},
+					},
+				},
+			}
+			addr, close := testutils.StartTestServer(t, fsi)
+			defer close()
+
+			// Inject the address of our fake server to the address flag variable.
+			tmpAddr := address
+			address = addr
+			defer func() { address = tmpAddr }()
+			historyBarcode = "123"
+			defer func() { historyBarcode = "" }()
+
+			if err := snackHistory(nil, nil); err != nil {
+				t.Fatalf("snackHistory(nil, nil) = got err %v, want err nil", err)
+			}
+		})
+	}
+}
+
+func TestSnackHistory_ServerError(t *testing.T) {
+	fsi := &fakeserver.FakeSnackInventoryServer{
+		GetSnackHistoryErr: status.Error(codes.NotFound, "no history"),
+	}
+	addr, close := testutils.StartTestServer(t, fsi)
+	defer close()
+
+	// Inject the address of our fake server to the address flag variable.
+	tmpAddr := address
+	address = addr
+	defer func() { address = tmpAddr }()
+
+	if err := snackHistory(nil, nil); err == nil {
+		t.Fatal("snackHistory(nil, nil) = got err nil, want err")
+	}
+}
+
+func TestFormatRevision(t *testing.T) {
+	revisionTime := time.Date(2020, 1, 2, 3, 4, 5, 0, time.Local)
+	prefix := revisionTime.Format(time.RFC3339) + " "
+	tests := []struct {
+		rev  *sipb.SnackRevision
+		want string
+	}{
+		{
+			rev: &sipb.SnackRevision{
+				Snack:        &sipb.Snack{Barcode: "123", Name: "chips"},
+				Type:         sipb.Change_CREATED,
+				RevisionTime: timestamppb.New(revisionTime),
+			},
+			want: prefix + `CREATED "chips"`,
+		},
+		{
+			rev: &sipb.SnackRevision{
+				Snack:        &sipb.Snack{Barcode: "123", Name: "salty chips", Brand: "Acme", PackageSize: "150 g"},
+				Type:         sipb.Change_UPDATED,
+				RevisionTime: timestamppb.New(revisionTime),
+			},
+			want: prefix + `UPDATED "salty chips" brand="Acme" package_size="150 g"`,
+		},
+	}
+	for _, tc := range tests {
+		if got := formatRevision(tc.rev); got != tc.want {
+			t.Errorf("formatRevision(%v) = got %q, want %q", tc.rev, got, tc.want)
+		}
+	}
+}
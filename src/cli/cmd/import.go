@@ -0,0 +1,475 @@
+/*
+Copyright 2020 Robert Barron
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package cmd provides the various subcommands of the SnackInventory CLI.
+// This file implements importing snacks from a file via the batch RPCs.
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/rmbarron/SnackInventory/src/limits"
+	sipb "github.com/rmbarron/SnackInventory/src/proto/snackinventory"
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// snackFields are the fields of Snack, by their proto names, that can be
+// imported.
+var snackFields = map[string]bool{"barcode": true, "name": true, "brand": true, "category": true, "package_size": true}
+
+var (
+	importFile      string
+	importFormat    string
+	importColumns   string
+	importDryRun    bool
+	importUpsert    bool
+	importBatchSize int
+
+	importCmd = &cobra.Command{
+		Use:   "import --file=snacks.csv [--flags]",
+		Short: "Register snacks from a CSV, JSON or JSON lines file.",
+		Long: `Register snacks from a CSV, JSON or JSON lines file.
+    CSV files need a header row; columns named "barcode", "name", "brand",
+    "category" & "package_size" are mapped to the matching snack fields, and
+    other columns are ignored. Use --columns to map differently named columns,
+    ex: --columns=UPC=barcode,Product=name.
+    JSON files hold an array of snacks, and JSON lines files one snack per line,
+    ex: {"barcode": "123", "name": "chips"}.
+
+    Invalid rows are reported by position and skipped. Valid rows are sent to the
+    server in batches of --batch_size. With --upsert, registered snacks keep the
+    values of fields the file doesn't supply (ex: a brand found by product
+    lookup, when the file has no brand column).`,
+		RunE: importSnacks,
+	}
+)
+
+func init() {
+	importCmd.Flags().StringVar(&importFile, "file", "", "File to import snacks from.")
+	importCmd.Flags().StringVar(
+		&importFormat, "format", "", "One of csv, json, jsonl. Guessed from the --file extension if unset.")
+	importCmd.Flags().StringVar(
+		&importColumns, "columns", "",
+		"Comma separated CSV column=field mappings, ex: UPC=barcode,Product=name. Fields are barcode, name, brand, category & package_size.")
+	importCmd.Flags().BoolVar(&importDryRun, "dry_run", false, "Validate the file without importing anything.")
+	importCmd.Flags().BoolVar(&importUpsert, "upsert", false, "Update snacks that are already registered, instead of failing them.")
+	importCmd.Flags().IntVar(&importBatchSize, "batch_size", 100, "Snacks sent to the server per batch.")
+	importCmd.MarkFlagRequired("file")
+}
+
+// importRow is a snack read from an import file.
+type importRow struct {
+	// pos identifies the row in errors, ex: "row 3" or "line 3".
+	pos   string
+	snack *sipb.Snack
+	// fields are the fields of snack supplied by the file, by proto name.
+	fields map[string]bool
+}
+
+// snackReader reads snacks from an import file. Next returns io.EOF when done.
+// Errors wrapped in a *rowError only affect that row, and reading may continue.
+type snackReader interface {
+	Next() (*importRow, error)
+}
+
+// rowError is an error in a single row of an import file.
+type rowError struct {
+	pos string
+	err error
+}
+
+func (e *rowError) Error() string {
+	return fmt.Sprintf("%s: %v", e.pos, e.err)
+}
+
+// newSnackReader creates a snackReader for r, in the given format.
+func newSnackReader(r io.Reader, format, columns string) (snackReader, error) {
+	switch format {
+	case "csv":
+		return newCSVSnackReader(r, columns)
+	case "json":
+		return newJSONSnackReader(r)
+	case "jsonl":
+		return &jsonlSnackReader{s: bufio.NewScanner(r)}, nil
+	}
+	return nil, fmt.Errorf("unsupported format %q; want one of csv, json, jsonl", format)
+}
+
+// csvSnackReader reads snacks from CSV with a header row.
+type csvSnackReader struct {
+	r *csv.Reader
+	// row is the number of the last row read, counting the header as row 1 to
+	// match spreadsheet row numbers.
+	row int
+	// fields maps column indices to Snack fields.
+	fields map[int]string
+	// supplied are the Snack fields with a column.
+	supplied map[string]bool
+}
+
+func newCSVSnackReader(r io.Reader, columns string) (*csvSnackReader, error) {
+	mapping := map[string]string{}
+	for field := range snackFields {
+		mapping[field] = field
+	}
+	if columns != "" {
+		for _, kv := range strings.Split(columns, ",") {
+			parts := strings.SplitN(kv, "=", 2)
+			if len(parts) != 2 {
+				return nil, fmt.Errorf("bad --columns mapping %q; want column=field", kv)
+			}
+			field := strings.TrimSpace(parts[1])
+			if !snackFields[field] {
+				return nil, fmt.Errorf("bad --columns mapping %q; snacks have no field %q", kv, field)
+			}
+			mapping[strings.ToLower(strings.TrimSpace(parts[0]))] = field
+		}
+	}
+
+	c := &csvSnackReader{r: csv.NewReader(r), row: 1, fields: map[int]string{}}
+	c.r.TrimLeadingSpace = true
+	header, err := c.r.Read()
+	if err != nil {
+		return nil, fmt.Errorf("could not read CSV header: %w", err)
+	}
+	found := map[string]bool{}
+	c.supplied = found
+	for i, col := range header {
+		if field, ok := mapping[strings.ToLower(strings.TrimSpace(col))]; ok {
+			if found[field] {
+				return nil, fmt.Errorf("CSV header maps more than one column to %q", field)
+			}
+			c.fields[i] = field
+			found[field] = true
+		}
+	}
+	if !found["barcode"] {
+		return nil, fmt.Errorf("CSV header %q has no barcode column; use --columns to map one", header)
+	}
+	return c, nil
+}
+
+func (c *csvSnackReader) Next() (*importRow, error) {
+	record, err := c.r.Read()
+	c.row++
+	pos := fmt.Sprintf("row %d", c.row)
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) && errors.Is(parseErr.Err, csv.ErrFieldCount) {
+		return nil, &rowError{pos, fmt.Errorf("has %d columns, want as many as the header", len(record))}
+	}
+	if err != nil {
+		return nil, err
+	}
+	snack := &sipb.Snack{}
+	for i, field := range c.fields {
+		value := strings.TrimSpace(record[i])
+		switch field {
+		case "barcode":
+			snack.Barcode = value
+		case "name":
+			snack.Name = value
+		case "brand":
+			snack.Brand = value
+		case "category":
+			snack.Category = value
+		case "package_size":
+			snack.PackageSize = value
+		}
+	}
+	return &importRow{pos: pos, snack: snack, fields: c.supplied}, nil
+}
+
+// jsonlSnackReader reads one JSON snack per line, skipping blank lines.
+type jsonlSnackReader struct {
+	s    *bufio.Scanner
+	line int
+}
+
+func (j *jsonlSnackReader) Next() (*importRow, error) {
+	for j.s.Scan() {
+		j.line++
+		text := strings.TrimSpace(j.s.Text())
+		if text == "" {
+			continue
+		}
+		pos := fmt.Sprintf("line %d", j.line)
+		return newJSONRow(pos, []byte(text))
+	}
+	if err := j.s.Err(); err != nil {
+		return nil, err
+	}
+	return nil, io.EOF
+}
+
+// jsonSnackReader reads a JSON array of snacks, one element at a time.
+type jsonSnackReader struct {
+	d     *json.Decoder
+	index int
+}
+
+func newJSONSnackReader(r io.Reader) (*jsonSnackReader, error) {
+	d := json.NewDecoder(r)
+	if tok, err := d.Token(); err != nil || tok != json.Delim('[') {
+		return nil, fmt.Errorf("JSON import files must hold an array of snacks")
+	}
+	return &jsonSnackReader{d: d}, nil
+}
+
+func (j *jsonSnackReader) Next() (*importRow, error) {
+	if !j.d.More() {
+		return nil, io.EOF
+	}
+	j.index++
+	pos := fmt.Sprintf("item %d", j.index)
+	var raw json.RawMessage
+	if err := j.d.Decode(&raw); err != nil {
+		// The array itself is malformed, so reading can't continue.
+		return nil, fmt.Errorf("%s: %w", pos, err)
+	}
+	return newJSONRow(pos, raw)
+}
+
+// newJSONRow parses the JSON snack raw, at pos. Its supplied fields are the
+// keys present, by proto or JSON name, even if empty.
+func newJSONRow(pos string, raw []byte) (*importRow, error) {
+	snack := &sipb.Snack{}
+	if err := protojson.Unmarshal(raw, snack); err != nil {
+		return nil, &rowError{pos, err}
+	}
+	var keys map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &keys); err != nil {
+		return nil, &rowError{pos, err}
+	}
+	fields := map[string]bool{}
+	fds := snack.ProtoReflect().Descriptor().Fields()
+	for i := 0; i < fds.Len(); i++ {
+		fd := fds.Get(i)
+		_, byName := keys[string(fd.Name())]
+		_, byJSONName := keys[fd.JSONName()]
+		if byName || byJSONName {
+			fields[string(fd.Name())] = true
+		}
+	}
+	return &importRow{pos: pos, snack: snack, fields: fields}, nil
+}
+
+// validateSnack checks snack fits the SnackRegistry schema.
+func validateSnack(snack *sipb.Snack) error {
+	switch {
+	case snack.GetBarcode() == "":
+		return errors.New("barcode is required")
+	case utf8.RuneCountInString(snack.GetBarcode()) > limits.MaxBarcodeLen:
+		return fmt.Errorf("barcode %q is longer than %d characters", snack.GetBarcode(), limits.MaxBarcodeLen)
+	case utf8.RuneCountInString(snack.GetName()) > limits.MaxNameLen:
+		return fmt.Errorf("name of %q is longer than %d characters", snack.GetBarcode(), limits.MaxNameLen)
+	case utf8.RuneCountInString(snack.GetBrand()) > limits.MaxBrandLen:
+		return fmt.Errorf("brand of %q is longer than %d characters", snack.GetBarcode(), limits.MaxBrandLen)
+	case utf8.RuneCountInString(snack.GetCategory()) > limits.MaxCategoryLen:
+		return fmt.Errorf("category of %q is longer than %d characters", snack.GetBarcode(), limits.MaxCategoryLen)
+	case utf8.RuneCountInString(snack.GetPackageSize()) > limits.MaxPackageSizeLen:
+		return fmt.Errorf("package size of %q is longer than %d characters", snack.GetBarcode(), limits.MaxPackageSizeLen)
+	}
+	return nil
+}
+
+// importSummary counts the outcome of an import.
+type importSummary struct {
+	valid, created, updated int
+	errs                    []error
+}
+
+func importSnacks(_ *cobra.Command, _ []string) error {
+	if importBatchSize < 1 {
+		return fmt.Errorf("--batch_size must be positive, got %d", importBatchSize)
+	}
+	format := importFormat
+	if format == "" {
+		format = strings.TrimPrefix(strings.ToLower(filepath.Ext(importFile)), ".")
+	}
+	f, err := os.Open(importFile)
+	if err != nil {
+		return fmt.Errorf("could not open --file: %w", err)
+	}
+	defer f.Close()
+	reader, err := newSnackReader(f, format, importColumns)
+	if err != nil {
+		return fmt.Errorf("could not read %s: %w", importFile, err)
+	}
+
+	ctx, cancel := commandContext()
+	defer cancel()
+	var client sipb.SnackInventoryClient
+	if !importDryRun {
+		c, err := newClient(ctx)
+		if err != nil {
+			return err
+		}
+		defer c.Close()
+		client = c
+	}
+
+	sum := &importSummary{}
+	if err := runImport(ctx, client, reader, sum); err != nil {
+		return fmt.Errorf("could not import %s: %w", importFile, err)
+	}
+
+	for _, err := range sum.errs {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", importFile, err)
+	}
+	if importDryRun {
+		fmt.Printf("Dry run: %d valid snacks, %d errors.\n", sum.valid, len(sum.errs))
+	} else {
+		fmt.Printf("Imported %d snacks (%d created, %d updated), %d errors.\n",
+			sum.created+sum.updated, sum.created, sum.updated, len(sum.errs))
+	}
+	if len(sum.errs) > 0 {
+		return fmt.Errorf("%d rows could not be imported", len(sum.errs))
+	}
+	return nil
+}
+
+// runImport validates every row from reader, sending valid rows to client in
+// batches unless client is nil (a dry run). Errors in individual rows are
+// recorded in sum; the returned error means the import could not continue.
+func runImport(ctx context.Context, client sipb.SnackInventoryClient, reader snackReader, sum *importSummary) error {
+	seen := map[string]string{}
+	var batch []*importRow
+	for {
+		row, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		var rowErr *rowError
+		if errors.As(err, &rowErr) {
+			sum.errs = append(sum.errs, rowErr)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := validateSnack(row.snack); err != nil {
+			sum.errs = append(sum.errs, &rowError{row.pos, err})
+			continue
+		}
+		if first, ok := seen[row.snack.GetBarcode()]; ok {
+			sum.errs = append(sum.errs, &rowError{row.pos, fmt.Errorf("barcode %q repeats %s", row.snack.GetBarcode(), first)})
+			continue
+		}
+		seen[row.snack.GetBarcode()] = row.pos
+		sum.valid++
+
+		if client == nil {
+			continue
+		}
+		batch = append(batch, row)
+		if len(batch) == importBatchSize {
+			if err := sendImportBatch(ctx, client, batch, sum); err != nil {
+				return err
+			}
+			batch = nil
+		}
+	}
+	if client != nil && len(batch) > 0 {
+		return sendImportBatch(ctx, client, batch, sum)
+	}
+	return nil
+}
+
+// sendImportBatch creates the snacks of rows, updating those already
+// registered if --upsert is set. Updated snacks keep the values of fields the
+// rows don't supply, as UpdateSnack writes every field.
+func sendImportBatch(ctx context.Context, client sipb.SnackInventoryClient, rows []*importRow, sum *importSummary) error {
+	req := &sipb.BatchCreateSnacksRequest{Mode: sipb.BatchMode_PER_ITEM}
+	for _, row := range rows {
+		req.Snacks = append(req.Snacks, row.snack)
+	}
+	res, err := client.BatchCreateSnacks(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	var existing []*importRow
+	for _, r := range res.GetResults() {
+		row := rows[r.GetIndex()]
+		switch code := codes.Code(r.GetStatus().GetCode()); {
+		case code == codes.OK:
+			sum.created++
+		case code == codes.AlreadyExists && importUpsert:
+			existing = append(existing, row)
+		default:
+			sum.errs = append(sum.errs, &rowError{row.pos, errors.New(r.GetStatus().GetMessage())})
+		}
+	}
+	if len(existing) == 0 {
+		return nil
+	}
+
+	listRes, err := client.ListSnacks(ctx, &sipb.ListSnacksRequest{})
+	if err != nil {
+		return err
+	}
+	registered := map[string]*sipb.Snack{}
+	for _, snack := range listRes.GetSnacks() {
+		registered[snack.GetBarcode()] = snack
+	}
+	updateReq := &sipb.BatchUpdateSnacksRequest{Mode: sipb.BatchMode_PER_ITEM}
+	for _, row := range existing {
+		updateReq.Snacks = append(updateReq.Snacks, mergeSnack(registered[row.snack.GetBarcode()], row))
+	}
+	updateRes, err := client.BatchUpdateSnacks(ctx, updateReq)
+	if err != nil {
+		return err
+	}
+	for _, r := range updateRes.GetResults() {
+		if codes.Code(r.GetStatus().GetCode()) == codes.OK {
+			sum.updated++
+			continue
+		}
+		sum.errs = append(sum.errs, &rowError{existing[r.GetIndex()].pos, errors.New(r.GetStatus().GetMessage())})
+	}
+	return nil
+}
+
+// mergeSnack returns old with the fields supplied by row set from it. If old
+// is nil (ex: deleted since), row's snack is returned as is.
+func mergeSnack(old *sipb.Snack, row *importRow) *sipb.Snack {
+	if old == nil {
+		return row.snack
+	}
+	merged := proto.Clone(old).(*sipb.Snack)
+	src, dst := row.snack.ProtoReflect(), merged.ProtoReflect()
+	fds := src.Descriptor().Fields()
+	for i := 0; i < fds.Len(); i++ {
+		if fd := fds.Get(i); row.fields[string(fd.Name())] {
+			dst.Set(fd, src.Get(fd))
+		}
+	}
+	return merged
+}
@@ -0,0 +1,360 @@
+/*
+Copyright 2020 Robert Barron
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/rmbarron/SnackInventory/src/backend/fakes/fakeserver"
+	"github.com/rmbarron/SnackInventory/src/cli/testutils"
+	sipb "github.com/rmbarron/SnackInventory/src/proto/snackinventory"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// results returns batch results with the given codes.
+func results(cs ...codes.Code) []*sipb.BatchResult {
+	var res []*sipb.BatchResult
+	for i, c := range cs {
+		res = append(res, &sipb.BatchResult{Index: int32(i), Status: status.New(c, c.String()).Proto()})
+	}
+	return res
+}
+
+// setImportFlagsT writes contents to a file named name, and points the import
+// flags at it. Flags are restored when t completes.
+func setImportFlagsT(t *testing.T, name, contents string, dryRun, upsert bool) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := ioutil.WriteFile(path, []byte(contents), 0600); err != nil {
+		t.Fatalf("ioutil.WriteFile(%q) = got err %v, want err nil", path, err)
+	}
+	tmpFile, tmpDryRun, tmpUpsert, tmpBatchSize := importFile, importDryRun, importUpsert, importBatchSize
+	importFile, importDryRun, importUpsert, importBatchSize = path, dryRun, upsert, 100
+	t.Cleanup(func() {
+		importFile, importDryRun, importUpsert, importBatchSize = tmpFile, tmpDryRun, tmpUpsert, tmpBatchSize
+	})
+}
+
+func TestImportSnacks(t *testing.T) {
+	fsi := &fakeserver.FakeSnackInventoryServer{
+		BatchCreateSnacksRes: &sipb.BatchCreateSnacksResponse{Results: results(codes.OK, codes.OK)},
+	}
+	addr, close := testutils.StartTestServer(t, fsi)
+	defer close()
+
+	// Inject the address of our fake server to the address flag variable.
+	tmpAddr := address
+	address = addr
+	defer func() { address = tmpAddr }()
+
+	setImportFlagsT(t, "snacks.csv", "barcode,name\n123,chips\n456,salsa\n", false, false)
+	if err := importSnacks(nil, nil); err != nil {
+		t.Fatalf("importSnacks(nil, nil) = got err %v, want err nil", err)
+	}
+}
+
+func TestImportSnacks_Upsert(t *testing.T) {
+	fsi := &fakeserver.FakeSnackInventoryServer{
+		BatchCreateSnacksRes: &sipb.BatchCreateSnacksResponse{Results: results(codes.OK, codes.AlreadyExists)},
+		ListSnacksRes:        &sipb.ListSnacksResponse{Snacks: []*sipb.Snack{{Barcode: "456", Name: "salsa"}}},
+		BatchUpdateSnacksRes: &sipb.BatchUpdateSnacksResponse{Results: results(codes.OK)},
+	}
+	addr, close := testutils.StartTestServer(t, fsi)
+	defer close()
+
+	// Inject the address of our fake server to the address flag variable.
+	tmpAddr := address
+	address = addr
+	defer func() { address = tmpAddr }()
+
+	contents := `{"barcode": "123", "name": "chips"}` + "\n" + `{"barcode": "456", "name": "salsa"}` + "\n"
+	setImportFlagsT(t, "snacks.jsonl", contents, false, false)
+	if err := importSnacks(nil, nil); err == nil {
+		t.Fatal("importSnacks(nil, nil) without --upsert = got err nil, want err")
+	}
+
+	setImportFlagsT(t, "snacks.jsonl", contents, false, true)
+	if err := importSnacks(nil, nil); err != nil {
+		t.Fatalf("importSnacks(nil, nil) with --upsert = got err %v, want err nil", err)
+	}
+}
+
+// upsertRecorder is a SnackInventoryClient with every snack registered,
+// recording BatchUpdateSnacks calls.
+type upsertRecorder struct {
+	sipb.SnackInventoryClient
+	registered []*sipb.Snack
+	updated    []*sipb.Snack
+}
+
+func (u *upsertRecorder) BatchCreateSnacks(_ context.Context, req *sipb.BatchCreateSnacksRequest, _ ...grpc.CallOption) (*sipb.BatchCreateSnacksResponse, error) {
+	res := &sipb.BatchCreateSnacksResponse{}
+	for i := range req.GetSnacks() {
+		res.Results = append(res.Results, &sipb.BatchResult{Index: int32(i), Status: status.New(codes.AlreadyExists, "").Proto()})
+	}
+	return res, nil
+}
+
+func (u *upsertRecorder) ListSnacks(context.Context, *sipb.ListSnacksRequest, ...grpc.CallOption) (*sipb.ListSnacksResponse, error) {
+	return &sipb.ListSnacksResponse{Snacks: u.registered}, nil
+}
+
+func (u *upsertRecorder) BatchUpdateSnacks(_ context.Context, req *sipb.BatchUpdateSnacksRequest, _ ...grpc.CallOption) (*sipb.BatchUpdateSnacksResponse, error) {
+	u.updated = append(u.updated, req.GetSnacks()...)
+	res := &sipb.BatchUpdateSnacksResponse{}
+	for i := range req.GetSnacks() {
+		res.Results = append(res.Results, &sipb.BatchResult{Index: int32(i), Status: status.New(codes.OK, "").Proto()})
+	}
+	return res, nil
+}
+
+func TestRunImport_UpsertKeepsUnsuppliedFields(t *testing.T) {
+	tmpUpsert := importUpsert
+	importUpsert = true
+	defer func() { importUpsert = tmpUpsert }()
+
+	client := &upsertRecorder{registered: []*sipb.Snack{
+		{Barcode: "123", Name: "chips", Brand: "Acme", Category: "Snacks", PackageSize: "150 g"},
+		{Barcode: "456", Name: "salsa", Brand: "Hot Co", Category: "Dips", PackageSize: "300 ml"},
+	}}
+	tests := []struct {
+		desc, format, contents string
+		want                   []*sipb.Snack
+	}{
+		{
+			desc:     "CSV",
+			format:   "csv",
+			contents: "barcode,name,category\n123,salty chips,\n456,salsa,Sauces\n",
+			want: []*sipb.Snack{
+				{Barcode: "123", Name: "salty chips", Brand: "Acme", PackageSize: "150 g"},
+				{Barcode: "456", Name: "salsa", Brand: "Hot Co", Category: "Sauces", PackageSize: "300 ml"},
+			},
+		},
+		{
+			desc:     "JSONL",
+			format:   "jsonl",
+			contents: `{"barcode": "123", "name": "salty chips"}` + "\n" + `{"barcode": "456", "packageSize": ""}` + "\n",
+			want: []*sipb.Snack{
+				{Barcode: "123", Name: "salty chips", Brand: "Acme", Category: "Snacks", PackageSize: "150 g"},
+				{Barcode: "456", Name: "salsa", Brand: "Hot Co", Category: "Dips"},
+			},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			client.updated = nil
+			r, err := newSnackReader(strings.NewReader(tc.contents), tc.format, "")
+			if err != nil {
+				t.Fatalf("newSnackReader(...) = got err %v, want err nil", err)
+			}
+			sum := &importSummary{}
+			if err := runImport(context.Background(), client, r, sum); err != nil {
+				t.Fatalf("runImport(...) = got err %v, want err nil", err)
+			}
+			if diff := cmp.Diff(client.updated, tc.want, cmp.Comparer(proto.Equal)); diff != "" {
+				t.Errorf("runImport(...) updated snacks = got diff (-got +want): %s", diff)
+			}
+			if sum.updated != 2 || len(sum.errs) != 0 {
+				t.Errorf("runImport(...) = got %d updated, errs %v, want 2 updated, no errs", sum.updated, sum.errs)
+			}
+		})
+	}
+}
+
+func TestImportSnacks_ServerError(t *testing.T) {
+	fsi := &fakeserver.FakeSnackInventoryServer{
+		BatchCreateSnacksErr: status.Error(codes.ResourceExhausted, "server overloaded"),
+	}
+	addr, close := testutils.StartTestServer(t, fsi)
+	defer close()
+
+	// Inject the address of our fake server to the address flag variable.
+	tmpAddr := address
+	address = addr
+	defer func() { address = tmpAddr }()
+
+	setImportFlagsT(t, "snacks.json", `[{"barcode": "123"}]`, false, false)
+	if err := importSnacks(nil, nil); err == nil {
+		t.Fatal("importSnacks(nil, nil) = got err nil, want err")
+	}
+}
+
+func TestImportSnacks_DryRun(t *testing.T) {
+	// No server is started; a dry run must not dial one.
+	tmpAddr := address
+	address = "localhost:0"
+	defer func() { address = tmpAddr }()
+
+	setImportFlagsT(t, "snacks.csv", "barcode,name\n123,chips\n", true, false)
+	if err := importSnacks(nil, nil); err != nil {
+		t.Fatalf("importSnacks(nil, nil) with --dry_run = got err %v, want err nil", err)
+	}
+
+	setImportFlagsT(t, "snacks.csv", "barcode,name\n,chips\n", true, false)
+	if err := importSnacks(nil, nil); err == nil {
+		t.Fatal("importSnacks(nil, nil) with --dry_run and invalid rows = got err nil, want err")
+	}
+}
+
+// readAllT reads every row from r, returning snacks and row errors separately.
+func readAllT(t *testing.T, r snackReader) ([]*sipb.Snack, []string) {
+	t.Helper()
+	var snacks []*sipb.Snack
+	var errs []string
+	for {
+		row, err := r.Next()
+		if errors.Is(err, io.EOF) {
+			return snacks, errs
+		}
+		var rowErr *rowError
+		if errors.As(err, &rowErr) {
+			errs = append(errs, rowErr.pos)
+			continue
+		}
+		if err != nil {
+			t.Fatalf("r.Next() = got err %v, want err nil", err)
+		}
+		snacks = append(snacks, row.snack)
+	}
+}
+
+func TestSnackReaders(t *testing.T) {
+	tests := []struct {
+		desc     string
+		format   string
+		columns  string
+		contents string
+		want     []*sipb.Snack
+		wantErrs []string
+	}{
+		{
+			desc:     "CSV",
+			format:   "csv",
+			contents: "Name,Barcode,Price\nchips, 123,2.99\nsalsa,456\n\"dip, spicy\",789,1.00\n",
+			want:     []*sipb.Snack{{Barcode: "123", Name: "chips"}, {Barcode: "789", Name: "dip, spicy"}},
+			wantErrs: []string{"row 3"},
+		},
+		{
+			desc:     "CSVColumns",
+			format:   "csv",
+			columns:  "UPC=barcode,Product=name",
+			contents: "UPC,Product\n123,chips\n",
+			want:     []*sipb.Snack{{Barcode: "123", Name: "chips"}},
+		},
+		{
+			desc:     "JSON",
+			format:   "json",
+			contents: `[{"barcode": "123", "name": "chips"}, {"barcode": 7}, {"barcode": "456"}]`,
+			want:     []*sipb.Snack{{Barcode: "123", Name: "chips"}, {Barcode: "456"}},
+			wantErrs: []string{"item 2"},
+		},
+		{
+			desc:     "JSONL",
+			format:   "jsonl",
+			contents: "{\"barcode\": \"123\"}\n\n{\"color\": \"red\"}\n{\"barcode\": \"456\"}\n",
+			want:     []*sipb.Snack{{Barcode: "123"}, {Barcode: "456"}},
+			wantErrs: []string{"line 3"},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			r, err := newSnackReader(strings.NewReader(tc.contents), tc.format, tc.columns)
+			if err != nil {
+				t.Fatalf("newSnackReader(..., %q, %q) = got err %v, want err nil", tc.format, tc.columns, err)
+			}
+			got, gotErrs := readAllT(t, r)
+			if diff := cmp.Diff(got, tc.want, cmp.Comparer(func(a, b *sipb.Snack) bool {
+				return a.GetBarcode() == b.GetBarcode() && a.GetName() == b.GetName()
+			})); diff != "" {
+				t.Errorf("snacks read = got diff (-got +want): %s", diff)
+			}
+			if diff := cmp.Diff(gotErrs, tc.wantErrs); diff != "" {
+				t.Errorf("row errors = got diff (-got +want): %s", diff)
+			}
+		})
+	}
+}
+
+func TestNewSnackReader_Errors(t *testing.T) {
+	tests := []struct {
+		desc, format, columns, contents string
+	}{
+		{"UnknownFormat", "xml", "", "<snacks/>"},
+		{"CSVNoBarcode", "csv", "", "name\nchips\n"},
+		{"CSVBadColumns", "csv", "UPC=price", "UPC\n123\n"},
+		{"JSONNotArray", "json", "", `{"barcode": "123"}`},
+	}
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			if _, err := newSnackReader(strings.NewReader(tc.contents), tc.format, tc.columns); err == nil {
+				t.Fatalf("newSnackReader(..., %q, %q) = got err nil, want err", tc.format, tc.columns)
+			}
+		})
+	}
+}
+
+// batchRecorder is a SnackInventoryClient recording BatchCreateSnacks calls.
+type batchRecorder struct {
+	sipb.SnackInventoryClient
+	sizes []int
+}
+
+func (b *batchRecorder) BatchCreateSnacks(_ context.Context, req *sipb.BatchCreateSnacksRequest, _ ...grpc.CallOption) (*sipb.BatchCreateSnacksResponse, error) {
+	b.sizes = append(b.sizes, len(req.GetSnacks()))
+	res := &sipb.BatchCreateSnacksResponse{}
+	for i := range req.GetSnacks() {
+		res.Results = append(res.Results, &sipb.BatchResult{Index: int32(i), Status: status.New(codes.OK, "").Proto()})
+	}
+	return res, nil
+}
+
+func TestRunImport_Batches(t *testing.T) {
+	tmpBatchSize := importBatchSize
+	importBatchSize = 2
+	defer func() { importBatchSize = tmpBatchSize }()
+
+	contents := "barcode\n1\n2\n\n3\n2\n4\n5\n"
+	r, err := newSnackReader(strings.NewReader(contents), "csv", "")
+	if err != nil {
+		t.Fatalf("newSnackReader(...) = got err %v, want err nil", err)
+	}
+	client := &batchRecorder{}
+	sum := &importSummary{}
+	if err := runImport(context.Background(), client, r, sum); err != nil {
+		t.Fatalf("runImport(...) = got err %v, want err nil", err)
+	}
+
+	if diff := cmp.Diff(client.sizes, []int{2, 2, 1}); diff != "" {
+		t.Errorf("runImport(...) batch sizes = got diff (-got +want): %s", diff)
+	}
+	if sum.created != 5 {
+		t.Errorf("runImport(...) created = got %d, want 5", sum.created)
+	}
+	// The repeated barcode "2" is rejected.
+	if len(sum.errs) != 1 {
+		t.Errorf("runImport(...) errs = got %v, want 1 error", sum.errs)
+	}
+}
@@ -19,38 +19,121 @@ limitations under the License.
 package cmd
 
 import (
-	"context"
 	"fmt"
+	"io"
+	"os"
+	"strings"
 
 	sipb "github.com/rmbarron/SnackInventory/src/proto/snackinventory"
 	"github.com/spf13/cobra"
-	"google.golang.org/grpc"
 )
 
-var listLocationsCmd = &cobra.Command{
-	Use:   "listlocations",
-	Short: "List all locations currently registered to SnackInventory.",
-	Long:  "List all locations currently registered to SnackInventory.",
-	RunE:  listLocations,
+var (
+	listLocationsRoot string
+	listLocationsTree bool
+	listLocationsAsOf string
+
+	listLocationsCmd = &cobra.Command{
+		Use:   "listlocations [--root=location] [--tree]",
+		Short: "List all locations currently registered to SnackInventory.",
+		Long: `List all locations currently registered to SnackInventory, each
+    followed by the locations nested inside it, with the stock at & inside
+    each. --tree draws the nesting instead, ignoring --output. With --as_of,
+    lists those registered at a past time, as they were then, without stock.`,
+		RunE: listLocations,
+	}
+)
+
+func init() {
+	listLocationsCmd.Flags().StringVar(&listLocationsRoot, "root", "",
+		"If set, only list this location & those inside it, by name or path (ex: Garage/Freezer).")
+	listLocationsCmd.Flags().BoolVar(&listLocationsTree, "tree", false,
+		"Draw locations as a tree, with the total stock inside each.")
+	listLocationsCmd.Flags().StringVar(&listLocationsAsOf, "as_of", "", asOfUsage)
 }
 
 func listLocations(_ *cobra.Command, _ []string) error {
-	conn, err := grpc.Dial(address, grpc.WithInsecure(), grpc.WithBlock(), grpc.WithTimeout(connTimeout))
+	var p printer
+	if !listLocationsTree {
+		var err error
+		if p, err = newPrinter(os.Stdout, (&sipb.Location{}).ProtoReflect().Descriptor(), false); err != nil {
+			return err
+		}
+	}
+	asOf, err := parseAsOf(listLocationsAsOf)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := commandContext()
+	defer cancel()
+	client, err := newClient(ctx)
 	if err != nil {
-		return fmt.Errorf("could not dial %s: %w", address, err)
+		return err
 	}
-	defer conn.Close()
+	defer client.Close()
 
-	req := &sipb.ListLocationsRequest{}
-	client := sipb.NewSnackInventoryClient(conn)
+	req := &sipb.ListLocationsRequest{Root: listLocationsRoot, AsOf: asOf}
 
-	res, err := client.ListLocations(context.Background(), req)
+	res, err := client.ListLocations(ctx, req)
 	if err != nil {
 		return fmt.Errorf("could not list locations: %v", err)
 	}
-	fmt.Println("Found locations:")
+	if listLocationsTree {
+		return printTree(os.Stdout, res.GetLocations())
+	}
 	for _, location := range res.GetLocations() {
-		fmt.Println(location)
+		if err := p.Print(location); err != nil {
+			return err
+		}
+	}
+	return p.Flush()
+}
+
+// printTree draws locations, listed depth first, as a tree. Each location is
+// followed by the total stock inside it, if any.
+func printTree(w io.Writer, locations []*sipb.Location) error {
+	if len(locations) == 0 {
+		return nil
+	}
+	// A subtree is drawn from its root.
+	base := locations[0].GetDepth()
+	// last[d] is whether the location drawn at depth d is the last inside its
+	// parent, so nothing more hangs from it.
+	var last []bool
+	for i, l := range locations {
+		depth := int(l.GetDepth() - base)
+		isLast := true
+		for _, next := range locations[i+1:] {
+			if d := int(next.GetDepth() - base); d <= depth {
+				isLast = d < depth
+				break
+			}
+		}
+		last = append(last[:depth], isLast)
+
+		var b strings.Builder
+		for d := 1; d < depth; d++ {
+			if last[d] {
+				b.WriteString("    ")
+			} else {
+				b.WriteString("│   ")
+			}
+		}
+		if depth > 0 {
+			if isLast {
+				b.WriteString("└── ")
+			} else {
+				b.WriteString("├── ")
+			}
+		}
+		b.WriteString(l.GetName())
+		if l.GetTotalCount() > 0 {
+			fmt.Fprintf(&b, " (%d)", l.GetTotalCount())
+		}
+		if _, err := fmt.Fprintln(w, b.String()); err != nil {
+			return err
+		}
 	}
 	return nil
 }
@@ -16,6 +16,7 @@ limitations under the License.
 package cmd
 
 import (
+	"bytes"
 	"testing"
 
 	"github.com/rmbarron/SnackInventory/src/backend/fakes/fakeserver"
@@ -49,6 +50,85 @@ func TestListLocations(t *testing.T) {
 	}
 }
 
+func TestListLocations_Tree(t *testing.T) {
+	fsi := &fakeserver.FakeSnackInventoryServer{
+		ListLocationsRes: &sipb.ListLocationsResponse{
+			Locations: []*sipb.Location{
+				{Name: "garage", Path: "garage"},
+				{Name: "freezer", Parent: "garage", Path: "garage/freezer", Depth: 1},
+			},
+		},
+	}
+	addr, close := testutils.StartTestServer(t, fsi)
+	defer close()
+
+	// Inject the address of our fake server to the address flag variable.
+	tmpAddr := address
+	address = addr
+	defer func() { address = tmpAddr }()
+	listLocationsTree = true
+	defer func() { listLocationsTree = false }()
+
+	if err := listLocations(nil, nil); err != nil {
+		t.Fatalf("listLocations(nil, nil) = got err %v, want err nil", err)
+	}
+}
+
+func TestPrintTree(t *testing.T) {
+	tests := []struct {
+		desc      string
+		locations []*sipb.Location
+		want      string
+	}{
+		{
+			desc: "All",
+			locations: []*sipb.Location{
+				{Name: "garage", TotalCount: 9},
+				{Name: "freezer", Depth: 1, TotalCount: 9},
+				{Name: "bottom", Depth: 2},
+				{Name: "top", Depth: 2, TotalCount: 6},
+				{Name: "left", Depth: 3, TotalCount: 6},
+				{Name: "shelf", Depth: 1},
+				{Name: "pantry", TotalCount: 1},
+				{Name: "bin", Depth: 1, TotalCount: 1},
+			},
+			want: "garage (9)\n" +
+				"├── freezer (9)\n" +
+				"│   ├── bottom\n" +
+				"│   └── top (6)\n" +
+				"│       └── left (6)\n" +
+				"└── shelf\n" +
+				"pantry (1)\n" +
+				"└── bin (1)\n",
+		},
+		{
+			desc: "Subtree",
+			locations: []*sipb.Location{
+				{Name: "freezer", Depth: 1},
+				{Name: "top", Depth: 2},
+				{Name: "bottom", Depth: 2},
+			},
+			want: "freezer\n" +
+				"├── top\n" +
+				"└── bottom\n",
+		},
+		{
+			desc: "Empty",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := printTree(&buf, tc.locations); err != nil {
+				t.Fatalf("printTree(...) = got err %v, want err nil", err)
+			}
+			if got := buf.String(); got != tc.want {
+				t.Errorf("printTree(...) = got %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
 func TestListLocations_ServerError(t *testing.T) {
 	fsi := &fakeserver.FakeSnackInventoryServer{
 		ListLocationsErr: status.Error(codes.Internal, "something failed"),
@@ -19,39 +19,58 @@ limitations under the License.
 package cmd
 
 import (
-	"context"
 	"fmt"
+	"os"
 
 	"github.com/spf13/cobra"
-	"google.golang.org/grpc"
 
 	sipb "github.com/rmbarron/SnackInventory/src/proto/snackinventory"
 )
 
-var listSnacksCmd = &cobra.Command{
-	Use:   "listsnacks",
-	Short: "List all snacks currently registered to SnackInventory.",
-	Long:  "List all snacks currently registered to SnackInventory.",
-	RunE:  listSnacks,
+var (
+	listSnacksAsOf string
+
+	listSnacksCmd = &cobra.Command{
+		Use:   "listsnacks [--as_of=time]",
+		Short: "List all snacks currently registered to SnackInventory.",
+		Long: `List all snacks currently registered to SnackInventory, or with
+    --as_of, those registered at a past time, as they were then.`,
+		RunE: listSnacks,
+	}
+)
+
+func init() {
+	listSnacksCmd.Flags().StringVar(&listSnacksAsOf, "as_of", "", asOfUsage)
 }
 
 func listSnacks(_ *cobra.Command, _ []string) error {
-	conn, err := grpc.Dial(address, grpc.WithInsecure(), grpc.WithBlock(), grpc.WithTimeout(connTimeout))
+	p, err := newPrinter(os.Stdout, (&sipb.Snack{}).ProtoReflect().Descriptor(), false)
+	if err != nil {
+		return err
+	}
+	asOf, err := parseAsOf(listSnacksAsOf)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := commandContext()
+	defer cancel()
+	client, err := newClient(ctx)
 	if err != nil {
-		return fmt.Errorf("could not dial %s: %w", address, err)
+		return err
 	}
-	defer conn.Close()
+	defer client.Close()
 
-	req := &sipb.ListSnacksRequest{}
-	client := sipb.NewSnackInventoryClient(conn)
+	req := &sipb.ListSnacksRequest{AsOf: asOf}
 
-	res, err := client.ListSnacks(context.Background(), req)
+	res, err := client.ListSnacks(ctx, req)
 	if err != nil {
 		return fmt.Errorf("could not list snacks: %v", err)
 	}
-	fmt.Println("Found snacks:")
 	for _, snack := range res.GetSnacks() {
-		fmt.Println(snack)
+		if err := p.Print(snack); err != nil {
+			return err
+		}
 	}
-	return nil
+	return p.Flush()
 }
@@ -66,3 +66,15 @@ func TestListSnacks_ServerError(t *testing.T) {
 		t.Fatal("listSnacks(nil, nil) = got err nil, want err")
 	}
 }
+
+func TestListSnacks_BadOutput(t *testing.T) {
+	// No server is started; a bad --output must fail before dialing.
+	tmpAddr := address
+	address = "localhost:0"
+	defer func() { address = tmpAddr }()
+	setOutputT(t, "xml", "")
+
+	if err := listSnacks(nil, nil); err == nil {
+		t.Fatal("listSnacks(nil, nil) with --output=xml = got err nil, want err")
+	}
+}
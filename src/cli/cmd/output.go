@@ -0,0 +1,318 @@
+/*
+Copyright 2020 Robert Barron
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package cmd provides the various subcommands of the SnackInventory CLI.
+// This file implements the formats selected by --output for printing results.
+package cmd
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"text/template"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"gopkg.in/yaml.v2"
+)
+
+// Values of --output.
+const (
+	outputTable    = "table"
+	outputJSON     = "json"
+	outputJSONL    = "jsonl"
+	outputCSV      = "csv"
+	outputYAML     = "yaml"
+	outputTemplate = "template"
+)
+
+var (
+	outputFormat       string
+	outputTemplateText string
+
+	// Field names match the REST gateway, & are stable across releases.
+	outputMarshaler = protojson.MarshalOptions{UseProtoNames: true, EmitUnpopulated: true}
+)
+
+// printer prints a sequence of messages of one type.
+type printer interface {
+	// Print prints m, possibly buffering it until Flush.
+	Print(m proto.Message) error
+	// Flush completes the output. Call it once, after the last Print.
+	Flush() error
+}
+
+// newPrinter returns a printer for --output, writing messages described by md
+// to w. If stream is set, each message is written as soon as it is printed;
+// JSON is then written as JSON lines, since an array can't be streamed.
+func newPrinter(w io.Writer, md protoreflect.MessageDescriptor, stream bool) (printer, error) {
+	switch outputFormat {
+	case outputTable:
+		return newTablePrinter(w, md), nil
+	case outputJSON:
+		if stream {
+			return &jsonlPrinter{w: w}, nil
+		}
+		return &jsonPrinter{w: w}, nil
+	case outputJSONL:
+		return &jsonlPrinter{w: w}, nil
+	case outputCSV:
+		return &csvPrinter{w: csv.NewWriter(w), md: md}, nil
+	case outputYAML:
+		return &yamlPrinter{w: w, md: md}, nil
+	case outputTemplate:
+		if outputTemplateText == "" {
+			return nil, fmt.Errorf("--output=%s requires --template", outputTemplate)
+		}
+		tmpl, err := template.New("output").Option("missingkey=error").Parse(outputTemplateText)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --template: %w", err)
+		}
+		return &templatePrinter{w: w, tmpl: tmpl}, nil
+	}
+	return nil, fmt.Errorf("unknown --output %q; valid values are %s", outputFormat,
+		strings.Join([]string{outputTable, outputJSON, outputJSONL, outputCSV, outputYAML, outputTemplate}, ", "))
+}
+
+// marshalJSON returns m as compact JSON. protojson's whitespace is deliberately
+// unstable, so it is normalized for output that is diffed or piped.
+func marshalJSON(m proto.Message) ([]byte, error) {
+	b, err := outputMarshaler.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, b); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// fieldValues returns the JSON value of each field of m, keyed by field name.
+func fieldValues(m proto.Message) (map[string]interface{}, error) {
+	b, err := outputMarshaler.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	values := map[string]interface{}{}
+	if err := json.Unmarshal(b, &values); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// cells returns the fields of m, in the order of md, as text. Nested messages
+// & lists are written as compact JSON.
+func cells(md protoreflect.MessageDescriptor, m proto.Message) ([]string, error) {
+	values, err := fieldValues(m)
+	if err != nil {
+		return nil, err
+	}
+	fields := md.Fields()
+	row := make([]string, fields.Len())
+	for i := range row {
+		switch v := values[string(fields.Get(i).Name())].(type) {
+		case nil:
+		case string:
+			row[i] = v
+		case float64, bool:
+			row[i] = fmt.Sprint(v)
+		default:
+			b, err := json.Marshal(v)
+			if err != nil {
+				return nil, err
+			}
+			row[i] = string(b)
+		}
+	}
+	return row, nil
+}
+
+// fieldNames returns the names of the fields of md, in order.
+func fieldNames(md protoreflect.MessageDescriptor) []string {
+	fields := md.Fields()
+	names := make([]string, fields.Len())
+	for i := range names {
+		names[i] = string(fields.Get(i).Name())
+	}
+	return names
+}
+
+// tablePrinter prints aligned columns, headed by upper case field names.
+type tablePrinter struct {
+	w  *tabwriter.Writer
+	md protoreflect.MessageDescriptor
+}
+
+func newTablePrinter(w io.Writer, md protoreflect.MessageDescriptor) *tablePrinter {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.ToUpper(strings.Join(fieldNames(md), "\t")))
+	return &tablePrinter{w: tw, md: md}
+}
+
+func (p *tablePrinter) Print(m proto.Message) error {
+	row, err := cells(p.md, m)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(p.w, strings.Join(row, "\t"))
+	return err
+}
+
+func (p *tablePrinter) Flush() error {
+	return p.w.Flush()
+}
+
+// jsonPrinter prints a JSON array.
+type jsonPrinter struct {
+	w    io.Writer
+	msgs []json.RawMessage
+}
+
+func (p *jsonPrinter) Print(m proto.Message) error {
+	b, err := marshalJSON(m)
+	if err != nil {
+		return err
+	}
+	p.msgs = append(p.msgs, b)
+	return nil
+}
+
+func (p *jsonPrinter) Flush() error {
+	msgs := p.msgs
+	if msgs == nil {
+		// Print [] rather than null.
+		msgs = []json.RawMessage{}
+	}
+	b, err := json.MarshalIndent(msgs, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(p.w, "%s\n", b)
+	return err
+}
+
+// jsonlPrinter prints one JSON object per line.
+type jsonlPrinter struct {
+	w io.Writer
+}
+
+func (p *jsonlPrinter) Print(m proto.Message) error {
+	b, err := marshalJSON(m)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(p.w, "%s\n", b)
+	return err
+}
+
+func (p *jsonlPrinter) Flush() error {
+	return nil
+}
+
+// csvPrinter prints CSV, with a header row of field names.
+type csvPrinter struct {
+	w       *csv.Writer
+	md      protoreflect.MessageDescriptor
+	started bool
+}
+
+func (p *csvPrinter) header() {
+	if !p.started {
+		p.started = true
+		p.w.Write(fieldNames(p.md))
+	}
+}
+
+func (p *csvPrinter) Print(m proto.Message) error {
+	p.header()
+	row, err := cells(p.md, m)
+	if err != nil {
+		return err
+	}
+	p.w.Write(row)
+	p.w.Flush()
+	return p.w.Error()
+}
+
+func (p *csvPrinter) Flush() error {
+	p.header()
+	p.w.Flush()
+	return p.w.Error()
+}
+
+// yamlPrinter prints a YAML sequence, keeping fields in declaration order.
+type yamlPrinter struct {
+	w       io.Writer
+	md      protoreflect.MessageDescriptor
+	printed bool
+}
+
+func (p *yamlPrinter) Print(m proto.Message) error {
+	values, err := fieldValues(m)
+	if err != nil {
+		return err
+	}
+	var item yaml.MapSlice
+	for _, name := range fieldNames(p.md) {
+		if v, ok := values[name]; ok {
+			item = append(item, yaml.MapItem{Key: name, Value: v})
+		}
+	}
+	b, err := yaml.Marshal([]yaml.MapSlice{item})
+	if err != nil {
+		return err
+	}
+	p.printed = true
+	_, err = p.w.Write(b)
+	return err
+}
+
+func (p *yamlPrinter) Flush() error {
+	if p.printed {
+		return nil
+	}
+	_, err := io.WriteString(p.w, "[]\n")
+	return err
+}
+
+// templatePrinter executes a text/template for each message, followed by a
+// newline. Fields are accessed by name, ex: {{.barcode}}.
+type templatePrinter struct {
+	w    io.Writer
+	tmpl *template.Template
+}
+
+func (p *templatePrinter) Print(m proto.Message) error {
+	values, err := fieldValues(m)
+	if err != nil {
+		return err
+	}
+	if err := p.tmpl.Execute(p.w, values); err != nil {
+		return err
+	}
+	_, err = io.WriteString(p.w, "\n")
+	return err
+}
+
+func (p *templatePrinter) Flush() error {
+	return nil
+}
@@ -0,0 +1,182 @@
+/*
+Copyright 2020 Robert Barron
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	sipb "github.com/rmbarron/SnackInventory/src/proto/snackinventory"
+)
+
+// setOutputT sets --output & --template, restoring them when t completes.
+func setOutputT(t *testing.T, format, tmpl string) {
+	t.Helper()
+	tmpFormat, tmpTemplate := outputFormat, outputTemplateText
+	outputFormat, outputTemplateText = format, tmpl
+	t.Cleanup(func() { outputFormat, outputTemplateText = tmpFormat, tmpTemplate })
+}
+
+func TestPrinter(t *testing.T) {
+	snacks := []*sipb.Snack{
+		{Barcode: "123", Name: "chips"},
+		{Barcode: "456", Name: "salsa, spicy"},
+		{Barcode: "789"},
+	}
+
+	tests := []struct {
+		format, tmpl string
+		want         string
+	}{
+		{
+			format: "table",
+			want: "BARCODE  NAME          BRAND  CATEGORY  PACKAGE_SIZE\n" +
+				"123      chips                          \n" +
+				"456      salsa, spicy                   \n" +
+				"789                                     \n",
+		},
+		{
+			format: "json",
+			want: "[\n" +
+				"  {\n    \"barcode\": \"123\",\n    \"name\": \"chips\",\n    \"brand\": \"\",\n    \"category\": \"\",\n    \"package_size\": \"\"\n  },\n" +
+				"  {\n    \"barcode\": \"456\",\n    \"name\": \"salsa, spicy\",\n    \"brand\": \"\",\n    \"category\": \"\",\n    \"package_size\": \"\"\n  },\n" +
+				"  {\n    \"barcode\": \"789\",\n    \"name\": \"\",\n    \"brand\": \"\",\n    \"category\": \"\",\n    \"package_size\": \"\"\n  }\n" +
+				"]\n",
+		},
+		{
+			format: "jsonl",
+			want: `{"barcode":"123","name":"chips","brand":"","category":"","package_size":""}` + "\n" +
+				`{"barcode":"456","name":"salsa, spicy","brand":"","category":"","package_size":""}` + "\n" +
+				`{"barcode":"789","name":"","brand":"","category":"","package_size":""}` + "\n",
+		},
+		{
+			format: "csv",
+			want:   "barcode,name,brand,category,package_size\n123,chips,,,\n456,\"salsa, spicy\",,,\n789,,,,\n",
+		},
+		{
+			format: "yaml",
+			want: "- barcode: \"123\"\n  name: chips\n  brand: \"\"\n  category: \"\"\n  package_size: \"\"\n" +
+				"- barcode: \"456\"\n  name: salsa, spicy\n  brand: \"\"\n  category: \"\"\n  package_size: \"\"\n" +
+				"- barcode: \"789\"\n  name: \"\"\n  brand: \"\"\n  category: \"\"\n  package_size: \"\"\n",
+		},
+		{
+			format: "template",
+			tmpl:   "{{.name}} ({{.barcode}})",
+			want:   "chips (123)\nsalsa, spicy (456)\n (789)\n",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.format, func(t *testing.T) {
+			setOutputT(t, tc.format, tc.tmpl)
+			var buf bytes.Buffer
+			p, err := newPrinter(&buf, (&sipb.Snack{}).ProtoReflect().Descriptor(), false)
+			if err != nil {
+				t.Fatalf("newPrinter(...) = got err %v, want err nil", err)
+			}
+			for _, snack := range snacks {
+				if err := p.Print(snack); err != nil {
+					t.Fatalf("p.Print(%v) = got err %v, want err nil", snack, err)
+				}
+			}
+			if err := p.Flush(); err != nil {
+				t.Fatalf("p.Flush() = got err %v, want err nil", err)
+			}
+			if diff := cmp.Diff(buf.String(), tc.want); diff != "" {
+				t.Errorf("printed output = got diff (-got +want): %s", diff)
+			}
+		})
+	}
+}
+
+func TestPrinter_Empty(t *testing.T) {
+	tests := map[string]string{
+		"table": "BARCODE  NAME  BRAND  CATEGORY  PACKAGE_SIZE\n",
+		"json":  "[]\n",
+		"jsonl": "",
+		"csv":   "barcode,name,brand,category,package_size\n",
+		"yaml":  "[]\n",
+	}
+	for format, want := range tests {
+		t.Run(format, func(t *testing.T) {
+			setOutputT(t, format, "")
+			var buf bytes.Buffer
+			p, err := newPrinter(&buf, (&sipb.Snack{}).ProtoReflect().Descriptor(), false)
+			if err != nil {
+				t.Fatalf("newPrinter(...) = got err %v, want err nil", err)
+			}
+			if err := p.Flush(); err != nil {
+				t.Fatalf("p.Flush() = got err %v, want err nil", err)
+			}
+			if got := buf.String(); got != want {
+				t.Errorf("printed output = got %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestPrinter_Nested(t *testing.T) {
+	change := &sipb.Change{
+		Type:        sipb.Change_CREATED,
+		Entity:      &sipb.Change_Snack{Snack: &sipb.Snack{Barcode: "123", Name: "chips"}},
+		ResumeToken: "1.1",
+	}
+	tests := []struct {
+		format string
+		stream bool
+		want   string
+	}{
+		{"csv", true, "type,snack,location,stock,change_time,resume_token\n" +
+			"CREATED,\"{\"\"barcode\"\":\"\"123\"\",\"\"brand\"\":\"\"\"\",\"\"category\"\":\"\"\"\",\"\"name\"\":\"\"chips\"\",\"\"package_size\"\":\"\"\"\"}\",,,,1.1\n"},
+		// JSON is streamed as JSON lines.
+		{"json", true, `{"type":"CREATED","snack":{"barcode":"123","name":"chips","brand":"","category":"","package_size":""},"change_time":null,"resume_token":"1.1"}` + "\n"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.format, func(t *testing.T) {
+			setOutputT(t, tc.format, "")
+			var buf bytes.Buffer
+			p, err := newPrinter(&buf, change.ProtoReflect().Descriptor(), tc.stream)
+			if err != nil {
+				t.Fatalf("newPrinter(...) = got err %v, want err nil", err)
+			}
+			if err := p.Print(change); err != nil {
+				t.Fatalf("p.Print(%v) = got err %v, want err nil", change, err)
+			}
+			// Streamed output is written before Flush.
+			if diff := cmp.Diff(buf.String(), tc.want); diff != "" {
+				t.Errorf("printed output = got diff (-got +want): %s", diff)
+			}
+		})
+	}
+}
+
+func TestNewPrinter_Errors(t *testing.T) {
+	tests := []struct {
+		desc, format, tmpl string
+	}{
+		{"UnknownFormat", "xml", ""},
+		{"MissingTemplate", "template", ""},
+		{"BadTemplate", "template", "{{.barcode"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			setOutputT(t, tc.format, tc.tmpl)
+			if _, err := newPrinter(&bytes.Buffer{}, (&sipb.Snack{}).ProtoReflect().Descriptor(), false); err == nil {
+				t.Fatalf("newPrinter(...) with --output=%q --template=%q = got err nil, want err", tc.format, tc.tmpl)
+			}
+		})
+	}
+}
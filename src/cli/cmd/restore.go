@@ -0,0 +1,114 @@
+/*
+Copyright 2020 Robert Barron
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package cmd provides the various subcommands of the SnackInventory CLI.
+// This file implements a call to the `ImportAll` RPC.
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/rmbarron/SnackInventory/src/backup"
+	sipb "github.com/rmbarron/SnackInventory/src/proto/snackinventory"
+	"github.com/spf13/cobra"
+)
+
+var (
+	restoreIn string
+
+	restoreCmd = &cobra.Command{
+		Use:   "restore --in=file",
+		Short: "Restore snacks, locations & stock from a backup file.",
+		Long: `Restore snacks, locations & stock from a file written by the
+    backup command. Snacks already registered are overwritten with their
+    backed up name, and stock counts set to those backed up; nothing is
+    deleted.`,
+		RunE: restoreAll,
+	}
+)
+
+func init() {
+	restoreCmd.Flags().StringVar(&restoreIn, "in", "", "Path of the backup file to restore.")
+	restoreCmd.MarkFlagRequired("in")
+}
+
+func restoreAll(_ *cobra.Command, _ []string) error {
+	f, err := os.Open(restoreIn)
+	if err != nil {
+		return fmt.Errorf("could not open --in: %w", err)
+	}
+	defer f.Close()
+	// Check the file before dialing, so a bad file fails fast.
+	r, err := backup.NewReader(f)
+	if err != nil {
+		return fmt.Errorf("could not read %s: %w", restoreIn, err)
+	}
+
+	ctx, cancel := commandContext()
+	defer cancel()
+	client, err := newClient(ctx)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	res, err := importAll(ctx, client, r)
+	if err != nil {
+		return fmt.Errorf("could not restore %s: %w", restoreIn, err)
+	}
+	fmt.Printf("Restored %d snacks, %d locations and %d stock counts\n", res.GetSnacks(), res.GetLocations(), res.GetStock())
+	return nil
+}
+
+// importAll streams the header & records of r to ImportAll.
+func importAll(ctx context.Context, client sipb.SnackInventoryClient, r *backup.Reader) (*sipb.ImportAllResponse, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	// Cancelling aborts the stream if we fail part way through.
+	defer cancel()
+	stream, err := client.ImportAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := stream.Send(&sipb.BackupRecord{Record: &sipb.BackupRecord_Header{Header: r.Header()}}); err != nil {
+		return nil, closeErr(stream, err)
+	}
+	for {
+		rec, err := r.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if err := stream.Send(rec); err != nil {
+			return nil, closeErr(stream, err)
+		}
+	}
+	return stream.CloseAndRecv()
+}
+
+// closeErr returns the reason the server ended stream, after sending failed
+// with err. The reason is only available from CloseAndRecv.
+func closeErr(stream sipb.SnackInventory_ImportAllClient, err error) error {
+	if _, rerr := stream.CloseAndRecv(); rerr != nil {
+		return rerr
+	}
+	return err
+}
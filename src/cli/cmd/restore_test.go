@@ -0,0 +1,110 @@
+/*
+Copyright 2020 Robert Barron
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/rmbarron/SnackInventory/src/backend/fakes/fakeserver"
+	"github.com/rmbarron/SnackInventory/src/backup"
+	"github.com/rmbarron/SnackInventory/src/cli/testutils"
+	sipb "github.com/rmbarron/SnackInventory/src/proto/snackinventory"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// setRestoreInT writes a backup of backupRecords, and points --in at it.
+// The flag is restored when t completes.
+func setRestoreInT(t *testing.T) {
+	t.Helper()
+	var buf bytes.Buffer
+	w, err := backup.NewWriter(&buf, backup.Header())
+	if err != nil {
+		t.Fatalf("backup.NewWriter(&buf, ...) = got err %v, want err nil", err)
+	}
+	for _, rec := range backupRecords[1:] {
+		if err := w.Write(rec); err != nil {
+			t.Fatalf("w.Write(%v) = got err %v, want err nil", rec, err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("w.Close() = got err %v, want err nil", err)
+	}
+	setRestoreInContentsT(t, buf.Bytes())
+}
+
+// setRestoreInContentsT writes contents to a file, and points --in at it.
+func setRestoreInContentsT(t *testing.T, contents []byte) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "snacks.backup")
+	if err := ioutil.WriteFile(path, contents, 0600); err != nil {
+		t.Fatalf("ioutil.WriteFile(%q) = got err %v, want err nil", path, err)
+	}
+	tmpIn := restoreIn
+	restoreIn = path
+	t.Cleanup(func() { restoreIn = tmpIn })
+}
+
+func TestRestoreAll(t *testing.T) {
+	fsi := &fakeserver.FakeSnackInventoryServer{
+		ImportAllRes: &sipb.ImportAllResponse{Snacks: 1, Locations: 1},
+	}
+	addr, close := testutils.StartTestServer(t, fsi)
+	defer close()
+
+	// Inject the address of our fake server to the address flag variable.
+	tmpAddr := address
+	address = addr
+	defer func() { address = tmpAddr }()
+
+	setRestoreInT(t)
+	if err := restoreAll(nil, nil); err != nil {
+		t.Fatalf("restoreAll(nil, nil) = got err %v, want err nil", err)
+	}
+}
+
+func TestRestoreAll_ServerError(t *testing.T) {
+	fsi := &fakeserver.FakeSnackInventoryServer{
+		ImportAllErr: status.Error(codes.InvalidArgument, "unsupported backup version"),
+	}
+	addr, close := testutils.StartTestServer(t, fsi)
+	defer close()
+
+	// Inject the address of our fake server to the address flag variable.
+	tmpAddr := address
+	address = addr
+	defer func() { address = tmpAddr }()
+
+	setRestoreInT(t)
+	if err := restoreAll(nil, nil); err == nil {
+		t.Fatal("restoreAll(nil, nil) = got err nil, want err")
+	}
+}
+
+func TestRestoreAll_BadFile(t *testing.T) {
+	// No server is started; a bad file must fail before dialing.
+	tmpAddr := address
+	address = "localhost:0"
+	defer func() { address = tmpAddr }()
+
+	setRestoreInContentsT(t, []byte("barcode,name\n123,chips\n"))
+	if err := restoreAll(nil, nil); err == nil {
+		t.Fatal("restoreAll(nil, nil) = got err nil, want err")
+	}
+}
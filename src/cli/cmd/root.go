@@ -19,40 +19,249 @@ limitations under the License.
 package cmd
 
 import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/signal"
 	"time"
 
+	"github.com/rmbarron/SnackInventory/src/cli/config"
+	"github.com/rmbarron/SnackInventory/src/client"
+	"github.com/rmbarron/SnackInventory/src/tracing"
 	"github.com/spf13/cobra"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 )
 
 var (
 	address     string
 	connTimeout time.Duration
+	rpcTimeout  time.Duration
+	traceFile   string
+	configPath  string
+	profileName string
+
+	// profile is the effective profile, set up before each subcommand runs.
+	profile = config.Default()
+	// transportOptions secure the connection to the backend, per profile.
+	transportOptions = []grpc.DialOption{grpc.WithInsecure()}
+
+	// tracer starts a trace for each RPC, propagated to the backend.
+	// Spans are only recorded locally if --trace_file is given.
+	tracer    = tracing.NewTracer(nil)
+	traceDest *os.File
 
 	rootCmd = &cobra.Command{
 		Use:   "snackinventory [--address] subcommand [--flags]",
 		Short: "A CLI for interacting with the SnackInventory backend.",
 		Long: `snackinventory allows for viewing, creating, and removing current
     inventory counts within the SnackInventory backend.`,
+		PersistentPreRunE:  setUp,
+		PersistentPostRunE: stopTracing,
 	}
 )
 
+// newClient dials the backend, as every subcommand does. Unary RPCs are
+// bounded by --rpc_timeout, & idempotent ones retried while it is unavailable.
+func newClient(ctx context.Context) (*client.Client, error) {
+	opts := client.DefaultOptions()
+	opts.DialTimeout = connTimeout
+	opts.RPCTimeout = rpcTimeout
+	opts.DialOptions = transportOptions
+	opts.UnaryInterceptors = []grpc.UnaryClientInterceptor{tracing.UnaryClientInterceptor(tracer)}
+	c, err := client.Dial(ctx, address, opts)
+	if err != nil {
+		return nil, fmt.Errorf("could not dial %s: %w", address, err)
+	}
+	return c, nil
+}
+
+// commandContext returns a context for a subcommand, cancelled if the user
+// interrupts the CLI with Ctrl-C. Call the returned function when done.
+func commandContext() (context.Context, func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	go func() {
+		select {
+		case <-interrupt:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	return ctx, func() {
+		signal.Stop(interrupt)
+		cancel()
+	}
+}
+
+func setUp(cmd *cobra.Command, args []string) error {
+	if err := loadProfile(cmd); err != nil {
+		return err
+	}
+	return startTracing(cmd, args)
+}
+
+// configFile returns the path of the config file, from --config or the default.
+func configFile() (string, error) {
+	if configPath != "" {
+		return configPath, nil
+	}
+	return config.DefaultPath()
+}
+
+// loadProfile sets up the effective profile: the selected profile of the config
+// file, overridden by the environment & explicitly set flags of cmd.
+func loadProfile(cmd *cobra.Command) error {
+	path, err := configFile()
+	if err != nil {
+		return err
+	}
+	f, err := config.Load(path)
+	if err != nil {
+		return err
+	}
+	p, err := f.Resolve(f.ProfileName(profileName, os.LookupEnv))
+	if err != nil {
+		return err
+	}
+	if err := p.ApplyEnv(os.LookupEnv); err != nil {
+		return err
+	}
+	// Flags share their names with config keys.
+	for _, key := range config.Keys() {
+		if fl := cmd.Flags().Lookup(key); fl != nil && fl.Changed {
+			if err := p.Set(key, fl.Value.String()); err != nil {
+				return err
+			}
+		}
+	}
+	if err := p.Validate(); err != nil {
+		return err
+	}
+	opts, err := transportDialOptions(p)
+	if err != nil {
+		return err
+	}
+
+	profile, transportOptions = p, opts
+	address, connTimeout, rpcTimeout, outputFormat = p.Address, p.DialTimeout, p.RPCTimeout, p.Output
+	return nil
+}
+
+// transportDialOptions returns the options to secure connections per p.
+func transportDialOptions(p *config.Profile) ([]grpc.DialOption, error) {
+	if !p.TLS {
+		return []grpc.DialOption{grpc.WithInsecure()}, nil
+	}
+	cfg := &tls.Config{ServerName: p.TLSServerName}
+	if p.TLSCAFile != "" {
+		pem, err := ioutil.ReadFile(p.TLSCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("could not read tls_ca_file: %w", err)
+		}
+		cfg.RootCAs = x509.NewCertPool()
+		if !cfg.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, errors.New("tls_ca_file: no PEM certificates found")
+		}
+	}
+	opts := []grpc.DialOption{grpc.WithTransportCredentials(credentials.NewTLS(cfg))}
+
+	token, err := p.ReadToken()
+	if err != nil {
+		return nil, err
+	}
+	if token != "" {
+		opts = append(opts, grpc.WithPerRPCCredentials(tokenCredentials(token)))
+	}
+	return opts, nil
+}
+
+// tokenCredentials sends a bearer token with each RPC.
+type tokenCredentials string
+
+func (t tokenCredentials) GetRequestMetadata(_ context.Context, _ ...string) (map[string]string, error) {
+	return map[string]string{"authorization": "Bearer " + string(t)}, nil
+}
+
+func (tokenCredentials) RequireTransportSecurity() bool {
+	return true
+}
+
+func startTracing(_ *cobra.Command, _ []string) error {
+	if traceFile == "" {
+		return nil
+	}
+	f, err := os.OpenFile(traceFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("could not open --trace_file: %w", err)
+	}
+	traceDest = f
+	tracer = tracing.NewTracer(tracing.NewJSONExporter(f))
+	return nil
+}
+
+func stopTracing(_ *cobra.Command, _ []string) error {
+	if traceDest == nil {
+		return nil
+	}
+	return traceDest.Close()
+}
+
 // Execute executes the root command.
 func Execute() error {
 	return rootCmd.Execute()
 }
 
 func init() {
+	d := config.Default()
+	rootCmd.PersistentFlags().StringVar(
+		&configPath, "config", "",
+		"Path to the config file. Defaults to ~/.config/snackinventory/config.yaml.")
 	rootCmd.PersistentFlags().StringVar(
-		&address, "address", "localhost:10000", "Address to contact SnackInventory backend.")
+		&profileName, "profile", "",
+		"Profile of the config file to use. Defaults to $"+config.ProfileEnv+", then the current profile.")
+	rootCmd.PersistentFlags().StringVar(
+		&address, "address", d.Address, "Address to contact SnackInventory backend.")
+	rootCmd.PersistentFlags().DurationVar(
+		&connTimeout, "dial_timeout", d.DialTimeout, "Timeout for connecting to backend.")
 	rootCmd.PersistentFlags().DurationVar(
-		&connTimeout, "dial_timeout", 30*time.Second, "Timeout for connecting to backend.")
-	rootCmd.MarkFlagRequired("address")
+		&rpcTimeout, "rpc_timeout", d.RPCTimeout,
+		"Timeout for each RPC to the backend, including retries. Streams, like watch, are not bounded. Disabled if 0.")
+	rootCmd.PersistentFlags().StringVar(
+		&traceFile, "trace_file", "", "If set, client trace spans are appended to this file as JSON lines.")
+	rootCmd.PersistentFlags().StringVarP(
+		&outputFormat, "output", "o", d.Output,
+		"Format of printed results. One of table, json, jsonl, csv, yaml, template.")
+	rootCmd.PersistentFlags().StringVar(
+		&outputTemplateText, "template", "",
+		"Go text/template executed for each result with --output=template, ex: '{{.barcode}} {{.name}}'.")
 
 	rootCmd.AddCommand(createSnackCmd)
 	rootCmd.AddCommand(listSnacksCmd)
 	rootCmd.AddCommand(updateSnackCmd)
 	rootCmd.AddCommand(deleteSnackCmd)
+	rootCmd.AddCommand(importCmd)
+	rootCmd.AddCommand(historyCmd)
 
 	rootCmd.AddCommand(listLocationsCmd)
 	rootCmd.AddCommand(createLocationCmd)
+	rootCmd.AddCommand(updateLocationCmd)
+	rootCmd.AddCommand(deleteLocationCmd)
+
+	rootCmd.AddCommand(trashCmd)
+
+	rootCmd.AddCommand(scanCmd)
+
+	rootCmd.AddCommand(watchCmd)
+
+	rootCmd.AddCommand(backupCmd)
+	rootCmd.AddCommand(restoreCmd)
+
+	rootCmd.AddCommand(configCmd)
+	rootCmd.AddCommand(shellCmd)
 }
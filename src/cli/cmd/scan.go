@@ -0,0 +1,244 @@
+/*
+Copyright 2020 Robert Barron
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package cmd provides the various subcommands of the SnackInventory CLI.
+// This file implements `scan`, a session recording stock from scanned barcodes.
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	sipb "github.com/rmbarron/SnackInventory/src/proto/snackinventory"
+	"github.com/spf13/cobra"
+)
+
+const (
+	scanModeIn  = "in"
+	scanModeOut = "out"
+	// scanUndo is typed instead of a barcode to undo the last scan.
+	scanUndo = "undo"
+)
+
+var (
+	scanLocation string
+	scanMode     string
+
+	scanCmd = &cobra.Command{
+		Use:   "scan [--location=name] [--mode=in|out]",
+		Short: "Record stock from barcodes scanned one per line.",
+		Long: `Read barcodes one per line from stdin, as typed by a keyboard-wedge
+    barcode scanner, and add (--mode=in) or remove (--mode=out) one of each at
+    --location, which defaults to the profile's default_location. Unknown
+    barcodes prompt for a name to register them under. Type "undo" to revert
+    the last scan. A summary of the session is printed at the end of input.`,
+		RunE: scanSession,
+	}
+)
+
+func init() {
+	scanCmd.Flags().StringVar(&scanLocation, "location", "", "Location to record stock at. Defaults to the profile's default_location.")
+	scanCmd.Flags().StringVar(&scanMode, "mode", scanModeIn, "Whether scanned snacks are put away (in) or taken out (out).")
+}
+
+func scanSession(_ *cobra.Command, _ []string) error {
+	location := scanLocation
+	if location == "" {
+		location = profile.DefaultLocation
+	}
+	if location == "" {
+		return errors.New("--location is required, unless the profile has a default_location")
+	}
+	var delta int32
+	switch scanMode {
+	case scanModeIn:
+		delta = 1
+	case scanModeOut:
+		delta = -1
+	default:
+		return fmt.Errorf("unsupported --mode %q; want %q or %q", scanMode, scanModeIn, scanModeOut)
+	}
+
+	ctx, cancel := commandContext()
+	defer cancel()
+	client, err := newClient(ctx)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	s, err := newScanner(ctx, client, os.Stdin, os.Stdout, location, delta)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Scanning %s %s. Type %q to revert the last scan; end input (Ctrl-D) when done.\n",
+		scanMode, location, scanUndo)
+	err = s.run(ctx)
+	s.printSummary()
+	if ctx.Err() != nil {
+		// Interrupted by the user.
+		return nil
+	}
+	return err
+}
+
+// scan is a stock change applied by a scanner, kept for undo.
+type scan struct {
+	barcode string
+	delta   int32
+}
+
+// scanner records stock changes for barcodes read from in.
+type scanner struct {
+	client   sipb.SnackInventoryClient
+	in       *bufio.Scanner
+	out      io.Writer
+	location string
+	delta    int32
+
+	// names of registered snacks, by barcode.
+	names map[string]string
+	// scans applied this session, most recent last.
+	scans []scan
+	// net change per barcode this session, with barcodes in order of first scan.
+	net      map[string]int32
+	barcodes []string
+}
+
+// newScanner starts a session adding delta of each scanned snack at location.
+func newScanner(ctx context.Context, client sipb.SnackInventoryClient, in io.Reader, out io.Writer, location string, delta int32) (*scanner, error) {
+	res, err := client.ListSnacks(ctx, &sipb.ListSnacksRequest{})
+	if err != nil {
+		return nil, fmt.Errorf("could not list snacks: %w", err)
+	}
+	s := &scanner{
+		client:   client,
+		in:       bufio.NewScanner(in),
+		out:      out,
+		location: location,
+		delta:    delta,
+		names:    map[string]string{},
+		net:      map[string]int32{},
+	}
+	for _, snack := range res.GetSnacks() {
+		s.names[snack.GetBarcode()] = snack.GetName()
+	}
+	return s, nil
+}
+
+// run handles lines until the end of input. A failed scan is reported and the
+// session continues, so one bad barcode doesn't lose the rest.
+func (s *scanner) run(ctx context.Context) error {
+	for s.in.Scan() {
+		line := strings.TrimSpace(s.in.Text())
+		var err error
+		switch line {
+		case "":
+			continue
+		case scanUndo:
+			err = s.undo(ctx)
+		default:
+			err = s.scan(ctx, line)
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil {
+			fmt.Fprintf(s.out, "Error: %v\n", err)
+		}
+	}
+	return s.in.Err()
+}
+
+// scan records one scan of barcode, registering it first if unknown.
+func (s *scanner) scan(ctx context.Context, barcode string) error {
+	if _, ok := s.names[barcode]; !ok {
+		fmt.Fprintf(s.out, "Unknown barcode %s. Name (blank to skip): ", barcode)
+		if !s.in.Scan() {
+			fmt.Fprintln(s.out)
+			return s.in.Err()
+		}
+		name := strings.TrimSpace(s.in.Text())
+		if name == "" {
+			fmt.Fprintf(s.out, "Skipped %s\n", barcode)
+			return nil
+		}
+		snack := &sipb.Snack{Barcode: barcode, Name: name}
+		if _, err := s.client.CreateSnack(ctx, &sipb.CreateSnackRequest{Snack: snack}); err != nil {
+			return fmt.Errorf("could not create snack: %w", err)
+		}
+		s.names[barcode] = name
+	}
+	count, err := s.adjust(ctx, barcode, s.delta)
+	if err != nil {
+		return err
+	}
+	s.scans = append(s.scans, scan{barcode, s.delta})
+	fmt.Fprintf(s.out, "%+d %s: %d at %s (%d scanned)\n", s.delta, s.label(barcode), count, s.location, len(s.scans))
+	return nil
+}
+
+// undo reverts the most recent scan not yet undone. Snacks registered by a
+// scan stay registered.
+func (s *scanner) undo(ctx context.Context) error {
+	if len(s.scans) == 0 {
+		return errors.New("nothing to undo")
+	}
+	last := s.scans[len(s.scans)-1]
+	count, err := s.adjust(ctx, last.barcode, -last.delta)
+	if err != nil {
+		return err
+	}
+	s.scans = s.scans[:len(s.scans)-1]
+	fmt.Fprintf(s.out, "Undid %+d %s: %d at %s (%d scanned)\n", last.delta, s.label(last.barcode), count, s.location, len(s.scans))
+	return nil
+}
+
+// adjust changes the stock of barcode, returning the new count.
+func (s *scanner) adjust(ctx context.Context, barcode string, delta int32) (int32, error) {
+	res, err := s.client.AdjustStock(ctx, &sipb.AdjustStockRequest{Barcode: barcode, Location: s.location, Delta: delta})
+	if err != nil {
+		return 0, fmt.Errorf("could not adjust stock of %s: %w", barcode, err)
+	}
+	if _, ok := s.net[barcode]; !ok {
+		s.barcodes = append(s.barcodes, barcode)
+	}
+	s.net[barcode] += delta
+	return res.GetStock().GetCount(), nil
+}
+
+// label describes a snack for people, ex: "chips (123)".
+func (s *scanner) label(barcode string) string {
+	if name := s.names[barcode]; name != "" {
+		return fmt.Sprintf("%s (%s)", name, barcode)
+	}
+	return barcode
+}
+
+// printSummary prints the net change of each snack scanned this session.
+func (s *scanner) printSummary() {
+	fmt.Fprintf(s.out, "Session at %s: %d scanned\n", s.location, len(s.scans))
+	for _, barcode := range s.barcodes {
+		if n := s.net[barcode]; n != 0 {
+			fmt.Fprintf(s.out, "  %+d %s\n", n, s.label(barcode))
+		}
+	}
+}
@@ -0,0 +1,117 @@
+/*
+Copyright 2020 Robert Barron
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// scanT runs a scan session over input, returning its output.
+func scanT(t *testing.T, c *stubClient, input string, delta int32) string {
+	t.Helper()
+	ctx := context.Background()
+	var out bytes.Buffer
+	s, err := newScanner(ctx, c, strings.NewReader(input), &out, "pantry", delta)
+	if err != nil {
+		t.Fatalf("newScanner(ctx, ...) = got err %v, want err nil", err)
+	}
+	if err := s.run(ctx); err != nil {
+		t.Fatalf("s.run(ctx) = got err %v, want err nil", err)
+	}
+	s.printSummary()
+	return out.String()
+}
+
+func TestScan(t *testing.T) {
+	c := newStubClient()
+	out := scanT(t, c, "123\n\n999\ntrail mix\n123\n200\nundo\n", 1)
+
+	want := []string{
+		"AdjustStock 123 pantry +1",
+		"CreateSnack 999 trail mix",
+		"AdjustStock 999 pantry +1",
+		"AdjustStock 123 pantry +1",
+		"AdjustStock 200 pantry +1",
+		"AdjustStock 200 pantry -1",
+	}
+	if diff := cmp.Diff(want, c.calls); diff != "" {
+		t.Errorf("scan = got diff in calls (-want +got):\n%s", diff)
+	}
+	for _, line := range []string{
+		"+1 chips (123): 2 at pantry (3 scanned)",
+		"Undid +1 soda (200): 0 at pantry (3 scanned)",
+		"Session at pantry: 3 scanned\n  +2 chips (123)\n  +1 trail mix (999)\n",
+	} {
+		if !strings.Contains(out, line) {
+			t.Errorf("scan = got output %q, want it to contain %q", out, line)
+		}
+	}
+}
+
+func TestScan_Out(t *testing.T) {
+	c := newStubClient()
+	c.stock = map[string]int32{"123@pantry": 1}
+	// The second scan is out of stock; the session carries on.
+	out := scanT(t, c, "123\n123\nundo\nundo\n", -1)
+
+	want := []string{
+		"AdjustStock 123 pantry -1",
+		"AdjustStock 123 pantry -1",
+		"AdjustStock 123 pantry +1",
+	}
+	if diff := cmp.Diff(want, c.calls); diff != "" {
+		t.Errorf("scan = got diff in calls (-want +got):\n%s", diff)
+	}
+	for _, line := range []string{"Error: could not adjust stock of 123", "Error: nothing to undo"} {
+		if !strings.Contains(out, line) {
+			t.Errorf("scan = got output %q, want it to contain %q", out, line)
+		}
+	}
+}
+
+func TestScan_SkipUnknown(t *testing.T) {
+	c := newStubClient()
+	out := scanT(t, c, "999\n\n", 1)
+
+	if len(c.calls) != 0 {
+		t.Errorf("scan = got calls %v, want none", c.calls)
+	}
+	if !strings.Contains(out, "Skipped 999") {
+		t.Errorf("scan = got output %q, want it to contain %q", out, "Skipped 999")
+	}
+}
+
+func TestScanSession_BadFlags(t *testing.T) {
+	tmpLocation, tmpMode, tmpDefault := scanLocation, scanMode, profile.DefaultLocation
+	defer func() { scanLocation, scanMode, profile.DefaultLocation = tmpLocation, tmpMode, tmpDefault }()
+
+	for _, tc := range []struct{ location, mode, defaultLocation string }{
+		{"", scanModeIn, ""},
+		{"pantry", "sideways", ""},
+		{"", "sideways", "pantry"},
+	} {
+		scanLocation, scanMode, profile.DefaultLocation = tc.location, tc.mode, tc.defaultLocation
+		if err := scanSession(nil, nil); err == nil {
+			t.Errorf("scanSession(nil, nil) with --location=%q --mode=%q default_location=%q = got err nil, want err",
+				tc.location, tc.mode, tc.defaultLocation)
+		}
+	}
+}
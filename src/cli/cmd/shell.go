@@ -0,0 +1,389 @@
+/*
+Copyright 2020 Robert Barron
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package cmd provides the various subcommands of the SnackInventory CLI.
+// This file implements `shell`, an interactive mode sharing one connection.
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/peterh/liner"
+	sipb "github.com/rmbarron/SnackInventory/src/proto/snackinventory"
+	"github.com/spf13/cobra"
+	"google.golang.org/protobuf/proto"
+)
+
+var shellCmd = &cobra.Command{
+	Use:   "shell",
+	Short: "Start an interactive SnackInventory shell.",
+	Long: `Start an interactive shell, keeping one connection to the backend.
+    Supports command history, tab completion of commands, barcodes & location
+    names, and a full-screen view to browse & edit snacks. Type "help" in the
+    shell for its commands.`,
+	RunE: runShell,
+}
+
+// argKind is the kind of value an argument of a shell command takes, for
+// completion.
+type argKind int
+
+const (
+	argNone argKind = iota
+	argBarcode
+	argLocation
+)
+
+// shellCommand is a command of the shell.
+type shellCommand struct {
+	name  string
+	usage string
+	help  string
+	// arg is completed for the first argument.
+	arg argKind
+	// minArgs is the number of required arguments.
+	minArgs int
+	run     func(sh *shell, ctx context.Context, args []string) error
+}
+
+// errQuit ends the shell.
+var errQuit = errors.New("quit")
+
+// shellCommands are the commands of the shell, in the order of "help".
+var shellCommands []*shellCommand
+
+func init() {
+	// Assigned in init, as "help" refers to shellCommands.
+	shellCommands = []*shellCommand{
+		{name: "snacks", help: "List all snacks.", run: (*shell).listSnacks},
+		{name: "add", usage: "barcode [name...]", help: "Register a snack.", minArgs: 1, run: (*shell).addSnack},
+		{name: "rename", usage: "barcode name...", help: "Rename a snack.", arg: argBarcode, minArgs: 2, run: (*shell).renameSnack},
+		{name: "rm", usage: "barcode", help: "Delete a snack.", arg: argBarcode, minArgs: 1, run: (*shell).removeSnack},
+		{name: "locations", help: "List all locations.", run: (*shell).listLocations},
+		{name: "addloc", usage: "[parent/]name", help: "Register a location, inside parent if given.", minArgs: 1, run: (*shell).addLocation},
+		{name: "rmloc", usage: "name", help: "Delete a location.", arg: argLocation, minArgs: 1, run: (*shell).removeLocation},
+		{name: "browse", help: "Browse & edit snacks in a full-screen table.", run: (*shell).browse},
+		{name: "help", help: "Print this help.", run: (*shell).help},
+		{name: "exit", help: "Leave the shell. Ctrl-D also works.", run: func(*shell, context.Context, []string) error { return errQuit }},
+	}
+}
+
+// shell runs shell commands against the backend.
+type shell struct {
+	client sipb.SnackInventoryClient
+	out    io.Writer
+	// snacks & locations are cached for completion, refreshed after changes.
+	snacks    []*sipb.Snack
+	locations []*sipb.Location
+	// browseFn runs the full-screen view; replaced in tests.
+	browseFn func(ctx context.Context, client sipb.SnackInventoryClient) error
+}
+
+func runShell(_ *cobra.Command, _ []string) error {
+	ctx, cancel := commandContext()
+	client, err := newClient(ctx)
+	cancel()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	sh := &shell{client: client, out: os.Stdout, browseFn: browseSnacks}
+	if err := sh.refresh(context.Background()); err != nil {
+		return err
+	}
+
+	historyPath := ""
+	if path, err := configFile(); err == nil {
+		historyPath = filepath.Join(filepath.Dir(path), "shell_history")
+	}
+	line := newLineReader(sh, historyPath)
+	defer func() { line.Close() }()
+
+	fmt.Fprintf(sh.out, "Connected to %s. Type \"help\" for commands.\n", address)
+	for {
+		input, err := line.Prompt("snackinventory> ")
+		if errors.Is(err, liner.ErrPromptAborted) {
+			continue
+		}
+		if errors.Is(err, io.EOF) {
+			fmt.Fprintln(sh.out)
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if strings.TrimSpace(input) == "" {
+			continue
+		}
+		line.AppendHistory(input)
+
+		if cmd, _ := parseShellLine(input); cmd == "browse" {
+			// The full-screen view needs the terminal to itself.
+			saveHistory(line, historyPath)
+			line.Close()
+			err = sh.exec(input)
+			line = newLineReader(sh, historyPath)
+		} else {
+			err = sh.exec(input)
+		}
+		if errors.Is(err, errQuit) {
+			break
+		}
+		if err != nil {
+			fmt.Fprintf(sh.out, "Error: %v\n", err)
+		}
+	}
+	saveHistory(line, historyPath)
+	return nil
+}
+
+// newLineReader starts line editing with history from historyPath, if any.
+func newLineReader(sh *shell, historyPath string) *liner.State {
+	line := liner.NewLiner()
+	line.SetCtrlCAborts(true)
+	line.SetTabCompletionStyle(liner.TabPrints)
+	line.SetWordCompleter(sh.complete)
+	if historyPath != "" {
+		if f, err := os.Open(historyPath); err == nil {
+			line.ReadHistory(f)
+			f.Close()
+		}
+	}
+	return line
+}
+
+// saveHistory writes the history of line to historyPath, if set. Failures are
+// ignored; history is a convenience.
+func saveHistory(line *liner.State, historyPath string) {
+	if historyPath == "" {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(historyPath), 0700); err != nil {
+		return
+	}
+	if f, err := os.OpenFile(historyPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600); err == nil {
+		line.WriteHistory(f)
+		f.Close()
+	}
+}
+
+// parseShellLine splits a line into its command & arguments.
+func parseShellLine(line string) (string, []string) {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return "", nil
+	}
+	return fields[0], fields[1:]
+}
+
+// findShellCommand returns the command called name, or nil.
+func findShellCommand(name string) *shellCommand {
+	for _, c := range shellCommands {
+		if c.name == name {
+			return c
+		}
+	}
+	return nil
+}
+
+// exec runs a line of input. Each line may be cancelled with Ctrl-C.
+func (sh *shell) exec(line string) error {
+	name, args := parseShellLine(line)
+	c := findShellCommand(name)
+	if c == nil {
+		return fmt.Errorf("unknown command %q; type \"help\" for commands", name)
+	}
+	if len(args) < c.minArgs {
+		return fmt.Errorf("usage: %s %s", c.name, c.usage)
+	}
+	ctx, cancel := commandContext()
+	defer cancel()
+	return c.run(sh, ctx, args)
+}
+
+// complete completes the word before pos, for liner.
+func (sh *shell) complete(line string, pos int) (head string, completions []string, tail string) {
+	head, tail = line[:pos], line[pos:]
+	start := strings.LastIndexAny(head, " \t") + 1
+	prefix := head[start:]
+	head = head[:start]
+
+	var candidates []string
+	fields := strings.Fields(head)
+	switch len(fields) {
+	case 0:
+		for _, c := range shellCommands {
+			candidates = append(candidates, c.name)
+		}
+	case 1:
+		c := findShellCommand(fields[0])
+		if c == nil {
+			return head, nil, tail
+		}
+		switch c.arg {
+		case argBarcode:
+			for _, s := range sh.snacks {
+				candidates = append(candidates, s.GetBarcode())
+			}
+		case argLocation:
+			for _, l := range sh.locations {
+				candidates = append(candidates, l.GetName())
+			}
+		}
+	}
+	for _, c := range candidates {
+		if strings.HasPrefix(c, prefix) {
+			completions = append(completions, c)
+		}
+	}
+	sort.Strings(completions)
+	return head, completions, tail
+}
+
+// refresh reloads the cached snacks & locations.
+func (sh *shell) refresh(ctx context.Context) error {
+	snacks, err := sh.client.ListSnacks(ctx, &sipb.ListSnacksRequest{})
+	if err != nil {
+		return fmt.Errorf("could not list snacks: %w", err)
+	}
+	locations, err := sh.client.ListLocations(ctx, &sipb.ListLocationsRequest{})
+	if err != nil {
+		return fmt.Errorf("could not list locations: %w", err)
+	}
+	sh.snacks, sh.locations = snacks.GetSnacks(), locations.GetLocations()
+	return nil
+}
+
+func (sh *shell) listSnacks(ctx context.Context, _ []string) error {
+	if err := sh.refresh(ctx); err != nil {
+		return err
+	}
+	p, err := newPrinter(sh.out, (&sipb.Snack{}).ProtoReflect().Descriptor(), false)
+	if err != nil {
+		return err
+	}
+	for _, snack := range sh.snacks {
+		if err := p.Print(snack); err != nil {
+			return err
+		}
+	}
+	return p.Flush()
+}
+
+func (sh *shell) addSnack(ctx context.Context, args []string) error {
+	snack := &sipb.Snack{Barcode: args[0], Name: strings.Join(args[1:], " ")}
+	res, err := sh.client.CreateSnack(ctx, &sipb.CreateSnackRequest{Snack: snack})
+	if err != nil {
+		return fmt.Errorf("could not create snack: %w", err)
+	}
+	if name := res.GetSnack().GetName(); name != "" && snack.GetName() == "" {
+		fmt.Fprintf(sh.out, "Added %s, looked up as %q\n", snack.GetBarcode(), name)
+	} else {
+		fmt.Fprintf(sh.out, "Added %s\n", snack.GetBarcode())
+	}
+	return sh.refresh(ctx)
+}
+
+// cachedSnack returns a copy of the cached snack with barcode, or a snack with
+// only the barcode set if it isn't cached.
+func (sh *shell) cachedSnack(barcode string) *sipb.Snack {
+	for _, s := range sh.snacks {
+		if s.GetBarcode() == barcode {
+			return proto.Clone(s).(*sipb.Snack)
+		}
+	}
+	return &sipb.Snack{Barcode: barcode}
+}
+
+func (sh *shell) renameSnack(ctx context.Context, args []string) error {
+	// Updates write every field, so keep the rest of the snack as is.
+	snack := sh.cachedSnack(args[0])
+	snack.Name = strings.Join(args[1:], " ")
+	if _, err := sh.client.UpdateSnack(ctx, &sipb.UpdateSnackRequest{Snack: snack}); err != nil {
+		return fmt.Errorf("could not update snack: %w", err)
+	}
+	fmt.Fprintf(sh.out, "Renamed %s\n", snack.GetBarcode())
+	return sh.refresh(ctx)
+}
+
+func (sh *shell) removeSnack(ctx context.Context, args []string) error {
+	if _, err := sh.client.DeleteSnack(ctx, &sipb.DeleteSnackRequest{Barcode: args[0]}); err != nil {
+		return fmt.Errorf("could not delete snack: %w", err)
+	}
+	fmt.Fprintf(sh.out, "Deleted %s\n", args[0])
+	return sh.refresh(ctx)
+}
+
+func (sh *shell) listLocations(ctx context.Context, _ []string) error {
+	if err := sh.refresh(ctx); err != nil {
+		return err
+	}
+	p, err := newPrinter(sh.out, (&sipb.Location{}).ProtoReflect().Descriptor(), false)
+	if err != nil {
+		return err
+	}
+	for _, l := range sh.locations {
+		if err := p.Print(l); err != nil {
+			return err
+		}
+	}
+	return p.Flush()
+}
+
+func (sh *shell) addLocation(ctx context.Context, args []string) error {
+	path := strings.Join(args, " ")
+	// The location is named by the last element of a path, ex: Garage/Freezer.
+	location := &sipb.Location{Name: path}
+	if i := strings.LastIndex(path, "/"); i >= 0 {
+		location.Parent, location.Name = path[:i], path[i+1:]
+	}
+	if _, err := sh.client.CreateLocation(ctx, &sipb.CreateLocationRequest{Location: location}); err != nil {
+		return fmt.Errorf("could not create location: %w", err)
+	}
+	fmt.Fprintf(sh.out, "Added location %s\n", path)
+	return sh.refresh(ctx)
+}
+
+func (sh *shell) removeLocation(ctx context.Context, args []string) error {
+	name := strings.Join(args, " ")
+	if _, err := sh.client.DeleteLocation(ctx, &sipb.DeleteLocationRequest{Name: name}); err != nil {
+		return fmt.Errorf("could not delete location: %w", err)
+	}
+	fmt.Fprintf(sh.out, "Deleted location %s\n", name)
+	return sh.refresh(ctx)
+}
+
+func (sh *shell) browse(ctx context.Context, _ []string) error {
+	if err := sh.browseFn(ctx, sh.client); err != nil {
+		return err
+	}
+	return sh.refresh(ctx)
+}
+
+func (sh *shell) help(context.Context, []string) error {
+	for _, c := range shellCommands {
+		fmt.Fprintf(sh.out, "  %-28s %s\n", strings.TrimSpace(c.name+" "+c.usage), c.help)
+	}
+	return nil
+}
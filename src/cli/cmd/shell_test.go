@@ -0,0 +1,275 @@
+/*
+Copyright 2020 Robert Barron
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	sipb "github.com/rmbarron/SnackInventory/src/proto/snackinventory"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// stubClient serves snacks & locations from memory, recording the RPCs called.
+// RPCs it doesn't implement panic.
+type stubClient struct {
+	sipb.SnackInventoryClient
+	snacks    []*sipb.Snack
+	locations []*sipb.Location
+	// stock counts, by barcode & location joined by "@".
+	stock map[string]int32
+	calls []string
+}
+
+func (c *stubClient) ListSnacks(context.Context, *sipb.ListSnacksRequest, ...grpc.CallOption) (*sipb.ListSnacksResponse, error) {
+	return &sipb.ListSnacksResponse{Snacks: c.snacks}, nil
+}
+
+func (c *stubClient) ListLocations(context.Context, *sipb.ListLocationsRequest, ...grpc.CallOption) (*sipb.ListLocationsResponse, error) {
+	return &sipb.ListLocationsResponse{Locations: c.locations}, nil
+}
+
+func (c *stubClient) CreateSnack(_ context.Context, req *sipb.CreateSnackRequest, _ ...grpc.CallOption) (*sipb.CreateSnackResponse, error) {
+	c.calls = append(c.calls, "CreateSnack "+req.GetSnack().GetBarcode()+" "+req.GetSnack().GetName())
+	c.snacks = append(c.snacks, req.GetSnack())
+	return &sipb.CreateSnackResponse{}, nil
+}
+
+func (c *stubClient) UpdateSnack(_ context.Context, req *sipb.UpdateSnackRequest, _ ...grpc.CallOption) (*sipb.UpdateSnackResponse, error) {
+	c.calls = append(c.calls, "UpdateSnack "+req.GetSnack().GetBarcode()+" "+req.GetSnack().GetName())
+	return &sipb.UpdateSnackResponse{}, nil
+}
+
+func (c *stubClient) DeleteSnack(_ context.Context, req *sipb.DeleteSnackRequest, _ ...grpc.CallOption) (*sipb.DeleteSnackResponse, error) {
+	c.calls = append(c.calls, "DeleteSnack "+req.GetBarcode())
+	return &sipb.DeleteSnackResponse{}, nil
+}
+
+func (c *stubClient) CreateLocation(_ context.Context, req *sipb.CreateLocationRequest, _ ...grpc.CallOption) (*sipb.CreateLocationResponse, error) {
+	call := "CreateLocation " + req.GetLocation().GetName()
+	if req.GetLocation().GetParent() != "" {
+		call += " in " + req.GetLocation().GetParent()
+	}
+	c.calls = append(c.calls, call)
+	return &sipb.CreateLocationResponse{}, nil
+}
+
+func (c *stubClient) DeleteLocation(_ context.Context, req *sipb.DeleteLocationRequest, _ ...grpc.CallOption) (*sipb.DeleteLocationResponse, error) {
+	if req.GetName() == "missing" {
+		return nil, status.Error(codes.NotFound, "no such location")
+	}
+	c.calls = append(c.calls, "DeleteLocation "+req.GetName())
+	return &sipb.DeleteLocationResponse{}, nil
+}
+
+func (c *stubClient) AdjustStock(_ context.Context, req *sipb.AdjustStockRequest, _ ...grpc.CallOption) (*sipb.AdjustStockResponse, error) {
+	c.calls = append(c.calls, fmt.Sprintf("AdjustStock %s %s %+d", req.GetBarcode(), req.GetLocation(), req.GetDelta()))
+	key := req.GetBarcode() + "@" + req.GetLocation()
+	if c.stock[key]+req.GetDelta() < 0 {
+		return nil, status.Error(codes.FailedPrecondition, "out of stock")
+	}
+	if c.stock == nil {
+		c.stock = map[string]int32{}
+	}
+	c.stock[key] += req.GetDelta()
+	return &sipb.AdjustStockResponse{
+		Stock: &sipb.Stock{Barcode: req.GetBarcode(), Location: req.GetLocation(), Count: c.stock[key]},
+	}, nil
+}
+
+func (c *stubClient) ListStock(_ context.Context, req *sipb.ListStockRequest, _ ...grpc.CallOption) (*sipb.ListStockResponse, error) {
+	res := &sipb.ListStockResponse{}
+	for key, count := range c.stock {
+		parts := strings.SplitN(key, "@", 2)
+		if count > 0 && (req.GetLocation() == "" || req.GetLocation() == parts[1]) {
+			res.Stock = append(res.Stock, &sipb.Stock{Barcode: parts[0], Location: parts[1], Count: count})
+		}
+	}
+	sort.Slice(res.Stock, func(i, j int) bool { return res.Stock[i].GetBarcode() < res.Stock[j].GetBarcode() })
+	return res, nil
+}
+
+func newStubClient() *stubClient {
+	return &stubClient{
+		snacks: []*sipb.Snack{
+			{Barcode: "123", Name: "chips"},
+			{Barcode: "124", Name: "pretzels"},
+			{Barcode: "200", Name: "soda"},
+		},
+		locations: []*sipb.Location{{Name: "pantry"}, {Name: "fridge"}},
+	}
+}
+
+func TestShellExec(t *testing.T) {
+	c := newStubClient()
+	var out bytes.Buffer
+	sh := &shell{client: c, out: &out}
+	for _, line := range []string{
+		"add 300 trail mix",
+		"rename 123 salty chips",
+		"rm 124",
+		"addloc top shelf",
+		"addloc pantry/top shelf/left bin",
+		"rmloc fridge",
+	} {
+		if err := sh.exec(line); err != nil {
+			t.Fatalf("sh.exec(%q) = got err %v, want err nil", line, err)
+		}
+	}
+
+	want := []string{
+		"CreateSnack 300 trail mix",
+		"UpdateSnack 123 salty chips",
+		"DeleteSnack 124",
+		"CreateLocation top shelf",
+		"CreateLocation left bin in pantry/top shelf",
+		"DeleteLocation fridge",
+	}
+	if diff := cmp.Diff(want, c.calls); diff != "" {
+		t.Errorf("sh.exec(...) = got diff in calls (-want +got):\n%s", diff)
+	}
+}
+
+func TestShellExec_List(t *testing.T) {
+	setOutputT(t, outputTable, "")
+	var out bytes.Buffer
+	sh := &shell{client: newStubClient(), out: &out}
+	if err := sh.exec("snacks"); err != nil {
+		t.Fatalf("sh.exec(%q) = got err %v, want err nil", "snacks", err)
+	}
+	for _, want := range []string{"BARCODE", "123", "pretzels"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("sh.exec(%q) = got output %q, want it to contain %q", "snacks", out.String(), want)
+		}
+	}
+}
+
+func TestShellExec_Errors(t *testing.T) {
+	for _, line := range []string{
+		// Unknown command.
+		"eat 123",
+		// Missing arguments.
+		"rename 123",
+		"rm",
+		// Server errors are returned.
+		"rmloc missing",
+	} {
+		sh := &shell{client: newStubClient(), out: &bytes.Buffer{}}
+		if err := sh.exec(line); err == nil {
+			t.Errorf("sh.exec(%q) = got err nil, want err", line)
+		}
+	}
+}
+
+func TestShellExec_Exit(t *testing.T) {
+	sh := &shell{client: newStubClient(), out: &bytes.Buffer{}}
+	if err := sh.exec("exit"); err != errQuit {
+		t.Errorf("sh.exec(%q) = got err %v, want err %v", "exit", err, errQuit)
+	}
+}
+
+func TestShellExec_Browse(t *testing.T) {
+	c := newStubClient()
+	browsed := false
+	sh := &shell{
+		client: c,
+		out:    &bytes.Buffer{},
+		browseFn: func(_ context.Context, client sipb.SnackInventoryClient) error {
+			browsed = client == c
+			return nil
+		},
+	}
+	if err := sh.exec("browse"); err != nil {
+		t.Fatalf("sh.exec(%q) = got err %v, want err nil", "browse", err)
+	}
+	if !browsed {
+		t.Error("sh.exec(\"browse\") = got browseFn not called with the shell's client, want called")
+	}
+}
+
+func TestShellComplete(t *testing.T) {
+	sh := &shell{client: newStubClient()}
+	if err := sh.refresh(context.Background()); err != nil {
+		t.Fatalf("sh.refresh(ctx) = got err %v, want err nil", err)
+	}
+	for _, tc := range []struct {
+		desc     string
+		line     string
+		pos      int
+		wantHead string
+		want     []string
+		wantTail string
+	}{
+		{
+			desc: "command names",
+			line: "re",
+			pos:  2,
+			want: []string{"rename"},
+		},
+		{
+			desc:     "barcodes",
+			line:     "rm 12",
+			pos:      5,
+			wantHead: "rm ",
+			want:     []string{"123", "124"},
+		},
+		{
+			desc:     "locations",
+			line:     "rmloc f",
+			pos:      7,
+			wantHead: "rmloc ",
+			want:     []string{"fridge"},
+		},
+		{
+			desc:     "mid line",
+			line:     "rm 2 now",
+			pos:      4,
+			wantHead: "rm ",
+			want:     []string{"200"},
+			wantTail: " now",
+		},
+		{
+			desc:     "only the first argument",
+			line:     "rename 123 ",
+			pos:      11,
+			wantHead: "rename 123 ",
+		},
+		{
+			desc:     "unknown command",
+			line:     "eat 1",
+			pos:      5,
+			wantHead: "eat ",
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			head, got, tail := sh.complete(tc.line, tc.pos)
+			if head != tc.wantHead || tail != tc.wantTail {
+				t.Errorf("sh.complete(%q, %d) = got head %q tail %q, want head %q tail %q",
+					tc.line, tc.pos, head, tail, tc.wantHead, tc.wantTail)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("sh.complete(%q, %d) = got diff (-want +got):\n%s", tc.line, tc.pos, diff)
+			}
+		})
+	}
+}
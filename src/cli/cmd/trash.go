@@ -0,0 +1,202 @@
+/*
+Copyright 2020 Robert Barron
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package cmd provides the various subcommands of the SnackInventory CLI.
+// This file implements the `trash` subcommands, listing, restoring & purging
+// deleted snacks and locations.
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	sipb "github.com/rmbarron/SnackInventory/src/proto/snackinventory"
+)
+
+var (
+	trashRestoreBarcode  string
+	trashRestoreLocation string
+	trashPurgeOlderThan  time.Duration
+	trashPurgeYes        bool
+
+	trashCmd = &cobra.Command{
+		Use:   "trash subcommand",
+		Short: "List, restore & purge deleted snacks and locations.",
+		Long: `Deleted snacks and locations are kept in the trash until purged,
+    and may be restored until then. Their barcodes and names can't be reused
+    while they are in the trash.`,
+	}
+
+	trashListCmd = &cobra.Command{
+		Use:   "list",
+		Short: "List the snacks & locations in the trash.",
+		Long:  "List the snacks & locations in the trash, most recently deleted first.",
+		Args:  cobra.NoArgs,
+		RunE:  trashList,
+	}
+
+	trashRestoreCmd = &cobra.Command{
+		Use:   "restore [--flags]",
+		Short: "Restore a snack or location from the trash.",
+		Long: `Restore a snack and/or location from the trash. At least one of
+    --barcode and --location is required. A restored snack gets its stock back.`,
+		Args: cobra.NoArgs,
+		RunE: trashRestore,
+	}
+
+	trashPurgeCmd = &cobra.Command{
+		Use:   "purge [--flags]",
+		Short: "Permanently remove snacks & locations from the trash.",
+		Long: `Permanently remove snacks & locations from the trash, along with the
+    stock of the snacks. With --older_than, only those deleted longer ago than
+    that are removed. Asks for confirmation unless --yes is given.`,
+		Args: cobra.NoArgs,
+		RunE: trashPurge,
+	}
+)
+
+func init() {
+	trashRestoreCmd.Flags().StringVar(&trashRestoreBarcode, "barcode", "", "Barcode of the snack to restore.")
+	trashRestoreCmd.Flags().StringVar(&trashRestoreLocation, "location", "", "Name of the location to restore.")
+	trashPurgeCmd.Flags().DurationVar(
+		&trashPurgeOlderThan, "older_than", 0, "If set, only purge entries deleted longer ago than this, ex: 720h.")
+	trashPurgeCmd.Flags().BoolVar(&trashPurgeYes, "yes", false, "Purge without asking for confirmation.")
+
+	trashCmd.AddCommand(trashListCmd)
+	trashCmd.AddCommand(trashRestoreCmd)
+	trashCmd.AddCommand(trashPurgeCmd)
+}
+
+func trashList(_ *cobra.Command, _ []string) error {
+	// The default table output is one human readable line per entry.
+	var p printer
+	if outputFormat != outputTable {
+		var err error
+		if p, err = newPrinter(os.Stdout, (&sipb.DeletedEntity{}).ProtoReflect().Descriptor(), false); err != nil {
+			return err
+		}
+	}
+
+	ctx, cancel := commandContext()
+	defer cancel()
+	client, err := newClient(ctx)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	res, err := client.ListDeleted(ctx, &sipb.ListDeletedRequest{})
+	if err != nil {
+		return fmt.Errorf("could not list trash: %v", err)
+	}
+	if p == nil {
+		for _, d := range res.GetDeleted() {
+			fmt.Println(formatDeleted(d))
+		}
+		return nil
+	}
+	for _, d := range res.GetDeleted() {
+		if err := p.Print(d); err != nil {
+			return err
+		}
+	}
+	return p.Flush()
+}
+
+// formatDeleted returns a single line describing a trash entry.
+func formatDeleted(d *sipb.DeletedEntity) string {
+	var entity string
+	switch e := d.GetEntity().(type) {
+	case *sipb.DeletedEntity_Snack:
+		entity = fmt.Sprintf("snack %q %s", e.Snack.GetBarcode(), e.Snack.GetName())
+	case *sipb.DeletedEntity_Location:
+		entity = fmt.Sprintf("location %q", e.Location.GetName())
+	default:
+		entity = "unknown entity"
+	}
+	return fmt.Sprintf("%s %s", d.GetDeleteTime().AsTime().Local().Format(time.RFC3339), entity)
+}
+
+func trashRestore(_ *cobra.Command, _ []string) error {
+	if trashRestoreBarcode == "" && trashRestoreLocation == "" {
+		return errors.New("at least one of --barcode and --location is required")
+	}
+
+	ctx, cancel := commandContext()
+	defer cancel()
+	client, err := newClient(ctx)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	// Restore the location first, so a failed snack restore doesn't hide it.
+	if trashRestoreLocation != "" {
+		if _, err := client.UndeleteLocation(ctx, &sipb.UndeleteLocationRequest{Name: trashRestoreLocation}); err != nil {
+			return fmt.Errorf("could not restore location: %w", err)
+		}
+		fmt.Printf("Restored location %q\n", trashRestoreLocation)
+	}
+	if trashRestoreBarcode != "" {
+		if _, err := client.UndeleteSnack(ctx, &sipb.UndeleteSnackRequest{Barcode: trashRestoreBarcode}); err != nil {
+			return fmt.Errorf("could not restore snack: %w", err)
+		}
+		fmt.Printf("Restored snack %q\n", trashRestoreBarcode)
+	}
+	return nil
+}
+
+func trashPurge(_ *cobra.Command, _ []string) error {
+	if trashPurgeOlderThan < 0 {
+		return errors.New("--older_than must not be negative")
+	}
+	req := &sipb.PurgeDeletedRequest{}
+	prompt := "Permanently remove everything in the trash?"
+	if trashPurgeOlderThan > 0 {
+		req.OlderThan = durationpb.New(trashPurgeOlderThan)
+		prompt = fmt.Sprintf("Permanently remove everything deleted more than %v ago?", trashPurgeOlderThan)
+	}
+	if !trashPurgeYes {
+		ok, err := confirm(os.Stdin, os.Stdout, prompt)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			fmt.Println("Trash not purged.")
+			return nil
+		}
+	}
+
+	ctx, cancel := commandContext()
+	defer cancel()
+	client, err := newClient(ctx)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	res, err := client.PurgeDeleted(ctx, req)
+	if err != nil {
+		return fmt.Errorf("could not purge trash: %w", err)
+	}
+	fmt.Printf("Purged %d snacks and %d locations.\n", res.GetSnacks(), res.GetLocations())
+	return nil
+}
@@ -0,0 +1,160 @@
+/*
+Copyright 2020 Robert Barron
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/rmbarron/SnackInventory/src/backend/fakes/fakeserver"
+	"github.com/rmbarron/SnackInventory/src/cli/testutils"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	sipb "github.com/rmbarron/SnackInventory/src/proto/snackinventory"
+)
+
+// startTrashServerT starts fsi & points the address flag variable at it.
+func startTrashServerT(t *testing.T, fsi *fakeserver.FakeSnackInventoryServer) {
+	t.Helper()
+	addr, close := testutils.StartTestServer(t, fsi)
+	t.Cleanup(close)
+
+	tmpAddr := address
+	address = addr
+	t.Cleanup(func() { address = tmpAddr })
+}
+
+func TestTrashList(t *testing.T) {
+	for _, format := range []string{outputTable, "json"} {
+		t.Run(format, func(t *testing.T) {
+			setOutputT(t, format, "")
+			startTrashServerT(t, &fakeserver.FakeSnackInventoryServer{
+				ListDeletedRes: &sipb.ListDeletedResponse{
+					Deleted: []*sipb.DeletedEntity{
+						{Entity: &sipb.DeletedEntity_Snack{Snack: &sipb.Snack{Barcode: "123"}}},
+						{Entity: &sipb.DeletedEntity_Location{Location: &sipb.Location{Name: "fridge"}}},
+					},
+				},
+			})
+
+			if err := trashList(nil, nil); err != nil {
+				t.Fatalf("trashList(nil, nil) = got err %v, want err nil", err)
+			}
+		})
+	}
+}
+
+func TestTrashList_ServerError(t *testing.T) {
+	startTrashServerT(t, &fakeserver.FakeSnackInventoryServer{
+		ListDeletedErr: status.Error(codes.Internal, "could not list trash"),
+	})
+
+	if err := trashList(nil, nil); err == nil {
+		t.Fatal("trashList(nil, nil) = got err nil, want err")
+	}
+}
+
+func TestFormatDeleted(t *testing.T) {
+	deleteTime := time.Date(2020, 1, 2, 3, 4, 5, 0, time.Local)
+	prefix := deleteTime.Format(time.RFC3339) + " "
+	tests := []struct {
+		d    *sipb.DeletedEntity
+		want string
+	}{
+		{
+			d: &sipb.DeletedEntity{
+				Entity:     &sipb.DeletedEntity_Snack{Snack: &sipb.Snack{Barcode: "123", Name: "chips"}},
+				DeleteTime: timestamppb.New(deleteTime),
+			},
+			want: prefix + `snack "123" chips`,
+		},
+		{
+			d: &sipb.DeletedEntity{
+				Entity:     &sipb.DeletedEntity_Location{Location: &sipb.Location{Name: "fridge"}},
+				DeleteTime: timestamppb.New(deleteTime),
+			},
+			want: prefix + `location "fridge"`,
+		},
+	}
+	for _, tc := range tests {
+		if got := formatDeleted(tc.d); got != tc.want {
+			t.Errorf("formatDeleted(%v) = got %q, want %q", tc.d, got, tc.want)
+		}
+	}
+}
+
+func TestTrashRestore(t *testing.T) {
+	startTrashServerT(t, &fakeserver.FakeSnackInventoryServer{
+		UndeleteSnackRes:    &sipb.UndeleteSnackResponse{},
+		UndeleteLocationRes: &sipb.UndeleteLocationResponse{},
+	})
+	trashRestoreBarcode, trashRestoreLocation = "123", "fridge"
+	defer func() { trashRestoreBarcode, trashRestoreLocation = "", "" }()
+
+	if err := trashRestore(nil, nil); err != nil {
+		t.Fatalf("trashRestore(nil, nil) = got err %v, want err nil", err)
+	}
+}
+
+func TestTrashRestore_Errors(t *testing.T) {
+	// Neither flag given.
+	if err := trashRestore(nil, nil); err == nil {
+		t.Fatal("trashRestore(nil, nil) without flags = got err nil, want err")
+	}
+
+	startTrashServerT(t, &fakeserver.FakeSnackInventoryServer{
+		UndeleteSnackErr: status.Error(codes.NotFound, "barcode not in the trash"),
+	})
+	trashRestoreBarcode = "123"
+	defer func() { trashRestoreBarcode = "" }()
+
+	if err := trashRestore(nil, nil); err == nil {
+		t.Fatal("trashRestore(nil, nil) = got err nil, want err")
+	}
+}
+
+func TestTrashPurge(t *testing.T) {
+	startTrashServerT(t, &fakeserver.FakeSnackInventoryServer{
+		PurgeDeletedRes: &sipb.PurgeDeletedResponse{Snacks: 2, Locations: 1},
+	})
+	trashPurgeOlderThan, trashPurgeYes = 720*time.Hour, true
+	defer func() { trashPurgeOlderThan, trashPurgeYes = 0, false }()
+
+	if err := trashPurge(nil, nil); err != nil {
+		t.Fatalf("trashPurge(nil, nil) = got err %v, want err nil", err)
+	}
+}
+
+func TestTrashPurge_Errors(t *testing.T) {
+	trashPurgeYes = true
+	defer func() { trashPurgeOlderThan, trashPurgeYes = 0, false }()
+
+	trashPurgeOlderThan = -time.Hour
+	if err := trashPurge(nil, nil); err == nil {
+		t.Fatal("trashPurge(nil, nil) with negative --older_than = got err nil, want err")
+	}
+
+	trashPurgeOlderThan = 0
+	startTrashServerT(t, &fakeserver.FakeSnackInventoryServer{
+		PurgeDeletedErr: status.Error(codes.Internal, "could not purge"),
+	})
+	if err := trashPurge(nil, nil); err == nil {
+		t.Fatal("trashPurge(nil, nil) = got err nil, want err")
+	}
+}
@@ -0,0 +1,141 @@
+/*
+Copyright 2020 Robert Barron
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package cmd provides the various subcommands of the SnackInventory CLI.
+// This file implements a call to the `UpdateLocation` RPC.
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	sipb "github.com/rmbarron/SnackInventory/src/proto/snackinventory"
+	"github.com/spf13/cobra"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+)
+
+var (
+	updateLocationRef         string
+	updateLocationName        string
+	updateLocationParent      string
+	updateLocationDescription string
+	updateLocationType        string
+
+	updateLocationCmd = &cobra.Command{
+		Use:   "updatelocation --location=name [--flags]",
+		Short: "Update a location in SnackInventory",
+		Long: `Update a location in SnackInventory.
+    --location is required to find the location to be updated, by name or
+    path. Only fields whose flags are given are changed, ex: --parent=""
+    moves the location to the top level. Stock & nested locations follow
+    a renamed location.`,
+		RunE: updateLocation,
+	}
+)
+
+// locationFlags are the flags of updatelocation that each set the location
+// field of the same name.
+var locationFlags = []string{"name", "parent", "description", "type"}
+
+func init() {
+	updateLocationCmd.Flags().StringVar(
+		&updateLocationRef, "location", "", "Name or path of the location to update.")
+	updateLocationCmd.Flags().StringVar(
+		&updateLocationName, "name", "", "New name of the location.")
+	updateLocationCmd.Flags().StringVar(
+		&updateLocationParent, "parent", "", "Name or path of the location to move this one inside.")
+	updateLocationCmd.Flags().StringVar(
+		&updateLocationDescription, "description", "", "New description of the location.")
+	updateLocationCmd.Flags().StringVar(
+		&updateLocationType, "type", "", "New type of the location, one of: "+strings.Join(locationTypes(), ", ")+".")
+	updateLocationCmd.MarkFlagRequired("location")
+}
+
+// locationTypes returns the names of the location types, in lower case.
+func locationTypes() []string {
+	var names []string
+	for name, v := range sipb.LocationType_value {
+		if v != 0 {
+			names = append(names, strings.ToLower(name))
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// parseLocationType parses the name of a location type, ignoring case. An
+// empty name is LOCATION_TYPE_UNSPECIFIED.
+func parseLocationType(name string) (sipb.LocationType, error) {
+	if name == "" {
+		return sipb.LocationType_LOCATION_TYPE_UNSPECIFIED, nil
+	}
+	v, ok := sipb.LocationType_value[strings.ToUpper(name)]
+	if !ok {
+		return 0, fmt.Errorf("unknown location type %q, want one of: %s", name, strings.Join(locationTypes(), ", "))
+	}
+	return sipb.LocationType(v), nil
+}
+
+func updateLocation(cmd *cobra.Command, _ []string) error {
+	var fields []string
+	for _, f := range locationFlags {
+		if cmd.Flags().Changed(f) {
+			fields = append(fields, f)
+		}
+	}
+	if len(fields) == 0 {
+		return errors.New("nothing to update; give at least one of --name, --parent, --description or --type")
+	}
+	locationType, err := parseLocationType(updateLocationType)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := commandContext()
+	defer cancel()
+	client, err := newClient(ctx)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	// Locations are updated by id, which stays the same through renames.
+	res, err := client.ListLocations(ctx, &sipb.ListLocationsRequest{Root: updateLocationRef})
+	if err != nil {
+		return fmt.Errorf("could not find location: %w", err)
+	}
+	if len(res.GetLocations()) == 0 {
+		return fmt.Errorf("could not find location %q", updateLocationRef)
+	}
+
+	req := &sipb.UpdateLocationRequest{
+		Location: &sipb.Location{
+			Id:          res.GetLocations()[0].GetId(),
+			Name:        updateLocationName,
+			Parent:      updateLocationParent,
+			Description: updateLocationDescription,
+			Type:        locationType,
+		},
+		UpdateMask: &fieldmaskpb.FieldMask{Paths: fields},
+	}
+	if _, err := client.UpdateLocation(ctx, req); err != nil {
+		return fmt.Errorf("could not update location: %w", err)
+	}
+	fmt.Println("Successfully updated location!")
+	return nil
+}
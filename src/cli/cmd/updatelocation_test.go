@@ -0,0 +1,135 @@
+/*
+Copyright 2020 Robert Barron
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"context"
+	"testing"
+
+	"github.com/rmbarron/SnackInventory/src/backend/fakes/fakeserver"
+	"github.com/rmbarron/SnackInventory/src/cli/testutils"
+	sipb "github.com/rmbarron/SnackInventory/src/proto/snackinventory"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+)
+
+// recordingLocationServer records the UpdateLocation request it serves.
+type recordingLocationServer struct {
+	*fakeserver.FakeSnackInventoryServer
+	req *sipb.UpdateLocationRequest
+}
+
+func (r *recordingLocationServer) UpdateLocation(ctx context.Context, req *sipb.UpdateLocationRequest) (*sipb.UpdateLocationResponse, error) {
+	r.req = req
+	return r.FakeSnackInventoryServer.UpdateLocation(ctx, req)
+}
+
+// setUpdateLocationFlagsT sets flags of updatelocation, restoring them when t
+// completes.
+func setUpdateLocationFlagsT(t *testing.T, values map[string]string) {
+	t.Helper()
+	flags := updateLocationCmd.Flags()
+	for name, value := range values {
+		fl := flags.Lookup(name)
+		old := fl.Value.String()
+		if err := flags.Set(name, value); err != nil {
+			t.Fatalf("flags.Set(%q, %q) = got err %v, want err nil", name, value, err)
+		}
+		t.Cleanup(func() {
+			fl.Value.Set(old)
+			fl.Changed = false
+		})
+	}
+}
+
+func TestUpdateLocation(t *testing.T) {
+	rs := &recordingLocationServer{FakeSnackInventoryServer: &fakeserver.FakeSnackInventoryServer{
+		ListLocationsRes:  &sipb.ListLocationsResponse{Locations: []*sipb.Location{{Id: 3, Name: "cupbard"}}},
+		UpdateLocationRes: &sipb.UpdateLocationResponse{},
+	}}
+	addr, close := testutils.StartTestServer(t, rs)
+	defer close()
+
+	// Inject the address of our fake server to the address flag variable.
+	tmpAddr := address
+	address = addr
+	defer func() { address = tmpAddr }()
+	setUpdateLocationFlagsT(t, map[string]string{"location": "cupbard", "name": "cupboard", "type": "Cupboard"})
+
+	if err := updateLocation(updateLocationCmd, nil); err != nil {
+		t.Fatalf("updateLocation(updateLocationCmd, nil) = got err %v, want err nil", err)
+	}
+	want := &sipb.UpdateLocationRequest{
+		Location:   &sipb.Location{Id: 3, Name: "cupboard", Type: sipb.LocationType_CUPBOARD},
+		UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"name", "type"}},
+	}
+	if !proto.Equal(rs.req, want) {
+		t.Fatalf("updateLocation(updateLocationCmd, nil) sent %v, want %v", rs.req, want)
+	}
+}
+
+func TestUpdateLocation_Errors(t *testing.T) {
+	tests := []struct {
+		desc  string
+		fsi   *fakeserver.FakeSnackInventoryServer
+		flags map[string]string
+	}{
+		{
+			desc:  "NoFields",
+			fsi:   &fakeserver.FakeSnackInventoryServer{},
+			flags: map[string]string{"location": "cupbard"},
+		},
+		{
+			desc:  "BadType",
+			fsi:   &fakeserver.FakeSnackInventoryServer{},
+			flags: map[string]string{"location": "cupbard", "type": "attic"},
+		},
+		{
+			desc: "NotFound",
+			fsi: &fakeserver.FakeSnackInventoryServer{
+				ListLocationsErr: status.Error(codes.NotFound, "no such location"),
+			},
+			flags: map[string]string{"location": "cupbard", "name": "cupboard"},
+		},
+		{
+			desc: "ServerError",
+			fsi: &fakeserver.FakeSnackInventoryServer{
+				ListLocationsRes:  &sipb.ListLocationsResponse{Locations: []*sipb.Location{{Id: 3, Name: "cupbard"}}},
+				UpdateLocationErr: status.Error(codes.AlreadyExists, "name taken"),
+			},
+			flags: map[string]string{"location": "cupbard", "name": "pantry"},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			addr, close := testutils.StartTestServer(t, tc.fsi)
+			defer close()
+
+			// Inject the address of our fake server to the address flag variable.
+			tmpAddr := address
+			address = addr
+			defer func() { address = tmpAddr }()
+			setUpdateLocationFlagsT(t, tc.flags)
+
+			if err := updateLocation(updateLocationCmd, nil); err == nil {
+				t.Fatal("updateLocation(updateLocationCmd, nil) = got err nil, want err")
+			}
+		})
+	}
+}
@@ -19,17 +19,18 @@ limitations under the License.
 package cmd
 
 import (
-	"context"
 	"fmt"
 
 	sipb "github.com/rmbarron/SnackInventory/src/proto/snackinventory"
 	"github.com/spf13/cobra"
-	"google.golang.org/grpc"
 )
 
 var (
-	updateSnackBarcode string
-	updateSnackName    string
+	updateSnackBarcode     string
+	updateSnackName        string
+	updateSnackBrand       string
+	updateSnackCategory    string
+	updateSnackPackageSize string
 
 	updateSnackCmd = &cobra.Command{
 		Use:   "updatesnack [--flags]",
@@ -48,25 +49,35 @@ func init() {
 		&updateSnackBarcode, "barcode", "", "barcode of snack to update in SnackInventory.")
 	updateSnackCmd.Flags().StringVar(
 		&updateSnackName, "name", "", "name of snack to update in SnackInventory.")
+	updateSnackCmd.Flags().StringVar(
+		&updateSnackBrand, "brand", "", "brand of snack to update in SnackInventory.")
+	updateSnackCmd.Flags().StringVar(
+		&updateSnackCategory, "category", "", "category of snack to update in SnackInventory.")
+	updateSnackCmd.Flags().StringVar(
+		&updateSnackPackageSize, "package_size", "", "package size of snack to update in SnackInventory.")
 	updateSnackCmd.MarkFlagRequired("barcode")
 }
 
 func updateSnack(_ *cobra.Command, _ []string) error {
-	conn, err := grpc.Dial(address, grpc.WithInsecure(), grpc.WithBlock(), grpc.WithTimeout(connTimeout))
+	ctx, cancel := commandContext()
+	defer cancel()
+	client, err := newClient(ctx)
 	if err != nil {
-		return fmt.Errorf("could not dial %s: %w", address, err)
+		return err
 	}
-	defer conn.Close()
+	defer client.Close()
 
-	client := sipb.NewSnackInventoryClient(conn)
 	req := &sipb.UpdateSnackRequest{
 		Snack: &sipb.Snack{
-			Barcode: updateSnackBarcode,
-			Name:    updateSnackName,
+			Barcode:     updateSnackBarcode,
+			Name:        updateSnackName,
+			Brand:       updateSnackBrand,
+			Category:    updateSnackCategory,
+			PackageSize: updateSnackPackageSize,
 		},
 	}
 
-	if _, err = client.UpdateSnack(context.Background(), req); err != nil {
+	if _, err = client.UpdateSnack(ctx, req); err != nil {
 		return fmt.Errorf("could not update snack: %w", err)
 	}
 	fmt.Println("Successfully updated snack!")
@@ -0,0 +1,145 @@
+/*
+Copyright 2020 Robert Barron
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package cmd provides the various subcommands of the SnackInventory CLI.
+// This file implements a call to the `WatchChanges` RPC.
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	sipb "github.com/rmbarron/SnackInventory/src/proto/snackinventory"
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	watchResumeToken string
+
+	watchCmd = &cobra.Command{
+		Use:   "watch [--flags]",
+		Short: "Print changes to SnackInventory as they happen.",
+		Long: `Print changes to snacks, locations and stock as they happen, until
+    interrupted. On exit, prints a token to pass to --resume_token to continue
+    where this watch left off.`,
+		RunE: watchChanges,
+	}
+)
+
+func init() {
+	watchCmd.Flags().StringVar(
+		&watchResumeToken, "resume_token", "", "If set, print changes made after this token instead of from now.")
+}
+
+func watchChanges(_ *cobra.Command, _ []string) error {
+	// The default table output is one human readable line per change.
+	var p printer
+	if outputFormat != outputTable {
+		var err error
+		if p, err = newPrinter(os.Stdout, (&sipb.Change{}).ProtoReflect().Descriptor(), true); err != nil {
+			return err
+		}
+	}
+
+	ctx, cancel := commandContext()
+	defer cancel()
+	client, err := newClient(ctx)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	token := watchResumeToken
+	for {
+		token, err = watchOnce(ctx, client, token, p)
+		// The server ends watches that fall behind; pick up where we left off.
+		if status.Code(err) == codes.Aborted && token != "" {
+			time.Sleep(time.Second)
+			continue
+		}
+		break
+	}
+	if p != nil {
+		if ferr := p.Flush(); err == nil {
+			err = ferr
+		}
+	}
+	if token != "" {
+		// Printed to stderr to keep stdout parseable.
+		fmt.Fprintf(os.Stderr, "Resume with --resume_token=%s\n", token)
+	}
+	if ctx.Err() != nil {
+		// Interrupted by the user.
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("could not watch changes: %w", err)
+	}
+	return nil
+}
+
+// watchOnce prints changes after token until the stream ends, returning the
+// resume token of the last change printed. Changes are printed by p, or
+// formatted by formatChange if p is nil.
+func watchOnce(ctx context.Context, client sipb.SnackInventoryClient, token string, p printer) (string, error) {
+	stream, err := client.WatchChanges(ctx, &sipb.WatchChangesRequest{ResumeToken: token})
+	if err != nil {
+		return token, err
+	}
+	for {
+		c, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return token, nil
+		}
+		if err != nil {
+			return token, err
+		}
+		if p == nil {
+			fmt.Println(formatChange(c))
+		} else if err := p.Print(c); err != nil {
+			return token, err
+		}
+		token = c.GetResumeToken()
+	}
+}
+
+// formatChange formats c as a single human readable line.
+func formatChange(c *sipb.Change) string {
+	var entity string
+	switch e := c.GetEntity().(type) {
+	case *sipb.Change_Snack:
+		entity = fmt.Sprintf("snack %q %s", e.Snack.GetBarcode(), e.Snack.GetName())
+	case *sipb.Change_Location:
+		entity = fmt.Sprintf("location %q", e.Location.GetName())
+	case *sipb.Change_Stock:
+		entity = fmt.Sprintf("stock %q at %q %+d (now %d)",
+			e.Stock.GetBarcode(), e.Stock.GetLocation(), e.Stock.GetDelta(), e.Stock.GetCount())
+		if r := e.Stock.GetReason(); r != sipb.StockChange_REASON_UNSPECIFIED {
+			entity += " " + strings.ToLower(r.String())
+		}
+	default:
+		entity = "unknown entity"
+	}
+	return fmt.Sprintf("%s %-7s %s",
+		c.GetChangeTime().AsTime().Local().Format(time.RFC3339), c.GetType(), entity)
+}
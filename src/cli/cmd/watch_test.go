@@ -0,0 +1,94 @@
+/*
+Copyright 2020 Robert Barron
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/rmbarron/SnackInventory/src/backend/fakes/fakeserver"
+	"github.com/rmbarron/SnackInventory/src/cli/testutils"
+	sipb "github.com/rmbarron/SnackInventory/src/proto/snackinventory"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestWatchChanges(t *testing.T) {
+	fsi := &fakeserver.FakeSnackInventoryServer{
+		WatchChangesRes: []*sipb.Change{
+			{
+				Type:        sipb.Change_CREATED,
+				Entity:      &sipb.Change_Snack{Snack: &sipb.Snack{Barcode: "123", Name: "chips"}},
+				ResumeToken: "1.1",
+			},
+		},
+	}
+	addr, close := testutils.StartTestServer(t, fsi)
+	defer close()
+
+	// Inject the address of our fake server to the address flag variable.
+	tmpAddr := address
+	address = addr
+	defer func() { address = tmpAddr }()
+
+	if err := watchChanges(nil, nil); err != nil {
+		t.Fatalf("watchChanges(nil, nil) = got err %v, want err nil", err)
+	}
+}
+
+func TestWatchChanges_ServerError(t *testing.T) {
+	fsi := &fakeserver.FakeSnackInventoryServer{
+		WatchChangesErr: status.Error(codes.OutOfRange, "resume token expired"),
+	}
+	addr, close := testutils.StartTestServer(t, fsi)
+	defer close()
+
+	// Inject the address of our fake server to the address flag variable.
+	tmpAddr := address
+	address = addr
+	defer func() { address = tmpAddr }()
+
+	if err := watchChanges(nil, nil); err == nil {
+		t.Fatal("watchChanges(nil, nil) = got err nil, want err")
+	}
+}
+
+func TestFormatChange(t *testing.T) {
+	tests := []struct {
+		change *sipb.Change
+		want   string
+	}{
+		{
+			&sipb.Change{Type: sipb.Change_DELETED, Entity: &sipb.Change_Location{Location: &sipb.Location{Name: "fridge"}}},
+			`DELETED location "fridge"`,
+		},
+		{
+			&sipb.Change{Type: sipb.Change_UPDATED, Entity: &sipb.Change_Stock{Stock: &sipb.StockChange{
+				Barcode: "123", Location: "fridge", Delta: -1, Count: 2}}},
+			`UPDATED stock "123" at "fridge" -1 (now 2)`,
+		},
+		{
+			&sipb.Change{Type: sipb.Change_UPDATED, Entity: &sipb.Change_Stock{Stock: &sipb.StockChange{
+				Barcode: "123", Location: "fridge", Delta: -2, Reason: sipb.StockChange_DISCARDED}}},
+			`UPDATED stock "123" at "fridge" -2 (now 0) discarded`,
+		},
+	}
+	for _, tc := range tests {
+		if got := formatChange(tc.change); !strings.HasSuffix(got, tc.want) {
+			t.Errorf("formatChange(%v) = got %q, want suffix %q", tc.change, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,378 @@
+/*
+Copyright 2020 Robert Barron
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package config provides configuration for the SnackInventory CLI.
+//
+// Settings are grouped into named profiles, ex: one per server, stored in a
+// YAML file (see DefaultPath):
+//
+//	current_profile: home
+//	profiles:
+//	  home:
+//	    address: pantry.local:10000
+//	    default_location: pantry
+//
+// The settings of a profile are layered, with later sources overriding
+// earlier ones:
+//  1. Defaults.
+//  2. The profile in the config file.
+//  3. Environment variables, named SNACKINVENTORY_<KEY> (ex: SNACKINVENTORY_ADDRESS).
+//  4. Command line flags that were explicitly set.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+// EnvPrefix is prepended to upper-cased keys to form environment variable names.
+const EnvPrefix = "SNACKINVENTORY_"
+
+// ProfileEnv names the environment variable selecting a profile.
+const ProfileEnv = EnvPrefix + "PROFILE"
+
+// DefaultProfile is used when no profile is selected.
+const DefaultProfile = "default"
+
+// Profile holds the settings for talking to one SnackInventory server.
+// Fields tagged `secret:"true"` are redacted by Redacted.
+type Profile struct {
+	Address         string        `yaml:"address"`
+	DialTimeout     time.Duration `yaml:"dial_timeout"`
+	RPCTimeout      time.Duration `yaml:"rpc_timeout"`
+	Output          string        `yaml:"output"`
+	DefaultLocation string        `yaml:"default_location"`
+
+	// Transport security & credentials.
+	TLS           bool   `yaml:"tls"`
+	TLSCAFile     string `yaml:"tls_ca_file"`
+	TLSServerName string `yaml:"tls_server_name"`
+	Token         string `yaml:"token" secret:"true"`
+	TokenFile     string `yaml:"token_file"`
+}
+
+// Default returns a Profile populated with default values.
+func Default() *Profile {
+	return &Profile{
+		Address:     "localhost:10000",
+		DialTimeout: 30 * time.Second,
+		RPCTimeout:  30 * time.Second,
+		Output:      "table",
+	}
+}
+
+// Keys returns every profile key, in declaration order.
+func Keys() []string {
+	t := reflect.TypeOf(Profile{})
+	keys := make([]string, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		keys = append(keys, t.Field(i).Tag.Get("yaml"))
+	}
+	return keys
+}
+
+// field returns the field of p identified by key.
+func (p *Profile) field(key string) (reflect.Value, error) {
+	v := reflect.ValueOf(p).Elem()
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		if t.Field(i).Tag.Get("yaml") == key {
+			return v.Field(i), nil
+		}
+	}
+	return reflect.Value{}, fmt.Errorf("unknown config key %q; valid keys are %s", key, strings.Join(Keys(), ", "))
+}
+
+// Set sets the field identified by key, parsing value as needed.
+func (p *Profile) Set(key, value string) error {
+	f, err := p.field(key)
+	if err != nil {
+		return err
+	}
+	switch {
+	case f.Type() == reflect.TypeOf(time.Duration(0)):
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("%s: %q is not a duration", key, value)
+		}
+		f.SetInt(int64(d))
+	case f.Kind() == reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("%s: %q is not a boolean", key, value)
+		}
+		f.SetBool(b)
+	case f.Kind() == reflect.String:
+		f.SetString(value)
+	default:
+		return fmt.Errorf("%s: unsupported type %v", key, f.Kind())
+	}
+	return nil
+}
+
+// Get returns the field identified by key, formatted as accepted by Set.
+func (p *Profile) Get(key string) (string, error) {
+	f, err := p.field(key)
+	if err != nil {
+		return "", err
+	}
+	return format(f), nil
+}
+
+// format formats a field of Profile as accepted by Set.
+func format(f reflect.Value) string {
+	if d, ok := f.Interface().(time.Duration); ok {
+		return d.String()
+	}
+	return fmt.Sprint(f.Interface())
+}
+
+// MarshalYAML writes only the fields that are set, with durations in the form
+// accepted by Set, so config files stay short & readable.
+func (p *Profile) MarshalYAML() (interface{}, error) {
+	var out yaml.MapSlice
+	v := reflect.ValueOf(p).Elem()
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := v.Field(i)
+		if f.IsZero() {
+			continue
+		}
+		var value interface{} = f.Interface()
+		if _, ok := value.(time.Duration); ok {
+			value = format(f)
+		}
+		out = append(out, yaml.MapItem{Key: t.Field(i).Tag.Get("yaml"), Value: value})
+	}
+	return out, nil
+}
+
+// ApplyEnv overrides fields of p from environment variables, as returned by
+// lookup (ex: os.LookupEnv).
+func (p *Profile) ApplyEnv(lookup func(string) (string, bool)) error {
+	var errs []string
+	for _, key := range Keys() {
+		v, ok := lookup(EnvPrefix + strings.ToUpper(key))
+		if !ok {
+			continue
+		}
+		if err := p.Set(key, v); err != nil {
+			errs = append(errs, fmt.Sprintf("%s%s: %v", EnvPrefix, strings.ToUpper(key), err))
+		}
+	}
+	return joinErrors(errs)
+}
+
+// Validate checks p for errors, reporting all of them at once.
+func (p *Profile) Validate() error {
+	var errs []string
+	if p.Address == "" {
+		errs = append(errs, "address: required")
+	}
+	if p.DialTimeout <= 0 {
+		errs = append(errs, fmt.Sprintf("dial_timeout: %v must be positive", p.DialTimeout))
+	}
+	if p.RPCTimeout < 0 {
+		errs = append(errs, fmt.Sprintf("rpc_timeout: %v must not be negative", p.RPCTimeout))
+	}
+	if !p.TLS && (p.TLSCAFile != "" || p.TLSServerName != "") {
+		errs = append(errs, "tls_ca_file, tls_server_name: require tls")
+	}
+	if p.Token != "" && p.TokenFile != "" {
+		errs = append(errs, "token, token_file: at most one may be set")
+	}
+	if !p.TLS && (p.Token != "" || p.TokenFile != "") {
+		// Never send credentials in plain text.
+		errs = append(errs, "token, token_file: require tls")
+	}
+	return joinErrors(errs)
+}
+
+// ReadToken returns the bearer token sent with each RPC, read from token_file
+// or taken from token. It is empty if neither is set.
+func (p *Profile) ReadToken() (string, error) {
+	if p.TokenFile != "" {
+		b, err := ioutil.ReadFile(p.TokenFile)
+		if err != nil {
+			return "", fmt.Errorf("could not read token_file: %w", err)
+		}
+		return strings.TrimRight(string(b), "\r\n"), nil
+	}
+	return p.Token, nil
+}
+
+// Redacted returns a copy of p with secret fields replaced.
+func (p *Profile) Redacted() *Profile {
+	r := *p
+	v := reflect.ValueOf(&r).Elem()
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		if t.Field(i).Tag.Get("secret") == "true" && v.Field(i).String() != "" {
+			v.Field(i).SetString("REDACTED")
+		}
+	}
+	return &r
+}
+
+// File is the contents of a config file.
+type File struct {
+	// CurrentProfile is used when no profile is selected by flag or environment.
+	CurrentProfile string              `yaml:"current_profile,omitempty"`
+	Profiles       map[string]*Profile `yaml:"profiles,omitempty"`
+}
+
+// DefaultPath returns the path of the config file:
+// $XDG_CONFIG_HOME/snackinventory/config.yaml, or
+// ~/.config/snackinventory/config.yaml if XDG_CONFIG_HOME is unset.
+func DefaultPath() (string, error) {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("could not find config directory: %w", err)
+		}
+		dir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(dir, "snackinventory", "config.yaml"), nil
+}
+
+// Load reads the config file at path. A missing file is treated as empty, so
+// the CLI works without one.
+func Load(path string) (*File, error) {
+	f := &File{}
+	b, err := ioutil.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return f, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not read config file: %w", err)
+	}
+	if err := yaml.UnmarshalStrict(b, f); err != nil {
+		return nil, fmt.Errorf("could not parse config file %s: %w", path, err)
+	}
+	return f, nil
+}
+
+// Save writes f to path, creating its directory if needed. The file may hold
+// credentials, so it is only readable by the user.
+func (f *File) Save(path string) error {
+	b, err := yaml.Marshal(f)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("could not create config directory: %w", err)
+	}
+	// Write a temporary file & rename it, so a failed write never leaves a
+	// truncated config behind.
+	tmp, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return fmt.Errorf("could not write config file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	_, err = tmp.Write(b)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return fmt.Errorf("could not write config file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("could not write config file: %w", err)
+	}
+	return nil
+}
+
+// ProfileName returns the selected profile: name if set, else the profile
+// named by ProfileEnv in lookup, else CurrentProfile, else DefaultProfile.
+func (f *File) ProfileName(name string, lookup func(string) (string, bool)) string {
+	if name != "" {
+		return name
+	}
+	if v, ok := lookup(ProfileEnv); ok && v != "" {
+		return v
+	}
+	if f.CurrentProfile != "" {
+		return f.CurrentProfile
+	}
+	return DefaultProfile
+}
+
+// ProfileNames returns the names of all profiles in f, sorted.
+func (f *File) ProfileNames() []string {
+	names := make([]string, 0, len(f.Profiles))
+	for name := range f.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Resolve returns the profile called name layered over defaults. Only
+// DefaultProfile may be missing from f.
+func (f *File) Resolve(name string) (*Profile, error) {
+	p := Default()
+	stored, ok := f.Profiles[name]
+	if !ok {
+		if name == DefaultProfile {
+			return p, nil
+		}
+		return nil, fmt.Errorf("unknown profile %q; known profiles are [%s]", name, strings.Join(f.ProfileNames(), ", "))
+	}
+	// Round trip through YAML, which only carries the fields that are set.
+	b, err := yaml.Marshal(stored)
+	if err != nil {
+		return nil, err
+	}
+	if err := yaml.UnmarshalStrict(b, p); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Set sets key of the profile called name, creating the profile if needed.
+func (f *File) Set(name, key, value string) error {
+	p, ok := f.Profiles[name]
+	if !ok {
+		p = &Profile{}
+	}
+	if err := p.Set(key, value); err != nil {
+		return err
+	}
+	if f.Profiles == nil {
+		f.Profiles = map[string]*Profile{}
+	}
+	f.Profiles[name] = p
+	return nil
+}
+
+// joinErrors combines errs into a single error, or returns nil if empty.
+func joinErrors(errs []string) error {
+	if len(errs) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%d config error(s):\n  %s", len(errs), strings.Join(errs, "\n  "))
+}
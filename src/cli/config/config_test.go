@@ -0,0 +1,239 @@
+/*
+Copyright 2020 Robert Barron
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// writeFileT writes contents to a new file in a temp dir, returning its path.
+func writeFileT(t *testing.T, name, contents string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := ioutil.WriteFile(path, []byte(contents), 0600); err != nil {
+		t.Fatalf("ioutil.WriteFile(%q) = got err %v, want err nil", path, err)
+	}
+	return path
+}
+
+// lookupT returns a lookup function over env, as for ApplyEnv.
+func lookupT(env map[string]string) func(string) (string, bool) {
+	return func(k string) (string, bool) { v, ok := env[k]; return v, ok }
+}
+
+func TestLayering(t *testing.T) {
+	path := writeFileT(t, "config.yaml", `
+current_profile: home
+profiles:
+  home:
+    address: pantry.local:10000
+    dial_timeout: 5s
+    default_location: pantry
+  work:
+    address: office:10000
+`)
+	env := map[string]string{"SNACKINVENTORY_OUTPUT": "json"}
+
+	f, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load(%q) = got err %v, want err nil", path, err)
+	}
+	name := f.ProfileName("", lookupT(env))
+	if name != "home" {
+		t.Errorf("f.ProfileName(\"\", ...) = got %q, want %q", name, "home")
+	}
+	p, err := f.Resolve(name)
+	if err != nil {
+		t.Fatalf("f.Resolve(%q) = got err %v, want err nil", name, err)
+	}
+	if err := p.ApplyEnv(lookupT(env)); err != nil {
+		t.Fatalf("p.ApplyEnv(...) = got err %v, want err nil", err)
+	}
+	if err := p.Set("dial_timeout", "1s"); err != nil {
+		t.Fatalf("p.Set(%q, %q) = got err %v, want err nil", "dial_timeout", "1s", err)
+	}
+
+	want := Default()
+	want.Address = "pantry.local:10000"
+	want.DialTimeout = time.Second
+	want.DefaultLocation = "pantry"
+	want.Output = "json"
+	if diff := cmp.Diff(p, want); diff != "" {
+		t.Fatalf("layered profile = got diff (-got +want): %s", diff)
+	}
+}
+
+func TestProfileName(t *testing.T) {
+	f := &File{CurrentProfile: "home"}
+	env := lookupT(map[string]string{ProfileEnv: "work"})
+	tests := []struct {
+		f      *File
+		flag   string
+		lookup func(string) (string, bool)
+		want   string
+	}{
+		{&File{}, "", os.LookupEnv, DefaultProfile},
+		{f, "", lookupT(nil), "home"},
+		{f, "", env, "work"},
+		{f, "flag", env, "flag"},
+	}
+	for _, tc := range tests {
+		if got := tc.f.ProfileName(tc.flag, tc.lookup); got != tc.want {
+			t.Errorf("f.ProfileName(%q, ...) = got %q, want %q", tc.flag, got, tc.want)
+		}
+	}
+}
+
+func TestLoad_Missing(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	f, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load(%q) = got err %v, want err nil", path, err)
+	}
+	p, err := f.Resolve(DefaultProfile)
+	if err != nil {
+		t.Fatalf("f.Resolve(%q) = got err %v, want err nil", DefaultProfile, err)
+	}
+	if diff := cmp.Diff(p, Default()); diff != "" {
+		t.Fatalf("f.Resolve(%q) = got diff (-got +want): %s", DefaultProfile, diff)
+	}
+	if _, err := f.Resolve("work"); err == nil {
+		t.Fatalf("f.Resolve(%q) = got err nil, want err", "work")
+	}
+}
+
+func TestLoad_UnknownKey(t *testing.T) {
+	path := writeFileT(t, "config.yaml", "profiles:\n  home:\n    adress: pantry:10000\n")
+	if _, err := Load(path); err == nil {
+		t.Fatalf("Load(%q) = got err nil, want err", path)
+	}
+}
+
+func TestSave(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "snackinventory", "config.yaml")
+	f := &File{CurrentProfile: "home"}
+	for _, kv := range [][2]string{{"address", "pantry:10000"}, {"dial_timeout", "5s"}, {"tls", "true"}} {
+		if err := f.Set("home", kv[0], kv[1]); err != nil {
+			t.Fatalf("f.Set(%q, %q, %q) = got err %v, want err nil", "home", kv[0], kv[1], err)
+		}
+	}
+	if err := f.Save(path); err != nil {
+		t.Fatalf("f.Save(%q) = got err %v, want err nil", path, err)
+	}
+
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ioutil.ReadFile(%q) = got err %v, want err nil", path, err)
+	}
+	// Only set fields are written, with readable durations.
+	want := "current_profile: home\nprofiles:\n  home:\n    address: pantry:10000\n    dial_timeout: 5s\n    tls: true\n"
+	if diff := cmp.Diff(string(b), want); diff != "" {
+		t.Errorf("saved file = got diff (-got +want): %s", diff)
+	}
+	if fi, err := os.Stat(path); err != nil || fi.Mode().Perm() != 0600 {
+		t.Errorf("os.Stat(%q) = got (%v, %v), want mode 0600", path, fi.Mode(), err)
+	}
+
+	got, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load(%q) = got err %v, want err nil", path, err)
+	}
+	if diff := cmp.Diff(got, f); diff != "" {
+		t.Errorf("Load(%q) = got diff (-got +want): %s", path, diff)
+	}
+}
+
+func TestSetGet(t *testing.T) {
+	p := Default()
+	for _, key := range Keys() {
+		v, err := p.Get(key)
+		if err != nil {
+			t.Fatalf("p.Get(%q) = got err %v, want err nil", key, err)
+		}
+		if err := p.Set(key, v); err != nil {
+			t.Errorf("p.Set(%q, p.Get(%q)) = got err %v, want err nil", key, key, err)
+		}
+	}
+	if diff := cmp.Diff(p, Default()); diff != "" {
+		t.Errorf("Get & Set round trip = got diff (-got +want): %s", diff)
+	}
+
+	for _, kv := range [][2]string{{"adress", "x"}, {"dial_timeout", "5"}, {"tls", "maybe"}} {
+		if err := p.Set(kv[0], kv[1]); err == nil {
+			t.Errorf("p.Set(%q, %q) = got err nil, want err", kv[0], kv[1])
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	if err := Default().Validate(); err != nil {
+		t.Fatalf("Default().Validate() = got err %v, want err nil", err)
+	}
+
+	p := Default()
+	p.Address = ""
+	p.DialTimeout = 0
+	p.Token = "secret"
+	p.TokenFile = "token.txt"
+	err := p.Validate()
+	if err == nil {
+		t.Fatal("p.Validate() = got err nil, want err")
+	}
+	for _, want := range []string{"address", "dial_timeout", "at most one", "require tls"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("p.Validate() = got err %v, want it to mention %q", err, want)
+		}
+	}
+}
+
+func TestReadToken(t *testing.T) {
+	path := writeFileT(t, "token", "file-token\n")
+	tests := []struct {
+		p    *Profile
+		want string
+	}{
+		{&Profile{}, ""},
+		{&Profile{Token: "token"}, "token"},
+		{&Profile{TokenFile: path}, "file-token"},
+	}
+	for _, tc := range tests {
+		got, err := tc.p.ReadToken()
+		if err != nil {
+			t.Fatalf("p.ReadToken() = got err %v, want err nil", err)
+		}
+		if got != tc.want {
+			t.Errorf("p.ReadToken() = got %q, want %q", got, tc.want)
+		}
+	}
+}
+
+func TestRedacted(t *testing.T) {
+	p := &Profile{Address: "pantry:10000", Token: "secret"}
+	r := p.Redacted()
+	if r.Token != "REDACTED" || r.Address != p.Address {
+		t.Errorf("p.Redacted() = got %+v, want only token redacted", r)
+	}
+	if p.Token != "secret" {
+		t.Errorf("p.Redacted() modified p: got token %q, want %q", p.Token, "secret")
+	}
+}
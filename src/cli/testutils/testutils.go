@@ -22,7 +22,6 @@ import (
 	"net"
 	"testing"
 
-	"github.com/rmbarron/SnackInventory/src/backend/fakes/fakeserver"
 	"google.golang.org/grpc"
 
 	sipb "github.com/rmbarron/SnackInventory/src/proto/snackinventory"
@@ -34,7 +33,10 @@ import (
 //
 // addr, close := testutils.StartTestServer(t, fsi)
 // defer close()
-func StartTestServer(t *testing.T, fsi *fakeserver.FakeSnackInventoryServer) (addr string, close func()) {
+//
+// fsi is usually a *fakeserver.FakeSnackInventoryServer, or a type embedding
+// one to record requests.
+func StartTestServer(t *testing.T, fsi interface{}) (addr string, close func()) {
 	t.Helper()
 	// Use port 0 for the OS to choose an open port.
 	lis, err := net.Listen("tcp", ":0")
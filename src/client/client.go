@@ -0,0 +1,185 @@
+/*
+Copyright 2020 Robert Barron
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package client provides a Go client library for SnackInventory.
+//
+// It wraps the generated sipb.SnackInventoryClient with the connection
+// handling every tool needs: a bounded dial, a default deadline for unary
+// RPCs, retries of idempotent RPCs while the server is unavailable, and
+// keepalives to detect dead connections.
+//
+//	c, err := client.Dial(ctx, "localhost:10000", opts)
+//	if err != nil {
+//		...
+//	}
+//	defer c.Close()
+//	res, err := c.ListSnacks(ctx, &sipb.ListSnacksRequest{})
+package client
+
+import (
+	"context"
+	"math/rand"
+	"time"
+
+	sipb "github.com/rmbarron/SnackInventory/src/proto/snackinventory"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/status"
+)
+
+// maxRetryBackoff caps the exponential backoff between retries.
+const maxRetryBackoff = 5 * time.Second
+
+// idempotent holds the RPCs that are safe to retry, as applying them twice has
+// the same effect as once.
+var idempotent = map[string]bool{
+	"/snackinventory.SnackInventory/ListSnacks":        true,
+	"/snackinventory.SnackInventory/updateSnack":       true,
+	"/snackinventory.SnackInventory/DeleteSnack":       true,
+	"/snackinventory.SnackInventory/BatchUpdateSnacks": true,
+	"/snackinventory.SnackInventory/BatchDeleteSnacks": true,
+	"/snackinventory.SnackInventory/ListLocations":     true,
+	"/snackinventory.SnackInventory/DeleteLocation":    true,
+	"/snackinventory.SnackInventory/ListDeleted":       true,
+	"/snackinventory.SnackInventory/PurgeDeleted":      true,
+	"/snackinventory.SnackInventory/GetSnackHistory":   true,
+}
+
+// Options configure a Client.
+type Options struct {
+	// DialTimeout bounds establishing the connection. Zero means no bound.
+	DialTimeout time.Duration
+	// RPCTimeout is the deadline of unary RPCs whose context has none, including
+	// any retries. Zero means no deadline. Streaming RPCs are never bounded.
+	RPCTimeout time.Duration
+	// MaxRetries of idempotent unary RPCs failing with codes.Unavailable.
+	MaxRetries int
+	// RetryBackoff is the wait before the first retry, doubling for each one after.
+	RetryBackoff time.Duration
+	// KeepaliveTime is the interval of pings on an idle connection with active
+	// streams, detecting dead servers. Zero disables keepalives.
+	KeepaliveTime time.Duration
+
+	// DialOptions are passed to grpc.DialContext. They must set the transport
+	// security, ex: grpc.WithInsecure().
+	DialOptions []grpc.DialOption
+	// UnaryInterceptors are run for each attempt of an RPC, in order.
+	UnaryInterceptors []grpc.UnaryClientInterceptor
+}
+
+// DefaultOptions returns Options populated with default values.
+func DefaultOptions() Options {
+	return Options{
+		DialTimeout:   30 * time.Second,
+		RPCTimeout:    30 * time.Second,
+		MaxRetries:    3,
+		RetryBackoff:  100 * time.Millisecond,
+		KeepaliveTime: time.Minute,
+	}
+}
+
+// Client is a connection to a SnackInventory server.
+type Client struct {
+	sipb.SnackInventoryClient
+	conn *grpc.ClientConn
+}
+
+// Dial connects to the SnackInventory server at address, blocking until the
+// connection is up, ctx is done, or opts.DialTimeout passes.
+func Dial(ctx context.Context, address string, opts Options) (*Client, error) {
+	if opts.DialTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.DialTimeout)
+		defer cancel()
+	}
+	interceptors := append([]grpc.UnaryClientInterceptor{
+		timeoutInterceptor(opts.RPCTimeout),
+		retryInterceptor(opts.MaxRetries, opts.RetryBackoff),
+	}, opts.UnaryInterceptors...)
+	dialOpts := []grpc.DialOption{
+		grpc.WithBlock(),
+		grpc.WithChainUnaryInterceptor(interceptors...),
+	}
+	if opts.KeepaliveTime > 0 {
+		dialOpts = append(dialOpts, grpc.WithKeepaliveParams(keepalive.ClientParameters{
+			Time:    opts.KeepaliveTime,
+			Timeout: 20 * time.Second,
+		}))
+	}
+
+	conn, err := grpc.DialContext(ctx, address, append(dialOpts, opts.DialOptions...)...)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{SnackInventoryClient: sipb.NewSnackInventoryClient(conn), conn: conn}, nil
+}
+
+// Conn returns the underlying connection, ex: for a gateway.Handler.
+func (c *Client) Conn() *grpc.ClientConn {
+	return c.conn
+}
+
+// Close closes the connection.
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// timeoutInterceptor applies timeout to RPCs whose context has no deadline.
+func timeoutInterceptor(timeout time.Duration) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if _, ok := ctx.Deadline(); !ok && timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
+		}
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
+
+// retryInterceptor retries idempotent RPCs failing with codes.Unavailable up to
+// maxRetries times, with jittered exponential backoff.
+func retryInterceptor(maxRetries int, backoff time.Duration) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		for retry := 0; retry < maxRetries && status.Code(err) == codes.Unavailable && idempotent[method]; retry++ {
+			select {
+			case <-time.After(retryDelay(backoff, retry)):
+			case <-ctx.Done():
+				return err
+			}
+			err = invoker(ctx, method, req, reply, cc, opts...)
+		}
+		return err
+	}
+}
+
+// retryDelay returns the wait before the given retry, counting from 0: between
+// half & all of backoff doubled per retry, capped at maxRetryBackoff. The
+// jitter keeps clients from retrying in lockstep after a server restart.
+func retryDelay(backoff time.Duration, retry int) time.Duration {
+	d := backoff
+	for i := 0; i < retry && d < maxRetryBackoff; i++ {
+		d *= 2
+	}
+	if d > maxRetryBackoff {
+		d = maxRetryBackoff
+	}
+	if d <= 0 {
+		return 0
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
@@ -0,0 +1,207 @@
+/*
+Copyright 2020 Robert Barron
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package client
+
+import (
+	"context"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/rmbarron/SnackInventory/src/backend/fakes/fakeserver"
+	sipb "github.com/rmbarron/SnackInventory/src/proto/snackinventory"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// flakyServer fails the first `failures` calls of each RPC with Unavailable,
+// and blocks ListLocations until the client gives up.
+type flakyServer struct {
+	*fakeserver.FakeSnackInventoryServer
+	failures int32
+	calls    int32
+}
+
+func (f *flakyServer) fail() error {
+	if atomic.AddInt32(&f.calls, 1) <= f.failures {
+		return status.Error(codes.Unavailable, "server restarting")
+	}
+	return nil
+}
+
+func (f *flakyServer) ListSnacks(ctx context.Context, req *sipb.ListSnacksRequest) (*sipb.ListSnacksResponse, error) {
+	if err := f.fail(); err != nil {
+		return nil, err
+	}
+	return &sipb.ListSnacksResponse{}, nil
+}
+
+func (f *flakyServer) CreateSnack(ctx context.Context, req *sipb.CreateSnackRequest) (*sipb.CreateSnackResponse, error) {
+	if err := f.fail(); err != nil {
+		return nil, err
+	}
+	return &sipb.CreateSnackResponse{}, nil
+}
+
+func (f *flakyServer) ListLocations(ctx context.Context, req *sipb.ListLocationsRequest) (*sipb.ListLocationsResponse, error) {
+	<-ctx.Done()
+	return nil, status.FromContextError(ctx.Err()).Err()
+}
+
+// startServerT serves srv on a free port, returning its address. The server is
+// stopped when t completes.
+func startServerT(t *testing.T, srv interface{}) string {
+	t.Helper()
+	lis, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatalf("net.Listen(%q, %q) = got err %v, want err nil", "tcp", "localhost:0", err)
+	}
+	grpcServer := grpc.NewServer()
+	sipb.RegisterSnackInventoryService(grpcServer, sipb.NewSnackInventoryService(srv))
+	go grpcServer.Serve(lis)
+	t.Cleanup(grpcServer.Stop)
+	return lis.Addr().String()
+}
+
+// dialT dials addr with opts, closing the client when t completes.
+func dialT(t *testing.T, addr string, opts Options) *Client {
+	t.Helper()
+	opts.DialOptions = append(opts.DialOptions, grpc.WithInsecure())
+	c, err := Dial(context.Background(), addr, opts)
+	if err != nil {
+		t.Fatalf("Dial(ctx, %q, opts) = got err %v, want err nil", addr, err)
+	}
+	t.Cleanup(func() { c.Close() })
+	return c
+}
+
+// fastOptions are DefaultOptions with short waits, for tests.
+func fastOptions() Options {
+	opts := DefaultOptions()
+	opts.RetryBackoff = time.Millisecond
+	return opts
+}
+
+func TestRetry(t *testing.T) {
+	tests := []struct {
+		desc      string
+		failures  int32
+		call      func(c *Client) error
+		wantCode  codes.Code
+		wantCalls int32
+	}{
+		{
+			desc:     "IdempotentRecovers",
+			failures: 2,
+			call: func(c *Client) error {
+				_, err := c.ListSnacks(context.Background(), &sipb.ListSnacksRequest{})
+				return err
+			},
+			wantCode:  codes.OK,
+			wantCalls: 3,
+		},
+		{
+			desc:     "IdempotentGivesUp",
+			failures: 10,
+			call: func(c *Client) error {
+				_, err := c.ListSnacks(context.Background(), &sipb.ListSnacksRequest{})
+				return err
+			},
+			wantCode:  codes.Unavailable,
+			wantCalls: 4,
+		},
+		{
+			desc:     "NotIdempotent",
+			failures: 1,
+			call: func(c *Client) error {
+				_, err := c.CreateSnack(context.Background(), &sipb.CreateSnackRequest{})
+				return err
+			},
+			wantCode:  codes.Unavailable,
+			wantCalls: 1,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			srv := &flakyServer{FakeSnackInventoryServer: &fakeserver.FakeSnackInventoryServer{}, failures: tc.failures}
+			c := dialT(t, startServerT(t, srv), fastOptions())
+
+			if err := tc.call(c); status.Code(err) != tc.wantCode {
+				t.Errorf("RPC = got err %v, want code %v", err, tc.wantCode)
+			}
+			if got := atomic.LoadInt32(&srv.calls); got != tc.wantCalls {
+				t.Errorf("RPC = got %d calls to the server, want %d", got, tc.wantCalls)
+			}
+		})
+	}
+}
+
+func TestRPCTimeout(t *testing.T) {
+	srv := &flakyServer{FakeSnackInventoryServer: &fakeserver.FakeSnackInventoryServer{}}
+	opts := fastOptions()
+	opts.RPCTimeout = 50 * time.Millisecond
+	c := dialT(t, startServerT(t, srv), opts)
+
+	_, err := c.ListLocations(context.Background(), &sipb.ListLocationsRequest{})
+	if status.Code(err) != codes.DeadlineExceeded {
+		t.Fatalf("c.ListLocations(ctx, ...) = got err %v, want code %v", err, codes.DeadlineExceeded)
+	}
+
+	// A deadline set by the caller takes precedence.
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(100*time.Millisecond, cancel)
+	ctx, cancelTimeout := context.WithTimeout(ctx, time.Hour)
+	defer cancelTimeout()
+	if _, err := c.ListLocations(ctx, &sipb.ListLocationsRequest{}); status.Code(err) != codes.Canceled {
+		t.Fatalf("c.ListLocations(ctx, ...) with caller deadline = got err %v, want code %v", err, codes.Canceled)
+	}
+}
+
+func TestDial_Timeout(t *testing.T) {
+	// Reserve a port with nothing serving on it.
+	lis, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatalf("net.Listen(%q, %q) = got err %v, want err nil", "tcp", "localhost:0", err)
+	}
+	addr := lis.Addr().String()
+	lis.Close()
+
+	opts := DefaultOptions()
+	opts.DialTimeout = 50 * time.Millisecond
+	opts.DialOptions = []grpc.DialOption{grpc.WithInsecure()}
+	if c, err := Dial(context.Background(), addr, opts); err == nil {
+		c.Close()
+		t.Fatalf("Dial(ctx, %q, opts) = got err nil, want err", addr)
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	tests := []struct {
+		retry    int
+		min, max time.Duration
+	}{
+		{0, 50 * time.Millisecond, 100 * time.Millisecond},
+		{2, 200 * time.Millisecond, 400 * time.Millisecond},
+		{20, maxRetryBackoff / 2, maxRetryBackoff},
+	}
+	for _, tc := range tests {
+		if got := retryDelay(100*time.Millisecond, tc.retry); got < tc.min || got > tc.max {
+			t.Errorf("retryDelay(100ms, %d) = got %v, want in [%v, %v]", tc.retry, got, tc.min, tc.max)
+		}
+	}
+}
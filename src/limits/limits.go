@@ -0,0 +1,35 @@
+/*
+Copyright 2020 Robert Barron
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package limits holds the maximum lengths, in characters, of Snack & Location
+// fields, matching the SnackRegistry & LocationRegistry schemas. Every Store
+// holds values up to these lengths, so clients should keep within them.
+package limits
+
+// Limits of Snack fields.
+const (
+	MaxBarcodeLen     = 20
+	MaxNameLen        = 255
+	MaxBrandLen       = 255
+	MaxCategoryLen    = 255
+	MaxPackageSizeLen = 64
+)
+
+// Limits of Location fields.
+const (
+	MaxLocationNameLen = 30
+	MaxDescriptionLen  = 255
+)
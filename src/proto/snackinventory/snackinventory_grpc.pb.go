@@ -21,9 +21,23 @@ type SnackInventoryClient interface {
 	ListSnacks(ctx context.Context, in *ListSnacksRequest, opts ...grpc.CallOption) (*ListSnacksResponse, error)
 	UpdateSnack(ctx context.Context, in *UpdateSnackRequest, opts ...grpc.CallOption) (*UpdateSnackResponse, error)
 	DeleteSnack(ctx context.Context, in *DeleteSnackRequest, opts ...grpc.CallOption) (*DeleteSnackResponse, error)
+	BatchCreateSnacks(ctx context.Context, in *BatchCreateSnacksRequest, opts ...grpc.CallOption) (*BatchCreateSnacksResponse, error)
+	BatchUpdateSnacks(ctx context.Context, in *BatchUpdateSnacksRequest, opts ...grpc.CallOption) (*BatchUpdateSnacksResponse, error)
+	BatchDeleteSnacks(ctx context.Context, in *BatchDeleteSnacksRequest, opts ...grpc.CallOption) (*BatchDeleteSnacksResponse, error)
 	CreateLocation(ctx context.Context, in *CreateLocationRequest, opts ...grpc.CallOption) (*CreateLocationResponse, error)
 	ListLocations(ctx context.Context, in *ListLocationsRequest, opts ...grpc.CallOption) (*ListLocationsResponse, error)
+	UpdateLocation(ctx context.Context, in *UpdateLocationRequest, opts ...grpc.CallOption) (*UpdateLocationResponse, error)
 	DeleteLocation(ctx context.Context, in *DeleteLocationRequest, opts ...grpc.CallOption) (*DeleteLocationResponse, error)
+	AdjustStock(ctx context.Context, in *AdjustStockRequest, opts ...grpc.CallOption) (*AdjustStockResponse, error)
+	ListStock(ctx context.Context, in *ListStockRequest, opts ...grpc.CallOption) (*ListStockResponse, error)
+	WatchChanges(ctx context.Context, in *WatchChangesRequest, opts ...grpc.CallOption) (SnackInventory_WatchChangesClient, error)
+	ListDeleted(ctx context.Context, in *ListDeletedRequest, opts ...grpc.CallOption) (*ListDeletedResponse, error)
+	UndeleteSnack(ctx context.Context, in *UndeleteSnackRequest, opts ...grpc.CallOption) (*UndeleteSnackResponse, error)
+	UndeleteLocation(ctx context.Context, in *UndeleteLocationRequest, opts ...grpc.CallOption) (*UndeleteLocationResponse, error)
+	PurgeDeleted(ctx context.Context, in *PurgeDeletedRequest, opts ...grpc.CallOption) (*PurgeDeletedResponse, error)
+	GetSnackHistory(ctx context.Context, in *GetSnackHistoryRequest, opts ...grpc.CallOption) (*GetSnackHistoryResponse, error)
+	ExportAll(ctx context.Context, in *ExportAllRequest, opts ...grpc.CallOption) (SnackInventory_ExportAllClient, error)
+	ImportAll(ctx context.Context, opts ...grpc.CallOption) (SnackInventory_ImportAllClient, error)
 }
 
 type snackInventoryClient struct {
@@ -86,6 +100,45 @@ func (c *snackInventoryClient) DeleteSnack(ctx context.Context, in *DeleteSnackR
 	return out, nil
 }
 
+var snackInventoryBatchCreateSnacksStreamDesc = &grpc.StreamDesc{
+	StreamName: "BatchCreateSnacks",
+}
+
+func (c *snackInventoryClient) BatchCreateSnacks(ctx context.Context, in *BatchCreateSnacksRequest, opts ...grpc.CallOption) (*BatchCreateSnacksResponse, error) {
+	out := new(BatchCreateSnacksResponse)
+	err := c.cc.Invoke(ctx, "/snackinventory.SnackInventory/BatchCreateSnacks", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+var snackInventoryBatchUpdateSnacksStreamDesc = &grpc.StreamDesc{
+	StreamName: "BatchUpdateSnacks",
+}
+
+func (c *snackInventoryClient) BatchUpdateSnacks(ctx context.Context, in *BatchUpdateSnacksRequest, opts ...grpc.CallOption) (*BatchUpdateSnacksResponse, error) {
+	out := new(BatchUpdateSnacksResponse)
+	err := c.cc.Invoke(ctx, "/snackinventory.SnackInventory/BatchUpdateSnacks", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+var snackInventoryBatchDeleteSnacksStreamDesc = &grpc.StreamDesc{
+	StreamName: "BatchDeleteSnacks",
+}
+
+func (c *snackInventoryClient) BatchDeleteSnacks(ctx context.Context, in *BatchDeleteSnacksRequest, opts ...grpc.CallOption) (*BatchDeleteSnacksResponse, error) {
+	out := new(BatchDeleteSnacksResponse)
+	err := c.cc.Invoke(ctx, "/snackinventory.SnackInventory/BatchDeleteSnacks", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 var snackInventoryCreateLocationStreamDesc = &grpc.StreamDesc{
 	StreamName: "CreateLocation",
 }
@@ -112,6 +165,19 @@ func (c *snackInventoryClient) ListLocations(ctx context.Context, in *ListLocati
 	return out, nil
 }
 
+var snackInventoryUpdateLocationStreamDesc = &grpc.StreamDesc{
+	StreamName: "UpdateLocation",
+}
+
+func (c *snackInventoryClient) UpdateLocation(ctx context.Context, in *UpdateLocationRequest, opts ...grpc.CallOption) (*UpdateLocationResponse, error) {
+	out := new(UpdateLocationResponse)
+	err := c.cc.Invoke(ctx, "/snackinventory.SnackInventory/UpdateLocation", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 var snackInventoryDeleteLocationStreamDesc = &grpc.StreamDesc{
 	StreamName: "DeleteLocation",
 }
@@ -125,18 +191,236 @@ func (c *snackInventoryClient) DeleteLocation(ctx context.Context, in *DeleteLoc
 	return out, nil
 }
 
+var snackInventoryAdjustStockStreamDesc = &grpc.StreamDesc{
+	StreamName: "AdjustStock",
+}
+
+func (c *snackInventoryClient) AdjustStock(ctx context.Context, in *AdjustStockRequest, opts ...grpc.CallOption) (*AdjustStockResponse, error) {
+	out := new(AdjustStockResponse)
+	err := c.cc.Invoke(ctx, "/snackinventory.SnackInventory/AdjustStock", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+var snackInventoryListStockStreamDesc = &grpc.StreamDesc{
+	StreamName: "ListStock",
+}
+
+func (c *snackInventoryClient) ListStock(ctx context.Context, in *ListStockRequest, opts ...grpc.CallOption) (*ListStockResponse, error) {
+	out := new(ListStockResponse)
+	err := c.cc.Invoke(ctx, "/snackinventory.SnackInventory/ListStock", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+var snackInventoryWatchChangesStreamDesc = &grpc.StreamDesc{
+	StreamName:    "WatchChanges",
+	ServerStreams: true,
+}
+
+func (c *snackInventoryClient) WatchChanges(ctx context.Context, in *WatchChangesRequest, opts ...grpc.CallOption) (SnackInventory_WatchChangesClient, error) {
+	stream, err := c.cc.NewStream(ctx, snackInventoryWatchChangesStreamDesc, "/snackinventory.SnackInventory/WatchChanges", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &snackInventoryWatchChangesClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type SnackInventory_WatchChangesClient interface {
+	Recv() (*Change, error)
+	grpc.ClientStream
+}
+
+type snackInventoryWatchChangesClient struct {
+	grpc.ClientStream
+}
+
+func (x *snackInventoryWatchChangesClient) Recv() (*Change, error) {
+	m := new(Change)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+var snackInventoryListDeletedStreamDesc = &grpc.StreamDesc{
+	StreamName: "ListDeleted",
+}
+
+func (c *snackInventoryClient) ListDeleted(ctx context.Context, in *ListDeletedRequest, opts ...grpc.CallOption) (*ListDeletedResponse, error) {
+	out := new(ListDeletedResponse)
+	err := c.cc.Invoke(ctx, "/snackinventory.SnackInventory/ListDeleted", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+var snackInventoryUndeleteSnackStreamDesc = &grpc.StreamDesc{
+	StreamName: "UndeleteSnack",
+}
+
+func (c *snackInventoryClient) UndeleteSnack(ctx context.Context, in *UndeleteSnackRequest, opts ...grpc.CallOption) (*UndeleteSnackResponse, error) {
+	out := new(UndeleteSnackResponse)
+	err := c.cc.Invoke(ctx, "/snackinventory.SnackInventory/UndeleteSnack", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+var snackInventoryUndeleteLocationStreamDesc = &grpc.StreamDesc{
+	StreamName: "UndeleteLocation",
+}
+
+func (c *snackInventoryClient) UndeleteLocation(ctx context.Context, in *UndeleteLocationRequest, opts ...grpc.CallOption) (*UndeleteLocationResponse, error) {
+	out := new(UndeleteLocationResponse)
+	err := c.cc.Invoke(ctx, "/snackinventory.SnackInventory/UndeleteLocation", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+var snackInventoryPurgeDeletedStreamDesc = &grpc.StreamDesc{
+	StreamName: "PurgeDeleted",
+}
+
+func (c *snackInventoryClient) PurgeDeleted(ctx context.Context, in *PurgeDeletedRequest, opts ...grpc.CallOption) (*PurgeDeletedResponse, error) {
+	out := new(PurgeDeletedResponse)
+	err := c.cc.Invoke(ctx, "/snackinventory.SnackInventory/PurgeDeleted", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+var snackInventoryGetSnackHistoryStreamDesc = &grpc.StreamDesc{
+	StreamName: "GetSnackHistory",
+}
+
+func (c *snackInventoryClient) GetSnackHistory(ctx context.Context, in *GetSnackHistoryRequest, opts ...grpc.CallOption) (*GetSnackHistoryResponse, error) {
+	out := new(GetSnackHistoryResponse)
+	err := c.cc.Invoke(ctx, "/snackinventory.SnackInventory/GetSnackHistory", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+var snackInventoryExportAllStreamDesc = &grpc.StreamDesc{
+	StreamName:    "ExportAll",
+	ServerStreams: true,
+}
+
+func (c *snackInventoryClient) ExportAll(ctx context.Context, in *ExportAllRequest, opts ...grpc.CallOption) (SnackInventory_ExportAllClient, error) {
+	stream, err := c.cc.NewStream(ctx, snackInventoryExportAllStreamDesc, "/snackinventory.SnackInventory/ExportAll", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &snackInventoryExportAllClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type SnackInventory_ExportAllClient interface {
+	Recv() (*BackupRecord, error)
+	grpc.ClientStream
+}
+
+type snackInventoryExportAllClient struct {
+	grpc.ClientStream
+}
+
+func (x *snackInventoryExportAllClient) Recv() (*BackupRecord, error) {
+	m := new(BackupRecord)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+var snackInventoryImportAllStreamDesc = &grpc.StreamDesc{
+	StreamName:    "ImportAll",
+	ClientStreams: true,
+}
+
+func (c *snackInventoryClient) ImportAll(ctx context.Context, opts ...grpc.CallOption) (SnackInventory_ImportAllClient, error) {
+	stream, err := c.cc.NewStream(ctx, snackInventoryImportAllStreamDesc, "/snackinventory.SnackInventory/ImportAll", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &snackInventoryImportAllClient{stream}
+	return x, nil
+}
+
+type SnackInventory_ImportAllClient interface {
+	Send(*BackupRecord) error
+	CloseAndRecv() (*ImportAllResponse, error)
+	grpc.ClientStream
+}
+
+type snackInventoryImportAllClient struct {
+	grpc.ClientStream
+}
+
+func (x *snackInventoryImportAllClient) Send(m *BackupRecord) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *snackInventoryImportAllClient) CloseAndRecv() (*ImportAllResponse, error) {
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	m := new(ImportAllResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 // SnackInventoryService is the service API for SnackInventory service.
 // Fields should be assigned to their respective handler implementations only before
 // RegisterSnackInventoryService is called.  Any unassigned fields will result in the
 // handler for that method returning an Unimplemented error.
 type SnackInventoryService struct {
-	CreateSnack    func(context.Context, *CreateSnackRequest) (*CreateSnackResponse, error)
-	ListSnacks     func(context.Context, *ListSnacksRequest) (*ListSnacksResponse, error)
-	UpdateSnack    func(context.Context, *UpdateSnackRequest) (*UpdateSnackResponse, error)
-	DeleteSnack    func(context.Context, *DeleteSnackRequest) (*DeleteSnackResponse, error)
-	CreateLocation func(context.Context, *CreateLocationRequest) (*CreateLocationResponse, error)
-	ListLocations  func(context.Context, *ListLocationsRequest) (*ListLocationsResponse, error)
-	DeleteLocation func(context.Context, *DeleteLocationRequest) (*DeleteLocationResponse, error)
+	CreateSnack       func(context.Context, *CreateSnackRequest) (*CreateSnackResponse, error)
+	ListSnacks        func(context.Context, *ListSnacksRequest) (*ListSnacksResponse, error)
+	UpdateSnack       func(context.Context, *UpdateSnackRequest) (*UpdateSnackResponse, error)
+	DeleteSnack       func(context.Context, *DeleteSnackRequest) (*DeleteSnackResponse, error)
+	BatchCreateSnacks func(context.Context, *BatchCreateSnacksRequest) (*BatchCreateSnacksResponse, error)
+	BatchUpdateSnacks func(context.Context, *BatchUpdateSnacksRequest) (*BatchUpdateSnacksResponse, error)
+	BatchDeleteSnacks func(context.Context, *BatchDeleteSnacksRequest) (*BatchDeleteSnacksResponse, error)
+	CreateLocation    func(context.Context, *CreateLocationRequest) (*CreateLocationResponse, error)
+	ListLocations     func(context.Context, *ListLocationsRequest) (*ListLocationsResponse, error)
+	UpdateLocation    func(context.Context, *UpdateLocationRequest) (*UpdateLocationResponse, error)
+	DeleteLocation    func(context.Context, *DeleteLocationRequest) (*DeleteLocationResponse, error)
+	AdjustStock       func(context.Context, *AdjustStockRequest) (*AdjustStockResponse, error)
+	ListStock         func(context.Context, *ListStockRequest) (*ListStockResponse, error)
+	WatchChanges      func(*WatchChangesRequest, SnackInventory_WatchChangesServer) error
+	ListDeleted       func(context.Context, *ListDeletedRequest) (*ListDeletedResponse, error)
+	UndeleteSnack     func(context.Context, *UndeleteSnackRequest) (*UndeleteSnackResponse, error)
+	UndeleteLocation  func(context.Context, *UndeleteLocationRequest) (*UndeleteLocationResponse, error)
+	PurgeDeleted      func(context.Context, *PurgeDeletedRequest) (*PurgeDeletedResponse, error)
+	GetSnackHistory   func(context.Context, *GetSnackHistoryRequest) (*GetSnackHistoryResponse, error)
+	ExportAll         func(*ExportAllRequest, SnackInventory_ExportAllServer) error
+	ImportAll         func(SnackInventory_ImportAllServer) error
 }
 
 func (s *SnackInventoryService) createSnack(_ interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
@@ -207,6 +491,57 @@ func (s *SnackInventoryService) deleteSnack(_ interface{}, ctx context.Context,
 	}
 	return interceptor(ctx, in, info, handler)
 }
+func (s *SnackInventoryService) batchCreateSnacks(_ interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BatchCreateSnacksRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return s.BatchCreateSnacks(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     s,
+		FullMethod: "/snackinventory.SnackInventory/BatchCreateSnacks",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return s.BatchCreateSnacks(ctx, req.(*BatchCreateSnacksRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+func (s *SnackInventoryService) batchUpdateSnacks(_ interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BatchUpdateSnacksRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return s.BatchUpdateSnacks(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     s,
+		FullMethod: "/snackinventory.SnackInventory/BatchUpdateSnacks",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return s.BatchUpdateSnacks(ctx, req.(*BatchUpdateSnacksRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+func (s *SnackInventoryService) batchDeleteSnacks(_ interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BatchDeleteSnacksRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return s.BatchDeleteSnacks(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     s,
+		FullMethod: "/snackinventory.SnackInventory/BatchDeleteSnacks",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return s.BatchDeleteSnacks(ctx, req.(*BatchDeleteSnacksRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
 func (s *SnackInventoryService) createLocation(_ interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(CreateLocationRequest)
 	if err := dec(in); err != nil {
@@ -241,6 +576,23 @@ func (s *SnackInventoryService) listLocations(_ interface{}, ctx context.Context
 	}
 	return interceptor(ctx, in, info, handler)
 }
+func (s *SnackInventoryService) updateLocation(_ interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateLocationRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return s.UpdateLocation(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     s,
+		FullMethod: "/snackinventory.SnackInventory/UpdateLocation",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return s.UpdateLocation(ctx, req.(*UpdateLocationRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
 func (s *SnackInventoryService) deleteLocation(_ interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(DeleteLocationRequest)
 	if err := dec(in); err != nil {
@@ -258,6 +610,192 @@ func (s *SnackInventoryService) deleteLocation(_ interface{}, ctx context.Contex
 	}
 	return interceptor(ctx, in, info, handler)
 }
+func (s *SnackInventoryService) adjustStock(_ interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AdjustStockRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return s.AdjustStock(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     s,
+		FullMethod: "/snackinventory.SnackInventory/AdjustStock",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return s.AdjustStock(ctx, req.(*AdjustStockRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+func (s *SnackInventoryService) listStock(_ interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListStockRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return s.ListStock(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     s,
+		FullMethod: "/snackinventory.SnackInventory/ListStock",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return s.ListStock(ctx, req.(*ListStockRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+func (s *SnackInventoryService) watchChanges(_ interface{}, stream grpc.ServerStream) error {
+	m := new(WatchChangesRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return s.WatchChanges(m, &snackInventoryWatchChangesServer{stream})
+}
+
+type SnackInventory_WatchChangesServer interface {
+	Send(*Change) error
+	grpc.ServerStream
+}
+
+type snackInventoryWatchChangesServer struct {
+	grpc.ServerStream
+}
+
+func (x *snackInventoryWatchChangesServer) Send(m *Change) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (s *SnackInventoryService) listDeleted(_ interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListDeletedRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return s.ListDeleted(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     s,
+		FullMethod: "/snackinventory.SnackInventory/ListDeleted",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return s.ListDeleted(ctx, req.(*ListDeletedRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+func (s *SnackInventoryService) undeleteSnack(_ interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UndeleteSnackRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return s.UndeleteSnack(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     s,
+		FullMethod: "/snackinventory.SnackInventory/UndeleteSnack",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return s.UndeleteSnack(ctx, req.(*UndeleteSnackRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+func (s *SnackInventoryService) undeleteLocation(_ interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UndeleteLocationRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return s.UndeleteLocation(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     s,
+		FullMethod: "/snackinventory.SnackInventory/UndeleteLocation",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return s.UndeleteLocation(ctx, req.(*UndeleteLocationRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+func (s *SnackInventoryService) purgeDeleted(_ interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PurgeDeletedRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return s.PurgeDeleted(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     s,
+		FullMethod: "/snackinventory.SnackInventory/PurgeDeleted",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return s.PurgeDeleted(ctx, req.(*PurgeDeletedRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+func (s *SnackInventoryService) getSnackHistory(_ interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetSnackHistoryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return s.GetSnackHistory(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     s,
+		FullMethod: "/snackinventory.SnackInventory/GetSnackHistory",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return s.GetSnackHistory(ctx, req.(*GetSnackHistoryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+func (s *SnackInventoryService) exportAll(_ interface{}, stream grpc.ServerStream) error {
+	m := new(ExportAllRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return s.ExportAll(m, &snackInventoryExportAllServer{stream})
+}
+
+type SnackInventory_ExportAllServer interface {
+	Send(*BackupRecord) error
+	grpc.ServerStream
+}
+
+type snackInventoryExportAllServer struct {
+	grpc.ServerStream
+}
+
+func (x *snackInventoryExportAllServer) Send(m *BackupRecord) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (s *SnackInventoryService) importAll(_ interface{}, stream grpc.ServerStream) error {
+	return s.ImportAll(&snackInventoryImportAllServer{stream})
+}
+
+type SnackInventory_ImportAllServer interface {
+	SendAndClose(*ImportAllResponse) error
+	Recv() (*BackupRecord, error)
+	grpc.ServerStream
+}
+
+type snackInventoryImportAllServer struct {
+	grpc.ServerStream
+}
+
+func (x *snackInventoryImportAllServer) SendAndClose(m *ImportAllResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *snackInventoryImportAllServer) Recv() (*BackupRecord, error) {
+	m := new(BackupRecord)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
 
 // RegisterSnackInventoryService registers a service implementation with a gRPC server.
 func RegisterSnackInventoryService(s grpc.ServiceRegistrar, srv *SnackInventoryService) {
@@ -282,6 +820,21 @@ func RegisterSnackInventoryService(s grpc.ServiceRegistrar, srv *SnackInventoryS
 			return nil, status.Errorf(codes.Unimplemented, "method DeleteSnack not implemented")
 		}
 	}
+	if srvCopy.BatchCreateSnacks == nil {
+		srvCopy.BatchCreateSnacks = func(context.Context, *BatchCreateSnacksRequest) (*BatchCreateSnacksResponse, error) {
+			return nil, status.Errorf(codes.Unimplemented, "method BatchCreateSnacks not implemented")
+		}
+	}
+	if srvCopy.BatchUpdateSnacks == nil {
+		srvCopy.BatchUpdateSnacks = func(context.Context, *BatchUpdateSnacksRequest) (*BatchUpdateSnacksResponse, error) {
+			return nil, status.Errorf(codes.Unimplemented, "method BatchUpdateSnacks not implemented")
+		}
+	}
+	if srvCopy.BatchDeleteSnacks == nil {
+		srvCopy.BatchDeleteSnacks = func(context.Context, *BatchDeleteSnacksRequest) (*BatchDeleteSnacksResponse, error) {
+			return nil, status.Errorf(codes.Unimplemented, "method BatchDeleteSnacks not implemented")
+		}
+	}
 	if srvCopy.CreateLocation == nil {
 		srvCopy.CreateLocation = func(context.Context, *CreateLocationRequest) (*CreateLocationResponse, error) {
 			return nil, status.Errorf(codes.Unimplemented, "method CreateLocation not implemented")
@@ -292,11 +845,66 @@ func RegisterSnackInventoryService(s grpc.ServiceRegistrar, srv *SnackInventoryS
 			return nil, status.Errorf(codes.Unimplemented, "method ListLocations not implemented")
 		}
 	}
+	if srvCopy.UpdateLocation == nil {
+		srvCopy.UpdateLocation = func(context.Context, *UpdateLocationRequest) (*UpdateLocationResponse, error) {
+			return nil, status.Errorf(codes.Unimplemented, "method UpdateLocation not implemented")
+		}
+	}
 	if srvCopy.DeleteLocation == nil {
 		srvCopy.DeleteLocation = func(context.Context, *DeleteLocationRequest) (*DeleteLocationResponse, error) {
 			return nil, status.Errorf(codes.Unimplemented, "method DeleteLocation not implemented")
 		}
 	}
+	if srvCopy.AdjustStock == nil {
+		srvCopy.AdjustStock = func(context.Context, *AdjustStockRequest) (*AdjustStockResponse, error) {
+			return nil, status.Errorf(codes.Unimplemented, "method AdjustStock not implemented")
+		}
+	}
+	if srvCopy.ListStock == nil {
+		srvCopy.ListStock = func(context.Context, *ListStockRequest) (*ListStockResponse, error) {
+			return nil, status.Errorf(codes.Unimplemented, "method ListStock not implemented")
+		}
+	}
+	if srvCopy.WatchChanges == nil {
+		srvCopy.WatchChanges = func(*WatchChangesRequest, SnackInventory_WatchChangesServer) error {
+			return status.Errorf(codes.Unimplemented, "method WatchChanges not implemented")
+		}
+	}
+	if srvCopy.ListDeleted == nil {
+		srvCopy.ListDeleted = func(context.Context, *ListDeletedRequest) (*ListDeletedResponse, error) {
+			return nil, status.Errorf(codes.Unimplemented, "method ListDeleted not implemented")
+		}
+	}
+	if srvCopy.UndeleteSnack == nil {
+		srvCopy.UndeleteSnack = func(context.Context, *UndeleteSnackRequest) (*UndeleteSnackResponse, error) {
+			return nil, status.Errorf(codes.Unimplemented, "method UndeleteSnack not implemented")
+		}
+	}
+	if srvCopy.UndeleteLocation == nil {
+		srvCopy.UndeleteLocation = func(context.Context, *UndeleteLocationRequest) (*UndeleteLocationResponse, error) {
+			return nil, status.Errorf(codes.Unimplemented, "method UndeleteLocation not implemented")
+		}
+	}
+	if srvCopy.PurgeDeleted == nil {
+		srvCopy.PurgeDeleted = func(context.Context, *PurgeDeletedRequest) (*PurgeDeletedResponse, error) {
+			return nil, status.Errorf(codes.Unimplemented, "method PurgeDeleted not implemented")
+		}
+	}
+	if srvCopy.GetSnackHistory == nil {
+		srvCopy.GetSnackHistory = func(context.Context, *GetSnackHistoryRequest) (*GetSnackHistoryResponse, error) {
+			return nil, status.Errorf(codes.Unimplemented, "method GetSnackHistory not implemented")
+		}
+	}
+	if srvCopy.ExportAll == nil {
+		srvCopy.ExportAll = func(*ExportAllRequest, SnackInventory_ExportAllServer) error {
+			return status.Errorf(codes.Unimplemented, "method ExportAll not implemented")
+		}
+	}
+	if srvCopy.ImportAll == nil {
+		srvCopy.ImportAll = func(SnackInventory_ImportAllServer) error {
+			return status.Errorf(codes.Unimplemented, "method ImportAll not implemented")
+		}
+	}
 	sd := grpc.ServiceDesc{
 		ServiceName: "snackinventory.SnackInventory",
 		Methods: []grpc.MethodDesc{
@@ -316,6 +924,18 @@ func RegisterSnackInventoryService(s grpc.ServiceRegistrar, srv *SnackInventoryS
 				MethodName: "DeleteSnack",
 				Handler:    srvCopy.deleteSnack,
 			},
+			{
+				MethodName: "BatchCreateSnacks",
+				Handler:    srvCopy.batchCreateSnacks,
+			},
+			{
+				MethodName: "BatchUpdateSnacks",
+				Handler:    srvCopy.batchUpdateSnacks,
+			},
+			{
+				MethodName: "BatchDeleteSnacks",
+				Handler:    srvCopy.batchDeleteSnacks,
+			},
 			{
 				MethodName: "CreateLocation",
 				Handler:    srvCopy.createLocation,
@@ -324,12 +944,60 @@ func RegisterSnackInventoryService(s grpc.ServiceRegistrar, srv *SnackInventoryS
 				MethodName: "ListLocations",
 				Handler:    srvCopy.listLocations,
 			},
+			{
+				MethodName: "UpdateLocation",
+				Handler:    srvCopy.updateLocation,
+			},
 			{
 				MethodName: "DeleteLocation",
 				Handler:    srvCopy.deleteLocation,
 			},
+			{
+				MethodName: "AdjustStock",
+				Handler:    srvCopy.adjustStock,
+			},
+			{
+				MethodName: "ListStock",
+				Handler:    srvCopy.listStock,
+			},
+			{
+				MethodName: "ListDeleted",
+				Handler:    srvCopy.listDeleted,
+			},
+			{
+				MethodName: "UndeleteSnack",
+				Handler:    srvCopy.undeleteSnack,
+			},
+			{
+				MethodName: "UndeleteLocation",
+				Handler:    srvCopy.undeleteLocation,
+			},
+			{
+				MethodName: "PurgeDeleted",
+				Handler:    srvCopy.purgeDeleted,
+			},
+			{
+				MethodName: "GetSnackHistory",
+				Handler:    srvCopy.getSnackHistory,
+			},
+		},
+		Streams: []grpc.StreamDesc{
+			{
+				StreamName:    "WatchChanges",
+				Handler:       srvCopy.watchChanges,
+				ServerStreams: true,
+			},
+			{
+				StreamName:    "ExportAll",
+				Handler:       srvCopy.exportAll,
+				ServerStreams: true,
+			},
+			{
+				StreamName:    "ImportAll",
+				Handler:       srvCopy.importAll,
+				ClientStreams: true,
+			},
 		},
-		Streams:  []grpc.StreamDesc{},
 		Metadata: "snackinventory.proto",
 	}
 
@@ -364,6 +1032,21 @@ func NewSnackInventoryService(s interface{}) *SnackInventoryService {
 	}); ok {
 		ns.DeleteSnack = h.DeleteSnack
 	}
+	if h, ok := s.(interface {
+		BatchCreateSnacks(context.Context, *BatchCreateSnacksRequest) (*BatchCreateSnacksResponse, error)
+	}); ok {
+		ns.BatchCreateSnacks = h.BatchCreateSnacks
+	}
+	if h, ok := s.(interface {
+		BatchUpdateSnacks(context.Context, *BatchUpdateSnacksRequest) (*BatchUpdateSnacksResponse, error)
+	}); ok {
+		ns.BatchUpdateSnacks = h.BatchUpdateSnacks
+	}
+	if h, ok := s.(interface {
+		BatchDeleteSnacks(context.Context, *BatchDeleteSnacksRequest) (*BatchDeleteSnacksResponse, error)
+	}); ok {
+		ns.BatchDeleteSnacks = h.BatchDeleteSnacks
+	}
 	if h, ok := s.(interface {
 		CreateLocation(context.Context, *CreateLocationRequest) (*CreateLocationResponse, error)
 	}); ok {
@@ -374,11 +1057,66 @@ func NewSnackInventoryService(s interface{}) *SnackInventoryService {
 	}); ok {
 		ns.ListLocations = h.ListLocations
 	}
+	if h, ok := s.(interface {
+		UpdateLocation(context.Context, *UpdateLocationRequest) (*UpdateLocationResponse, error)
+	}); ok {
+		ns.UpdateLocation = h.UpdateLocation
+	}
 	if h, ok := s.(interface {
 		DeleteLocation(context.Context, *DeleteLocationRequest) (*DeleteLocationResponse, error)
 	}); ok {
 		ns.DeleteLocation = h.DeleteLocation
 	}
+	if h, ok := s.(interface {
+		AdjustStock(context.Context, *AdjustStockRequest) (*AdjustStockResponse, error)
+	}); ok {
+		ns.AdjustStock = h.AdjustStock
+	}
+	if h, ok := s.(interface {
+		ListStock(context.Context, *ListStockRequest) (*ListStockResponse, error)
+	}); ok {
+		ns.ListStock = h.ListStock
+	}
+	if h, ok := s.(interface {
+		WatchChanges(*WatchChangesRequest, SnackInventory_WatchChangesServer) error
+	}); ok {
+		ns.WatchChanges = h.WatchChanges
+	}
+	if h, ok := s.(interface {
+		ListDeleted(context.Context, *ListDeletedRequest) (*ListDeletedResponse, error)
+	}); ok {
+		ns.ListDeleted = h.ListDeleted
+	}
+	if h, ok := s.(interface {
+		UndeleteSnack(context.Context, *UndeleteSnackRequest) (*UndeleteSnackResponse, error)
+	}); ok {
+		ns.UndeleteSnack = h.UndeleteSnack
+	}
+	if h, ok := s.(interface {
+		UndeleteLocation(context.Context, *UndeleteLocationRequest) (*UndeleteLocationResponse, error)
+	}); ok {
+		ns.UndeleteLocation = h.UndeleteLocation
+	}
+	if h, ok := s.(interface {
+		PurgeDeleted(context.Context, *PurgeDeletedRequest) (*PurgeDeletedResponse, error)
+	}); ok {
+		ns.PurgeDeleted = h.PurgeDeleted
+	}
+	if h, ok := s.(interface {
+		GetSnackHistory(context.Context, *GetSnackHistoryRequest) (*GetSnackHistoryResponse, error)
+	}); ok {
+		ns.GetSnackHistory = h.GetSnackHistory
+	}
+	if h, ok := s.(interface {
+		ExportAll(*ExportAllRequest, SnackInventory_ExportAllServer) error
+	}); ok {
+		ns.ExportAll = h.ExportAll
+	}
+	if h, ok := s.(interface {
+		ImportAll(SnackInventory_ImportAllServer) error
+	}); ok {
+		ns.ImportAll = h.ImportAll
+	}
 	return ns
 }
 
@@ -391,7 +1129,21 @@ type UnstableSnackInventoryService interface {
 	ListSnacks(context.Context, *ListSnacksRequest) (*ListSnacksResponse, error)
 	UpdateSnack(context.Context, *UpdateSnackRequest) (*UpdateSnackResponse, error)
 	DeleteSnack(context.Context, *DeleteSnackRequest) (*DeleteSnackResponse, error)
+	BatchCreateSnacks(context.Context, *BatchCreateSnacksRequest) (*BatchCreateSnacksResponse, error)
+	BatchUpdateSnacks(context.Context, *BatchUpdateSnacksRequest) (*BatchUpdateSnacksResponse, error)
+	BatchDeleteSnacks(context.Context, *BatchDeleteSnacksRequest) (*BatchDeleteSnacksResponse, error)
 	CreateLocation(context.Context, *CreateLocationRequest) (*CreateLocationResponse, error)
 	ListLocations(context.Context, *ListLocationsRequest) (*ListLocationsResponse, error)
+	UpdateLocation(context.Context, *UpdateLocationRequest) (*UpdateLocationResponse, error)
 	DeleteLocation(context.Context, *DeleteLocationRequest) (*DeleteLocationResponse, error)
+	AdjustStock(context.Context, *AdjustStockRequest) (*AdjustStockResponse, error)
+	ListStock(context.Context, *ListStockRequest) (*ListStockResponse, error)
+	WatchChanges(*WatchChangesRequest, SnackInventory_WatchChangesServer) error
+	ListDeleted(context.Context, *ListDeletedRequest) (*ListDeletedResponse, error)
+	UndeleteSnack(context.Context, *UndeleteSnackRequest) (*UndeleteSnackResponse, error)
+	UndeleteLocation(context.Context, *UndeleteLocationRequest) (*UndeleteLocationResponse, error)
+	PurgeDeleted(context.Context, *PurgeDeletedRequest) (*PurgeDeletedResponse, error)
+	GetSnackHistory(context.Context, *GetSnackHistoryRequest) (*GetSnackHistoryResponse, error)
+	ExportAll(*ExportAllRequest, SnackInventory_ExportAllServer) error
+	ImportAll(SnackInventory_ImportAllServer) error
 }
@@ -0,0 +1,132 @@
+/*
+Copyright 2020 Robert Barron
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tracing
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// JSONExporter writes each span as a single line of JSON.
+type JSONExporter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewJSONExporter creates an Exporter writing JSON lines to w.
+func NewJSONExporter(w io.Writer) *JSONExporter {
+	return &JSONExporter{w: w}
+}
+
+// Export implements Exporter.
+func (e *JSONExporter) Export(d *SpanData) error {
+	b, err := json.Marshal(d)
+	if err != nil {
+		return err
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	_, err = e.w.Write(append(b, '\n'))
+	return err
+}
+
+// HTTPExporter posts spans as JSON to a collector endpoint, in the spirit of
+// OTLP/HTTP. Spans are buffered and sent in the background so that exporting
+// never blocks request handling; spans are dropped if the buffer is full.
+type HTTPExporter struct {
+	url    string
+	client *http.Client
+	spans  chan *SpanData
+	done   chan struct{}
+}
+
+// NewHTTPExporter creates an HTTPExporter posting to url.
+// Call Shutdown to flush buffered spans.
+func NewHTTPExporter(url string) *HTTPExporter {
+	e := &HTTPExporter{
+		url:    url,
+		client: &http.Client{Timeout: 5 * time.Second},
+		spans:  make(chan *SpanData, 1024),
+		done:   make(chan struct{}),
+	}
+	go e.run()
+	return e
+}
+
+// Export implements Exporter.
+func (e *HTTPExporter) Export(d *SpanData) error {
+	select {
+	case e.spans <- d:
+		return nil
+	default:
+		return fmt.Errorf("export buffer full, dropping span")
+	}
+}
+
+// Shutdown sends any buffered spans and stops the exporter.
+// Export must not be called after Shutdown.
+func (e *HTTPExporter) Shutdown() {
+	close(e.spans)
+	<-e.done
+}
+
+// run sends spans to the collector in batches of whatever is buffered.
+func (e *HTTPExporter) run() {
+	defer close(e.done)
+	for d := range e.spans {
+		batch := []*SpanData{d}
+	drain:
+		for len(batch) < 100 {
+			select {
+			case d, ok := <-e.spans:
+				if !ok {
+					break drain
+				}
+				batch = append(batch, d)
+			default:
+				break drain
+			}
+		}
+		if err := e.post(batch); err != nil {
+			log.Printf("tracing: could not export %d spans to %s: %v", len(batch), e.url, err)
+		}
+	}
+}
+
+func (e *HTTPExporter) post(batch []*SpanData) error {
+	b, err := json.Marshal(struct {
+		Spans []*SpanData `json:"spans"`
+	}{batch})
+	if err != nil {
+		return err
+	}
+	res, err := e.client.Post(e.url, "application/json", bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode/100 != 2 {
+		return fmt.Errorf("collector returned %s", res.Status)
+	}
+	return nil
+}
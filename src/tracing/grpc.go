@@ -0,0 +1,100 @@
+/*
+Copyright 2020 Robert Barron
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tracing
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// UnaryClientInterceptor starts a client span for each RPC and propagates its
+// context to the server via the `traceparent` metadata key.
+func UnaryClientInterceptor(t *Tracer) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		var parent SpanContext
+		if p := SpanFromContext(ctx); p != nil {
+			parent = p.sc
+		}
+		ctx, span := t.start(ctx, method, KindClient, parent)
+		defer span.End()
+		span.SetAttribute("rpc.method", method)
+
+		ctx = metadata.AppendToOutgoingContext(ctx, TraceparentHeader, span.sc.Traceparent())
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		span.SetAttribute("rpc.grpc.status_code", status.Code(err).String())
+		span.SetError(err)
+		return err
+	}
+}
+
+// UnaryServerInterceptor starts a server span for each RPC, continuing the
+// trace from an incoming `traceparent` if present. The span and t are carried
+// in the handler's context, so StartSpan creates children of the RPC span.
+func UnaryServerInterceptor(t *Tracer) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, span := startServerSpan(ctx, t, info.FullMethod)
+		defer span.End()
+
+		res, err := handler(ctx, req)
+		span.SetAttribute("rpc.grpc.status_code", status.Code(err).String())
+		span.SetError(err)
+		return res, err
+	}
+}
+
+// StreamServerInterceptor is UnaryServerInterceptor for streaming RPCs. The
+// span covers the whole stream.
+func StreamServerInterceptor(t *Tracer) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, span := startServerSpan(ss.Context(), t, info.FullMethod)
+		defer span.End()
+
+		err := handler(srv, &serverStream{ServerStream: ss, ctx: ctx})
+		span.SetAttribute("rpc.grpc.status_code", status.Code(err).String())
+		span.SetError(err)
+		return err
+	}
+}
+
+// startServerSpan starts the server span of an RPC to method, continuing the
+// trace from an incoming `traceparent` if present.
+func startServerSpan(ctx context.Context, t *Tracer, method string) (context.Context, *Span) {
+	var parent SpanContext
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if v := md.Get(TraceparentHeader); len(v) > 0 {
+			// A malformed header starts a new trace rather than failing the RPC.
+			parent, _ = ParseTraceparent(v[0])
+		}
+	}
+	ctx, span := t.start(ctx, method, KindServer, parent)
+	span.SetAttribute("rpc.method", method)
+	return ctx, span
+}
+
+// serverStream overrides the context of a grpc.ServerStream, so handlers see
+// the RPC's span.
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
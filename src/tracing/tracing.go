@@ -0,0 +1,259 @@
+/*
+Copyright 2020 Robert Barron
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package tracing provides lightweight, OpenTelemetry-style request tracing
+// shared by the SnackInventory CLI and backend.
+//
+// Trace context is propagated between processes via the W3C `traceparent`
+// header, carried in gRPC metadata. Within a process, the active span is
+// carried in a context.Context. Finished spans are handed to an Exporter,
+// which may write them to a local file or ship them to a collector.
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TraceparentHeader is the W3C Trace Context header used for propagation.
+const TraceparentHeader = "traceparent"
+
+// SpanContext identifies a span within a trace.
+type SpanContext struct {
+	TraceID [16]byte
+	SpanID  [8]byte
+}
+
+// IsValid reports whether sc has non-zero trace and span IDs.
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID != [16]byte{} && sc.SpanID != [8]byte{}
+}
+
+// Traceparent formats sc as a W3C `traceparent` header value.
+func (sc SpanContext) Traceparent() string {
+	return fmt.Sprintf("00-%s-%s-01", hex.EncodeToString(sc.TraceID[:]), hex.EncodeToString(sc.SpanID[:]))
+}
+
+// ParseTraceparent parses a W3C `traceparent` header value.
+func ParseTraceparent(s string) (SpanContext, error) {
+	var sc SpanContext
+	parts := strings.Split(strings.TrimSpace(s), "-")
+	if len(parts) != 4 || parts[0] != "00" {
+		return sc, fmt.Errorf("malformed traceparent %q", s)
+	}
+	// Lengths are checked first, as hex.Decode panics on overlong input.
+	if len(parts[1]) != hex.EncodedLen(len(sc.TraceID)) {
+		return sc, fmt.Errorf("malformed trace ID in traceparent %q", s)
+	}
+	if n, err := hex.Decode(sc.TraceID[:], []byte(parts[1])); err != nil || n != len(sc.TraceID) {
+		return sc, fmt.Errorf("malformed trace ID in traceparent %q", s)
+	}
+	if len(parts[2]) != hex.EncodedLen(len(sc.SpanID)) {
+		return sc, fmt.Errorf("malformed span ID in traceparent %q", s)
+	}
+	if n, err := hex.Decode(sc.SpanID[:], []byte(parts[2])); err != nil || n != len(sc.SpanID) {
+		return sc, fmt.Errorf("malformed span ID in traceparent %q", s)
+	}
+	if !sc.IsValid() {
+		return sc, fmt.Errorf("traceparent %q has zero IDs", s)
+	}
+	return sc, nil
+}
+
+// SpanData is the exported record of a finished span.
+type SpanData struct {
+	Name       string            `json:"name"`
+	TraceID    string            `json:"trace_id"`
+	SpanID     string            `json:"span_id"`
+	ParentID   string            `json:"parent_span_id,omitempty"`
+	Kind       string            `json:"kind"`
+	Start      time.Time         `json:"start_time"`
+	End        time.Time         `json:"end_time"`
+	Attributes map[string]string `json:"attributes,omitempty"`
+	Error      string            `json:"error,omitempty"`
+}
+
+// Exporter receives spans as they finish.
+// Implementations must be safe for concurrent use.
+type Exporter interface {
+	Export(*SpanData) error
+}
+
+// Span kinds, following OpenTelemetry naming.
+const (
+	KindInternal = "internal"
+	KindServer   = "server"
+	KindClient   = "client"
+)
+
+// Tracer creates spans and hands them to an Exporter when they end.
+type Tracer struct {
+	exporter Exporter
+}
+
+// NewTracer creates a Tracer exporting to e.
+// A nil Exporter is valid: spans are created & propagated, but not exported.
+func NewTracer(e Exporter) *Tracer {
+	return &Tracer{exporter: e}
+}
+
+// Span is a single timed operation within a trace.
+// A nil *Span is valid, and all operations on it are no-ops.
+type Span struct {
+	tracer *Tracer
+	sc     SpanContext
+	parent SpanContext
+	name   string
+	kind   string
+	start  time.Time
+
+	mu    sync.Mutex
+	attrs map[string]string
+	err   error
+	ended bool
+}
+
+type spanKey struct{}
+type tracerKey struct{}
+
+// ContextWithTracer returns a copy of ctx carrying t. Spans started from the
+// returned context (or its children) via StartSpan are created by t.
+func ContextWithTracer(ctx context.Context, t *Tracer) context.Context {
+	return context.WithValue(ctx, tracerKey{}, t)
+}
+
+// SpanFromContext returns the active span in ctx, or nil if there is none.
+func SpanFromContext(ctx context.Context) *Span {
+	s, _ := ctx.Value(spanKey{}).(*Span)
+	return s
+}
+
+// StartSpan starts an internal span as a child of the active span in ctx,
+// using the Tracer carried by ctx. If ctx carries no Tracer, the returned span
+// is nil and ctx is returned unchanged, so callers need no special handling
+// when tracing is disabled.
+func StartSpan(ctx context.Context, name string) (context.Context, *Span) {
+	t, ok := ctx.Value(tracerKey{}).(*Tracer)
+	if !ok || t == nil {
+		return ctx, nil
+	}
+	var parent SpanContext
+	if p := SpanFromContext(ctx); p != nil {
+		parent = p.sc
+	}
+	return t.start(ctx, name, KindInternal, parent)
+}
+
+// start creates a span of the given kind, continuing the trace in parent if
+// valid, and otherwise starting a new trace.
+func (t *Tracer) start(ctx context.Context, name, kind string, parent SpanContext) (context.Context, *Span) {
+	span := &Span{
+		tracer: t,
+		parent: parent,
+		name:   name,
+		kind:   kind,
+		start:  time.Now(),
+	}
+	if parent.IsValid() {
+		span.sc.TraceID = parent.TraceID
+	} else {
+		rand.Read(span.sc.TraceID[:])
+	}
+	rand.Read(span.sc.SpanID[:])
+	ctx = ContextWithTracer(ctx, t)
+	return context.WithValue(ctx, spanKey{}, span), span
+}
+
+// SpanContext returns the identifiers of s.
+func (s *Span) SpanContext() SpanContext {
+	if s == nil {
+		return SpanContext{}
+	}
+	return s.sc
+}
+
+// TraceID returns the hex-encoded trace ID of s, or "" for a nil span.
+func (s *Span) TraceID() string {
+	if s == nil {
+		return ""
+	}
+	return hex.EncodeToString(s.sc.TraceID[:])
+}
+
+// SetAttribute records a key/value pair on s.
+func (s *Span) SetAttribute(key, value string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.attrs == nil {
+		s.attrs = make(map[string]string)
+	}
+	s.attrs[key] = value
+}
+
+// SetError marks s as failed with err. A nil err is ignored.
+func (s *Span) SetError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.err = err
+}
+
+// End finishes s and exports it. Calling End more than once has no effect.
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	d := &SpanData{
+		Name:       s.name,
+		TraceID:    hex.EncodeToString(s.sc.TraceID[:]),
+		SpanID:     hex.EncodeToString(s.sc.SpanID[:]),
+		Kind:       s.kind,
+		Start:      s.start,
+		End:        time.Now(),
+		Attributes: s.attrs,
+	}
+	if s.parent.IsValid() {
+		d.ParentID = hex.EncodeToString(s.parent.SpanID[:])
+	}
+	if s.err != nil {
+		d.Error = s.err.Error()
+	}
+	s.mu.Unlock()
+
+	if s.tracer.exporter == nil {
+		return
+	}
+	if err := s.tracer.exporter.Export(d); err != nil {
+		log.Printf("tracing: could not export span %q: %v", d.Name, err)
+	}
+}
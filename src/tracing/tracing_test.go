@@ -0,0 +1,227 @@
+/*
+Copyright 2020 Robert Barron
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// readSpans decodes JSON lines written by a JSONExporter.
+func readSpans(t *testing.T, buf *bytes.Buffer) []*SpanData {
+	t.Helper()
+	var spans []*SpanData
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		d := &SpanData{}
+		if err := json.Unmarshal([]byte(line), d); err != nil {
+			t.Fatalf("json.Unmarshal(%q) = got err %v, want err nil", line, err)
+		}
+		spans = append(spans, d)
+	}
+	return spans
+}
+
+func TestTraceparentRoundTrip(t *testing.T) {
+	_, span := NewTracer(nil).start(context.Background(), "test", KindInternal, SpanContext{})
+	want := span.SpanContext()
+
+	got, err := ParseTraceparent(want.Traceparent())
+	if err != nil {
+		t.Fatalf("ParseTraceparent(%q) = got err %v, want err nil", want.Traceparent(), err)
+	}
+	if got != want {
+		t.Fatalf("ParseTraceparent(%q) = got %v, want %v", want.Traceparent(), got, want)
+	}
+}
+
+func TestParseTraceparent_Malformed(t *testing.T) {
+	for _, tp := range []string{
+		"",
+		"00-abc-def-01",
+		"01-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01",
+		"00-00000000000000000000000000000000-0000000000000000-01",
+		// Overlong & short IDs.
+		"00-0af7651916cd43dd8448eb211c80319c00-b7ad6b7169203331-01",
+		"00-0af7651916cd43dd8448eb211c80319c-b7ad6b716920333100-01",
+		"00-0af7651916cd43dd8448eb211c8031-b7ad6b7169203331-01",
+		"00-0af7651916cd43dd8448eb211c80319c-b7ad6b71692033-01",
+	} {
+		if _, err := ParseTraceparent(tp); err == nil {
+			t.Errorf("ParseTraceparent(%q) = got err nil, want err", tp)
+		}
+	}
+}
+
+func TestStartSpan_NoTracer(t *testing.T) {
+	ctx := context.Background()
+	gotCtx, span := StartSpan(ctx, "test")
+	if span != nil {
+		t.Fatalf("StartSpan(ctx, %q) = got span %v, want nil", "test", span)
+	}
+	if gotCtx != ctx {
+		t.Fatal("StartSpan(ctx, \"test\") = got new ctx, want ctx unchanged")
+	}
+	// Operations on the nil span must not panic.
+	span.SetAttribute("k", "v")
+	span.SetError(context.Canceled)
+	span.End()
+}
+
+func TestStartSpan_Child(t *testing.T) {
+	buf := &bytes.Buffer{}
+	tr := NewTracer(NewJSONExporter(buf))
+
+	ctx, parent := tr.start(context.Background(), "parent", KindServer, SpanContext{})
+	_, child := StartSpan(ctx, "child")
+	child.SetAttribute("db.statement", "SELECT 1")
+	child.End()
+	parent.End()
+
+	spans := readSpans(t, buf)
+	if len(spans) != 2 {
+		t.Fatalf("got %d spans, want 2", len(spans))
+	}
+	c, p := spans[0], spans[1]
+	if c.TraceID != p.TraceID {
+		t.Errorf("child.TraceID = got %q, want %q", c.TraceID, p.TraceID)
+	}
+	if c.ParentID != p.SpanID {
+		t.Errorf("child.ParentID = got %q, want %q", c.ParentID, p.SpanID)
+	}
+	if got := c.Attributes["db.statement"]; got != "SELECT 1" {
+		t.Errorf("child.Attributes[db.statement] = got %q, want %q", got, "SELECT 1")
+	}
+}
+
+func TestInterceptors_Propagate(t *testing.T) {
+	buf := &bytes.Buffer{}
+	tr := NewTracer(NewJSONExporter(buf))
+
+	// The client interceptor's outgoing metadata becomes the server's incoming
+	// metadata, as it would over the wire.
+	invoker := func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		md, _ := metadata.FromOutgoingContext(ctx)
+		sctx := metadata.NewIncomingContext(context.Background(), md)
+		info := &grpc.UnaryServerInfo{FullMethod: method}
+		handler := func(ctx context.Context, _ interface{}) (interface{}, error) {
+			_, span := StartSpan(ctx, "SQLImpl.Query")
+			span.End()
+			return nil, nil
+		}
+		_, err := UnaryServerInterceptor(tr)(sctx, req, info, handler)
+		return err
+	}
+	if err := UnaryClientInterceptor(tr)(context.Background(), "/snackinventory.SnackInventory/ListSnacks", nil, nil, nil, invoker); err != nil {
+		t.Fatalf("UnaryClientInterceptor(...) = got err %v, want err nil", err)
+	}
+
+	spans := readSpans(t, buf)
+	if len(spans) != 3 {
+		t.Fatalf("got %d spans, want 3", len(spans))
+	}
+	query, server, client := spans[0], spans[1], spans[2]
+	for _, s := range []*SpanData{query, server} {
+		if s.TraceID != client.TraceID {
+			t.Errorf("%s.TraceID = got %q, want %q", s.Name, s.TraceID, client.TraceID)
+		}
+	}
+	if server.ParentID != client.SpanID {
+		t.Errorf("server.ParentID = got %q, want %q", server.ParentID, client.SpanID)
+	}
+	if query.ParentID != server.SpanID {
+		t.Errorf("query.ParentID = got %q, want %q", query.ParentID, server.SpanID)
+	}
+}
+
+// fakeServerStream is a grpc.ServerStream with only a context.
+type fakeServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *fakeServerStream) Context() context.Context { return s.ctx }
+
+func TestStreamServerInterceptor(t *testing.T) {
+	buf := &bytes.Buffer{}
+	tr := NewTracer(NewJSONExporter(buf))
+
+	parent := SpanContext{TraceID: [16]byte{1}, SpanID: [8]byte{2}}
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(TraceparentHeader, parent.Traceparent()))
+	info := &grpc.StreamServerInfo{FullMethod: "/snackinventory.SnackInventory/WatchChanges"}
+	handler := func(_ interface{}, ss grpc.ServerStream) error {
+		// The handler's stream carries the RPC span.
+		_, span := StartSpan(ss.Context(), "Hub.Subscribe")
+		span.End()
+		return nil
+	}
+	if err := StreamServerInterceptor(tr)(nil, &fakeServerStream{ctx: ctx}, info, handler); err != nil {
+		t.Fatalf("StreamServerInterceptor(...) = got err %v, want err nil", err)
+	}
+
+	spans := readSpans(t, buf)
+	if len(spans) != 2 {
+		t.Fatalf("got %d spans, want 2", len(spans))
+	}
+	child, server := spans[0], spans[1]
+	if want := hex.EncodeToString(parent.TraceID[:]); server.TraceID != want || child.TraceID != want {
+		t.Errorf("TraceIDs = got %q, %q, want %q", server.TraceID, child.TraceID, want)
+	}
+	if child.ParentID != server.SpanID {
+		t.Errorf("child.ParentID = got %q, want %q", child.ParentID, server.SpanID)
+	}
+}
+
+func TestHTTPExporter(t *testing.T) {
+	var mu sync.Mutex
+	var got []*SpanData
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Spans []*SpanData `json:"spans"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("json.Decode(body) = got err %v, want err nil", err)
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		got = append(got, body.Spans...)
+	}))
+	defer srv.Close()
+
+	e := NewHTTPExporter(srv.URL)
+	tr := NewTracer(e)
+	_, span := tr.start(context.Background(), "test", KindInternal, SpanContext{})
+	span.End()
+	e.Shutdown()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(got) != 1 || got[0].Name != "test" {
+		t.Fatalf("collector received %v, want 1 span named %q", got, "test")
+	}
+}